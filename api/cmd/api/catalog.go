@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/validation"
+	"github.com/google/uuid"
+)
+
+const importCatalogUsage = `usage: api import-catalog <file>
+
+Saves the global recipes in a JSON file, an array of recipes in the shape
+GET /catalog returns, to the catalog in a single transaction. Recipes with
+the ID of one already in the catalog replace it, and its forks are flagged
+as having an update available. Recipes without an ID are added.`
+
+// runImportCatalog loads global recipes into the database at DB_DSN.
+func runImportCatalog(ctx context.Context, dsn string, args []string) error {
+	if len(args) != 1 {
+		return errors.New(importCatalogUsage)
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	var recipes []models.GlobalRecipe
+	if err := json.Unmarshal(data, &recipes); err != nil {
+		return fmt.Errorf("failed to read catalog: %w", err)
+	}
+	for i, recipe := range recipes {
+		if err := validation.RecipeBody(recipe.RecipeBody); err != nil {
+			return fmt.Errorf("recipe %d (%q): %w", i, recipe.Title, err)
+		}
+	}
+
+	store, err := openStore(dsn)
+	if err != nil {
+		return err
+	}
+	err = store.WithTx(func(tx db.Store) error {
+		for _, recipe := range recipes {
+			if recipe.ID == "" {
+				recipe.ID = uuid.New().String()
+			}
+			if recipe.SourceType == "" {
+				recipe.SourceType = models.RecipeSourceGenerated
+			}
+			if err := tx.SaveGlobalRecipe(ctx, recipe); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d recipe(s)\n", len(recipes))
+	return nil
+}
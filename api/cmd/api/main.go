@@ -2,10 +2,15 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	_ "github.com/ajohnston1219/eatme/api/docs"
+	"github.com/ajohnston1219/eatme/api/internal/auth"
 	"github.com/ajohnston1219/eatme/api/internal/clients"
 	"github.com/ajohnston1219/eatme/api/internal/db"
 	"github.com/ajohnston1219/eatme/api/internal/router"
@@ -27,14 +32,37 @@ func main() {
 	zap.ReplaceGlobals(baseLogger)
 
 	ctx := context.Background()
-	shutdown := telemetry.InitTracer(ctx, "backend-api")
-	defer shutdown(ctx)
 
 	dsn, ok := os.LookupEnv("DB_DSN")
 	if !ok {
 		dsn = "file:./.data/dev.db"
 	}
-	store, err := db.NewSQLiteStore(dsn)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, dsn, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "repair-events" {
+		if err := runRepairEvents(ctx, dsn, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import-catalog" {
+		if err := runImportCatalog(ctx, dsn, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	shutdown := telemetry.InitTracer(ctx, "backend-api")
+	defer shutdown(ctx)
+
+	store, err := openStore(dsn)
 	if err != nil {
 		panic(err)
 	}
@@ -43,8 +71,45 @@ func main() {
 	if !ok {
 		mlHost = "http://ml-gateway:8000"
 	}
+	mlConfig := clients.DefaultMLClientConfig()
+	// Timeouts are set per chat type and apply to its streaming variant too
+	for env, endpoints := range map[string][]clients.Endpoint{
+		"ML_SUGGEST_TIMEOUT": {clients.EndpointSuggest, clients.EndpointSuggestStream},
+		"ML_MODIFY_TIMEOUT":  {clients.EndpointModify, clients.EndpointModifyStream},
+		"ML_GENERAL_TIMEOUT": {clients.EndpointGeneral, clients.EndpointGeneralStream},
+	} {
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			panic(fmt.Sprintf("invalid %s: %v", env, err))
+		}
+		for _, endpoint := range endpoints {
+			mlConfig.Timeouts[endpoint] = timeout
+		}
+	}
+	if value, ok := os.LookupEnv("ML_MAX_RETRIES"); ok {
+		retries, err := strconv.Atoi(value)
+		if err != nil {
+			panic(fmt.Sprintf("invalid ML_MAX_RETRIES: %v", err))
+		}
+		mlConfig.MaxRetries = retries
+	}
+
+	jwtSecret, ok := os.LookupEnv("JWT_SECRET")
+	if !ok {
+		logger.Logger(ctx).Warn("JWT_SECRET not set, using a random secret, sessions will not survive restarts")
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+		jwtSecret = string(secret)
+	}
+	tokens := auth.NewTokenManager([]byte(jwtSecret))
 
-	app := router.NewApp(store, clients.NewMLClient(mlHost))
+	app := router.NewApp(store, clients.NewMLClient(mlHost, mlConfig), tokens)
 	router := router.NewRouter(app)
 
 	port := os.Getenv("PORT")
@@ -56,3 +121,13 @@ func main() {
 	logger.Logger(ctx).Info("📚 API documentation available at http://localhost:%s/swagger/index.html", zap.String("port", port))
 	http.ListenAndServe(":"+port, router)
 }
+
+// openStore picks the store implementation from the DSN scheme. Stores apply
+// pending migrations when they open and refuse to start against a schema
+// from a newer build.
+func openStore(dsn string) (db.Store, error) {
+	if db.DialectFromDSN(dsn) == db.DialectPostgres {
+		return db.NewPostgresStore(dsn)
+	}
+	return db.NewSQLiteStore(dsn)
+}
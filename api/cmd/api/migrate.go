@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/ajohnston1219/eatme/api/internal/db"
+)
+
+const migrateUsage = `usage: api migrate [command]
+
+Commands:
+  up          apply every pending migration (default)
+  down [n]    roll back the last n migrations (default 1)
+  status      list migrations and when they were applied
+  version     print the current schema version`
+
+// runMigrate manages the schema of the database at DB_DSN.
+func runMigrate(ctx context.Context, dsn string, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	steps := 1
+	switch {
+	case command == "down" && len(args) == 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of migrations %q\n\n%s", args[0], migrateUsage)
+		}
+		steps = n
+	case len(args) > 0:
+		return errors.New(migrateUsage)
+	}
+
+	sqlDB, dialect, err := db.Open(dsn)
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	migrator, err := db.NewMigrator(sqlDB, dialect)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migration(s), schema is at version %d\n", applied, migrator.Latest())
+	case "down":
+		rolledBack, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("rolled back %d migration(s), schema is at version %d\n", rolledBack, version)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, applied)
+		}
+		w.Flush()
+		return migrator.Check(ctx)
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d (latest %d)\n", version, migrator.Latest())
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ajohnston1219/eatme/api/internal/thread"
+)
+
+const repairEventsUsage = `usage: api repair-events [--dry-run]
+
+Checks that every stored thread event decodes, and rewrites the malformed
+ones in a single transaction. Events malformed in a known way are repaired,
+and the rest are replaced by an EventDiscarded event that keeps the original
+payload. Events with a schema version newer than this server's are left
+alone for the newer server that wrote them.
+
+Flags:
+  --dry-run   report what would be repaired without writing anything`
+
+// runRepairEvents repairs the thread events in the database at DB_DSN.
+func runRepairEvents(ctx context.Context, dsn string, args []string) error {
+	dryRun := false
+	switch {
+	case len(args) == 1 && args[0] == "--dry-run":
+		dryRun = true
+	case len(args) > 0:
+		return errors.New(repairEventsUsage)
+	}
+
+	store, err := openStore(dsn)
+	if err != nil {
+		return err
+	}
+	report, err := thread.RepairThreadEvents(ctx, store, dryRun)
+	if err != nil {
+		return err
+	}
+
+	repaired, discarded := "repaired", "discarded"
+	if dryRun {
+		repaired, discarded = "repairable", "to discard"
+	}
+	if len(report.Malformed) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "THREAD\tINDEX\tTYPE\tVERSION\tRESULT\tERROR")
+		for _, event := range report.Malformed {
+			result := "newer schema, left"
+			switch {
+			case event.Discarded:
+				result = discarded
+			case event.Repaired != nil:
+				result = repaired
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%s\t%v\n", event.ThreadID, event.Index, event.Event.Type, max(event.Event.SchemaVersion, 1), result, event.Err)
+		}
+		w.Flush()
+	}
+	for threadID, skipped := range report.Skipped {
+		for _, event := range skipped {
+			fmt.Printf("thread %s: event %d (%s) decodes but doesn't apply to the thread and is skipped when read: %v\n", threadID, event.Index, event.Type, event.Err)
+		}
+	}
+
+	unrepaired := report.Unrepaired()
+	nDiscarded := report.Discarded()
+	fmt.Printf("scanned %d event(s), %d malformed, %d %s, %d %s\n", report.Scanned, len(report.Malformed), len(report.Malformed)-unrepaired-nDiscarded, repaired, nDiscarded, discarded)
+	if dryRun {
+		fmt.Println("dry run, nothing was written")
+	}
+	if unrepaired > 0 {
+		return fmt.Errorf("%d event(s) have a newer schema version than this server, run repair-events from the newer server", unrepaired)
+	}
+	return nil
+}
@@ -15,36 +15,61 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/login": {
-            "post": {
-                "description": "Log in to an existing user account",
-                "consumes": [
-                    "application/json"
-                ],
+        "/catalog": {
+            "get": {
+                "description": "Get the global recipes anyone can fork into their recipe book, optionally filtered by a full-text query. Results are ordered oldest first and paged; the X-Next-Cursor header holds the cursor for the next page and is absent on the last one.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "Catalog"
                 ],
-                "summary": "Log in",
-                "operationId": "login",
+                "summary": "Search the catalog",
+                "operationId": "getCatalog",
                 "parameters": [
                     {
-                        "description": "User login information",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.LoginRequest"
-                        }
+                        "type": "string",
+                        "description": "Words to find in the title, description, ingredients or steps",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 1 to 100, defaults to 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Measurement system to show ingredients in, defaults to the user's preference",
+                        "name": "unit_system",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.LoginResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.GlobalRecipe"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor for the next page"
+                            }
                         }
                     },
                     "400": {
@@ -68,22 +93,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/profile": {
+        "/catalog/{globalRecipeId}": {
             "get": {
-                "description": "Gets the profile for a user",
+                "description": "Get a global recipe from the catalog by ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "Catalog"
+                ],
+                "summary": "Get catalog recipe",
+                "operationId": "getCatalogRecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Global recipe ID",
+                        "name": "globalRecipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Measurement system to show ingredients in, defaults to the user's preference",
+                        "name": "unit_system",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get user profile",
-                "operationId": "getProfile",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Profile"
+                            "$ref": "#/definitions/models.GlobalRecipe"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
                         }
                     },
                     "401": {
@@ -93,7 +143,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Not found",
+                        "description": "Catalog recipe not found",
                         "schema": {
                             "$ref": "#/definitions/models.APIError"
                         }
@@ -105,36 +155,39 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Save a user's profile",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/catalog/{globalRecipeId}/fork": {
+            "post": {
+                "description": "Copy a global recipe from the catalog into the user's recipe book. The copy stays linked to the global recipe and is flagged when an update is available.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "Catalog"
                 ],
-                "summary": "Save user profile",
-                "operationId": "saveProfile",
+                "summary": "Fork catalog recipe",
+                "operationId": "forkCatalogRecipe",
                 "parameters": [
                     {
-                        "description": "User profile",
-                        "name": "profile",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.ProfileUpdateRequest"
-                        }
+                        "type": "string",
+                        "description": "Global recipe ID",
+                        "name": "globalRecipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Profile"
+                            "$ref": "#/definitions/models.UserRecipe"
                         }
                     },
                     "400": {
@@ -149,6 +202,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.APIError"
                         }
                     },
+                    "404": {
+                        "description": "Catalog recipe not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "A request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -158,30 +229,72 @@ const docTemplate = `{
                 }
             }
         },
-        "/recipes": {
+        "/collections": {
             "get": {
-                "description": "Get all recipes for user",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get all recipe collections for user",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Recipe"
+                    "Collection"
                 ],
-                "summary": "Get all recipes for user",
-                "operationId": "getAllRecipes",
+                "summary": "Get all collections",
+                "operationId": "getAllCollections",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.UserRecipe"
+                                "$ref": "#/definitions/models.Collection"
                             }
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create an empty recipe collection",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collection"
+                ],
+                "summary": "Create a collection",
+                "operationId": "createCollection",
+                "parameters": [
+                    {
+                        "description": "Create collection request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateCollectionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Collection"
+                        }
+                    },
                     "400": {
                         "description": "Invalid input",
                         "schema": {
@@ -203,25 +316,22 @@ const docTemplate = `{
                 }
             }
         },
-        "/recipes/{recipeId}": {
+        "/collections/{collectionId}": {
             "get": {
-                "description": "Get recipe by ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get a recipe collection by ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Recipe"
+                    "Collection"
                 ],
-                "summary": "Get recipe by ID",
-                "operationId": "getRecipe",
+                "summary": "Get a collection",
+                "operationId": "getCollection",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Recipe ID",
-                        "name": "recipeId",
+                        "description": "Collection ID",
+                        "name": "collectionId",
                         "in": "path",
                         "required": true
                     }
@@ -230,7 +340,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.UserRecipe"
+                            "$ref": "#/definitions/models.Collection"
                         }
                     },
                     "400": {
@@ -246,7 +356,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Recipe not found",
+                        "description": "Collection not found",
                         "schema": {
                             "$ref": "#/definitions/models.APIError"
                         }
@@ -259,8 +369,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
-                "description": "Delete recipe",
+            "put": {
+                "description": "Rename a recipe collection",
                 "consumes": [
                     "application/json"
                 ],
@@ -268,24 +378,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Recipe"
+                    "Collection"
                 ],
-                "summary": "Delete recipe",
-                "operationId": "deleteRecipe",
+                "summary": "Update a collection",
+                "operationId": "updateCollection",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Recipe ID",
-                        "name": "recipeId",
+                        "description": "Collection ID",
+                        "name": "collectionId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Update collection request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateCollectionRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.UserRecipe"
+                            "$ref": "#/definitions/models.Collection"
                         }
                     },
                     "400": {
@@ -301,7 +420,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Recipe not found",
+                        "description": "Collection not found",
                         "schema": {
                             "$ref": "#/definitions/models.APIError"
                         }
@@ -313,34 +432,32 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/recipes/{recipeId}/modify/accept": {
-            "post": {
-                "description": "Accept a recipe modification",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+            },
+            "delete": {
+                "description": "Delete a recipe collection. The recipes in it are kept.",
                 "tags": [
-                    "thread"
+                    "Collection"
                 ],
-                "summary": "Accept a recipe modification",
-                "operationId": "acceptRecipeModification",
+                "summary": "Delete a collection",
+                "operationId": "deleteCollection",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Recipe ID",
-                        "name": "recipeId",
+                        "description": "Collection ID",
+                        "name": "collectionId",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "204": {
-                        "description": "Recipe modified"
+                        "description": "Collection deleted"
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
                     },
                     "401": {
                         "description": "Unauthorized",
@@ -349,7 +466,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Thread not found",
+                        "description": "Collection not found",
                         "schema": {
                             "$ref": "#/definitions/models.APIError"
                         }
@@ -363,9 +480,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/recipes/{recipeId}/modify/chat": {
+        "/collections/{collectionId}/recipes": {
             "post": {
-                "description": "Modify a recipe via chat",
+                "description": "Add a recipe to the end of a collection. Adding a recipe that is already in the collection leaves it where it is.",
                 "consumes": [
                     "application/json"
                 ],
@@ -373,25 +490,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "thread"
+                    "Collection"
                 ],
-                "summary": "Modify a recipe via chat",
-                "operationId": "modifyRecipe",
+                "summary": "Add a recipe to a collection",
+                "operationId": "addCollectionRecipe",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Recipe ID",
-                        "name": "recipeId",
+                        "description": "Collection ID",
+                        "name": "collectionId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Modify recipe via chat request",
+                        "description": "Add recipe request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ModifyRecipeViaChatRequest"
+                            "$ref": "#/definitions/models.AddCollectionRecipeRequest"
                         }
                     }
                 ],
@@ -399,7 +516,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ModifyRecipeResponse"
+                            "$ref": "#/definitions/models.Collection"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
                         }
                     },
                     "401": {
@@ -409,7 +532,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Thread not found",
+                        "description": "Collection or recipe not found",
                         "schema": {
                             "$ref": "#/definitions/models.APIError"
                         }
@@ -423,9 +546,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/recipes/{recipeId}/modify/reject": {
-            "post": {
-                "description": "Reject a recipe modification",
+        "/collections/{collectionId}/recipes/{recipeId}": {
+            "put": {
+                "description": "Move a recipe to another position of a collection",
                 "consumes": [
                     "application/json"
                 ],
@@ -433,22 +556,47 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "thread"
+                    "Collection"
                 ],
-                "summary": "Reject a recipe modification",
-                "operationId": "rejectRecipeModification",
+                "summary": "Move a recipe within a collection",
+                "operationId": "moveCollectionRecipe",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Collection ID",
+                        "name": "collectionId",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "Recipe ID",
                         "name": "recipeId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Move recipe request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MoveCollectionRecipeRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Recipe modified"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Collection"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
                     },
                     "401": {
                         "description": "Unauthorized",
@@ -457,7 +605,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Thread not found",
+                        "description": "Collection not found or recipe not in it",
                         "schema": {
                             "$ref": "#/definitions/models.APIError"
                         }
@@ -469,38 +617,38 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/signup": {
-            "post": {
-                "description": "Register a new user account",
-                "consumes": [
-                    "application/json"
-                ],
+            },
+            "delete": {
+                "description": "Remove a recipe from a collection. The recipe itself is kept.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "Collection"
                 ],
-                "summary": "Create a new user account",
-                "operationId": "signup",
+                "summary": "Remove a recipe from a collection",
+                "operationId": "removeCollectionRecipe",
                 "parameters": [
                     {
-                        "description": "User signup information",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.SignupRequest"
-                        }
+                        "type": "string",
+                        "description": "Collection ID",
+                        "name": "collectionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.SignupResponse"
+                            "$ref": "#/definitions/models.Collection"
                         }
                     },
                     "400": {
@@ -509,8 +657,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.APIError"
                         }
                     },
-                    "409": {
-                        "description": "Email already exists",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Collection not found or recipe not in it",
                         "schema": {
                             "$ref": "#/definitions/models.APIError"
                         }
@@ -524,9 +678,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/thread/suggest": {
+        "/login": {
             "post": {
-                "description": "Start a new suggestion thread",
+                "description": "Log in to an existing user account",
                 "consumes": [
                     "application/json"
                 ],
@@ -534,18 +688,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "thread"
+                    "users"
                 ],
-                "summary": "Start a new suggestion thread",
-                "operationId": "startSuggestionThread",
+                "summary": "Log in",
+                "operationId": "login",
                 "parameters": [
                     {
-                        "description": "Suggestion thread request",
+                        "description": "User login information",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.StartSuggestionThreadRequest"
+                            "$ref": "#/definitions/models.LoginRequest"
                         }
                     }
                 ],
@@ -553,7 +707,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ThreadState"
+                            "$ref": "#/definitions/models.LoginResponse"
                         }
                     },
                     "400": {
@@ -577,35 +731,31 @@ const docTemplate = `{
                 }
             }
         },
-        "/thread/{threadId}": {
-            "get": {
-                "description": "Get a thread",
+        "/logout": {
+            "post": {
+                "description": "Revoke a refresh token, ending the session it belongs to",
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "thread"
+                    "users"
                 ],
-                "summary": "Get a thread",
-                "operationId": "getThread",
+                "summary": "Log out",
+                "operationId": "logout",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Thread ID",
-                        "name": "threadId",
-                        "in": "path",
-                        "required": true
+                        "description": "Refresh token to revoke",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LogoutRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.ThreadState"
-                        }
+                    "204": {
+                        "description": "Logged out"
                     },
                     "400": {
                         "description": "Invalid input",
@@ -613,14 +763,38 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.APIError"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/models.APIError"
                         }
+                    }
+                }
+            }
+        },
+        "/plans": {
+            "get": {
+                "description": "Get all meal plans for user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MealPlan"
+                ],
+                "summary": "Get all meal plans",
+                "operationId": "getAllPlans",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.MealPlan"
+                            }
+                        }
                     },
-                    "404": {
-                        "description": "Thread not found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/models.APIError"
                         }
@@ -632,11 +806,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/thread/{threadId}/accept/{suggestionId}": {
+            },
             "post": {
-                "description": "Accept a suggestion",
+                "description": "Create an empty meal plan",
                 "consumes": [
                     "application/json"
                 ],
@@ -644,22 +816,65 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "thread"
+                    "MealPlan"
                 ],
-                "summary": "Accept a suggestion",
-                "operationId": "acceptSuggestion",
+                "summary": "Create a meal plan",
+                "operationId": "createPlan",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Thread ID",
-                        "name": "threadId",
-                        "in": "path",
-                        "required": true
+                        "description": "Create meal plan request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateMealPlanRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.MealPlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
                     },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/{planId}": {
+            "get": {
+                "description": "Get a meal plan by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MealPlan"
+                ],
+                "summary": "Get a meal plan",
+                "operationId": "getPlan",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Suggestion ID",
-                        "name": "suggestionId",
+                        "description": "Meal plan ID",
+                        "name": "planId",
                         "in": "path",
                         "required": true
                     }
@@ -668,7 +883,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.UserRecipe"
+                            "$ref": "#/definitions/models.MealPlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
                         }
                     },
                     "401": {
@@ -678,7 +899,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Thread not found",
+                        "description": "Meal plan not found",
                         "schema": {
                             "$ref": "#/definitions/models.APIError"
                         }
@@ -690,11 +911,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/thread/{threadId}/question": {
-            "post": {
-                "description": "Answer a cooking question",
+            },
+            "put": {
+                "description": "Rename a meal plan",
                 "consumes": [
                     "application/json"
                 ],
@@ -702,25 +921,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "thread"
+                    "MealPlan"
                 ],
-                "summary": "Answer a cooking question",
-                "operationId": "answerCookingQuestion",
+                "summary": "Update a meal plan",
+                "operationId": "updatePlan",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Thread ID",
-                        "name": "threadId",
+                        "description": "Meal plan ID",
+                        "name": "planId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Answer cooking question request",
+                        "description": "Update meal plan request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.AnswerCookingQuestionRequest"
+                            "$ref": "#/definitions/models.UpdateMealPlanRequest"
                         }
                     }
                 ],
@@ -728,8 +947,54 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.AnswerCookingQuestionResponse"
+                            "$ref": "#/definitions/models.MealPlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Meal plan not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
                         }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a meal plan",
+                "tags": [
+                    "MealPlan"
+                ],
+                "summary": "Delete a meal plan",
+                "operationId": "deletePlan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meal plan ID",
+                        "name": "planId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Meal plan deleted"
                     },
                     "400": {
                         "description": "Invalid input",
@@ -743,6 +1008,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.APIError"
                         }
                     },
+                    "404": {
+                        "description": "Meal plan not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -752,9 +1023,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/thread/{threadId}/suggest": {
+        "/plans/{planId}/recipes": {
             "post": {
-                "description": "Get new recipe suggestions",
+                "description": "Add a recipe to a day of a meal plan, pinned to the recipe's current version",
                 "consumes": [
                     "application/json"
                 ],
@@ -762,25 +1033,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "thread"
+                    "MealPlan"
                 ],
-                "summary": "Get new suggestions",
-                "operationId": "getNewSuggestions",
+                "summary": "Add a recipe to a meal plan",
+                "operationId": "addPlanRecipe",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Thread ID",
-                        "name": "threadId",
+                        "description": "Meal plan ID",
+                        "name": "planId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Get new suggestions request",
+                        "description": "Add recipe request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.GetNewSuggestionsRequest"
+                            "$ref": "#/definitions/models.AddMealPlanRecipeRequest"
                         }
                     }
                 ],
@@ -788,10 +1059,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.RecipeSuggestion"
-                            }
+                            "$ref": "#/definitions/models.MealPlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
                         }
                     },
                     "401": {
@@ -801,7 +1075,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Thread not found",
+                        "description": "Meal plan or recipe not found",
                         "schema": {
                             "$ref": "#/definitions/models.APIError"
                         }
@@ -814,180 +1088,3683 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.APIError": {
-            "description": "APIError represents an API error response",
-            "type": "object",
-            "required": [
-                "code",
-                "message"
-            ],
-            "properties": {
-                "code": {
-                    "type": "string"
-                },
-                "details": {
-                    "type": "string"
-                },
-                "field": {
-                    "type": "string"
-                },
-                "message": {
+        },
+        "/plans/{planId}/recipes/{entryId}": {
+            "put": {
+                "description": "Move a recipe to another day or position of a meal plan",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MealPlan"
+                ],
+                "summary": "Move a recipe within a meal plan",
+                "operationId": "movePlanRecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meal plan ID",
+                        "name": "planId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Meal plan recipe ID",
+                        "name": "entryId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Move recipe request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MoveMealPlanRecipeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MealPlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Meal plan or recipe not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a recipe from a meal plan",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MealPlan"
+                ],
+                "summary": "Remove a recipe from a meal plan",
+                "operationId": "removePlanRecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meal plan ID",
+                        "name": "planId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Meal plan recipe ID",
+                        "name": "entryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MealPlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Meal plan or recipe not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/{planId}/shopping-list": {
+            "get": {
+                "description": "Get the ingredients of every recipe in a meal plan, merged and grouped by aisle",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MealPlan"
+                ],
+                "summary": "Get the shopping list for a meal plan",
+                "operationId": "getPlanShoppingList",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Meal plan ID",
+                        "name": "planId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Measurement system to show quantities in, defaults to the user's preference",
+                        "name": "unit_system",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ShoppingList"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Meal plan not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/profile": {
+            "get": {
+                "description": "Gets the profile for a user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user profile",
+                "operationId": "getProfile",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Profile"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Save a user's profile",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Save user profile",
+                "operationId": "saveProfile",
+                "parameters": [
+                    {
+                        "description": "User profile",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProfileUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Profile"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes": {
+            "get": {
+                "description": "Get the user's recipes, optionally filtered by a full-text query and facets. Results are ordered oldest first and paged; the X-Next-Cursor header holds the cursor for the next page and is absent on the last one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Search recipes",
+                "operationId": "getAllRecipes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Words to find in the title, description, ingredients or steps",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only recipes that take at most this many minutes",
+                        "name": "max_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only recipes with an ingredient matching each of these names",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only recipes without an ingredient matching any of these names",
+                        "name": "exclude",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only favorite recipes",
+                        "name": "favorites",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only recipes in this collection",
+                        "name": "collection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only recipes with each of these tags",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 1 to 100, defaults to 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Measurement system to show ingredients in, defaults to the user's preference",
+                        "name": "unit_system",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UserRecipe"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor for the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a recipe entered by hand. It gets a thread of its own to record its history.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Create recipe",
+                "operationId": "createRecipe",
+                "parameters": [
+                    {
+                        "description": "Recipe",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RecipeBody"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRecipe"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input, the field says which part of the recipe",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "A request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/import": {
+            "post": {
+                "description": "Read a recipe from a saved recipe page, using its schema.org Recipe JSON-LD or microdata, or from a recipe written out as text. The recipe isn't saved; the draft is returned for the user to review and create with POST /recipes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Import recipe",
+                "operationId": "importRecipe",
+                "parameters": [
+                    {
+                        "description": "Import recipe request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportRecipeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RecipeBody"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "No recipe found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/tags": {
+            "get": {
+                "description": "Get every tag on the user's recipes with how many recipes have it, in alphabetical order",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Get recipe tags",
+                "operationId": "getRecipeTags",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RecipeTag"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}": {
+            "get": {
+                "description": "Get recipe by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Get recipe by ID",
+                "operationId": "getRecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Measurement system to show ingredients in, defaults to the user's preference",
+                        "name": "unit_system",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRecipe"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace a recipe's contents, saving them as a new version",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Update recipe",
+                "operationId": "updateRecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Recipe",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RecipeBody"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRecipe"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input, the field says which part of the recipe",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe or thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete recipe",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Delete recipe",
+                "operationId": "deleteRecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRecipe"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Apply a JSON Patch (RFC 6902) to a recipe, saving the result as a new version. Test operations can be used to make sure the recipe hasn't changed since it was read.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Patch recipe",
+                "operationId": "patchRecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "JSON Patch",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PatchOperation"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRecipe"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or patch, the field says which part of the recipe",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe or thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "A test operation failed, thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}/diff": {
+            "get": {
+                "description": "Compare two versions of a recipe",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Diff recipe versions",
+                "operationId": "diffRecipeVersions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the version to compare from",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the version to compare to, defaults to the latest version",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RecipeDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe or version not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}/favorite": {
+            "post": {
+                "description": "Mark a recipe as a favorite",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Favorite recipe",
+                "operationId": "favoriteRecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRecipe"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Unmark a recipe as a favorite",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Unfavorite recipe",
+                "operationId": "unfavoriteRecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRecipe"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}/merge": {
+            "get": {
+                "description": "Merge the changes made in one version of a recipe with those made in another, starting from the newest version both were made from. Nothing is saved.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Preview recipe merge",
+                "operationId": "getRecipeMerge",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the version to merge",
+                        "name": "theirs_version_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the version to merge into, defaults to the latest version",
+                        "name": "ours_version_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RecipeMerge"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe or version not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Versions have no common version",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Merge the changes made in one version of a recipe with those made in another and save the result as the recipe's new latest version. Every conflict of the merge must be resolved.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Merge recipe versions",
+                "operationId": "mergeRecipeVersions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge recipe versions request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MergeRecipeVersionsRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRecipe"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe, version or thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Merge has unresolved conflicts, listed in the details, thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Versions have no common version, or Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}/modify/accept": {
+            "post": {
+                "description": "Accept a recipe modification",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "Accept a recipe modification",
+                "operationId": "acceptRecipeModification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Recipe modified"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe or thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "No modification to accept, thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}/modify/chat": {
+            "post": {
+                "description": "Modify a recipe via chat",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "Modify a recipe via chat",
+                "operationId": "modifyRecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Modify recipe via chat request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ModifyRecipeViaChatRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Measurement system to show ingredients in, defaults to the user's preference",
+                        "name": "unit_system",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ModifyRecipeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe or thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Request rejected by the recipe assistant, or Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "502": {
+                        "description": "Recipe assistant failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "Recipe assistant unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "Recipe assistant timed out",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}/modify/chat/stream": {
+            "post": {
+                "description": "Modify a recipe via chat and stream the response as Server-Sent Events.\nEmits \"token\" events with partial response text and a final \"done\" event with the modification.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "Modify a recipe via chat, streaming the response",
+                "operationId": "modifyRecipeStream",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Modify recipe via chat request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ModifyRecipeViaChatRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Measurement system to show ingredients in, defaults to the user's preference",
+                        "name": "unit_system",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Final done event",
+                        "schema": {
+                            "$ref": "#/definitions/models.ModifyRecipeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe or thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Request rejected by the recipe assistant, or Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "502": {
+                        "description": "Recipe assistant failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "Recipe assistant unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "Recipe assistant timed out",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}/modify/redo": {
+            "post": {
+                "description": "Move the recipe forward to the version most recently undone. Accepting a modification after an undo discards what could be redone.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "Redo a recipe modification",
+                "operationId": "redoRecipeModification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRecipe"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe or thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Nothing to redo, thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}/modify/reject": {
+            "post": {
+                "description": "Reject a recipe modification",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "Reject a recipe modification",
+                "operationId": "rejectRecipeModification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Recipe modified"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe or thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}/modify/undo": {
+            "post": {
+                "description": "Move the recipe back to the version its latest version was made from. The newer version is kept and can be restored with redo.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "Undo a recipe modification",
+                "operationId": "undoRecipeModification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRecipe"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe or thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Nothing to undo, thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}/scale": {
+            "post": {
+                "description": "Rescale a recipe to a number of servings, saving the result as a new version",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Scale recipe",
+                "operationId": "scaleRecipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Scale recipe request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ScaleRecipeRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRecipe"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe or thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Recipe has no servings to scale from, or Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}/tags": {
+            "put": {
+                "description": "Replace the tags of a recipe. Tags are trimmed and lowercased, duplicates are dropped.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Set recipe tags",
+                "operationId": "setRecipeTags",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Set recipe tags request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SetRecipeTagsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRecipe"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}/versions": {
+            "get": {
+                "description": "Get every version of a recipe as a tree flattened so that each version comes after the version it was made from",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Get recipe versions",
+                "operationId": "getRecipeVersions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RecipeVersionSummary"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}/versions/{versionId}": {
+            "get": {
+                "description": "Get one version of a recipe",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Get recipe version",
+                "operationId": "getRecipeVersion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version ID",
+                        "name": "versionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Measurement system to show ingredients in, defaults to the user's preference",
+                        "name": "unit_system",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RecipeVersion"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe or version not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipes/{recipeId}/versions/{versionId}/restore": {
+            "post": {
+                "description": "Save a copy of an earlier version as the recipe's new latest version. The versions in between are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Recipe"
+                ],
+                "summary": "Restore recipe version",
+                "operationId": "restoreRecipeVersion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipe ID",
+                        "name": "recipeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version ID",
+                        "name": "versionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRecipe"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Recipe, version or thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/signup": {
+            "post": {
+                "description": "Register a new user account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a new user account",
+                "operationId": "signup",
+                "parameters": [
+                    {
+                        "description": "User signup information",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SignupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SignupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Email already exists",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/thread": {
+            "get": {
+                "description": "Get the user's threads without their events, optionally filtered by type or linked recipe. Results are ordered newest first and paged; the X-Next-Cursor header holds the cursor for the next page and is absent on the last one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "List threads",
+                "operationId": "listThreads",
+                "parameters": [
+                    {
+                        "enum": [
+                            "Suggestion"
+                        ],
+                        "type": "string",
+                        "description": "Only threads of this type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only threads linked to this recipe",
+                        "name": "recipe_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 1 to 100, defaults to 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ThreadSummary"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor for the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/thread/suggest": {
+            "post": {
+                "description": "Start a new suggestion thread",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "Start a new suggestion thread",
+                "operationId": "startSuggestionThread",
+                "parameters": [
+                    {
+                        "description": "Suggestion thread request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.StartSuggestionThreadRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Measurement system to show ingredients in, defaults to the user's preference",
+                        "name": "unit_system",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ThreadState"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "A request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Request rejected by the recipe assistant, or Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "502": {
+                        "description": "Recipe assistant failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "Recipe assistant unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "Recipe assistant timed out",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/thread/suggest/stream": {
+            "post": {
+                "description": "Start a new suggestion thread and stream the suggestions as Server-Sent Events.\nEmits \"token\" events with partial response text, a \"suggestion\" event per suggestion\nand a final \"done\" event with the thread state.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "Start a new suggestion thread, streaming the response",
+                "operationId": "startSuggestionThreadStream",
+                "parameters": [
+                    {
+                        "description": "Suggestion thread request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.StartSuggestionThreadRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Measurement system to show ingredients in, defaults to the user's preference",
+                        "name": "unit_system",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Final done event",
+                        "schema": {
+                            "$ref": "#/definitions/models.ThreadState"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "A request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Request rejected by the recipe assistant, or Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "502": {
+                        "description": "Recipe assistant failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "Recipe assistant unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "Recipe assistant timed out",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/thread/{threadId}": {
+            "get": {
+                "description": "Get a thread's current state, or with at, the state it had at an earlier version or time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "Get a thread",
+                "operationId": "getThread",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Thread ID",
+                        "name": "threadId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "A version of the thread, counting events from 0, or an RFC 3339 timestamp",
+                        "name": "at",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Measurement system to show ingredients in, defaults to the user's preference",
+                        "name": "unit_system",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ThreadState"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/thread/{threadId}/accept/{suggestionId}": {
+            "post": {
+                "description": "Accept a suggestion",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "Accept a suggestion",
+                "operationId": "acceptSuggestion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Thread ID",
+                        "name": "threadId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Suggestion ID",
+                        "name": "suggestionId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserRecipe"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/thread/{threadId}/events": {
+            "get": {
+                "description": "Get every event in a thread, oldest first. An event's position in the list is its index, and the thread is at version n once it has its first n events.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "Get a thread's events",
+                "operationId": "getThreadEvents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Thread ID",
+                        "name": "threadId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ThreadEvent"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/thread/{threadId}/question": {
+            "post": {
+                "description": "Answer a cooking question",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "Answer a cooking question",
+                "operationId": "answerCookingQuestion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Thread ID",
+                        "name": "threadId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Answer cooking question request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AnswerCookingQuestionRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnswerCookingQuestionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Request rejected by the recipe assistant, or Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "502": {
+                        "description": "Recipe assistant failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "Recipe assistant unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "Recipe assistant timed out",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/thread/{threadId}/question/stream": {
+            "post": {
+                "description": "Answer a cooking question and stream the answer as Server-Sent Events.\nEmits \"token\" events with partial answer text and a final \"done\" event with the full answer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "Answer a cooking question, streaming the response",
+                "operationId": "answerCookingQuestionStream",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Thread ID",
+                        "name": "threadId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Answer cooking question request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AnswerCookingQuestionRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Final done event",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnswerCookingQuestionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Request rejected by the recipe assistant, or Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "502": {
+                        "description": "Recipe assistant failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "Recipe assistant unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "Recipe assistant timed out",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/thread/{threadId}/suggest": {
+            "post": {
+                "description": "Get new recipe suggestions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "thread"
+                ],
+                "summary": "Get new suggestions",
+                "operationId": "getNewSuggestions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Thread ID",
+                        "name": "threadId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Get new suggestions request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GetNewSuggestionsRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key replay the first response instead of repeating the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Measurement system to show ingredients in, defaults to the user's preference",
+                        "name": "unit_system",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RecipeSuggestion"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Thread not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Thread changed by another request, or a request with this Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "422": {
+                        "description": "Request rejected by the recipe assistant, or Idempotency-Key reused for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "502": {
+                        "description": "Recipe assistant failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "Recipe assistant unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "Recipe assistant timed out",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/token/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access token and a rotated refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Refresh access token",
+                "operationId": "refreshToken",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIError": {
+            "description": "APIError represents an API error response",
+            "type": "object",
+            "required": [
+                "code",
+                "message"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AddCollectionRecipeRequest": {
+            "description": "AddCollectionRecipeRequest represents a request to add a recipe to the end of a collection",
+            "type": "object",
+            "required": [
+                "recipe_id"
+            ],
+            "properties": {
+                "recipe_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AddMealPlanRecipeRequest": {
+            "description": "AddMealPlanRecipeRequest represents a request to add a recipe to a meal plan",
+            "type": "object",
+            "required": [
+                "recipe_id"
+            ],
+            "properties": {
+                "day": {
+                    "description": "Day of the plan, 0 leaves the recipe unscheduled",
+                    "type": "integer",
+                    "example": 1
+                },
+                "recipe_id": {
+                    "description": "Recipe to add, the entry is pinned to its current version",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Aisle": {
+            "type": "string",
+            "enum": [
+                "produce",
+                "meat_seafood",
+                "dairy_eggs",
+                "bakery",
+                "pantry",
+                "spices",
+                "frozen",
+                "beverages",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "AisleProduce",
+                "AisleMeatSeafood",
+                "AisleDairyEggs",
+                "AisleBakery",
+                "AislePantry",
+                "AisleSpices",
+                "AisleFrozen",
+                "AisleBeverages",
+                "AisleOther"
+            ]
+        },
+        "models.AnswerCookingQuestionRequest": {
+            "description": "AnswerCookingQuestionRequest represents a request to answer a cooking question",
+            "type": "object",
+            "required": [
+                "question"
+            ],
+            "properties": {
+                "question": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AnswerCookingQuestionResponse": {
+            "description": "AnswerCookingQuestionResponse represents a response to an answer cooking question",
+            "type": "object",
+            "required": [
+                "answer"
+            ],
+            "properties": {
+                "answer": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ChatMessage": {
+            "description": "ChatMessage represents a message in the chat history",
+            "type": "object",
+            "required": [
+                "message",
+                "source"
+            ],
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Collection": {
+            "description": "Collection is a user-defined, ordered group of recipes",
+            "type": "object",
+            "required": [
+                "created_at",
+                "id",
+                "name",
+                "recipe_ids",
+                "updated_at",
+                "user_id"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "12345678-1234-1234-1234-123456789012"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Weeknight Dinners"
+                },
+                "recipe_ids": {
+                    "description": "IDs of the recipes in the collection, in order",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "12345678-1234-1234-1234-123456789012"
+                }
+            }
+        },
+        "models.CreateCollectionRequest": {
+            "description": "CreateCollectionRequest represents a request to create a collection",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Weeknight Dinners"
+                }
+            }
+        },
+        "models.CreateMealPlanRequest": {
+            "description": "CreateMealPlanRequest represents a request to create a meal plan",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "My Meal Plan"
+                }
+            }
+        },
+        "models.DiffStep": {
+            "description": "DiffStep represents a step in a recipe with information about the type of change",
+            "type": "object",
+            "required": [
+                "is_new",
+                "step"
+            ],
+            "properties": {
+                "is_new": {
+                    "type": "boolean"
+                },
+                "step": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GetNewSuggestionsRequest": {
+            "description": "GetNewSuggestionsRequest represents a request to get new suggestions",
+            "type": "object",
+            "properties": {
+                "prompt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GlobalRecipe": {
+            "description": "GlobalRecipe represents a global recipe that can be \"forked\" into a user's recipe book",
+            "type": "object",
+            "required": [
+                "created_at",
+                "description",
+                "id",
+                "ingredients",
+                "revision",
+                "servings",
+                "source_type",
+                "steps",
+                "title",
+                "total_time_minutes",
+                "updated_at"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "A classic Italian dish"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "ingredients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Ingredient"
+                    }
+                },
+                "revision": {
+                    "description": "Revision counts the times the recipe has been saved, starting at 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "servings": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "source_type": {
+                    "$ref": "#/definitions/models.RecipeSource"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Veal Bolognese"
+                },
+                "total_time_minutes": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "models.AnswerCookingQuestionRequest": {
-            "description": "AnswerCookingQuestionRequest represents a request to answer a cooking question",
+        "models.ImportRecipeRequest": {
+            "description": "ImportRecipeRequest represents a recipe to import, either a saved recipe page or a recipe written out as text. Exactly one of html and text is required.",
             "type": "object",
-            "required": [
-                "question"
-            ],
             "properties": {
-                "question": {
+                "html": {
+                    "description": "HTML is a recipe page with schema.org Recipe JSON-LD or microdata",
                     "type": "string"
+                },
+                "text": {
+                    "description": "Text is a recipe with a title on its first line and Ingredients and\nInstructions headings",
+                    "type": "string",
+                    "example": "Pancakes\nIngredients\n1 1/2 cups flour, sifted\nInstructions\nMix and fry."
                 }
             }
         },
-        "models.AnswerCookingQuestionResponse": {
-            "description": "AnswerCookingQuestionResponse represents a response to an answer cooking question",
+        "models.Ingredient": {
+            "description": "Ingredient represents an ingredient in a recipe",
             "type": "object",
             "required": [
-                "answer"
+                "name",
+                "quantity",
+                "unit"
             ],
             "properties": {
-                "answer": {
-                    "type": "string"
+                "name": {
+                    "type": "string",
+                    "example": "Flour"
+                },
+                "quantity": {
+                    "type": "number",
+                    "example": 1
+                },
+                "unit": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MeasurementUnit"
+                        }
+                    ],
+                    "example": "cup"
                 }
             }
         },
-        "models.ChatMessage": {
-            "description": "ChatMessage represents a message in the chat history",
+        "models.IngredientChange": {
+            "description": "IngredientChange describes how one ingredient changed. Moved is set when the ingredient also changed position; a type of moved means nothing else changed.",
             "type": "object",
             "required": [
-                "message",
-                "source"
+                "moved",
+                "type"
             ],
             "properties": {
-                "message": {
-                    "type": "string"
+                "after": {
+                    "$ref": "#/definitions/models.Ingredient"
                 },
-                "source": {
-                    "type": "string"
+                "before": {
+                    "$ref": "#/definitions/models.Ingredient"
+                },
+                "from_index": {
+                    "type": "integer"
+                },
+                "moved": {
+                    "type": "boolean"
+                },
+                "to_index": {
+                    "type": "integer"
+                },
+                "type": {
+                    "enum": [
+                        "added",
+                        "removed",
+                        "renamed",
+                        "moved",
+                        "quantity_changed",
+                        "unit_changed",
+                        "amount_changed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.IngredientChangeType"
+                        }
+                    ]
                 }
             }
         },
-        "models.DiffStep": {
-            "description": "DiffStep represents a step in a recipe with information about the type of change",
+        "models.IngredientChangeType": {
+            "type": "string",
+            "enum": [
+                "added",
+                "removed",
+                "renamed",
+                "moved",
+                "quantity_changed",
+                "unit_changed",
+                "amount_changed"
+            ],
+            "x-enum-varnames": [
+                "IngredientChangeAdded",
+                "IngredientChangeRemoved",
+                "IngredientChangeRenamed",
+                "IngredientChangeMoved",
+                "IngredientChangeQuantityChanged",
+                "IngredientChangeUnitChanged",
+                "IngredientChangeAmountChanged"
+            ]
+        },
+        "models.LoginRequest": {
+            "description": "LoginRequest represents the user login request payload",
             "type": "object",
             "required": [
-                "is_new",
-                "step"
+                "email",
+                "password"
             ],
             "properties": {
-                "is_new": {
-                    "type": "boolean"
+                "email": {
+                    "description": "User's email address",
+                    "type": "string",
+                    "example": "john.doe@example.com"
                 },
-                "step": {
-                    "type": "string"
+                "password": {
+                    "description": "User's password",
+                    "type": "string",
+                    "example": "Password123!"
                 }
             }
         },
-        "models.GetNewSuggestionsRequest": {
-            "description": "GetNewSuggestionsRequest represents a request to get new suggestions",
+        "models.LoginResponse": {
+            "description": "LoginResponse represents the user login response",
             "type": "object",
+            "required": [
+                "expires_at",
+                "refresh_token",
+                "token"
+            ],
             "properties": {
-                "prompt": {
+                "expires_at": {
+                    "description": "Expiry of the access token",
                     "type": "string"
+                },
+                "refresh_token": {
+                    "description": "Refresh token used to obtain a new access token",
+                    "type": "string",
+                    "example": "\u003cREFRESH_TOKEN\u003e"
+                },
+                "token": {
+                    "description": "Access token for user",
+                    "type": "string",
+                    "example": "\u003cJWT_TOKEN\u003e"
                 }
             }
         },
-        "models.Ingredient": {
-            "description": "Ingredient represents an ingredient in a recipe",
+        "models.LogoutRequest": {
+            "description": "LogoutRequest represents the user logout request payload",
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "description": "Refresh token to revoke",
+                    "type": "string",
+                    "example": "\u003cREFRESH_TOKEN\u003e"
+                }
+            }
+        },
+        "models.MealPlan": {
+            "description": "MealPlan represents a meal plan",
             "type": "object",
             "required": [
+                "id",
                 "name",
-                "quantity",
-                "unit"
+                "recipes",
+                "user_id"
             ],
             "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "12345678-1234-1234-1234-123456789012"
+                },
                 "name": {
                     "type": "string",
-                    "example": "Flour"
+                    "example": "My Meal Plan"
                 },
-                "quantity": {
-                    "type": "number",
-                    "example": 1
+                "recipes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MealPlanRecipe"
+                    }
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "12345678-1234-1234-1234-123456789012"
+                }
+            }
+        },
+        "models.MealPlanRecipe": {
+            "description": "MealPlanRecipe is a recipe in a meal plan, pinned to the recipe version it was added with",
+            "type": "object",
+            "required": [
+                "description",
+                "id",
+                "ingredients",
+                "plan_id",
+                "position",
+                "recipe_id",
+                "recipe_version_id",
+                "servings",
+                "steps",
+                "title",
+                "total_time_minutes"
+            ],
+            "properties": {
+                "day": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "A classic Italian dish"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "ingredients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Ingredient"
+                    }
+                },
+                "plan_id": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "recipe_id": {
+                    "type": "string"
+                },
+                "recipe_version_id": {
+                    "type": "string"
+                },
+                "servings": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Veal Bolognese"
+                },
+                "total_time_minutes": {
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "models.MeasurementUnit": {
+            "type": "string",
+            "enum": [
+                "g",
+                "kg",
+                "ml",
+                "l",
+                "tsp",
+                "tbsp",
+                "fl oz",
+                "cup",
+                "pint",
+                "quart",
+                "gallon",
+                "oz",
+                "lb",
+                "count"
+            ],
+            "x-enum-varnames": [
+                "MeasurementUnitGram",
+                "MeasurementUnitKilogram",
+                "MeasurementUnitMilliliter",
+                "MeasurementUnitLiter",
+                "MeasurementUnitTeaspoon",
+                "MeasurementUnitTablespoon",
+                "MeasurementUnitFluidOunce",
+                "MeasurementUnitCup",
+                "MeasurementUnitPint",
+                "MeasurementUnitQuart",
+                "MeasurementUnitGallon",
+                "MeasurementUnitOunce",
+                "MeasurementUnitPound",
+                "MeasurementUnitCount"
+            ]
+        },
+        "models.MergeConflict": {
+            "description": "MergeConflict is a part of the recipe both sides of a merge changed differently",
+            "type": "object",
+            "required": [
+                "base",
+                "id",
+                "ours",
+                "theirs",
+                "type"
+            ],
+            "properties": {
+                "base": {
+                    "$ref": "#/definitions/models.MergeValue"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "title"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "field:title"
                 },
-                "unit": {
+                "ours": {
+                    "$ref": "#/definitions/models.MergeValue"
+                },
+                "theirs": {
+                    "$ref": "#/definitions/models.MergeValue"
+                },
+                "type": {
+                    "enum": [
+                        "field",
+                        "ingredient",
+                        "steps"
+                    ],
                     "allOf": [
                         {
-                            "$ref": "#/definitions/models.MeasurementUnit"
+                            "$ref": "#/definitions/models.MergeConflictType"
                         }
-                    ],
-                    "example": "cup"
+                    ]
                 }
             }
         },
-        "models.LoginRequest": {
-            "description": "LoginRequest represents the user login request payload",
+        "models.MergeConflictType": {
+            "type": "string",
+            "enum": [
+                "field",
+                "ingredient",
+                "steps"
+            ],
+            "x-enum-varnames": [
+                "MergeConflictField",
+                "MergeConflictIngredient",
+                "MergeConflictSteps"
+            ]
+        },
+        "models.MergeRecipeVersionsRequest": {
+            "description": "MergeRecipeVersionsRequest represents a request to merge a version into the recipe",
             "type": "object",
             "required": [
-                "email",
-                "password"
+                "theirs_version_id"
             ],
             "properties": {
-                "email": {
-                    "description": "User's email address",
-                    "type": "string",
-                    "example": "john.doe@example.com"
+                "ours_version_id": {
+                    "type": "string"
                 },
-                "password": {
-                    "description": "User's password",
-                    "type": "string",
-                    "example": "Password123!"
+                "resolutions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MergeResolution"
+                    }
+                },
+                "theirs_version_id": {
+                    "type": "string"
                 }
             }
         },
-        "models.LoginResponse": {
-            "description": "LoginResponse represents the user login response",
+        "models.MergeResolution": {
+            "description": "MergeResolution picks the side of a merge conflict to keep",
             "type": "object",
             "required": [
-                "token"
+                "conflict_id",
+                "side"
             ],
             "properties": {
-                "token": {
-                    "description": "Access token for user",
+                "conflict_id": {
                     "type": "string",
-                    "example": "\u003cJWT_TOKEN\u003e"
+                    "example": "field:title"
+                },
+                "side": {
+                    "enum": [
+                        "base",
+                        "ours",
+                        "theirs"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MergeSide"
+                        }
+                    ]
                 }
             }
         },
-        "models.MeasurementUnit": {
+        "models.MergeSide": {
             "type": "string",
             "enum": [
-                "g",
-                "ml",
-                "tsp",
-                "tbsp",
-                "cup",
-                "oz",
-                "lb",
-                "count"
+                "base",
+                "ours",
+                "theirs"
             ],
             "x-enum-varnames": [
-                "MeasurementUnitGram",
-                "MeasurementUnitMilliliter",
-                "MeasurementUnitTeaspoon",
-                "MeasurementUnitTablespoon",
-                "MeasurementUnitCup",
-                "MeasurementUnitOunce",
-                "MeasurementUnitPound",
-                "MeasurementUnitCount"
+                "MergeSideBase",
+                "MergeSideOurs",
+                "MergeSideTheirs"
             ]
         },
+        "models.MergeValue": {
+            "description": "MergeValue is one side of a merge conflict. Field conflicts set Text or Number, ingredient conflicts set Ingredient and step conflicts set Steps; an empty value means the side removed it.",
+            "type": "object",
+            "properties": {
+                "ingredient": {
+                    "$ref": "#/definitions/models.Ingredient"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
         "models.ModifiedIngredient": {
             "description": "ModifiedIngredient represents a modification to an ingredient",
             "type": "object",
@@ -1051,6 +4828,65 @@ const docTemplate = `{
                 }
             }
         },
+        "models.MoveCollectionRecipeRequest": {
+            "description": "MoveCollectionRecipeRequest represents a request to move a recipe within a collection",
+            "type": "object",
+            "properties": {
+                "position": {
+                    "description": "Position in the collection, positions past the end move the recipe to the end",
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "models.MoveMealPlanRecipeRequest": {
+            "description": "MoveMealPlanRecipeRequest represents a request to move a recipe within a meal plan",
+            "type": "object",
+            "properties": {
+                "day": {
+                    "description": "Day to move the recipe to, 0 leaves the recipe unscheduled",
+                    "type": "integer",
+                    "example": 2
+                },
+                "position": {
+                    "description": "Position within the day, positions past the end of the day append the recipe",
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "models.PatchOperation": {
+            "description": "PatchOperation is one operation of a JSON Patch (RFC 6902) on a recipe. Paths point into the recipe's JSON, e.g. /ingredients/0/quantity or /steps/- to append a step.",
+            "type": "object",
+            "required": [
+                "op",
+                "path"
+            ],
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "add",
+                        "remove",
+                        "replace",
+                        "move",
+                        "copy",
+                        "test"
+                    ],
+                    "example": "replace"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/ingredients/0/quantity"
+                },
+                "value": {
+                    "type": "object"
+                }
+            }
+        },
         "models.Profile": {
             "description": "Profile represents a user's profile information",
             "type": "object",
@@ -1061,7 +4897,8 @@ const docTemplate = `{
                 "equipment",
                 "name",
                 "setup_step",
-                "skill"
+                "skill",
+                "unit_system"
             ],
             "properties": {
                 "allergies": {
@@ -1111,6 +4948,15 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Skill"
                         }
                     ]
+                },
+                "unit_system": {
+                    "description": "Measurement system recipes are displayed in",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UnitSystem"
+                        }
+                    ],
+                    "example": "imperial"
                 }
             }
         },
@@ -1165,6 +5011,15 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.Skill"
                         }
                     ]
+                },
+                "unit_system": {
+                    "description": "Measurement system recipes are displayed in",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UnitSystem"
+                        }
+                    ],
+                    "example": "metric"
                 }
             }
         },
@@ -1214,13 +5069,15 @@ const docTemplate = `{
             }
         },
         "models.RecipeDiff": {
-            "description": "RecipeDiff represents the difference between two recipe versions",
+            "description": "RecipeDiff represents the difference between two recipe versions. IngredientChanges and StepChanges list every change in the order of the proposed recipe, with removals where they used to be.",
             "type": "object",
             "required": [
                 "added_ingredients",
+                "ingredient_changes",
                 "modified_ingredients",
                 "new_steps",
-                "removed_ingredients"
+                "removed_ingredients",
+                "step_changes"
             ],
             "properties": {
                 "added_ingredients": {
@@ -1229,6 +5086,12 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Ingredient"
                     }
                 },
+                "ingredient_changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.IngredientChange"
+                    }
+                },
                 "modified_ingredients": {
                     "type": "array",
                     "items": {
@@ -1261,9 +5124,57 @@ const docTemplate = `{
                     "items": {
                         "$ref": "#/definitions/models.RemovedIngredient"
                     }
+                },
+                "step_changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StepChange"
+                    }
+                }
+            }
+        },
+        "models.RecipeMerge": {
+            "description": "RecipeMerge is the result of merging two versions of a recipe. Recipe takes our side of every conflict.",
+            "type": "object",
+            "required": [
+                "base_version_id",
+                "conflicts",
+                "ours_version_id",
+                "recipe",
+                "theirs_version_id"
+            ],
+            "properties": {
+                "base_version_id": {
+                    "type": "string"
+                },
+                "conflicts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MergeConflict"
+                    }
+                },
+                "ours_version_id": {
+                    "type": "string"
+                },
+                "recipe": {
+                    "$ref": "#/definitions/models.RecipeBody"
+                },
+                "theirs_version_id": {
+                    "type": "string"
                 }
             }
         },
+        "models.RecipeSource": {
+            "type": "string",
+            "enum": [
+                "scraped",
+                "generated"
+            ],
+            "x-enum-varnames": [
+                "RecipeSourceScraped",
+                "RecipeSourceGenerated"
+            ]
+        },
         "models.RecipeSuggestion": {
             "description": "RecipeSuggestion represents a suggestion for a recipe",
             "type": "object",
@@ -1293,14 +5204,164 @@ const docTemplate = `{
                 "response_text": {
                     "type": "string"
                 },
-                "suggestion": {
-                    "$ref": "#/definitions/models.RecipeBody"
-                },
-                "thread_id": {
-                    "type": "string"
+                "suggestion": {
+                    "$ref": "#/definitions/models.RecipeBody"
+                },
+                "thread_id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RecipeTag": {
+            "description": "RecipeTag is a tag and how many of the user's recipes have it",
+            "type": "object",
+            "required": [
+                "count",
+                "tag"
+            ],
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "tag": {
+                    "type": "string",
+                    "example": "weeknight"
+                }
+            }
+        },
+        "models.RecipeVersion": {
+            "description": "RecipeVersion is an immutable snapshot used inside meal plans.",
+            "type": "object",
+            "required": [
+                "created_at",
+                "description",
+                "id",
+                "ingredients",
+                "servings",
+                "steps",
+                "title",
+                "total_time_minutes",
+                "user_recipe_id"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "A classic Italian dish"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "ingredients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Ingredient"
+                    }
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "servings": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Veal Bolognese"
+                },
+                "total_time_minutes": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "user_recipe_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RecipeVersionSummary": {
+            "description": "RecipeVersionSummary is one version in a recipe's version tree",
+            "type": "object",
+            "required": [
+                "created_at",
+                "id",
+                "is_latest",
+                "title"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_latest": {
+                    "type": "boolean"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RefreshTokenRequest": {
+            "description": "RefreshTokenRequest represents the token refresh request payload",
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "description": "Refresh token issued at login, signup or a previous refresh",
+                    "type": "string",
+                    "example": "\u003cREFRESH_TOKEN\u003e"
+                }
+            }
+        },
+        "models.RefreshTokenResponse": {
+            "description": "RefreshTokenResponse represents the token refresh response",
+            "type": "object",
+            "required": [
+                "expires_at",
+                "refresh_token",
+                "token"
+            ],
+            "properties": {
+                "expires_at": {
+                    "description": "Expiry of the access token",
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "description": "Rotated refresh token, the one used in the request is no longer valid",
+                    "type": "string",
+                    "example": "\u003cREFRESH_TOKEN\u003e"
                 },
-                "updated_at": {
-                    "type": "string"
+                "token": {
+                    "description": "Access token for user",
+                    "type": "string",
+                    "example": "\u003cJWT_TOKEN\u003e"
                 }
             }
         },
@@ -1316,6 +5377,39 @@ const docTemplate = `{
                 }
             }
         },
+        "models.ScaleRecipeRequest": {
+            "description": "ScaleRecipeRequest represents a request to rescale a recipe to a number of servings",
+            "type": "object",
+            "required": [
+                "servings"
+            ],
+            "properties": {
+                "servings": {
+                    "type": "integer",
+                    "example": 8
+                }
+            }
+        },
+        "models.SetRecipeTagsRequest": {
+            "description": "SetRecipeTagsRequest represents a request to replace the tags of a recipe",
+            "type": "object",
+            "required": [
+                "tags"
+            ],
+            "properties": {
+                "tags": {
+                    "description": "Tags are trimmed and lowercased, duplicates are dropped",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "weeknight",
+                        "vegetarian"
+                    ]
+                }
+            }
+        },
         "models.SetupStep": {
             "type": "string",
             "enum": [
@@ -1337,6 +5431,84 @@ const docTemplate = `{
                 "SetupStepDone"
             ]
         },
+        "models.ShoppingList": {
+            "description": "ShoppingList is the aggregated list of ingredients needed for a meal plan",
+            "type": "object",
+            "required": [
+                "categories",
+                "plan_id"
+            ],
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ShoppingListCategory"
+                    }
+                },
+                "plan_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ShoppingListCategory": {
+            "description": "ShoppingListCategory groups shopping list items by store aisle",
+            "type": "object",
+            "required": [
+                "aisle",
+                "items"
+            ],
+            "properties": {
+                "aisle": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Aisle"
+                        }
+                    ],
+                    "example": "produce"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ShoppingListItem"
+                    }
+                }
+            }
+        },
+        "models.ShoppingListItem": {
+            "description": "ShoppingListItem is an ingredient merged across the recipes of a meal plan",
+            "type": "object",
+            "required": [
+                "name",
+                "quantity",
+                "recipes",
+                "unit"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "onion"
+                },
+                "quantity": {
+                    "type": "number",
+                    "example": 3
+                },
+                "recipes": {
+                    "description": "Titles of the recipes that use the ingredient",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "unit": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MeasurementUnit"
+                        }
+                    ],
+                    "example": "count"
+                }
+            }
+        },
         "models.SignupRequest": {
             "description": "SignupRequest represents the user signup request payload",
             "type": "object",
@@ -1361,9 +5533,20 @@ const docTemplate = `{
             "description": "SignupResponse represents the user signup response",
             "type": "object",
             "required": [
+                "expires_at",
+                "refresh_token",
                 "token"
             ],
             "properties": {
+                "expires_at": {
+                    "description": "Expiry of the access token",
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "description": "Refresh token used to obtain a new access token",
+                    "type": "string",
+                    "example": "\u003cREFRESH_TOKEN\u003e"
+                },
                 "token": {
                     "description": "Access token for user",
                     "type": "string",
@@ -1386,6 +5569,27 @@ const docTemplate = `{
                 "SkillChef"
             ]
         },
+        "models.SkippedThreadEvent": {
+            "description": "SkippedThreadEvent is an event of the thread that was left out of its state",
+            "type": "object",
+            "required": [
+                "error",
+                "index",
+                "type"
+            ],
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "description": "Index is the event's position in the thread's event log",
+                    "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.ThreadEventType"
+                }
+            }
+        },
         "models.StartSuggestionThreadRequest": {
             "description": "StartSuggestionThreadRequest represents a request to start a suggestion thread",
             "type": "object",
@@ -1398,6 +5602,122 @@ const docTemplate = `{
                 }
             }
         },
+        "models.StepChange": {
+            "description": "StepChange describes how one step changed. Edited steps have a word by word diff.",
+            "type": "object",
+            "required": [
+                "type"
+            ],
+            "properties": {
+                "after": {
+                    "type": "string"
+                },
+                "before": {
+                    "type": "string"
+                },
+                "from_index": {
+                    "type": "integer"
+                },
+                "to_index": {
+                    "type": "integer"
+                },
+                "type": {
+                    "enum": [
+                        "added",
+                        "removed",
+                        "edited",
+                        "moved"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.StepChangeType"
+                        }
+                    ]
+                },
+                "words": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WordChange"
+                    }
+                }
+            }
+        },
+        "models.StepChangeType": {
+            "type": "string",
+            "enum": [
+                "added",
+                "removed",
+                "edited",
+                "moved"
+            ],
+            "x-enum-varnames": [
+                "StepChangeAdded",
+                "StepChangeRemoved",
+                "StepChangeEdited",
+                "StepChangeMoved"
+            ]
+        },
+        "models.ThreadEvent": {
+            "description": "ThreadEvent represents an event that occurred as part of a suggestion thread",
+            "type": "object",
+            "required": [
+                "payload",
+                "schema_version",
+                "timestamp",
+                "type"
+            ],
+            "properties": {
+                "payload": {
+                    "type": "object"
+                },
+                "schema_version": {
+                    "description": "Version of the payload's schema, events written before payloads were\nversioned are version 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.ThreadEventType"
+                }
+            }
+        },
+        "models.ThreadEventType": {
+            "type": "string",
+            "enum": [
+                "PromptSet",
+                "PromptEdited",
+                "SuggestionGenerated",
+                "SuggestionAccepted",
+                "SuggestionRejected",
+                "RecipeModified",
+                "RecipeModificationAccepted",
+                "RecipeModificationRejected",
+                "RecipeModificationUndone",
+                "RecipeModificationRedone",
+                "RecipeCreated",
+                "RecipeEdited",
+                "QuestionAnswered",
+                "EventDiscarded"
+            ],
+            "x-enum-varnames": [
+                "ThreadEventTypePromptSet",
+                "ThreadEventTypePromptEdited",
+                "ThreadEventTypeSuggestionGenerated",
+                "ThreadEventTypeSuggestionAccepted",
+                "ThreadEventTypeSuggestionRejected",
+                "ThreadEventTypeRecipeModified",
+                "ThreadEventTypeRecipeModificationAccepted",
+                "ThreadEventTypeRecipeModificationRejected",
+                "ThreadEventTypeRecipeModificationUndone",
+                "ThreadEventTypeRecipeModificationRedone",
+                "ThreadEventTypeRecipeCreated",
+                "ThreadEventTypeRecipeEdited",
+                "ThreadEventTypeQuestionAnswered",
+                "ThreadEventTypeEventDiscarded"
+            ]
+        },
         "models.ThreadState": {
             "description": "A thread of suggestions for a recipe",
             "type": "object",
@@ -1407,8 +5727,10 @@ const docTemplate = `{
                 "current_prompt",
                 "id",
                 "original_prompt",
+                "redo_version_ids",
                 "suggestions",
-                "updated_at"
+                "updated_at",
+                "version"
             ],
             "properties": {
                 "chat_history": {
@@ -1438,6 +5760,19 @@ const docTemplate = `{
                 "recipe_id": {
                     "type": "string"
                 },
+                "redo_version_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "skipped_events": {
+                    "description": "SkippedEvents are the events left out of the state because they\ncouldn't be decoded or applied",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SkippedThreadEvent"
+                    }
+                },
                 "suggestions": {
                     "type": "array",
                     "items": {
@@ -1446,6 +5781,88 @@ const docTemplate = `{
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ThreadSummary": {
+            "description": "ThreadSummary describes a thread without its events",
+            "type": "object",
+            "required": [
+                "created_at",
+                "id",
+                "type",
+                "updated_at",
+                "version"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "recipe_id": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.ThreadType"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ThreadType": {
+            "type": "string",
+            "enum": [
+                "Suggestion",
+                "Manual"
+            ],
+            "x-enum-varnames": [
+                "ThreadTypeSuggestion",
+                "ThreadTypeManual"
+            ]
+        },
+        "models.UnitSystem": {
+            "type": "string",
+            "enum": [
+                "metric",
+                "imperial"
+            ],
+            "x-enum-varnames": [
+                "UnitSystemMetric",
+                "UnitSystemImperial"
+            ]
+        },
+        "models.UpdateCollectionRequest": {
+            "description": "UpdateCollectionRequest represents a request to rename a collection",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Weeknight Dinners"
+                }
+            }
+        },
+        "models.UpdateMealPlanRequest": {
+            "description": "UpdateMealPlanRequest represents a request to update a meal plan",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "My Meal Plan"
                 }
             }
         },
@@ -1461,9 +5878,11 @@ const docTemplate = `{
                 "latest_version_id",
                 "servings",
                 "steps",
+                "tags",
                 "thread_id",
                 "title",
                 "total_time_minutes",
+                "update_available",
                 "updated_at",
                 "user_id"
             ],
@@ -1478,6 +5897,10 @@ const docTemplate = `{
                 "global_recipe_id": {
                     "type": "string"
                 },
+                "global_recipe_revision": {
+                    "description": "GlobalRecipeRevision is the revision of the global recipe this recipe\nwas forked from",
+                    "type": "integer"
+                },
                 "id": {
                     "type": "string"
                 },
@@ -1506,6 +5929,12 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "thread_id": {
                     "type": "string"
                 },
@@ -1517,6 +5946,10 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 120
                 },
+                "update_available": {
+                    "description": "UpdateAvailable is set when the global recipe has changed since it was\nforked",
+                    "type": "boolean"
+                },
                 "updated_at": {
                     "type": "string"
                 },
@@ -1524,6 +5957,44 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "models.WordChange": {
+            "description": "WordChange is a run of words that were kept, added or removed in an edited step",
+            "type": "object",
+            "required": [
+                "text",
+                "type"
+            ],
+            "properties": {
+                "text": {
+                    "type": "string"
+                },
+                "type": {
+                    "enum": [
+                        "equal",
+                        "added",
+                        "removed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.WordChangeType"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.WordChangeType": {
+            "type": "string",
+            "enum": [
+                "equal",
+                "added",
+                "removed"
+            ],
+            "x-enum-varnames": [
+                "WordChangeEqual",
+                "WordChangeAdded",
+                "WordChangeRemoved"
+            ]
         }
     }
 }`
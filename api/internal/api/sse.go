@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+// EventStream writes Server-Sent Events. Headers are only sent with the first
+// event, so handlers can still fall back to a plain JSON error if something
+// fails before anything was streamed.
+type EventStream struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	started bool
+}
+
+func NewEventStream(w http.ResponseWriter) *EventStream {
+	return &EventStream{
+		w:  w,
+		rc: http.NewResponseController(w),
+	}
+}
+
+// Send writes a single event with a JSON encoded payload and flushes it to
+// the client.
+func (s *EventStream) Send(event string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", event, err)
+	}
+	if !s.started {
+		s.w.Header().Set("Content-Type", "text/event-stream")
+		s.w.Header().Set("Cache-Control", "no-cache")
+		s.w.Header().Set("Connection", "keep-alive")
+		s.w.Header().Set("X-Accel-Buffering", "no")
+		s.w.WriteHeader(http.StatusOK)
+		s.started = true
+	}
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return fmt.Errorf("failed to write %s event: %w", event, err)
+	}
+	if err := s.rc.Flush(); err != nil {
+		return fmt.Errorf("failed to flush %s event: %w", event, err)
+	}
+	return nil
+}
+
+// Error reports an error to the client, as a JSON response if the stream
+// has not started yet and as an error event otherwise. The 200 status of a
+// started stream has already been sent, so the status of the error is
+// reported to a FailureRecorder wrapping the response instead.
+func (s *EventStream) Error(status int, err models.APIError) {
+	if !s.started {
+		ErrorJSON(s.w, status, err)
+		return
+	}
+	recordFailure(s.w, status)
+	_ = s.Send("error", err)
+}
+
+// FailureRecorder is implemented by response writers that need to know the
+// status a streamed response failed with after its headers were sent.
+type FailureRecorder interface {
+	RecordFailure(status int)
+}
+
+// recordFailure reports the status to the first FailureRecorder among the
+// writer and the writers it wraps.
+func recordFailure(w http.ResponseWriter, status int) {
+	for {
+		if r, ok := w.(FailureRecorder); ok {
+			r.RecordFailure(status)
+			return
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = u.Unwrap()
+	}
+}
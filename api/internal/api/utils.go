@@ -2,9 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/validation"
 	"go.uber.org/zap"
 )
 
@@ -32,3 +34,23 @@ func ErrorJSON(w http.ResponseWriter, status int, err models.APIError) {
 		"error": err,
 	})
 }
+
+// DecodeJSON decodes the request body into v, which must be a pointer, and
+// validates the result.
+func DecodeJSON(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return err
+	}
+	return validation.Validate(v)
+}
+
+// RequestError is the error returned for a request body DecodeJSON rejected:
+// the field that failed validation, or a bad request if it couldn't be
+// decoded.
+func RequestError(err error) models.APIError {
+	var invalid *validation.Error
+	if errors.As(err, &invalid) {
+		return invalid.APIError()
+	}
+	return models.ApiErrBadRequest
+}
@@ -0,0 +1,8 @@
+package auth
+
+import "errors"
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
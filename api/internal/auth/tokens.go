@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultIssuer          = "eatme-api"
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// Claims are the claims carried by an access token. The user ID is stored in
+// the standard "sub" claim.
+type Claims struct {
+	jwt.RegisteredClaims
+}
+
+type TokenManager struct {
+	secret          []byte
+	issuer          string
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	now             func() time.Time
+}
+
+type TokenManagerOpt func(m *TokenManager)
+
+func WithIssuer(issuer string) TokenManagerOpt {
+	return func(m *TokenManager) {
+		m.issuer = issuer
+	}
+}
+
+func WithAccessTokenTTL(ttl time.Duration) TokenManagerOpt {
+	return func(m *TokenManager) {
+		m.accessTokenTTL = ttl
+	}
+}
+
+func WithRefreshTokenTTL(ttl time.Duration) TokenManagerOpt {
+	return func(m *TokenManager) {
+		m.refreshTokenTTL = ttl
+	}
+}
+
+func WithClock(now func() time.Time) TokenManagerOpt {
+	return func(m *TokenManager) {
+		m.now = now
+	}
+}
+
+func NewTokenManager(secret []byte, opts ...TokenManagerOpt) *TokenManager {
+	m := &TokenManager{
+		secret:          secret,
+		issuer:          DefaultIssuer,
+		accessTokenTTL:  DefaultAccessTokenTTL,
+		refreshTokenTTL: DefaultRefreshTokenTTL,
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// IssueAccessToken signs a new access token for the user and returns it along
+// with its expiry.
+func (m *TokenManager) IssueAccessToken(userID string) (string, time.Time, error) {
+	now := m.now()
+	expiresAt := now.Add(m.accessTokenTTL)
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID,
+			Issuer:    m.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// ParseAccessToken verifies the token signature and its claims and returns
+// the claims of a valid token.
+func (m *TokenManager) ParseAccessToken(token string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		return m.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(m.now),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, fmt.Errorf("%w: %w", ErrTokenExpired, err)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+	return &claims, nil
+}
+
+// NewRefreshToken generates an opaque refresh token. The raw token is handed
+// to the client, only its hash is meant to be persisted.
+func (m *TokenManager) NewRefreshToken() (token string, hash string, expiresAt time.Time, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashRefreshToken(token), m.now().Add(m.refreshTokenTTL), nil
+}
+
+func (m *TokenManager) Now() time.Time {
+	return m.now()
+}
+
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
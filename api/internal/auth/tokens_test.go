@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAccessTokenRoundTrip(t *testing.T) {
+	m := NewTokenManager([]byte("secret"))
+	token, expiresAt, err := m.IssueAccessToken("user_1")
+	if err != nil {
+		t.Fatalf("failed to issue access token: %v", err)
+	}
+	if !expiresAt.After(time.Now()) {
+		t.Fatalf("expected expiry in the future, got %v", expiresAt)
+	}
+	claims, err := m.ParseAccessToken(token)
+	if err != nil {
+		t.Fatalf("failed to parse access token: %v", err)
+	}
+	if claims.Subject != "user_1" {
+		t.Fatalf("expected subject %s, got %s", "user_1", claims.Subject)
+	}
+}
+
+func TestAccessTokenExpired(t *testing.T) {
+	issuedAt := time.Now().Add(-time.Hour)
+	issuer := NewTokenManager([]byte("secret"), WithClock(func() time.Time { return issuedAt }))
+	token, _, err := issuer.IssueAccessToken("user_1")
+	if err != nil {
+		t.Fatalf("failed to issue access token: %v", err)
+	}
+	_, err = NewTokenManager([]byte("secret")).ParseAccessToken(token)
+	if !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("expected %v, got %v", ErrTokenExpired, err)
+	}
+}
+
+func TestAccessTokenRejected(t *testing.T) {
+	m := NewTokenManager([]byte("secret"))
+
+	forged, _, err := NewTokenManager([]byte("other-secret")).IssueAccessToken("user_1")
+	if err != nil {
+		t.Fatalf("failed to issue access token: %v", err)
+	}
+
+	otherIssuer, _, err := NewTokenManager([]byte("secret"), WithIssuer("someone-else")).IssueAccessToken("user_1")
+	if err != nil {
+		t.Fatalf("failed to issue access token: %v", err)
+	}
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{
+		Subject:   "user_1",
+		Issuer:    DefaultIssuer,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("failed to build unsigned token: %v", err)
+	}
+
+	for name, token := range map[string]string{
+		"raw user id":    "user_1",
+		"wrong secret":   forged,
+		"wrong issuer":   otherIssuer,
+		"none algorithm": unsigned,
+	} {
+		if _, err := m.ParseAccessToken(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected %v, got %v", name, ErrInvalidToken, err)
+		}
+	}
+}
+
+func TestNewRefreshToken(t *testing.T) {
+	m := NewTokenManager([]byte("secret"))
+	token, hash, expiresAt, err := m.NewRefreshToken()
+	if err != nil {
+		t.Fatalf("failed to create refresh token: %v", err)
+	}
+	if hash != HashRefreshToken(token) {
+		t.Fatalf("expected hash of token, got %s", hash)
+	}
+	if hash == token {
+		t.Fatalf("expected hash to differ from token")
+	}
+	if !expiresAt.After(time.Now().Add(DefaultRefreshTokenTTL - time.Minute)) {
+		t.Fatalf("unexpected refresh token expiry %v", expiresAt)
+	}
+	other, _, _, err := m.NewRefreshToken()
+	if err != nil {
+		t.Fatalf("failed to create refresh token: %v", err)
+	}
+	if other == token {
+		t.Fatalf("expected unique refresh tokens")
+	}
+}
@@ -60,3 +60,45 @@ func (s *ChatService) AnswerCookingQuestion(ctx context.Context, req *models.Gen
 	zap.L().Debug("got chat response")
 	return resp, nil
 }
+
+func (s *ChatService) GenerateSuggestionsStream(ctx context.Context, req *models.SuggestChatRequest, handlers clients.StreamHandlers) (*models.SuggestChatResponse, error) {
+	internalReq := &models.InternalSuggestChatRequest{
+		Message: req.Message,
+		Profile: req.Profile,
+		History: req.History,
+	}
+	resp, err := s.mlClient.SuggestChatStream(ctx, internalReq, handlers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stream chat response: %w", err)
+	}
+	zap.L().Debug("streamed chat response", zap.Int("suggestions", len(resp.Suggestions)))
+	return resp, nil
+}
+
+func (s *ChatService) ModifyRecipeViaChatStream(ctx context.Context, req *models.ModifyChatRequest, handlers clients.StreamHandlers) (*models.ModifyChatResponse, error) {
+	internalReq := &models.InternalModifyChatRequest{
+		Message: req.Message,
+		Recipe:  req.Recipe,
+		Profile: req.Profile,
+	}
+	resp, err := s.mlClient.ModifyChatStream(ctx, internalReq, handlers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stream chat response: %w", err)
+	}
+	zap.L().Debug("streamed chat response")
+	return resp, nil
+}
+
+func (s *ChatService) AnswerCookingQuestionStream(ctx context.Context, req *models.GeneralChatRequest, handlers clients.StreamHandlers) (*models.GeneralChatResponse, error) {
+	internalReq := &models.InternalGeneralChatRequest{
+		Message: req.Message,
+		Recipe:  req.Recipe,
+		Profile: req.Profile,
+	}
+	resp, err := s.mlClient.GeneralChatStream(ctx, internalReq, handlers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stream chat response: %w", err)
+	}
+	zap.L().Debug("streamed chat response")
+	return resp, nil
+}
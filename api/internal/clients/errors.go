@@ -1,8 +1,100 @@
 package clients
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
-	ErrMLCallFailed = errors.New("ML gateway call failed")
-	ErrMLBadRequest = errors.New("ML gateway bad request")
+	ErrMLCallFailed  = errors.New("ML gateway call failed")
+	ErrMLBadRequest  = errors.New("ML gateway bad request")
+	ErrMLTimeout     = errors.New("ML gateway timed out")
+	ErrMLUnavailable = errors.New("ML gateway unavailable")
+	// ErrMLCircuitOpen is returned without calling the gateway after it has
+	// failed repeatedly. It is also an ErrMLUnavailable.
+	ErrMLCircuitOpen = fmt.Errorf("%w: circuit open", ErrMLUnavailable)
+	// ErrMLInvalidResponse is returned when the gateway responds with a
+	// model that fails validation, e.g. a recipe without steps. It is also an
+	// ErrMLCallFailed.
+	ErrMLInvalidResponse = fmt.Errorf("%w: invalid response", ErrMLCallFailed)
 )
+
+// MLError describes a failed call to the ML gateway. It wraps one of the
+// ErrML* errors, so callers can keep matching with errors.Is and use
+// errors.As when they need the status code or the gateway's message.
+type MLError struct {
+	Endpoint Endpoint
+	// StatusCode is the gateway's HTTP status, zero if no response arrived
+	StatusCode int
+	// Message is the error reported by the gateway, if any
+	Message string
+	Err     error
+}
+
+func (e *MLError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", e.Endpoint, e.Err)
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, " (status %d)", e.StatusCode)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Message)
+	}
+	return b.String()
+}
+
+func (e *MLError) Unwrap() error {
+	return e.Err
+}
+
+// retryable reports whether the call may succeed if it is simply repeated.
+// Only connection errors, server errors and invalid responses are retried:
+// the gateway rejected a bad request on purpose, and a timed out call already
+// took as long as we are willing to wait. Responses are generated, so asking
+// again may well produce a valid one.
+func (e *MLError) retryable() bool {
+	if errors.Is(e.Err, ErrMLCircuitOpen) || errors.Is(e.Err, ErrMLTimeout) {
+		return false
+	}
+	if errors.Is(e.Err, ErrMLInvalidResponse) {
+		return true
+	}
+	return e.StatusCode == 0 || e.StatusCode >= 500
+}
+
+// maxErrorMessage bounds how much of an error body is kept.
+const maxErrorMessage = 1 << 10
+
+// errorMessage extracts the error message from a gateway error body. FastAPI
+// reports errors as {"detail": ...}, stream errors use {"error": ...};
+// anything else is kept as text.
+func errorMessage(body []byte) string {
+	var parsed struct {
+		Detail any    `json:"detail"`
+		Error  string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		switch detail := parsed.Detail.(type) {
+		case string:
+			return truncate(detail)
+		case nil:
+		default:
+			if b, err := json.Marshal(detail); err == nil {
+				return truncate(string(b))
+			}
+		}
+		if parsed.Error != "" {
+			return truncate(parsed.Error)
+		}
+	}
+	return truncate(strings.TrimSpace(string(body)))
+}
+
+func truncate(s string) string {
+	if len(s) <= maxErrorMessage {
+		return s
+	}
+	return s[:maxErrorMessage] + "…"
+}
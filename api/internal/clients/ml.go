@@ -4,10 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"io"
+	"math/rand/v2"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/validation"
 	"go.uber.org/zap"
 )
 
@@ -15,119 +20,425 @@ type MLClient interface {
 	SuggestChat(ctx context.Context, req *models.InternalSuggestChatRequest) (*models.SuggestChatResponse, error)
 	ModifyChat(ctx context.Context, req *models.InternalModifyChatRequest) (*models.ModifyChatResponse, error)
 	GeneralChat(ctx context.Context, req *models.InternalGeneralChatRequest) (*models.GeneralChatResponse, error)
+
+	// Streamed variants push partial results to the handlers as the gateway
+	// produces them and return the complete response once the stream ends.
+	SuggestChatStream(ctx context.Context, req *models.InternalSuggestChatRequest, handlers StreamHandlers) (*models.SuggestChatResponse, error)
+	ModifyChatStream(ctx context.Context, req *models.InternalModifyChatRequest, handlers StreamHandlers) (*models.ModifyChatResponse, error)
+	GeneralChatStream(ctx context.Context, req *models.InternalGeneralChatRequest, handlers StreamHandlers) (*models.GeneralChatResponse, error)
+}
+
+// StreamHandlers receive partial results of a streamed response. Nil
+// handlers are skipped, and an error returned by a handler aborts the stream.
+type StreamHandlers struct {
+	OnToken      func(text string) error
+	OnSuggestion func(suggestion *models.Suggestion) error
+}
+
+func (h StreamHandlers) token(endpoint Endpoint, data []byte) error {
+	var token models.StreamToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return invalidResponse(endpoint, "invalid token event")
+	}
+	if h.OnToken == nil {
+		return nil
+	}
+	return h.OnToken(token.Text)
+}
+
+// Endpoint is a path on the ML gateway.
+type Endpoint string
+
+const (
+	EndpointSuggest       Endpoint = "/chat/suggest"
+	EndpointModify        Endpoint = "/chat/modify"
+	EndpointGeneral       Endpoint = "/chat/general"
+	EndpointSuggestStream Endpoint = "/chat/suggest/stream"
+	EndpointModifyStream  Endpoint = "/chat/modify/stream"
+	EndpointGeneralStream Endpoint = "/chat/general/stream"
+)
+
+// MLClientConfig controls how the client calls the gateway.
+type MLClientConfig struct {
+	// Timeouts bound a single attempt at calling an endpoint. For streaming
+	// endpoints they bound the wait for each event rather than the whole
+	// stream.
+	Timeouts map[Endpoint]time.Duration
+	// DefaultTimeout applies to endpoints without a timeout of their own
+	DefaultTimeout time.Duration
+	// MaxRetries is how many times a call failing with a connection error or
+	// a server error is retried
+	MaxRetries int
+	// BackoffBase is the delay before the first retry, doubling with every
+	// further retry up to BackoffMax. Delays are jittered by up to half.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// BreakerThreshold is the number of consecutive failed calls that opens
+	// the circuit, zero disables the breaker
+	BreakerThreshold int
+	// BreakerCooldown is how long the circuit stays open before a trial call
+	// is let through
+	BreakerCooldown time.Duration
+}
+
+func DefaultMLClientConfig() MLClientConfig {
+	return MLClientConfig{
+		Timeouts: map[Endpoint]time.Duration{
+			// suggesting generates an image for every recipe
+			EndpointSuggest:       2 * time.Minute,
+			EndpointModify:        90 * time.Second,
+			EndpointGeneral:       30 * time.Second,
+			EndpointSuggestStream: 90 * time.Second,
+			EndpointModifyStream:  90 * time.Second,
+			EndpointGeneralStream: 30 * time.Second,
+		},
+		DefaultTimeout:   60 * time.Second,
+		MaxRetries:       2,
+		BackoffBase:      250 * time.Millisecond,
+		BackoffMax:       2 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+func (c MLClientConfig) timeout(endpoint Endpoint) time.Duration {
+	if t, ok := c.Timeouts[endpoint]; ok && t > 0 {
+		return t
+	}
+	return c.DefaultTimeout
 }
 
 type mlClient struct {
-	http *http.Client
-	host string
+	http    *http.Client
+	host    string
+	config  MLClientConfig
+	breaker *breaker
 }
 
-func NewMLClient(host string) MLClient {
+func NewMLClient(host string, config MLClientConfig) MLClient {
 	return mlClient{
-		http: &http.Client{},
-		host: host,
+		http:    &http.Client{},
+		host:    host,
+		config:  config,
+		breaker: newBreaker(config.BreakerThreshold, config.BreakerCooldown),
 	}
 }
 
 func (c mlClient) SuggestChat(ctx context.Context, req *models.InternalSuggestChatRequest) (*models.SuggestChatResponse, error) {
+	var mlResp models.SuggestChatResponse
+	if err := c.call(ctx, EndpointSuggest, req, &mlResp); err != nil {
+		return nil, err
+	}
+	return &mlResp, nil
+}
+
+func (c mlClient) ModifyChat(ctx context.Context, req *models.InternalModifyChatRequest) (*models.ModifyChatResponse, error) {
+	var mlResp models.ModifyChatResponse
+	if err := c.call(ctx, EndpointModify, req, &mlResp); err != nil {
+		return nil, err
+	}
+	return &mlResp, nil
+}
+
+func (c mlClient) GeneralChat(ctx context.Context, req *models.InternalGeneralChatRequest) (*models.GeneralChatResponse, error) {
+	var mlResp models.GeneralChatResponse
+	if err := c.call(ctx, EndpointGeneral, req, &mlResp); err != nil {
+		return nil, err
+	}
+	return &mlResp, nil
+}
+
+// call posts a request to the gateway and decodes the response into out,
+// retrying and timing out attempts as configured. Responses that fail
+// validation are retried too.
+func (c mlClient) call(ctx context.Context, endpoint Endpoint, req any, out any) error {
 	body, err := json.Marshal(req)
 	if err != nil {
-		return nil, fmt.Errorf("marshal req: %w", ErrMLBadRequest)
+		return &MLError{Endpoint: endpoint, Message: err.Error(), Err: ErrMLBadRequest}
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.host+"/chat/suggest", bytes.NewReader(body))
+	var data []byte
+	err = c.withRetries(ctx, endpoint, func() error {
+		attemptCtx, cancel := context.WithTimeout(ctx, c.config.timeout(endpoint))
+		defer cancel()
+		resp, err := c.post(ctx, attemptCtx, endpoint, body, "application/json")
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		data, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return transportError(ctx, attemptCtx, endpoint)
+		}
+		zap.L().Debug("got raw ml response", zap.String("endpoint", string(endpoint)), zap.ByteString("response_body", data))
+		if err := json.Unmarshal(data, out); err != nil {
+			return invalidResponse(endpoint, "invalid response body")
+		}
+		return validResponse(endpoint, validation.Validate(out))
+	})
+	return err
+}
+
+// post sends a single request. attemptCtx is the context of this attempt,
+// ctx the caller's, so a cancelled call can be told apart from a timed out
+// one.
+func (c mlClient) post(ctx, attemptCtx context.Context, endpoint Endpoint, body []byte, accept string) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(attemptCtx, http.MethodPost, c.host+string(endpoint), bytes.NewReader(body))
 	if err != nil {
-		return nil, fmt.Errorf("new req: %w", ErrMLCallFailed)
+		return nil, &MLError{Endpoint: endpoint, Message: err.Error(), Err: ErrMLCallFailed}
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", accept)
 
 	resp, err := c.http.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("ml call: %w", ErrMLCallFailed)
+		zap.L().Debug("ml call failed", zap.String("endpoint", string(endpoint)), zap.Error(err))
+		return nil, transportError(ctx, attemptCtx, endpoint)
 	}
-	defer resp.Body.Close()
-
 	if resp.StatusCode != http.StatusOK {
-		switch resp.StatusCode {
-		case http.StatusBadRequest:
-			return nil, fmt.Errorf("ml bad status: %s", ErrMLBadRequest)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorMessage*4))
+		return nil, &MLError{
+			Endpoint:   endpoint,
+			StatusCode: resp.StatusCode,
+			Message:    errorMessage(data),
+			Err:        statusError(resp.StatusCode),
+		}
+	}
+	return resp, nil
+}
+
+// withRetries makes attempts until one succeeds, fails in a way retrying
+// won't fix, or the retries run out. Every attempt goes through the circuit
+// breaker.
+func (c mlClient) withRetries(ctx context.Context, endpoint Endpoint, attempt func() error) error {
+	for retry := 0; ; retry++ {
+		if !c.breaker.allow() {
+			return &MLError{Endpoint: endpoint, Err: ErrMLCircuitOpen}
+		}
+		err := attempt()
+		var mlErr *MLError
+		switch {
+		case err == nil:
+			c.breaker.success()
+			return nil
+		case !errors.As(err, &mlErr):
+			// the caller gave up, which says nothing about the gateway
+			c.breaker.release()
+			return err
+		case mlErr.StatusCode == 0 || mlErr.StatusCode >= 500:
+			c.breaker.failure()
 		default:
-			return nil, fmt.Errorf("ml bad status: %s", ErrMLCallFailed)
+			c.breaker.success()
+		}
+		if !mlErr.retryable() || retry >= c.config.MaxRetries {
+			return err
+		}
+
+		delay := c.backoff(retry)
+		zap.L().Warn("retrying ml call", zap.String("endpoint", string(endpoint)), zap.Int("retry", retry+1), zap.Duration("delay", delay), zap.Error(err))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
 		}
 	}
+}
 
-	zap.L().Debug("got raw ml response", zap.Any("response body", resp.Body))
-	var mlResp models.SuggestChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&mlResp); err != nil {
-		return nil, fmt.Errorf("decode ml resp: %w", ErrMLCallFailed)
+// backoff returns the delay before a retry: exponential, capped, and
+// jittered so that clients that failed together don't retry together.
+func (c mlClient) backoff(retry int) time.Duration {
+	delay := c.config.BackoffBase << retry
+	if delay <= 0 || (c.config.BackoffMax > 0 && delay > c.config.BackoffMax) {
+		delay = c.config.BackoffMax
 	}
-	return &mlResp, nil
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
 }
 
-func (c mlClient) ModifyChat(ctx context.Context, req *models.InternalModifyChatRequest) (*models.ModifyChatResponse, error) {
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("marshal req: %w", ErrMLBadRequest)
+func transportError(ctx, attemptCtx context.Context, endpoint Endpoint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if attemptCtx.Err() != nil {
+		return &MLError{Endpoint: endpoint, Err: ErrMLTimeout}
 	}
+	return &MLError{Endpoint: endpoint, Err: ErrMLUnavailable}
+}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.host+"/chat/modify", bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("new req: %w", ErrMLCallFailed)
+func statusError(status int) error {
+	switch {
+	case status == http.StatusGatewayTimeout:
+		return ErrMLTimeout
+	case status == http.StatusBadGateway, status == http.StatusServiceUnavailable:
+		return ErrMLUnavailable
+	case status >= 400 && status < 500:
+		return ErrMLBadRequest
+	default:
+		return ErrMLCallFailed
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
+}
 
-	resp, err := c.http.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("ml call: %w", ErrMLCallFailed)
+func invalidResponse(endpoint Endpoint, message string) error {
+	return &MLError{Endpoint: endpoint, StatusCode: http.StatusOK, Message: message, Err: ErrMLCallFailed}
+}
+
+// validResponse turns a validation error for a response into the error
+// returned to the caller.
+func validResponse(endpoint Endpoint, err error) error {
+	if err == nil {
+		return nil
 	}
-	defer resp.Body.Close()
+	return &MLError{Endpoint: endpoint, StatusCode: http.StatusOK, Message: err.Error(), Err: ErrMLInvalidResponse}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		switch resp.StatusCode {
-		case http.StatusBadRequest:
-			return nil, fmt.Errorf("ml bad status: %s", ErrMLBadRequest)
-		default:
-			return nil, fmt.Errorf("ml bad status: %s", ErrMLCallFailed)
+// Stream events sent by the ML gateway
+const (
+	streamEventToken      = "token"
+	streamEventSuggestion = "suggestion"
+	streamEventResult     = "result"
+	streamEventError      = "error"
+	streamEventDone       = "done"
+)
+
+func (c mlClient) SuggestChatStream(ctx context.Context, req *models.InternalSuggestChatRequest, handlers StreamHandlers) (*models.SuggestChatResponse, error) {
+	mlResp := &models.SuggestChatResponse{Suggestions: []*models.Suggestion{}}
+	err := c.stream(ctx, EndpointSuggestStream, req, func(e sseEvent) error {
+		switch e.event {
+		case streamEventToken:
+			return handlers.token(EndpointSuggestStream, e.data)
+		case streamEventSuggestion:
+			var suggestion models.Suggestion
+			if err := json.Unmarshal(e.data, &suggestion); err != nil {
+				return invalidResponse(EndpointSuggestStream, "invalid suggestion event")
+			}
+			if err := validResponse(EndpointSuggestStream, validation.Suggestion(&suggestion)); err != nil {
+				return err
+			}
+			mlResp.Suggestions = append(mlResp.Suggestions, &suggestion)
+			if handlers.OnSuggestion != nil {
+				return handlers.OnSuggestion(&suggestion)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return mlResp, nil
+}
 
-	var mlResp models.ModifyChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&mlResp); err != nil {
-		return nil, fmt.Errorf("decode ml resp: %w", ErrMLCallFailed)
+func (c mlClient) ModifyChatStream(ctx context.Context, req *models.InternalModifyChatRequest, handlers StreamHandlers) (*models.ModifyChatResponse, error) {
+	var mlResp *models.ModifyChatResponse
+	err := c.stream(ctx, EndpointModifyStream, req, func(e sseEvent) error {
+		switch e.event {
+		case streamEventToken:
+			return handlers.token(EndpointModifyStream, e.data)
+		case streamEventResult:
+			mlResp = &models.ModifyChatResponse{}
+			if err := json.Unmarshal(e.data, mlResp); err != nil {
+				return invalidResponse(EndpointModifyStream, "invalid result event")
+			}
+			if err := validResponse(EndpointModifyStream, validation.Validate(mlResp)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return &mlResp, nil
+	if mlResp == nil {
+		return nil, invalidResponse(EndpointModifyStream, "stream has no result")
+	}
+	return mlResp, nil
 }
 
-func (c mlClient) GeneralChat(ctx context.Context, req *models.InternalGeneralChatRequest) (*models.GeneralChatResponse, error) {
-	body, err := json.Marshal(req)
+func (c mlClient) GeneralChatStream(ctx context.Context, req *models.InternalGeneralChatRequest, handlers StreamHandlers) (*models.GeneralChatResponse, error) {
+	var text strings.Builder
+	onToken := handlers.OnToken
+	handlers.OnToken = func(token string) error {
+		text.WriteString(token)
+		if onToken == nil {
+			return nil
+		}
+		return onToken(token)
+	}
+	err := c.stream(ctx, EndpointGeneralStream, req, func(e sseEvent) error {
+		if e.event != streamEventToken {
+			return nil
+		}
+		return handlers.token(EndpointGeneralStream, e.data)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("marshal req: %w", ErrMLBadRequest)
+		return nil, err
 	}
+	return &models.GeneralChatResponse{ResponseText: text.String()}, nil
+}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.host+"/chat/general", bytes.NewReader(body))
+// stream posts a request to a streaming gateway endpoint and calls fn for
+// every event until the gateway reports it is done. Opening the stream is
+// retried like any other call, but once events have been received a failure
+// ends the call, since they may already have been passed on. A stream that
+// ends without a done event is treated as a failed call.
+func (c mlClient) stream(ctx context.Context, endpoint Endpoint, req any, fn func(sseEvent) error) error {
+	body, err := json.Marshal(req)
 	if err != nil {
-		return nil, fmt.Errorf("new req: %w", ErrMLCallFailed)
+		return &MLError{Endpoint: endpoint, Message: err.Error(), Err: ErrMLBadRequest}
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.http.Do(httpReq)
+	timeout := c.config.timeout(endpoint)
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	idle := time.AfterFunc(timeout, cancel)
+	defer idle.Stop()
+
+	var resp *http.Response
+	err = c.withRetries(ctx, endpoint, func() error {
+		idle.Reset(timeout)
+		resp, err = c.post(ctx, streamCtx, endpoint, body, "text/event-stream")
+		if err != nil {
+			idle.Stop()
+		}
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("ml call: %w", ErrMLCallFailed)
+		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		switch resp.StatusCode {
-		case http.StatusBadRequest:
-			return nil, fmt.Errorf("ml bad status: %s", ErrMLBadRequest)
-		default:
-			return nil, fmt.Errorf("ml bad status: %s", ErrMLCallFailed)
+	done := false
+	err = readEvents(resp.Body, func(e sseEvent) error {
+		idle.Reset(timeout)
+		switch e.event {
+		case streamEventError:
+			return &MLError{
+				Endpoint:   endpoint,
+				StatusCode: http.StatusOK,
+				Message:    errorMessage(e.data),
+				Err:        ErrMLCallFailed,
+			}
+		case streamEventDone:
+			done = true
+			return nil
 		}
+		return fn(e)
+	})
+	if err != nil {
+		if streamCtx.Err() != nil {
+			return transportError(ctx, streamCtx, endpoint)
+		}
+		return err
 	}
-
-	var mlResp models.GeneralChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&mlResp); err != nil {
-		return nil, fmt.Errorf("decode ml resp: %w", ErrMLCallFailed)
+	if !done {
+		if streamCtx.Err() != nil {
+			return transportError(ctx, streamCtx, endpoint)
+		}
+		return invalidResponse(endpoint, "stream ended early")
 	}
-	return &mlResp, nil
+	return nil
 }
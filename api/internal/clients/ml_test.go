@@ -0,0 +1,290 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func testConfig() MLClientConfig {
+	return MLClientConfig{
+		DefaultTimeout:   time.Second,
+		MaxRetries:       2,
+		BackoffBase:      time.Millisecond,
+		BackoffMax:       5 * time.Millisecond,
+		BreakerThreshold: 5,
+		BreakerCooldown:  time.Minute,
+	}
+}
+
+// newGateway serves the given responses in turn on every path, repeating the
+// last one, and counts the calls it receives.
+func newGateway(t *testing.T, responses ...func(w http.ResponseWriter)) (string, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		responses[min(n, len(responses))-1](w)
+	}))
+	t.Cleanup(ts.Close)
+	return ts.URL, &calls
+}
+
+func status(code int, body string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.WriteHeader(code)
+		fmt.Fprint(w, body)
+	}
+}
+
+// tacos is a recipe that passes validation.
+const tacos = `{"title":"Tacos","description":"Street tacos","servings":4,"total_time_minutes":30,` +
+	`"ingredients":[{"name":"Tortillas","quantity":8,"unit":"count"}],"steps":["Warm the tortillas"]}`
+
+func TestRetriesServerErrors(t *testing.T) {
+	host, calls := newGateway(t,
+		status(http.StatusInternalServerError, `{"detail":"boom"}`),
+		status(http.StatusBadGateway, ""),
+		status(http.StatusOK, `{"response_text":"Bake it"}`),
+	)
+	client := NewMLClient(host, testConfig())
+
+	resp, err := client.GeneralChat(context.Background(), &models.InternalGeneralChatRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ResponseText != "Bake it" {
+		t.Errorf("expected %q, got %q", "Bake it", resp.ResponseText)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 calls, got %d", calls.Load())
+	}
+}
+
+func TestGivesUpAfterMaxRetries(t *testing.T) {
+	host, calls := newGateway(t, status(http.StatusInternalServerError, `{"detail":"model overloaded"}`))
+	client := NewMLClient(host, testConfig())
+
+	_, err := client.GeneralChat(context.Background(), &models.InternalGeneralChatRequest{})
+	var mlErr *MLError
+	if !errors.As(err, &mlErr) {
+		t.Fatalf("expected an MLError, got %v", err)
+	}
+	if mlErr.StatusCode != http.StatusInternalServerError || mlErr.Message != "model overloaded" {
+		t.Errorf("unexpected error details: %+v", mlErr)
+	}
+	if !errors.Is(err, ErrMLCallFailed) {
+		t.Errorf("expected ErrMLCallFailed, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 1 call and 2 retries, got %d calls", calls.Load())
+	}
+}
+
+func TestDoesNotRetryBadRequests(t *testing.T) {
+	host, calls := newGateway(t, status(http.StatusUnprocessableEntity, `{"detail":[{"msg":"field required"}]}`))
+	client := NewMLClient(host, testConfig())
+
+	_, err := client.GeneralChat(context.Background(), &models.InternalGeneralChatRequest{})
+	var mlErr *MLError
+	if !errors.As(err, &mlErr) || !errors.Is(err, ErrMLBadRequest) {
+		t.Fatalf("expected a bad request MLError, got %v", err)
+	}
+	if mlErr.Message != `[{"msg":"field required"}]` {
+		t.Errorf("unexpected message %q", mlErr.Message)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single call, got %d", calls.Load())
+	}
+}
+
+func TestTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	host, calls := newGateway(t, func(w http.ResponseWriter) {
+		<-release
+	})
+	config := testConfig()
+	config.Timeouts = map[Endpoint]time.Duration{EndpointGeneral: 20 * time.Millisecond}
+	client := NewMLClient(host, config)
+
+	_, err := client.GeneralChat(context.Background(), &models.InternalGeneralChatRequest{})
+	if !errors.Is(err, ErrMLTimeout) {
+		t.Fatalf("expected ErrMLTimeout, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected timeouts not to be retried, got %d calls", calls.Load())
+	}
+}
+
+func TestCallerCancellation(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	host, _ := newGateway(t, func(w http.ResponseWriter) {
+		<-release
+	})
+	client := NewMLClient(host, testConfig())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.GeneralChat(ctx, &models.InternalGeneralChatRequest{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the caller's context error, got %v", err)
+	}
+	var mlErr *MLError
+	if errors.As(err, &mlErr) {
+		t.Errorf("expected a cancelled call not to be reported as a gateway failure, got %v", err)
+	}
+}
+
+func TestConnectionErrors(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	host := ts.URL
+	ts.Close()
+	client := NewMLClient(host, testConfig())
+
+	_, err := client.GeneralChat(context.Background(), &models.InternalGeneralChatRequest{})
+	if !errors.Is(err, ErrMLUnavailable) {
+		t.Fatalf("expected ErrMLUnavailable, got %v", err)
+	}
+}
+
+func TestCircuitBreakerOpens(t *testing.T) {
+	host, calls := newGateway(t, status(http.StatusServiceUnavailable, ""))
+	config := testConfig()
+	config.MaxRetries = 0
+	config.BreakerThreshold = 3
+	client := NewMLClient(host, config)
+
+	for range 3 {
+		_, err := client.GeneralChat(context.Background(), &models.InternalGeneralChatRequest{})
+		if !errors.Is(err, ErrMLUnavailable) || errors.Is(err, ErrMLCircuitOpen) {
+			t.Fatalf("expected the gateway to be called, got %v", err)
+		}
+	}
+	_, err := client.GeneralChat(context.Background(), &models.InternalGeneralChatRequest{})
+	if !errors.Is(err, ErrMLCircuitOpen) {
+		t.Fatalf("expected ErrMLCircuitOpen, got %v", err)
+	}
+	if !errors.Is(err, ErrMLUnavailable) {
+		t.Errorf("expected an open circuit to be an ErrMLUnavailable")
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected the open circuit to fail fast, got %d calls", calls.Load())
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	b := newBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	if !b.allow() {
+		t.Fatal("expected a single failure to leave the circuit closed")
+	}
+	b.success()
+	b.failure()
+	if !b.allow() {
+		t.Fatal("expected a success to reset the failure count")
+	}
+	b.failure()
+	if b.allow() {
+		t.Fatal("expected the circuit to open")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("expected a trial call after the cooldown")
+	}
+	if b.allow() {
+		t.Fatal("expected only one trial call at a time")
+	}
+	b.failure()
+	if b.allow() {
+		t.Fatal("expected a failed trial to reopen the circuit")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("expected a trial call after the cooldown")
+	}
+	b.success()
+	if !b.allow() || !b.allow() {
+		t.Fatal("expected a successful trial to close the circuit")
+	}
+}
+
+func TestStreamIdleTimeout(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: token\ndata: {\"text\":\"Bake\"}\n\n")
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+	config := testConfig()
+	config.Timeouts = map[Endpoint]time.Duration{EndpointGeneralStream: 50 * time.Millisecond}
+	client := NewMLClient(ts.URL, config)
+
+	var tokens []string
+	_, err := client.GeneralChatStream(context.Background(), &models.InternalGeneralChatRequest{}, StreamHandlers{
+		OnToken: func(text string) error {
+			tokens = append(tokens, text)
+			return nil
+		},
+	})
+	if !errors.Is(err, ErrMLTimeout) {
+		t.Fatalf("expected ErrMLTimeout, got %v", err)
+	}
+	if len(tokens) != 1 {
+		t.Errorf("expected the token sent before the stall, got %v", tokens)
+	}
+}
+
+func TestRetriesInvalidResponses(t *testing.T) {
+	host, calls := newGateway(t,
+		status(http.StatusOK, `{"response_text":"Try this","new_recipe":{"title":"Tacos","servings":0}}`),
+		status(http.StatusOK, `{"response_text":"Try this","new_recipe":`+tacos+`}`),
+	)
+	client := NewMLClient(host, testConfig())
+
+	resp, err := client.ModifyChat(context.Background(), &models.InternalModifyChatRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.NewRecipe.Title != "Tacos" || calls.Load() != 2 {
+		t.Errorf("expected the valid recipe on the second call, got %+v after %d calls", resp.NewRecipe, calls.Load())
+	}
+
+	host, calls = newGateway(t, status(http.StatusOK, `{"suggestions":[{"recipe":`+tacos+`},{"recipe":{"title":"Soup"}}]}`))
+	client = NewMLClient(host, testConfig())
+	_, err = client.SuggestChat(context.Background(), &models.InternalSuggestChatRequest{})
+	var mlErr *MLError
+	if !errors.As(err, &mlErr) || !errors.Is(err, ErrMLInvalidResponse) || !errors.Is(err, ErrMLCallFailed) {
+		t.Fatalf("expected an invalid response error, got %v", err)
+	}
+	if !strings.Contains(mlErr.Message, "suggestions[1].recipe.servings") {
+		t.Errorf("expected the error to name the invalid field, got %q", mlErr.Message)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 calls, got %d", calls.Load())
+	}
+
+	// Refused modifications come without a recipe
+	host, _ = newGateway(t, status(http.StatusOK, `{"response_text":"No","error":"contains peanuts"}`))
+	client = NewMLClient(host, testConfig())
+	if _, err := client.ModifyChat(context.Background(), &models.InternalModifyChatRequest{}); err != nil {
+		t.Errorf("expected a refused modification to be valid, got %v", err)
+	}
+}
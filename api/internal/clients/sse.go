@@ -0,0 +1,60 @@
+package clients
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// maxEventSize bounds a single event, suggestion events carry a full recipe.
+const maxEventSize = 4 << 20
+
+type sseEvent struct {
+	event string
+	data  []byte
+}
+
+// readEvents reads Server-Sent Events and calls fn for each complete event
+// until the stream ends or fn returns an error. Comment lines, which servers
+// send as keep-alives, are skipped.
+func readEvents(r io.Reader, fn func(sseEvent) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxEventSize)
+
+	var current sseEvent
+	hasData := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if !hasData {
+				current = sseEvent{}
+				continue
+			}
+			if current.event == "" {
+				current.event = "message"
+			}
+			if err := fn(current); err != nil {
+				return err
+			}
+			current = sseEvent{}
+			hasData = false
+		case strings.HasPrefix(line, ":"):
+			continue
+		default:
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				current.event = value
+			case "data":
+				if hasData {
+					current.data = append(current.data, '\n')
+				}
+				current.data = append(current.data, value...)
+				hasData = true
+			}
+		}
+	}
+	return scanner.Err()
+}
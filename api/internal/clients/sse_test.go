@@ -0,0 +1,196 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestReadEvents(t *testing.T) {
+	stream := ": keep-alive\n\n" +
+		"event: token\ndata: {\"text\":\"Hi\"}\n\n" +
+		"data: line one\ndata: line two\n\n" +
+		"event: done\ndata: {}\n\n"
+
+	var events []sseEvent
+	err := readEvents(strings.NewReader(stream), func(e sseEvent) error {
+		events = append(events, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []sseEvent{
+		{event: "token", data: []byte(`{"text":"Hi"}`)},
+		{event: "message", data: []byte("line one\nline two")},
+		{event: "done", data: []byte("{}")},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(events))
+	}
+	for i, e := range expected {
+		if events[i].event != e.event || string(events[i].data) != string(e.data) {
+			t.Errorf("event %d: expected %s %q, got %s %q", i, e.event, e.data, events[i].event, events[i].data)
+		}
+	}
+}
+
+func newStreamServer(t *testing.T, path string, events ...string) MLClient {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			fmt.Fprint(w, e)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return NewMLClient(ts.URL, testConfig())
+}
+
+func TestSuggestChatStream(t *testing.T) {
+	client := newStreamServer(t, "/chat/suggest/stream",
+		"event: token\ndata: {\"text\":\"How \"}\n\n",
+		"event: token\ndata: {\"text\":\"about\"}\n\n",
+		"event: suggestion\ndata: {\"recipe\":"+tacos+",\"response_text\":\"How about\"}\n\n",
+		"event: done\ndata: {}\n\n",
+	)
+
+	var tokens []string
+	var titles []string
+	resp, err := client.SuggestChatStream(context.Background(), &models.InternalSuggestChatRequest{}, StreamHandlers{
+		OnToken: func(text string) error {
+			tokens = append(tokens, text)
+			return nil
+		},
+		OnSuggestion: func(suggestion *models.Suggestion) error {
+			titles = append(titles, suggestion.Recipe.Title)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(tokens, "") != "How about" {
+		t.Errorf("expected tokens to spell %q, got %q", "How about", tokens)
+	}
+	if len(titles) != 1 || titles[0] != "Tacos" {
+		t.Errorf("expected one Tacos suggestion, got %v", titles)
+	}
+	if len(resp.Suggestions) != 1 || resp.Suggestions[0].ResponseText != "How about" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestSuggestChatStreamInvalidSuggestion(t *testing.T) {
+	client := newStreamServer(t, "/chat/suggest/stream",
+		"event: suggestion\ndata: {\"recipe\":{\"title\":\"Tacos\"},\"response_text\":\"How about\"}\n\n",
+		"event: done\ndata: {}\n\n",
+	)
+
+	called := false
+	_, err := client.SuggestChatStream(context.Background(), &models.InternalSuggestChatRequest{}, StreamHandlers{
+		OnSuggestion: func(suggestion *models.Suggestion) error {
+			called = true
+			return nil
+		},
+	})
+	var mlErr *MLError
+	if !errors.As(err, &mlErr) || !errors.Is(err, ErrMLInvalidResponse) {
+		t.Fatalf("expected an invalid response error, got %v", err)
+	}
+	if called {
+		t.Error("expected the invalid suggestion not to be passed on")
+	}
+}
+
+func TestGeneralChatStreamCollectsTokens(t *testing.T) {
+	client := newStreamServer(t, "/chat/general/stream",
+		"event: token\ndata: {\"text\":\"Bake at \"}\n\n",
+		"event: token\ndata: {\"text\":\"350F\"}\n\n",
+		"event: done\ndata: {}\n\n",
+	)
+
+	resp, err := client.GeneralChatStream(context.Background(), &models.InternalGeneralChatRequest{}, StreamHandlers{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ResponseText != "Bake at 350F" {
+		t.Errorf("expected %q, got %q", "Bake at 350F", resp.ResponseText)
+	}
+}
+
+func TestModifyChatStreamRequiresResult(t *testing.T) {
+	client := newStreamServer(t, "/chat/modify/stream",
+		"event: token\ndata: {\"text\":\"Sure\"}\n\n",
+		"event: done\ndata: {}\n\n",
+	)
+
+	_, err := client.ModifyChatStream(context.Background(), &models.InternalModifyChatRequest{}, StreamHandlers{})
+	if !errors.Is(err, ErrMLCallFailed) {
+		t.Errorf("expected ErrMLCallFailed, got %v", err)
+	}
+}
+
+func TestStreamFailures(t *testing.T) {
+	testCases := []struct {
+		name   string
+		events []string
+	}{
+		{
+			name:   "error event",
+			events: []string{"event: error\ndata: {\"error\":\"overloaded\"}\n\n"},
+		},
+		{
+			name:   "ends without done",
+			events: []string{"event: token\ndata: {\"text\":\"Bake\"}\n\n"},
+		},
+		{
+			name:   "malformed token",
+			events: []string{"event: token\ndata: not json\n\n", "event: done\ndata: {}\n\n"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newStreamServer(t, "/chat/general/stream", tc.events...)
+			_, err := client.GeneralChatStream(context.Background(), &models.InternalGeneralChatRequest{}, StreamHandlers{})
+			if !errors.Is(err, ErrMLCallFailed) {
+				t.Errorf("expected ErrMLCallFailed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStreamHandlerErrorAborts(t *testing.T) {
+	client := newStreamServer(t, "/chat/general/stream",
+		"event: token\ndata: {\"text\":\"Bake\"}\n\n",
+		"event: token\ndata: {\"text\":\" more\"}\n\n",
+		"event: done\ndata: {}\n\n",
+	)
+
+	errClientGone := errors.New("client gone")
+	calls := 0
+	_, err := client.GeneralChatStream(context.Background(), &models.InternalGeneralChatRequest{}, StreamHandlers{
+		OnToken: func(string) error {
+			calls++
+			return errClientGone
+		},
+	})
+	if !errors.Is(err, errClientGone) {
+		t.Errorf("expected handler error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected the stream to stop after the first token, got %d calls", calls)
+	}
+}
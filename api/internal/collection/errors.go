@@ -0,0 +1,9 @@
+package collection
+
+import "errors"
+
+var (
+	ErrCollectionNotFound       = errors.New("collection not found")
+	ErrCollectionRecipeNotFound = errors.New("recipe is not in the collection")
+	ErrInvalidCollectionName    = errors.New("invalid collection name")
+)
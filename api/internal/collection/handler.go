@@ -0,0 +1,313 @@
+package collection
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ajohnston1219/eatme/api/internal/api"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/recipe"
+	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type CollectionHandler struct {
+	collectionService *CollectionService
+}
+
+func NewCollectionHandler(collectionService *CollectionService) *CollectionHandler {
+	return &CollectionHandler{
+		collectionService: collectionService,
+	}
+}
+
+// @Summary Get all collections
+// @Description Get all recipe collections for user
+// @ID getAllCollections
+// @Tags Collection
+// @Produce json
+// @Success 200 {array} models.Collection
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /collections [get]
+func (h *CollectionHandler) GetAllCollections(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	collections, err := h.collectionService.GetAllCollections(r.Context(), userID)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to get collections", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, collections)
+}
+
+// @Summary Create a collection
+// @Description Create an empty recipe collection
+// @ID createCollection
+// @Tags Collection
+// @Accept json
+// @Produce json
+// @Param request body models.CreateCollectionRequest true "Create collection request"
+// @Success 201 {object} models.Collection
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /collections [post]
+func (h *CollectionHandler) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	var input models.CreateCollectionRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode create collection request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+	collection, err := h.collectionService.CreateCollection(r.Context(), userID, input.Name)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to create collection", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusCreated, collection)
+}
+
+// @Summary Get a collection
+// @Description Get a recipe collection by ID
+// @ID getCollection
+// @Tags Collection
+// @Produce json
+// @Param collectionId path string true "Collection ID"
+// @Success 200 {object} models.Collection
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Collection not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /collections/{collectionId} [get]
+func (h *CollectionHandler) GetCollection(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	collectionID := chi.URLParam(r, "collectionId")
+	if collectionID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	collection, err := h.collectionService.GetCollection(r.Context(), userID, collectionID)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to get collection", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, collection)
+}
+
+// @Summary Update a collection
+// @Description Rename a recipe collection
+// @ID updateCollection
+// @Tags Collection
+// @Accept json
+// @Produce json
+// @Param collectionId path string true "Collection ID"
+// @Param request body models.UpdateCollectionRequest true "Update collection request"
+// @Success 200 {object} models.Collection
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Collection not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /collections/{collectionId} [put]
+func (h *CollectionHandler) UpdateCollection(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	collectionID := chi.URLParam(r, "collectionId")
+	if collectionID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	var input models.UpdateCollectionRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode update collection request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+	collection, err := h.collectionService.RenameCollection(r.Context(), userID, collectionID, input.Name)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to update collection", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, collection)
+}
+
+// @Summary Delete a collection
+// @Description Delete a recipe collection. The recipes in it are kept.
+// @ID deleteCollection
+// @Tags Collection
+// @Param collectionId path string true "Collection ID"
+// @Success 204 "Collection deleted"
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Collection not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /collections/{collectionId} [delete]
+func (h *CollectionHandler) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	collectionID := chi.URLParam(r, "collectionId")
+	if collectionID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	if err := h.collectionService.DeleteCollection(r.Context(), userID, collectionID); err != nil {
+		logger.Logger(r.Context()).Error("failed to delete collection", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Add a recipe to a collection
+// @Description Add a recipe to the end of a collection. Adding a recipe that is already in the collection leaves it where it is.
+// @ID addCollectionRecipe
+// @Tags Collection
+// @Accept json
+// @Produce json
+// @Param collectionId path string true "Collection ID"
+// @Param request body models.AddCollectionRecipeRequest true "Add recipe request"
+// @Success 200 {object} models.Collection
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Collection or recipe not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /collections/{collectionId}/recipes [post]
+func (h *CollectionHandler) AddRecipe(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	collectionID := chi.URLParam(r, "collectionId")
+	if collectionID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	var input models.AddCollectionRecipeRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode add collection recipe request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+	collection, err := h.collectionService.AddRecipe(r.Context(), userID, collectionID, input.RecipeID)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to add recipe to collection", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, collection)
+}
+
+// @Summary Move a recipe within a collection
+// @Description Move a recipe to another position of a collection
+// @ID moveCollectionRecipe
+// @Tags Collection
+// @Accept json
+// @Produce json
+// @Param collectionId path string true "Collection ID"
+// @Param recipeId path string true "Recipe ID"
+// @Param request body models.MoveCollectionRecipeRequest true "Move recipe request"
+// @Success 200 {object} models.Collection
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Collection not found or recipe not in it"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /collections/{collectionId}/recipes/{recipeId} [put]
+func (h *CollectionHandler) MoveRecipe(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	collectionID := chi.URLParam(r, "collectionId")
+	recipeID := chi.URLParam(r, "recipeId")
+	if collectionID == "" || recipeID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	var input models.MoveCollectionRecipeRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode move collection recipe request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+	collection, err := h.collectionService.MoveRecipe(r.Context(), userID, collectionID, recipeID, input.Position)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to move collection recipe", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, collection)
+}
+
+// @Summary Remove a recipe from a collection
+// @Description Remove a recipe from a collection. The recipe itself is kept.
+// @ID removeCollectionRecipe
+// @Tags Collection
+// @Produce json
+// @Param collectionId path string true "Collection ID"
+// @Param recipeId path string true "Recipe ID"
+// @Success 200 {object} models.Collection
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Collection not found or recipe not in it"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /collections/{collectionId}/recipes/{recipeId} [delete]
+func (h *CollectionHandler) RemoveRecipe(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	collectionID := chi.URLParam(r, "collectionId")
+	recipeID := chi.URLParam(r, "recipeId")
+	if collectionID == "" || recipeID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	collection, err := h.collectionService.RemoveRecipe(r.Context(), userID, collectionID, recipeID)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to remove collection recipe", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, collection)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrCollectionNotFound):
+		api.ErrorJSON(w, http.StatusNotFound, models.ApiErrCollectionNotFound)
+	case errors.Is(err, ErrCollectionRecipeNotFound):
+		api.ErrorJSON(w, http.StatusNotFound, models.ApiErrCollectionRecipeNotFound)
+	case errors.Is(err, recipe.ErrRecipeNotFound):
+		api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeNotFound)
+	case errors.Is(err, ErrInvalidCollectionName):
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidCollectionName)
+	default:
+		api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+	}
+}
@@ -0,0 +1,173 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/recipe"
+	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type CollectionService struct {
+	store         db.Store
+	recipeService *recipe.RecipeService
+}
+
+func NewCollectionService(store db.Store, recipeService *recipe.RecipeService) *CollectionService {
+	return &CollectionService{
+		store:         store,
+		recipeService: recipeService,
+	}
+}
+
+func (s *CollectionService) getStore(ctx context.Context) db.Store {
+	if tx, ok := db.GetTx(ctx); ok {
+		return tx
+	}
+	return s.store
+}
+
+func (s *CollectionService) GetAllCollections(ctx context.Context, userID string) ([]models.Collection, error) {
+	store := s.getStore(ctx)
+	collections, err := store.GetAllCollections(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collections: %w", err)
+	}
+	if collections == nil {
+		collections = []models.Collection{}
+	}
+	return collections, nil
+}
+
+func (s *CollectionService) GetCollection(ctx context.Context, userID string, collectionID string) (*models.Collection, error) {
+	store := s.getStore(ctx)
+	collection, err := store.GetCollection(ctx, userID, collectionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return nil, ErrCollectionNotFound
+		default:
+			return nil, fmt.Errorf("failed to get collection: %w", err)
+		}
+	}
+	return &collection, nil
+}
+
+func (s *CollectionService) CreateCollection(ctx context.Context, userID string, name string) (*models.Collection, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidCollectionName
+	}
+	store := s.getStore(ctx)
+	collection := models.Collection{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      name,
+		RecipeIDs: []string{},
+	}
+	if err := store.SaveCollection(ctx, userID, collection); err != nil {
+		return nil, fmt.Errorf("failed to save collection: %w", err)
+	}
+	logger.Logger(ctx).Debug("created collection", zap.String("collection_id", collection.ID))
+	return s.GetCollection(ctx, userID, collection.ID)
+}
+
+func (s *CollectionService) RenameCollection(ctx context.Context, userID string, collectionID string, name string) (*models.Collection, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidCollectionName
+	}
+	return s.updateCollection(ctx, userID, collectionID, func(ctx context.Context, collection *models.Collection) error {
+		collection.Name = name
+		return nil
+	})
+}
+
+// DeleteCollection deletes a collection, leaving its recipes in the user's
+// recipe book.
+func (s *CollectionService) DeleteCollection(ctx context.Context, userID string, collectionID string) error {
+	store := s.getStore(ctx)
+	if err := store.DeleteCollection(ctx, userID, collectionID); err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return ErrCollectionNotFound
+		default:
+			return fmt.Errorf("failed to delete collection: %w", err)
+		}
+	}
+	logger.Logger(ctx).Debug("deleted collection", zap.String("collection_id", collectionID))
+	return nil
+}
+
+// AddRecipe appends a recipe to the end of a collection. Adding a recipe that
+// is already in the collection leaves it where it is.
+func (s *CollectionService) AddRecipe(ctx context.Context, userID string, collectionID string, recipeID string) (*models.Collection, error) {
+	return s.updateCollection(ctx, userID, collectionID, func(ctx context.Context, collection *models.Collection) error {
+		if _, err := s.recipeService.GetUserRecipe(ctx, userID, recipeID); err != nil {
+			return err
+		}
+		if !slices.Contains(collection.RecipeIDs, recipeID) {
+			collection.RecipeIDs = append(collection.RecipeIDs, recipeID)
+		}
+		return nil
+	})
+}
+
+func (s *CollectionService) RemoveRecipe(ctx context.Context, userID string, collectionID string, recipeID string) (*models.Collection, error) {
+	return s.updateCollection(ctx, userID, collectionID, func(ctx context.Context, collection *models.Collection) error {
+		idx := slices.Index(collection.RecipeIDs, recipeID)
+		if idx < 0 {
+			return ErrCollectionRecipeNotFound
+		}
+		collection.RecipeIDs = slices.Delete(collection.RecipeIDs, idx, idx+1)
+		return nil
+	})
+}
+
+// MoveRecipe moves a recipe to a position within a collection. Positions are
+// clamped, so anything past the end moves the recipe to the end.
+func (s *CollectionService) MoveRecipe(ctx context.Context, userID string, collectionID string, recipeID string, position int) (*models.Collection, error) {
+	return s.updateCollection(ctx, userID, collectionID, func(ctx context.Context, collection *models.Collection) error {
+		idx := slices.Index(collection.RecipeIDs, recipeID)
+		if idx < 0 {
+			return ErrCollectionRecipeNotFound
+		}
+		rest := slices.Delete(slices.Clone(collection.RecipeIDs), idx, idx+1)
+		position = max(0, min(position, len(rest)))
+		collection.RecipeIDs = slices.Insert(rest, position, recipeID)
+		return nil
+	})
+}
+
+func (s *CollectionService) updateCollection(ctx context.Context, userID string, collectionID string, fn func(ctx context.Context, collection *models.Collection) error) (*models.Collection, error) {
+	var collection *models.Collection
+	err := s.store.WithTx(func(tx db.Store) error {
+		var err error
+		ctx := db.ContextWithTx(ctx, tx)
+		collection, err = s.GetCollection(ctx, userID, collectionID)
+		if err != nil {
+			return err
+		}
+		if err := fn(ctx, collection); err != nil {
+			return err
+		}
+		if err := tx.SaveCollection(ctx, userID, *collection); err != nil {
+			return fmt.Errorf("failed to save collection: %w", err)
+		}
+		logger.Logger(ctx).Debug("saved collection", zap.String("collection_id", collection.ID))
+		// Re-read for the new updated_at
+		collection, err = s.GetCollection(ctx, userID, collectionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
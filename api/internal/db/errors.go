@@ -5,4 +5,9 @@ import "errors"
 var (
 	ErrEmailExists = errors.New("email already exists")
 	ErrNotFound    = errors.New("not found")
+	// ErrSchemaTooNew means the database was migrated by a newer build
+	ErrSchemaTooNew = errors.New("database schema is newer than this build")
+	// ErrVersionConflict means a thread changed since the caller last read it
+	ErrVersionConflict      = errors.New("version conflict")
+	ErrIdempotencyKeyExists = errors.New("idempotency key already exists")
 )
@@ -0,0 +1,247 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func newMemoryDB(t *testing.T) *sql.DB {
+	t.Helper()
+	sqlDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	sqlDB.SetMaxOpenConns(1)
+	return sqlDB
+}
+
+func hasColumn(t *testing.T, sqlDB *sql.DB, table, column string) bool {
+	t.Helper()
+	var n int
+	err := sqlDB.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?;`, table, column).Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n > 0
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := newMemoryDB(t)
+	migrator, err := NewMigrator(sqlDB, DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != migrator.Latest() {
+		t.Errorf("expected %d migrations to be applied, got %d", migrator.Latest(), applied)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || applied != 0 {
+		t.Errorf("expected nothing left to apply, got %d (%v)", applied, err)
+	}
+	if !hasColumn(t, sqlDB, "recipe_versions", "title") {
+		t.Error("expected recipe_versions.title to exist")
+	}
+
+	if _, err := migrator.Down(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := migrator.Version(ctx); version != migrator.Latest()-1 {
+		t.Errorf("expected version %d, got %d", migrator.Latest()-1, version)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := statuses[len(statuses)-1]
+	if last.AppliedAt != nil || statuses[0].AppliedAt == nil {
+		t.Errorf("expected only the last migration to be pending, got %+v", statuses)
+	}
+
+	if _, err := migrator.Down(ctx, migrator.Latest()-2); err != nil {
+		t.Fatal(err)
+	}
+	if hasColumn(t, sqlDB, "recipe_versions", "title") {
+		t.Error("expected recipe_versions.title to be dropped")
+	}
+
+	rolledBack, err := migrator.Down(ctx, migrator.Latest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rolledBack != 1 {
+		t.Errorf("expected 1 migration to be rolled back, got %d", rolledBack)
+	}
+	if hasColumn(t, sqlDB, "users", "id") {
+		t.Error("expected every table to be dropped")
+	}
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("expected migrations to apply again after rolling back, got %v", err)
+	}
+}
+
+func TestRefusesNewerSchema(t *testing.T) {
+	sqlDB := newMemoryDB(t)
+	if _, err := NewSQLiteStoreWithDB(sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sqlDB.Exec(`INSERT INTO schema_migrations (version, name) VALUES (999, 'from_the_future');`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewSQLiteStoreWithDB(sqlDB); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("expected ErrSchemaTooNew, got %v", err)
+	}
+	migrator, err := NewMigrator(sqlDB, DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Down(context.Background(), 1); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("expected rolling back an unknown migration to fail, got %v", err)
+	}
+}
+
+func TestUpgradesLegacySchema(t *testing.T) {
+	sqlDB := newMemoryDB(t)
+	legacy := []string{
+		`CREATE TABLE users (id TEXT PRIMARY KEY, email TEXT UNIQUE NOT NULL, password BLOB NOT NULL);`,
+		`CREATE TABLE profiles (
+			user_id    TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			setup_step TEXT NOT NULL DEFAULT 'account',
+			name       TEXT NOT NULL DEFAULT '',
+			skill      TEXT NOT NULL DEFAULT 'beginner',
+			cuisines   JSON NOT NULL DEFAULT '[]',
+			diets      JSON NOT NULL DEFAULT '[]',
+			equipment  JSON NOT NULL DEFAULT '[]',
+			allergies  JSON NOT NULL DEFAULT '[]'
+		);`,
+		`CREATE TABLE user_recipes (
+			id TEXT PRIMARY KEY, user_id TEXT, thread_id TEXT, global_recipe_id TEXT NULL,
+			title TEXT NOT NULL, description TEXT NOT NULL,
+			total_time_minutes INTEGER NOT NULL, servings INTEGER NOT NULL,
+			image_url TEXT NULL, is_favorite BOOLEAN DEFAULT FALSE, latest_version_id TEXT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE recipe_versions (
+			id TEXT PRIMARY KEY, user_recipe_id TEXT, parent_id TEXT,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			total_time_minutes INTEGER NOT NULL, servings INTEGER NOT NULL, image_url TEXT NULL,
+			ingredients JSON NOT NULL DEFAULT '[]', steps JSON NOT NULL DEFAULT '[]', notes TEXT NULL
+		);`,
+		`INSERT INTO user_recipes (id, title, description, total_time_minutes, servings, latest_version_id)
+		VALUES ('r1', 'Soup', 'Warm', 30, 2, 'v1');`,
+		`INSERT INTO recipe_versions (id, user_recipe_id, total_time_minutes, servings, image_url, ingredients, steps)
+		VALUES ('v1', 'r1', 30, 2, '', CAST('[]' AS BLOB), CAST('[]' AS BLOB));`,
+	}
+	for _, stmt := range legacy {
+		if _, err := sqlDB.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := NewSQLiteStoreWithDB(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasColumn(t, sqlDB, "profiles", "unit_system") {
+		t.Error("expected profiles.unit_system to be added")
+	}
+	version, err := store.GetRecipeVersion(context.Background(), "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version.Title != "Soup" || version.Description != "Warm" {
+		t.Errorf("expected the version to be backfilled from its recipe, got %q %q", version.Title, version.Description)
+	}
+}
+
+func TestRenumbersThreadEvents(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := newMemoryDB(t)
+	migrator, err := NewMigrator(sqlDB, DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Back to before event indexes were unique
+	if _, err := migrator.Down(ctx, migrator.Latest()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	seed := []string{
+		`INSERT INTO users (id, email, password) VALUES ('u1', 'cook@example.com', x'00');`,
+		`INSERT INTO threads (id, thread_type, user_id) VALUES ('t1', 'Suggestion', 'u1');`,
+		`INSERT INTO thread_events (id, thread_id, event_index, event_type, payload) VALUES
+			('a', 't1', 0, 'PromptSet', '{}'),
+			('b', 't1', 1, 'SuggestionGenerated', '{}'),
+			('c', 't1', 1, 'SuggestionGenerated', '{}'),
+			('d', 't1', 2, 'SuggestionAccepted', '{}');`,
+	}
+	for _, stmt := range seed {
+		if _, err := sqlDB.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := sqlDB.Query(`SELECT id FROM thread_events WHERE thread_id = 't1' ORDER BY event_index;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var order string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		order += id
+	}
+	if order != "abcd" {
+		t.Errorf("expected events to be renumbered in the order they were written, got %s", order)
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files []string
+		valid bool
+	}{
+		{"valid", []string{"0001_a.up.sql", "0001_a.down.sql", "0002_b.up.sql", "0002_b.down.sql"}, true},
+		{"missing down", []string{"0001_a.up.sql"}, false},
+		{"gap", []string{"0001_a.up.sql", "0001_a.down.sql", "0003_c.up.sql", "0003_c.down.sql"}, false},
+		{"bad name", []string{"first.up.sql", "first.down.sql"}, false},
+		{"bad direction", []string{"0001_a.sideways.sql"}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for _, f := range tc.files {
+				fsys["m/"+f] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+			}
+			migrations, err := loadMigrations(fsys, "m")
+			if tc.valid && (err != nil || len(migrations) != 2 || migrations[1].Name != "b") {
+				t.Errorf("expected two migrations, got %+v (%v)", migrations, err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
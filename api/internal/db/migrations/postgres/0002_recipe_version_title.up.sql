@@ -0,0 +1,9 @@
+ALTER TABLE recipe_versions ADD COLUMN title TEXT NOT NULL DEFAULT '';
+ALTER TABLE recipe_versions ADD COLUMN description TEXT NOT NULL DEFAULT '';
+
+-- Older versions only have the title and description their recipe has now
+UPDATE recipe_versions SET
+	title       = user_recipes.title,
+	description = user_recipes.description
+FROM user_recipes
+WHERE user_recipes.id = recipe_versions.user_recipe_id;
@@ -0,0 +1,18 @@
+CREATE TABLE recipe_search (
+	recipe_id TEXT PRIMARY KEY REFERENCES user_recipes(id) ON DELETE CASCADE,
+	document  TSVECTOR NOT NULL
+);
+
+CREATE INDEX recipe_search_document_idx ON recipe_search USING GIN (document);
+
+INSERT INTO recipe_search (recipe_id, document)
+SELECT
+	ur.id,
+	to_tsvector('english', concat_ws(E'\n',
+		ur.title,
+		ur.description,
+		(SELECT string_agg(i->>'name', E'\n') FROM jsonb_array_elements(rv.ingredients) i),
+		(SELECT string_agg(s #>> '{}', E'\n') FROM jsonb_array_elements(rv.steps) s)
+	))
+FROM user_recipes ur
+JOIN recipe_versions rv ON ur.latest_version_id = rv.id;
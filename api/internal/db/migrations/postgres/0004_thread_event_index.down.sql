@@ -0,0 +1 @@
+ALTER TABLE thread_events DROP CONSTRAINT IF EXISTS thread_events_thread_id_event_index_key;
@@ -0,0 +1,14 @@
+-- Responses to requests sent with an Idempotency-Key, replayed when a client
+-- retries. status is NULL while the first request is still being handled.
+CREATE TABLE idempotency_keys (
+	user_id         TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	idempotency_key TEXT NOT NULL,
+	method          TEXT NOT NULL,
+	path            TEXT NOT NULL,
+	request_hash    TEXT NOT NULL,
+	status          INTEGER NULL,
+	content_type    TEXT NOT NULL DEFAULT '',
+	body            BYTEA NULL,
+	created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (user_id, idempotency_key)
+);
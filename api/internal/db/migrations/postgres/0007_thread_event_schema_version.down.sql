@@ -0,0 +1 @@
+ALTER TABLE thread_events DROP COLUMN schema_version;
@@ -0,0 +1,3 @@
+-- Payloads are decoded according to the version of their schema they were
+-- written with. Events written before payloads were versioned are version 1.
+ALTER TABLE thread_events ADD COLUMN schema_version INTEGER NOT NULL DEFAULT 1;
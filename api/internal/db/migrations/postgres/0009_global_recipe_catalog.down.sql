@@ -0,0 +1,3 @@
+DROP TABLE global_recipe_search;
+ALTER TABLE user_recipes DROP COLUMN global_recipe_revision;
+ALTER TABLE global_recipes DROP COLUMN revision;
@@ -0,0 +1,25 @@
+-- The catalog of global recipes is searched like a user's recipe book. Each
+-- save of a global recipe bumps its revision, and forks remember the revision
+-- they were made from to tell when an update is available.
+ALTER TABLE global_recipes ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE user_recipes ADD COLUMN global_recipe_revision INTEGER NULL;
+
+UPDATE user_recipes SET global_recipe_revision = 1 WHERE global_recipe_id IS NOT NULL;
+
+CREATE TABLE global_recipe_search (
+	recipe_id TEXT PRIMARY KEY REFERENCES global_recipes(id) ON DELETE CASCADE,
+	document  TSVECTOR NOT NULL
+);
+
+CREATE INDEX global_recipe_search_document_idx ON global_recipe_search USING GIN (document);
+
+INSERT INTO global_recipe_search (recipe_id, document)
+SELECT
+	gr.id,
+	to_tsvector('english', concat_ws(E'\n',
+		gr.title,
+		gr.description,
+		(SELECT string_agg(i->>'name', E'\n') FROM jsonb_array_elements(gr.ingredients) i),
+		(SELECT string_agg(s #>> '{}', E'\n') FROM jsonb_array_elements(gr.steps) s)
+	))
+FROM global_recipes gr;
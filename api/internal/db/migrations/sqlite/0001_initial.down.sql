@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS meal_plans;
+DROP TABLE IF EXISTS recipe_versions;
+DROP TABLE IF EXISTS user_recipes;
+DROP TABLE IF EXISTS thread_events;
+DROP TABLE IF EXISTS threads;
+DROP TABLE IF EXISTS global_recipes;
+DROP TABLE IF EXISTS profiles;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,95 @@
+CREATE TABLE IF NOT EXISTS users (
+	id TEXT PRIMARY KEY,
+	email TEXT UNIQUE NOT NULL,
+	password BLOB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id          TEXT PRIMARY KEY,
+	user_id     TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	token_hash  TEXT UNIQUE NOT NULL,
+	expires_at  TIMESTAMP NOT NULL,
+	revoked_at  TIMESTAMP NULL,
+	replaced_by TEXT NULL,
+	created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS profiles (
+	user_id    TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	setup_step TEXT NOT NULL DEFAULT 'account',
+	name       TEXT NOT NULL DEFAULT '',
+	skill      TEXT NOT NULL DEFAULT 'beginner',
+	cuisines   JSON NOT NULL DEFAULT '[]',
+	diets      JSON NOT NULL DEFAULT '[]',
+	equipment  JSON NOT NULL DEFAULT '[]',
+	allergies  JSON NOT NULL DEFAULT '[]',
+	unit_system TEXT NOT NULL DEFAULT 'imperial'
+);
+
+CREATE TABLE IF NOT EXISTS global_recipes (
+	id                 TEXT PRIMARY KEY,
+	title              TEXT NOT NULL,
+	description        TEXT NOT NULL,
+	total_time_minutes INTEGER NOT NULL,
+	servings           INTEGER NOT NULL,
+	image_url          TEXT NULL,
+	ingredients        JSON NOT NULL DEFAULT '[]',
+	steps              JSON NOT NULL DEFAULT '[]',
+	source_type        TEXT NOT NULL DEFAULT 'generated',
+	created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS threads (
+	id                 TEXT PRIMARY KEY,
+	thread_type        TEXT NOT NULL,
+	recipe_id          TEXT NULL,
+	user_id            TEXT REFERENCES users(id) ON DELETE CASCADE,
+	created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS thread_events (
+	id                 TEXT PRIMARY KEY,
+	thread_id          TEXT REFERENCES threads(id) ON DELETE CASCADE,
+	event_index        INTEGER NOT NULL,
+	event_type         TEXT NOT NULL,
+	payload            JSON NOT NULL,
+	created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_recipes (
+	id                 TEXT PRIMARY KEY,
+	user_id            TEXT REFERENCES users(id) ON DELETE CASCADE,
+	thread_id          TEXT REFERENCES threads(id) ON DELETE CASCADE,
+	global_recipe_id   TEXT NULL REFERENCES global_recipes(id) ON DELETE SET NULL,
+	title              TEXT NOT NULL,
+	description        TEXT NOT NULL,
+	total_time_minutes INTEGER NOT NULL,
+	servings           INTEGER NOT NULL,
+	image_url          TEXT NULL,
+	is_favorite        BOOLEAN DEFAULT FALSE,
+	latest_version_id  TEXT NULL,
+	created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS recipe_versions (
+	id                 TEXT PRIMARY KEY,
+	user_recipe_id     TEXT,
+	parent_id          TEXT,
+	created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	total_time_minutes INTEGER NOT NULL,
+	servings           INTEGER NOT NULL,
+	image_url          TEXT NULL,
+	ingredients        JSON NOT NULL DEFAULT '[]',
+	steps              JSON NOT NULL DEFAULT '[]',
+	notes              TEXT NULL
+);
+
+CREATE TABLE IF NOT EXISTS meal_plans (
+	id         TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	user_id    TEXT REFERENCES users(id) ON DELETE CASCADE,
+	recipes    JSON NOT NULL DEFAULT '[]'
+);
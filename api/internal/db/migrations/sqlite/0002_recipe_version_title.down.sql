@@ -0,0 +1,2 @@
+ALTER TABLE recipe_versions DROP COLUMN description;
+ALTER TABLE recipe_versions DROP COLUMN title;
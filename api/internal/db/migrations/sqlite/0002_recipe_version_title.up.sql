@@ -0,0 +1,8 @@
+ALTER TABLE recipe_versions ADD COLUMN title TEXT NOT NULL DEFAULT '';
+ALTER TABLE recipe_versions ADD COLUMN description TEXT NOT NULL DEFAULT '';
+
+-- Older versions only have the title and description their recipe has now
+UPDATE recipe_versions SET
+	title       = (SELECT title FROM user_recipes WHERE user_recipes.id = recipe_versions.user_recipe_id),
+	description = (SELECT description FROM user_recipes WHERE user_recipes.id = recipe_versions.user_recipe_id)
+WHERE user_recipe_id IN (SELECT id FROM user_recipes);
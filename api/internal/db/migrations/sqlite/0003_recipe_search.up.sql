@@ -0,0 +1,18 @@
+CREATE VIRTUAL TABLE recipe_search USING fts5(
+	recipe_id UNINDEXED,
+	title,
+	description,
+	ingredients,
+	steps,
+	tokenize = 'porter unicode61'
+);
+
+INSERT INTO recipe_search (recipe_id, title, description, ingredients, steps)
+SELECT
+	ur.id,
+	ur.title,
+	ur.description,
+	COALESCE((SELECT group_concat(json_extract(value, '$.name'), char(10)) FROM json_each(CAST(rv.ingredients AS TEXT))), ''),
+	COALESCE((SELECT group_concat(value, char(10)) FROM json_each(CAST(rv.steps AS TEXT))), '')
+FROM user_recipes ur
+JOIN recipe_versions rv ON ur.latest_version_id = rv.id;
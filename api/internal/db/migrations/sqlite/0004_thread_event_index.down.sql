@@ -0,0 +1 @@
+DROP INDEX IF EXISTS thread_events_thread_id_event_index_key;
@@ -0,0 +1,17 @@
+-- Appends used to compute MAX(event_index) + 1 with nothing stopping two
+-- writers from picking the same index. Renumber every thread from zero, in
+-- the order its events were written, before making the index unique.
+UPDATE thread_events SET event_index = (
+	SELECT numbered.n FROM (
+		SELECT
+			id,
+			ROW_NUMBER() OVER (
+				PARTITION BY thread_id
+				ORDER BY event_index IS NULL, event_index, created_at, rowid
+			) - 1 AS n
+		FROM thread_events
+	) numbered
+	WHERE numbered.id = thread_events.id
+);
+
+CREATE UNIQUE INDEX thread_events_thread_id_event_index_key ON thread_events (thread_id, event_index);
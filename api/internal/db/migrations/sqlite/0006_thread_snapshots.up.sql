@@ -0,0 +1,10 @@
+-- The reduced state of a thread after its first version events, so reads only
+-- replay the events appended since. Snapshots taken by a different reducer
+-- version are ignored and replaced.
+CREATE TABLE thread_snapshots (
+	thread_id       TEXT PRIMARY KEY REFERENCES threads(id) ON DELETE CASCADE,
+	version         INTEGER NOT NULL,
+	reducer_version INTEGER NOT NULL,
+	state           JSON NOT NULL,
+	created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
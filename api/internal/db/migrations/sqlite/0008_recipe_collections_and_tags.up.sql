@@ -0,0 +1,29 @@
+-- Collections group a user's recipes in an order the user picks, and a recipe
+-- can be in any number of them. Tags are free-form labels, stored lowercase
+-- so that filtering by them ignores case.
+CREATE TABLE collections (
+	id         TEXT PRIMARY KEY,
+	user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name       TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX collections_user_id_idx ON collections (user_id);
+
+CREATE TABLE collection_recipes (
+	collection_id TEXT NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+	recipe_id     TEXT NOT NULL REFERENCES user_recipes(id) ON DELETE CASCADE,
+	position      INTEGER NOT NULL,
+	PRIMARY KEY (collection_id, recipe_id)
+);
+
+CREATE INDEX collection_recipes_recipe_id_idx ON collection_recipes (recipe_id);
+
+CREATE TABLE recipe_tags (
+	recipe_id TEXT NOT NULL REFERENCES user_recipes(id) ON DELETE CASCADE,
+	tag       TEXT NOT NULL,
+	PRIMARY KEY (recipe_id, tag)
+);
+
+CREATE INDEX recipe_tags_tag_idx ON recipe_tags (tag);
@@ -0,0 +1,25 @@
+-- The catalog of global recipes is searched like a user's recipe book. Each
+-- save of a global recipe bumps its revision, and forks remember the revision
+-- they were made from to tell when an update is available.
+ALTER TABLE global_recipes ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE user_recipes ADD COLUMN global_recipe_revision INTEGER NULL;
+
+UPDATE user_recipes SET global_recipe_revision = 1 WHERE global_recipe_id IS NOT NULL;
+
+CREATE VIRTUAL TABLE global_recipe_search USING fts5(
+	recipe_id UNINDEXED,
+	title,
+	description,
+	ingredients,
+	steps,
+	tokenize = 'porter unicode61'
+);
+
+INSERT INTO global_recipe_search (recipe_id, title, description, ingredients, steps)
+SELECT
+	gr.id,
+	gr.title,
+	gr.description,
+	COALESCE((SELECT group_concat(json_extract(value, '$.name'), char(10)) FROM json_each(CAST(gr.ingredients AS TEXT))), ''),
+	COALESCE((SELECT group_concat(value, char(10)) FROM json_each(CAST(gr.steps AS TEXT))), '')
+FROM global_recipes gr;
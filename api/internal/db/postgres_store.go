@@ -0,0 +1,1383 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type PostgresStore struct {
+	run sqlRunner
+}
+
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	db, err := openPostgres(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return NewPostgresStoreWithDB(db)
+}
+
+func NewPostgresStoreWithDB(db *sql.DB) (*PostgresStore, error) {
+	store := &PostgresStore{db}
+	if err := migratePostgres(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return store, nil
+}
+
+func openPostgres(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return db, nil
+}
+
+func (s *PostgresStore) CreateUser(ctx context.Context, email, password string) (models.User, error) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	id := uuid.NewString()
+
+	_, err := s.run.ExecContext(ctx, `
+		INSERT INTO users (id, email, password) VALUES ($1, $2, $3);`,
+		id, email, hash)
+	if err != nil {
+		if isPostgresUniqueViolation(err, "users_email_key") {
+			return models.User{}, ErrEmailExists
+		}
+		return models.User{}, fmt.Errorf("failed to create user: %w", err)
+	}
+	return models.User{ID: id, Email: email}, nil
+}
+
+func (s *PostgresStore) GetUser(ctx context.Context, userID string) (models.User, error) {
+	var user models.User
+	err := s.run.QueryRowContext(ctx, `
+		SELECT id, email
+		FROM users WHERE id = $1;
+	`, userID).Scan(&user.ID, &user.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, ErrNotFound
+		}
+		return models.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *PostgresStore) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := s.run.QueryRowContext(ctx, `
+		SELECT id, email
+		FROM users WHERE email = $1;
+	`, email).Scan(&user.ID, &user.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, ErrNotFound
+		}
+		return models.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *PostgresStore) CheckPassword(ctx context.Context, userID string, password string) error {
+	var hash []byte
+	err := s.run.QueryRowContext(ctx, `
+		SELECT password
+		FROM users WHERE id = $1;
+	`, userID).Scan(&hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	return bcrypt.CompareHashAndPassword(hash, []byte(password))
+}
+
+func (s *PostgresStore) SaveRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	_, err := s.run.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4);
+	`, token.ID, token.UserID, token.TokenHash, token.ExpiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	var revokedAt sql.NullTime
+	err := s.run.QueryRowContext(ctx, `
+		SELECT id, user_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_tokens WHERE token_hash = $1;
+	`, tokenHash).Scan(
+		&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt,
+		&revokedAt, &token.ReplacedBy, &token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return token, ErrNotFound
+		}
+		return token, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+func (s *PostgresStore) RevokeRefreshToken(ctx context.Context, tokenID string, replacedBy *string) error {
+	res, err := s.run.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = now(), replaced_by = $1
+		WHERE id = $2 AND revoked_at IS NULL;
+	`, replacedBy, tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	revoked, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	if revoked == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) RevokeAllRefreshTokens(ctx context.Context, userID string) error {
+	_, err := s.run.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = now()
+		WHERE user_id = $1 AND revoked_at IS NULL;
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) SaveProfile(ctx context.Context, userID string, p models.Profile) error {
+	cuisines, err := json.Marshal(p.Cuisines)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cuisines: %w", err)
+	}
+	diets, err := json.Marshal(p.Diets)
+	if err != nil {
+		return fmt.Errorf("failed to marshal diets: %w", err)
+	}
+	equipment, err := json.Marshal(p.Equipment)
+	if err != nil {
+		return fmt.Errorf("failed to marshal equipment: %w", err)
+	}
+	allergies, err := json.Marshal(p.Allergies)
+	if err != nil {
+		return fmt.Errorf("failed to marshal allergies: %w", err)
+	}
+
+	_, err = s.run.ExecContext(ctx, `
+		INSERT INTO profiles (
+		  user_id, setup_step, name, skill,
+		  cuisines, diets, equipment, allergies, unit_system
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id) DO UPDATE SET
+		  setup_step  = excluded.setup_step,
+		  name        = excluded.name,
+		  skill       = excluded.skill,
+		  cuisines    = excluded.cuisines,
+		  diets       = excluded.diets,
+		  equipment   = excluded.equipment,
+		  allergies   = excluded.allergies,
+		  unit_system = excluded.unit_system
+	`, userID, p.SetupStep, p.Name, p.Skill, cuisines, diets, equipment, allergies, p.UnitSystem)
+	if err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetProfile(ctx context.Context, userID string) (models.Profile, error) {
+	var p models.Profile
+	var cuisines, diets, equipment, allergies []byte
+
+	err := s.run.QueryRowContext(ctx, `
+		SELECT setup_step, name, skill,
+			cuisines, diets, equipment, allergies,
+			unit_system
+		FROM profiles WHERE user_id = $1;
+	`, userID).Scan(
+		&p.SetupStep, &p.Name, &p.Skill,
+		&cuisines, &diets, &equipment, &allergies,
+		&p.UnitSystem,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return p, ErrNotFound
+		}
+		return p, fmt.Errorf("failed to get profile: %w", err)
+	}
+
+	if err := json.Unmarshal(cuisines, &p.Cuisines); err != nil {
+		return p, fmt.Errorf("failed to unmarshal cuisines: %w", err)
+	}
+	if err := json.Unmarshal(diets, &p.Diets); err != nil {
+		return p, fmt.Errorf("failed to unmarshal diets: %w", err)
+	}
+	if err := json.Unmarshal(equipment, &p.Equipment); err != nil {
+		return p, fmt.Errorf("failed to unmarshal equipment: %w", err)
+	}
+	if err := json.Unmarshal(allergies, &p.Allergies); err != nil {
+		return p, fmt.Errorf("failed to unmarshal allergies: %w", err)
+	}
+
+	return p, nil
+}
+
+func (s *PostgresStore) GetGlobalRecipe(ctx context.Context, id string) (models.GlobalRecipe, error) {
+	var recipe models.GlobalRecipe
+
+	err := s.run.QueryRowContext(ctx, `
+		SELECT id, title, description, total_time_minutes, servings, image_url,
+			ingredients, steps, source_type, revision, created_at, updated_at
+		FROM global_recipes WHERE id = $1;
+	`, id).Scan(
+		&recipe.ID, &recipe.RecipeBody.Title, &recipe.RecipeBody.Description,
+		&recipe.RecipeBody.TotalTimeMinutes, &recipe.RecipeBody.Servings,
+		&recipe.RecipeBody.ImageURL, &recipe.RecipeBody.Ingredients,
+		&recipe.RecipeBody.Steps, &recipe.SourceType, &recipe.Revision,
+		&recipe.CreatedAt, &recipe.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return recipe, ErrNotFound
+		}
+		return recipe, fmt.Errorf("failed to get global recipe: %w", err)
+	}
+
+	return recipe, nil
+}
+
+func (s *PostgresStore) SaveGlobalRecipe(ctx context.Context, recipe models.GlobalRecipe) error {
+	ingredients, err := json.Marshal(recipe.RecipeBody.Ingredients)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ingredients: %w", err)
+	}
+	steps, err := json.Marshal(recipe.RecipeBody.Steps)
+	if err != nil {
+		return fmt.Errorf("failed to marshal steps: %w", err)
+	}
+
+	_, err = s.run.ExecContext(ctx, `
+		INSERT INTO global_recipes (id, title, description, total_time_minutes, servings, image_url, ingredients, steps, source_type)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE SET
+			title              = excluded.title,
+			description        = excluded.description,
+			total_time_minutes = excluded.total_time_minutes,
+			servings           = excluded.servings,
+			ingredients        = excluded.ingredients,
+			steps              = excluded.steps,
+			source_type        = excluded.source_type,
+			revision           = global_recipes.revision + 1,
+			updated_at         = now();
+	`, recipe.ID, recipe.RecipeBody.Title, recipe.RecipeBody.Description,
+		recipe.RecipeBody.TotalTimeMinutes, recipe.RecipeBody.Servings, recipe.RecipeBody.ImageURL,
+		ingredients, steps, recipe.SourceType)
+	if err != nil {
+		return fmt.Errorf("failed to save global recipe: %w", err)
+	}
+	return s.indexGlobalRecipe(ctx, recipe.ID, recipe.RecipeBody)
+}
+
+func (s *PostgresStore) indexGlobalRecipe(ctx context.Context, recipeID string, body models.RecipeBody) error {
+	ingredients, steps := searchDocument(body)
+	_, err := s.run.ExecContext(ctx, `
+		INSERT INTO global_recipe_search (recipe_id, document)
+		VALUES ($1, to_tsvector('english', concat_ws(E'\n', $2::text, $3::text, $4::text, $5::text)))
+		ON CONFLICT (recipe_id) DO UPDATE SET
+			document = excluded.document;
+	`, recipeID, body.Title, body.Description, ingredients, steps)
+	if err != nil {
+		return fmt.Errorf("failed to index global recipe: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) SearchGlobalRecipes(ctx context.Context, query GlobalRecipeQuery) ([]models.GlobalRecipe, error) {
+	where := []string{"TRUE"}
+	var args []any
+	if text := tsQuery(query.Text); text != "" {
+		where = append(where, "id IN (SELECT recipe_id FROM global_recipe_search WHERE document @@ to_tsquery('english', ?))")
+		args = append(args, text)
+	}
+	if query.After != nil {
+		where = append(where, "(created_at, id) > (?, ?)")
+		args = append(args, query.After.CreatedAt, query.After.ID)
+	}
+	limit := ""
+	if query.Limit > 0 {
+		limit = "LIMIT ?"
+		args = append(args, query.Limit)
+	}
+
+	rows, err := s.run.QueryContext(ctx, rebind(`
+		SELECT id, title, description, total_time_minutes, servings, image_url,
+			ingredients, steps, source_type, revision, created_at, updated_at
+		FROM global_recipes
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY created_at ASC, id ASC
+		`+limit+`;
+	`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search global recipes: %w", err)
+	}
+	defer rows.Close()
+
+	var recipes []models.GlobalRecipe
+	for rows.Next() {
+		var recipe models.GlobalRecipe
+		err := rows.Scan(
+			&recipe.ID, &recipe.RecipeBody.Title, &recipe.RecipeBody.Description,
+			&recipe.RecipeBody.TotalTimeMinutes, &recipe.RecipeBody.Servings,
+			&recipe.RecipeBody.ImageURL, &recipe.RecipeBody.Ingredients,
+			&recipe.RecipeBody.Steps, &recipe.SourceType, &recipe.Revision,
+			&recipe.CreatedAt, &recipe.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan global recipe: %w", err)
+		}
+		recipes = append(recipes, recipe)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search global recipes: %w", err)
+	}
+	return recipes, nil
+}
+
+func (s *PostgresStore) CreateThread(ctx context.Context, userID string, thread models.Thread) error {
+	res, err := s.run.ExecContext(ctx, `
+		INSERT INTO threads (id, user_id, thread_type)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET
+			thread_type        = excluded.thread_type,
+			updated_at         = now()
+		WHERE threads.user_id = excluded.user_id;
+	`, thread.ID, userID, thread.Type)
+	if err != nil {
+		return fmt.Errorf("failed to create thread: %w", err)
+	}
+	created, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to create thread: %w", err)
+	}
+	if created == 0 {
+		return ErrNotFound
+	}
+	for i, event := range thread.Events {
+		eventId := uuid.NewString()
+		_, err = s.run.ExecContext(ctx, `
+			INSERT INTO thread_events (id, thread_id, event_index, event_type, schema_version, payload)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, eventId, thread.ID, i, event.Type, max(event.SchemaVersion, 1), []byte(event.Payload))
+		if err != nil {
+			return fmt.Errorf("failed to append to thread: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *PostgresStore) AppendToThread(ctx context.Context, userID string, threadID string, expectedVersion int, events []models.ThreadEvent) error {
+	var version int
+	err := s.run.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM thread_events WHERE thread_id = t.id)
+		FROM threads t
+		WHERE t.id = $1 AND t.user_id = $2;
+	`, threadID, userID).Scan(&version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to get thread version: %w", err)
+	}
+	if version != expectedVersion {
+		return fmt.Errorf("%w: thread is at version %d, expected %d", ErrVersionConflict, version, expectedVersion)
+	}
+
+	for i, event := range events {
+		_, err := s.run.ExecContext(ctx, `
+			INSERT INTO thread_events (id, thread_id, event_index, event_type, schema_version, payload)
+			VALUES ($1, $2, $3, $4, $5, $6);
+		`, uuid.NewString(), threadID, expectedVersion+i, event.Type, max(event.SchemaVersion, 1), []byte(event.Payload))
+		if err != nil {
+			// Another append got in between reading the version and writing
+			if isPostgresUniqueViolation(err, "thread_events_thread_id_event_index_key") {
+				return fmt.Errorf("%w: thread changed while appending", ErrVersionConflict)
+			}
+			return fmt.Errorf("failed to append to thread: %w", err)
+		}
+	}
+	_, err = s.run.ExecContext(ctx, `
+		UPDATE threads SET updated_at = now() WHERE id = $1;
+	`, threadID)
+	if err != nil {
+		return fmt.Errorf("failed to append to thread: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) AssociateThreadWithRecipe(ctx context.Context, userID string, threadID string, recipeID string) error {
+	res, err := s.run.ExecContext(ctx, `
+		UPDATE threads SET recipe_id = $1 WHERE id = $2 AND user_id = $3;
+	`, recipeID, threadID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to associate recipe with thread: %w", err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to associate recipe with thread: %w", err)
+	}
+	if updated == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) ListThreads(ctx context.Context, userID string, query ThreadQuery) ([]models.ThreadSummary, error) {
+	where := []string{"t.user_id = ?"}
+	args := []any{userID}
+	if query.Type != "" {
+		where = append(where, "t.thread_type = ?")
+		args = append(args, query.Type)
+	}
+	if query.RecipeID != "" {
+		where = append(where, "t.recipe_id = ?")
+		args = append(args, query.RecipeID)
+	}
+	if query.Before != nil {
+		where = append(where, "(t.created_at, t.id) < (?, ?)")
+		args = append(args, query.Before.CreatedAt, query.Before.ID)
+	}
+	limit := ""
+	if query.Limit > 0 {
+		limit = "LIMIT ?"
+		args = append(args, query.Limit)
+	}
+
+	rows, err := s.run.QueryContext(ctx, rebind(`
+		SELECT
+			t.id, t.thread_type, t.recipe_id,
+			(SELECT COUNT(*) FROM thread_events WHERE thread_id = t.id),
+			t.created_at, t.updated_at
+		FROM threads t
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY t.created_at DESC, t.id DESC
+		`+limit+`;
+	`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list threads: %w", err)
+	}
+	defer rows.Close()
+
+	var threads []models.ThreadSummary
+	for rows.Next() {
+		var thread models.ThreadSummary
+		err := rows.Scan(&thread.ID, &thread.Type, &thread.RecipeID, &thread.Version, &thread.CreatedAt, &thread.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan thread: %w", err)
+		}
+		threads = append(threads, thread)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list threads: %w", err)
+	}
+	return threads, nil
+}
+
+func (s *PostgresStore) GetThread(ctx context.Context, userID string, threadID string) (models.Thread, error) {
+	return s.GetThreadSince(ctx, userID, threadID, 0)
+}
+
+func (s *PostgresStore) GetThreadSince(ctx context.Context, userID string, threadID string, version int) (models.Thread, error) {
+	var thread models.Thread
+	err := s.run.QueryRowContext(ctx, `
+		SELECT
+			id,
+			thread_type,
+			recipe_id,
+			created_at,
+			updated_at
+		FROM threads WHERE id = $1 AND user_id = $2;
+	`, threadID, userID).Scan(
+		&thread.ID, &thread.Type, &thread.RecipeID, &thread.CreatedAt, &thread.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return thread, ErrNotFound
+		}
+		return thread, fmt.Errorf("failed to get thread: %w", err)
+	}
+
+	var events []models.ThreadEvent
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT
+			event_type, schema_version, payload, created_at
+		FROM thread_events WHERE thread_id = $1 AND event_index >= $2
+		ORDER BY event_index ASC;
+	`, threadID, version)
+	if err != nil {
+		return thread, fmt.Errorf("failed to get thread: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event models.ThreadEvent
+		var payload []byte
+		err := rows.Scan(
+			&event.Type, &event.SchemaVersion, &payload, &event.Timestamp,
+		)
+		if err != nil {
+			return thread, fmt.Errorf("failed to scan thread event: %w", err)
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return thread, fmt.Errorf("failed to get thread events: %w", err)
+	}
+	thread.Events = events
+	thread.Version = version + len(events)
+
+	return thread, nil
+}
+
+func (s *PostgresStore) GetThreadSnapshot(ctx context.Context, threadID string) (models.ThreadSnapshot, error) {
+	snapshot := models.ThreadSnapshot{ThreadID: threadID}
+	var state []byte
+	err := s.run.QueryRowContext(ctx, `
+		SELECT version, reducer_version, state, created_at
+		FROM thread_snapshots WHERE thread_id = $1;
+	`, threadID).Scan(&snapshot.Version, &snapshot.ReducerVersion, &state, &snapshot.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return snapshot, ErrNotFound
+		}
+		return snapshot, fmt.Errorf("failed to get thread snapshot: %w", err)
+	}
+	if err := json.Unmarshal(state, &snapshot.State); err != nil {
+		return snapshot, fmt.Errorf("failed to unmarshal thread snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+func (s *PostgresStore) SaveThreadSnapshot(ctx context.Context, snapshot models.ThreadSnapshot) error {
+	state, err := json.Marshal(snapshot.State)
+	if err != nil {
+		return fmt.Errorf("failed to marshal thread snapshot: %w", err)
+	}
+	_, err = s.run.ExecContext(ctx, `
+		INSERT INTO thread_snapshots (thread_id, version, reducer_version, state)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (thread_id) DO UPDATE SET
+			version         = excluded.version,
+			reducer_version = excluded.reducer_version,
+			state           = excluded.state,
+			created_at      = now()
+		WHERE excluded.version > thread_snapshots.version
+			OR excluded.reducer_version <> thread_snapshots.reducer_version;
+	`, snapshot.ThreadID, snapshot.Version, snapshot.ReducerVersion, state)
+	if err != nil {
+		return fmt.Errorf("failed to save thread snapshot: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) ListAllThreadEvents(ctx context.Context, query ThreadEventQuery) ([]StoredThreadEvent, error) {
+	where := []string{"thread_id IS NOT NULL"}
+	args := []any{}
+	if query.After != nil {
+		where = append(where, "(thread_id, event_index) > (?, ?)")
+		args = append(args, query.After.ThreadID, query.After.Index)
+	}
+	limit := ""
+	if query.Limit > 0 {
+		limit = "LIMIT ?"
+		args = append(args, query.Limit)
+	}
+
+	rows, err := s.run.QueryContext(ctx, rebind(`
+		SELECT
+			id, thread_id, event_index,
+			event_type, schema_version, payload, created_at
+		FROM thread_events
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY thread_id ASC, event_index ASC
+		`+limit+`;
+	`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list thread events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []StoredThreadEvent
+	for rows.Next() {
+		var event StoredThreadEvent
+		var payload []byte
+		err := rows.Scan(
+			&event.ID, &event.ThreadID, &event.Index,
+			&event.Event.Type, &event.Event.SchemaVersion, &payload, &event.Event.Timestamp,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan thread event: %w", err)
+		}
+		event.Event.Payload = payload
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list thread events: %w", err)
+	}
+	return events, nil
+}
+
+func (s *PostgresStore) ReplaceThreadEvent(ctx context.Context, eventID string, event models.ThreadEvent) error {
+	res, err := s.run.ExecContext(ctx, `
+		UPDATE thread_events
+		SET event_type = $1, schema_version = $2, payload = $3
+		WHERE id = $4;
+	`, event.Type, max(event.SchemaVersion, 1), []byte(event.Payload), eventID)
+	if err != nil {
+		return fmt.Errorf("failed to replace thread event: %w", err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to replace thread event: %w", err)
+	}
+	if updated == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetUserRecipe(ctx context.Context, userID string, recipeID string) (models.UserRecipe, error) {
+	var recipe models.UserRecipe
+	err := s.run.QueryRowContext(ctx, `
+		SELECT
+			ur.id, ur.user_id, ur.thread_id, ur.global_recipe_id, ur.global_recipe_revision,
+			COALESCE((SELECT gr.revision FROM global_recipes gr WHERE gr.id = ur.global_recipe_id) > ur.global_recipe_revision, FALSE),
+			ur.title, ur.description, ur.is_favorite, ur.image_url,
+			ur.latest_version_id, ur.created_at, ur.updated_at,
+			rv.total_time_minutes, rv.servings,
+			rv.ingredients, rv.steps,
+			COALESCE((SELECT json_agg(tag) FROM recipe_tags WHERE recipe_id = ur.id), '[]'::json)
+		FROM user_recipes ur
+		JOIN recipe_versions rv ON ur.latest_version_id = rv.id
+		WHERE ur.id = $1 AND ur.user_id = $2;
+	`, recipeID, userID).Scan(
+		&recipe.ID, &recipe.UserID, &recipe.ThreadID, &recipe.GlobalRecipeID, &recipe.GlobalRecipeRevision, &recipe.UpdateAvailable, &recipe.Title, &recipe.Description, &recipe.IsFavorite,
+		&recipe.ImageURL, &recipe.LatestVersionID, &recipe.CreatedAt, &recipe.UpdatedAt,
+		&recipe.RecipeBody.TotalTimeMinutes, &recipe.RecipeBody.Servings, &recipe.RecipeBody.Ingredients, &recipe.RecipeBody.Steps, &recipe.Tags,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return recipe, ErrNotFound
+		}
+		return recipe, fmt.Errorf("failed to get user recipe: %w", err)
+	}
+
+	return recipe, nil
+}
+
+func (s *PostgresStore) GetAllUserRecipes(ctx context.Context, userID string) ([]models.UserRecipe, error) {
+	var recipes []models.UserRecipe
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT
+			ur.id, ur.user_id, ur.thread_id, ur.global_recipe_id, ur.global_recipe_revision,
+			COALESCE((SELECT gr.revision FROM global_recipes gr WHERE gr.id = ur.global_recipe_id) > ur.global_recipe_revision, FALSE),
+			ur.title, ur.description, ur.is_favorite, ur.image_url,
+			ur.latest_version_id, ur.created_at, ur.updated_at,
+			rv.total_time_minutes, rv.servings,
+			rv.ingredients, rv.steps,
+			COALESCE((SELECT json_agg(tag) FROM recipe_tags WHERE recipe_id = ur.id), '[]'::json)
+		FROM user_recipes ur
+		JOIN recipe_versions rv ON ur.latest_version_id = rv.id
+		WHERE ur.user_id = $1
+		ORDER BY ur.created_at ASC, ur.id ASC;
+	`, userID)
+	if err != nil {
+		return recipes, fmt.Errorf("failed to get user recipes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var recipe models.UserRecipe
+		err := rows.Scan(
+			&recipe.ID, &recipe.UserID, &recipe.ThreadID, &recipe.GlobalRecipeID, &recipe.GlobalRecipeRevision, &recipe.UpdateAvailable, &recipe.Title, &recipe.Description, &recipe.IsFavorite,
+			&recipe.ImageURL, &recipe.LatestVersionID, &recipe.CreatedAt, &recipe.UpdatedAt,
+			&recipe.RecipeBody.TotalTimeMinutes, &recipe.RecipeBody.Servings, &recipe.RecipeBody.Ingredients, &recipe.RecipeBody.Steps, &recipe.Tags,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user recipe: %w", err)
+		}
+		recipes = append(recipes, recipe)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get user recipes: %w", err)
+	}
+	return recipes, nil
+}
+
+func (s *PostgresStore) SaveUserRecipe(ctx context.Context, recipe models.UserRecipe) error {
+	_, err := s.run.ExecContext(ctx, `
+		INSERT INTO user_recipes (id, user_id, global_recipe_id, global_recipe_revision, thread_id, title, description, total_time_minutes, servings, is_favorite, image_url, latest_version_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (id) DO UPDATE SET
+			user_id                = excluded.user_id,
+			global_recipe_id       = excluded.global_recipe_id,
+			global_recipe_revision = excluded.global_recipe_revision,
+			thread_id              = excluded.thread_id,
+			title                  = excluded.title,
+			description            = excluded.description,
+			total_time_minutes     = excluded.total_time_minutes,
+			servings               = excluded.servings,
+			is_favorite            = excluded.is_favorite,
+			image_url              = excluded.image_url,
+			latest_version_id      = excluded.latest_version_id,
+			updated_at             = now();
+	`, recipe.ID, recipe.UserID, recipe.GlobalRecipeID, recipe.GlobalRecipeRevision, recipe.ThreadID, recipe.Title, recipe.Description,
+		recipe.RecipeBody.TotalTimeMinutes, recipe.RecipeBody.Servings, recipe.IsFavorite,
+		recipe.ImageURL, recipe.LatestVersionID)
+	if err != nil {
+		return fmt.Errorf("failed to save user recipe: %w", err)
+	}
+	return s.indexRecipe(ctx, recipe.ID, recipe.RecipeBody)
+}
+
+func (s *PostgresStore) DeleteUserRecipe(ctx context.Context, userID string, recipeID string) error {
+	res, err := s.run.ExecContext(ctx, `
+		DELETE FROM user_recipes WHERE id = $1 AND user_id = $2;`, recipeID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user recipe: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete user recipe: %w", err)
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) UpdateUserRecipeVersion(ctx context.Context, userID string, recipeID string, version models.RecipeVersion) error {
+	res, err := s.run.ExecContext(ctx, `
+		UPDATE user_recipes
+		SET
+			title              = $1,
+			description        = $2,
+			total_time_minutes = $3,
+			servings           = $4,
+			image_url          = $5,
+			latest_version_id  = $6
+		WHERE id = $7 AND user_id = $8;
+	`, version.Title, version.Description, version.TotalTimeMinutes, version.Servings, version.ImageURL, version.ID, recipeID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user recipe version: %w", err)
+	}
+	if updated, err := res.RowsAffected(); err != nil || updated == 0 {
+		return err
+	}
+	return s.indexRecipe(ctx, recipeID, version.RecipeBody)
+}
+
+// indexRecipe replaces a recipe's entry in the full-text index. Entries are
+// removed along with their recipe by the foreign key.
+func (s *PostgresStore) indexRecipe(ctx context.Context, recipeID string, body models.RecipeBody) error {
+	ingredients, steps := searchDocument(body)
+	_, err := s.run.ExecContext(ctx, `
+		INSERT INTO recipe_search (recipe_id, document)
+		VALUES ($1, to_tsvector('english', concat_ws(E'\n', $2::text, $3::text, $4::text, $5::text)))
+		ON CONFLICT (recipe_id) DO UPDATE SET
+			document = excluded.document;
+	`, recipeID, body.Title, body.Description, ingredients, steps)
+	if err != nil {
+		return fmt.Errorf("failed to index user recipe: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) SearchUserRecipes(ctx context.Context, userID string, query RecipeQuery) ([]models.UserRecipe, error) {
+	where := []string{"ur.user_id = ?"}
+	args := []any{userID}
+	if text := tsQuery(query.Text); text != "" {
+		where = append(where, "ur.id IN (SELECT recipe_id FROM recipe_search WHERE document @@ to_tsquery('english', ?))")
+		args = append(args, text)
+	}
+	if query.MaxTotalTimeMinutes > 0 {
+		where = append(where, "rv.total_time_minutes <= ?")
+		args = append(args, query.MaxTotalTimeMinutes)
+	}
+	if query.FavoritesOnly {
+		where = append(where, "ur.is_favorite")
+	}
+	if query.CollectionID != "" {
+		where = append(where, `ur.id IN (
+			SELECT cr.recipe_id FROM collection_recipes cr
+			JOIN collections c ON c.id = cr.collection_id
+			WHERE c.id = ? AND c.user_id = ur.user_id
+		)`)
+		args = append(args, query.CollectionID)
+	}
+	for _, tag := range query.Tags {
+		where = append(where, "EXISTS (SELECT 1 FROM recipe_tags WHERE recipe_id = ur.id AND tag = ?)")
+		args = append(args, tag)
+	}
+	const hasIngredient = `EXISTS (
+		SELECT 1 FROM jsonb_array_elements(rv.ingredients) i
+		WHERE lower(i->>'name') LIKE ? ESCAPE '\'
+	)`
+	for _, name := range query.Include {
+		where = append(where, hasIngredient)
+		args = append(args, likePattern(name))
+	}
+	for _, name := range query.Exclude {
+		where = append(where, "NOT "+hasIngredient)
+		args = append(args, likePattern(name))
+	}
+	if query.After != nil {
+		where = append(where, "(ur.created_at, ur.id) > (?, ?)")
+		args = append(args, query.After.CreatedAt, query.After.ID)
+	}
+	limit := ""
+	if query.Limit > 0 {
+		limit = "LIMIT ?"
+		args = append(args, query.Limit)
+	}
+
+	rows, err := s.run.QueryContext(ctx, rebind(`
+		SELECT
+			ur.id, ur.user_id, ur.thread_id, ur.global_recipe_id, ur.global_recipe_revision,
+			COALESCE((SELECT gr.revision FROM global_recipes gr WHERE gr.id = ur.global_recipe_id) > ur.global_recipe_revision, FALSE),
+			ur.title, ur.description, ur.is_favorite, ur.image_url,
+			ur.latest_version_id, ur.created_at, ur.updated_at,
+			rv.total_time_minutes, rv.servings,
+			rv.ingredients, rv.steps,
+			COALESCE((SELECT json_agg(tag) FROM recipe_tags WHERE recipe_id = ur.id), '[]'::json)
+		FROM user_recipes ur
+		JOIN recipe_versions rv ON ur.latest_version_id = rv.id
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY ur.created_at ASC, ur.id ASC
+		`+limit+`;
+	`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search user recipes: %w", err)
+	}
+	defer rows.Close()
+
+	var recipes []models.UserRecipe
+	for rows.Next() {
+		var recipe models.UserRecipe
+		err := rows.Scan(
+			&recipe.ID, &recipe.UserID, &recipe.ThreadID, &recipe.GlobalRecipeID, &recipe.GlobalRecipeRevision, &recipe.UpdateAvailable, &recipe.Title, &recipe.Description, &recipe.IsFavorite,
+			&recipe.ImageURL, &recipe.LatestVersionID, &recipe.CreatedAt, &recipe.UpdatedAt,
+			&recipe.RecipeBody.TotalTimeMinutes, &recipe.RecipeBody.Servings, &recipe.RecipeBody.Ingredients, &recipe.RecipeBody.Steps, &recipe.Tags,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user recipe: %w", err)
+		}
+		recipes = append(recipes, recipe)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search user recipes: %w", err)
+	}
+	return recipes, nil
+}
+
+func (s *PostgresStore) SetRecipeFavorite(ctx context.Context, userID string, recipeID string, favorite bool) error {
+	res, err := s.run.ExecContext(ctx, `
+		UPDATE user_recipes SET is_favorite = $1 WHERE id = $2 AND user_id = $3;
+	`, favorite, recipeID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set recipe favorite: %w", err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set recipe favorite: %w", err)
+	}
+	if updated == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) SetRecipeTags(ctx context.Context, userID string, recipeID string, tags []string) error {
+	var exists bool
+	err := s.run.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM user_recipes WHERE id = $1 AND user_id = $2);
+	`, recipeID, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to get user recipe: %w", err)
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	if _, err := s.run.ExecContext(ctx, `DELETE FROM recipe_tags WHERE recipe_id = $1;`, recipeID); err != nil {
+		return fmt.Errorf("failed to clear recipe tags: %w", err)
+	}
+	for _, tag := range tags {
+		_, err := s.run.ExecContext(ctx, `
+			INSERT INTO recipe_tags (recipe_id, tag) VALUES ($1, $2)
+			ON CONFLICT (recipe_id, tag) DO NOTHING;
+		`, recipeID, tag)
+		if err != nil {
+			return fmt.Errorf("failed to add recipe tag: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *PostgresStore) ListRecipeTags(ctx context.Context, userID string) ([]models.RecipeTag, error) {
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT rt.tag, COUNT(*)
+		FROM recipe_tags rt
+		JOIN user_recipes ur ON ur.id = rt.recipe_id
+		WHERE ur.user_id = $1
+		GROUP BY rt.tag
+		ORDER BY rt.tag ASC;
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recipe tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []models.RecipeTag
+	for rows.Next() {
+		var tag models.RecipeTag
+		if err := rows.Scan(&tag.Tag, &tag.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan recipe tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list recipe tags: %w", err)
+	}
+	return tags, nil
+}
+
+func (s *PostgresStore) GetAllCollections(ctx context.Context, userID string) ([]models.Collection, error) {
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT id, user_id, name, created_at, updated_at
+		FROM collections
+		WHERE user_id = $1
+		ORDER BY created_at ASC, id ASC;
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collections: %w", err)
+	}
+	defer rows.Close()
+
+	var collections []models.Collection
+	byID := make(map[string]int)
+	for rows.Next() {
+		collection := models.Collection{RecipeIDs: []string{}}
+		if err := rows.Scan(&collection.ID, &collection.UserID, &collection.Name, &collection.CreatedAt, &collection.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan collection: %w", err)
+		}
+		byID[collection.ID] = len(collections)
+		collections = append(collections, collection)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get collections: %w", err)
+	}
+
+	rows, err = s.run.QueryContext(ctx, `
+		SELECT cr.collection_id, cr.recipe_id
+		FROM collection_recipes cr
+		JOIN collections c ON c.id = cr.collection_id
+		WHERE c.user_id = $1
+		ORDER BY cr.collection_id, cr.position ASC;
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection recipes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var collectionID, recipeID string
+		if err := rows.Scan(&collectionID, &recipeID); err != nil {
+			return nil, fmt.Errorf("failed to scan collection recipe: %w", err)
+		}
+		if i, ok := byID[collectionID]; ok {
+			collections[i].RecipeIDs = append(collections[i].RecipeIDs, recipeID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get collection recipes: %w", err)
+	}
+	return collections, nil
+}
+
+func (s *PostgresStore) GetCollection(ctx context.Context, userID string, collectionID string) (models.Collection, error) {
+	collection := models.Collection{RecipeIDs: []string{}}
+	err := s.run.QueryRowContext(ctx, `
+		SELECT id, user_id, name, created_at, updated_at
+		FROM collections
+		WHERE id = $1 AND user_id = $2;
+	`, collectionID, userID).Scan(&collection.ID, &collection.UserID, &collection.Name, &collection.CreatedAt, &collection.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return collection, ErrNotFound
+		}
+		return collection, fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT recipe_id FROM collection_recipes
+		WHERE collection_id = $1
+		ORDER BY position ASC;
+	`, collectionID)
+	if err != nil {
+		return collection, fmt.Errorf("failed to get collection recipes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var recipeID string
+		if err := rows.Scan(&recipeID); err != nil {
+			return collection, fmt.Errorf("failed to scan collection recipe: %w", err)
+		}
+		collection.RecipeIDs = append(collection.RecipeIDs, recipeID)
+	}
+	if err := rows.Err(); err != nil {
+		return collection, fmt.Errorf("failed to get collection recipes: %w", err)
+	}
+	return collection, nil
+}
+
+func (s *PostgresStore) SaveCollection(ctx context.Context, userID string, collection models.Collection) error {
+	res, err := s.run.ExecContext(ctx, `
+		INSERT INTO collections (id, user_id, name) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET
+			name       = excluded.name,
+			updated_at = now()
+		WHERE collections.user_id = excluded.user_id;
+	`, collection.ID, userID, collection.Name)
+	if err != nil {
+		return fmt.Errorf("failed to save collection: %w", err)
+	}
+	saved, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to save collection: %w", err)
+	}
+	if saved == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := s.run.ExecContext(ctx, `DELETE FROM collection_recipes WHERE collection_id = $1;`, collection.ID); err != nil {
+		return fmt.Errorf("failed to clear collection recipes: %w", err)
+	}
+	for i, recipeID := range collection.RecipeIDs {
+		_, err := s.run.ExecContext(ctx, `
+			INSERT INTO collection_recipes (collection_id, recipe_id, position) VALUES ($1, $2, $3);
+		`, collection.ID, recipeID, i)
+		if err != nil {
+			return fmt.Errorf("failed to add collection recipe: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *PostgresStore) DeleteCollection(ctx context.Context, userID string, collectionID string) error {
+	res, err := s.run.ExecContext(ctx, `
+		DELETE FROM collections WHERE id = $1 AND user_id = $2;`, collectionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetRecipeVersion(ctx context.Context, recipeVersionID string) (models.RecipeVersion, error) {
+	var recipeVersion models.RecipeVersion
+
+	err := s.run.QueryRowContext(ctx, `
+		SELECT
+			id, user_recipe_id, parent_id,
+			title, description,
+			total_time_minutes, servings,
+			image_url,
+			ingredients, steps,
+			notes, created_at
+		FROM recipe_versions WHERE id = $1;
+	`, recipeVersionID).Scan(
+		&recipeVersion.ID, &recipeVersion.UserRecipeID, &recipeVersion.ParentID,
+		&recipeVersion.Title, &recipeVersion.Description,
+		&recipeVersion.TotalTimeMinutes, &recipeVersion.Servings, &recipeVersion.ImageURL,
+		&recipeVersion.Ingredients, &recipeVersion.Steps,
+		&recipeVersion.Notes, &recipeVersion.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return recipeVersion, ErrNotFound
+		}
+		return recipeVersion, fmt.Errorf("failed to get recipe version: %w", err)
+	}
+
+	return recipeVersion, nil
+}
+
+func (s *PostgresStore) ListRecipeVersions(ctx context.Context, userID string, recipeID string) ([]models.RecipeVersion, error) {
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT
+			rv.id, rv.user_recipe_id, rv.parent_id,
+			rv.title, rv.description,
+			rv.total_time_minutes, rv.servings,
+			rv.image_url,
+			rv.ingredients, rv.steps,
+			rv.notes, rv.created_at
+		FROM recipe_versions rv
+		JOIN user_recipes ur ON rv.user_recipe_id = ur.id
+		WHERE rv.user_recipe_id = $1 AND ur.user_id = $2
+		ORDER BY rv.created_at ASC, rv.id ASC;
+	`, recipeID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recipe versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []models.RecipeVersion
+	for rows.Next() {
+		var version models.RecipeVersion
+		err := rows.Scan(
+			&version.ID, &version.UserRecipeID, &version.ParentID,
+			&version.Title, &version.Description,
+			&version.TotalTimeMinutes, &version.Servings, &version.ImageURL,
+			&version.Ingredients, &version.Steps,
+			&version.Notes, &version.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan recipe version: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list recipe versions: %w", err)
+	}
+	return versions, nil
+}
+
+func (s *PostgresStore) AddRecipeVersion(ctx context.Context, recipeVersion models.RecipeVersion) error {
+	ingredients, err := json.Marshal(recipeVersion.Ingredients)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ingredients: %w", err)
+	}
+	steps, err := json.Marshal(recipeVersion.Steps)
+	if err != nil {
+		return fmt.Errorf("failed to marshal steps: %w", err)
+	}
+
+	_, err = s.run.ExecContext(ctx, `
+		INSERT INTO recipe_versions (id, user_recipe_id, parent_id, title, description, total_time_minutes, servings, image_url, ingredients, steps, notes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (id) DO UPDATE SET
+			user_recipe_id     = excluded.user_recipe_id,
+			parent_id          = excluded.parent_id,
+			title              = excluded.title,
+			description        = excluded.description,
+			total_time_minutes = excluded.total_time_minutes,
+			servings           = excluded.servings,
+			image_url          = excluded.image_url,
+			ingredients        = excluded.ingredients,
+			steps              = excluded.steps,
+			notes              = excluded.notes;
+	`, recipeVersion.ID, recipeVersion.UserRecipeID, recipeVersion.ParentID, recipeVersion.Title, recipeVersion.Description,
+		recipeVersion.TotalTimeMinutes, recipeVersion.Servings, recipeVersion.ImageURL, ingredients, steps, recipeVersion.Notes, recipeVersion.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save recipe version: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) SaveMealPlan(ctx context.Context, userID string, mealPlan models.MealPlan) error {
+	recipes, err := json.Marshal(mealPlan.Recipes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recipes: %w", err)
+	}
+
+	_, err = s.run.ExecContext(ctx, `
+		INSERT INTO meal_plans (id, name, user_id, recipes) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET
+			name    = excluded.name,
+			recipes = excluded.recipes
+		WHERE meal_plans.user_id = excluded.user_id;
+	`, mealPlan.ID, mealPlan.Name, userID, recipes)
+	if err != nil {
+		return fmt.Errorf("failed to save meal plan: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetAllPlans(ctx context.Context, userID string) ([]models.MealPlan, error) {
+	var mealPlans []models.MealPlan
+	var recipes []byte
+
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT id, user_id, name, recipes FROM meal_plans WHERE user_id = $1
+		ORDER BY created_at ASC, id ASC;
+	`, userID)
+	if err != nil {
+		return mealPlans, fmt.Errorf("failed to get meal plans: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var mealPlan models.MealPlan
+		if err := rows.Scan(&mealPlan.ID, &mealPlan.UserID, &mealPlan.Name, &recipes); err != nil {
+			return mealPlans, fmt.Errorf("failed to scan meal plan: %w", err)
+		}
+		if err := json.Unmarshal(recipes, &mealPlan.Recipes); err != nil {
+			return mealPlans, fmt.Errorf("failed to unmarshal recipes: %w", err)
+		}
+		mealPlans = append(mealPlans, mealPlan)
+	}
+	if err := rows.Err(); err != nil {
+		return mealPlans, fmt.Errorf("failed to get meal plans: %w", err)
+	}
+	return mealPlans, nil
+}
+
+func (s *PostgresStore) GetMealPlan(ctx context.Context, userID string, mealPlanID string) (models.MealPlan, error) {
+	var mealPlan models.MealPlan
+	var recipes []byte
+
+	err := s.run.QueryRowContext(ctx, `
+		SELECT id, user_id, name, recipes FROM meal_plans WHERE id = $1 AND user_id = $2;
+	`, mealPlanID, userID).Scan(&mealPlan.ID, &mealPlan.UserID, &mealPlan.Name, &recipes)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return mealPlan, ErrNotFound
+		}
+		return mealPlan, fmt.Errorf("failed to get meal plan: %w", err)
+	}
+
+	if err := json.Unmarshal(recipes, &mealPlan.Recipes); err != nil {
+		return mealPlan, fmt.Errorf("failed to unmarshal recipes: %w", err)
+	}
+	return mealPlan, nil
+}
+
+func (s *PostgresStore) DeleteMealPlan(ctx context.Context, userID string, mealPlanID string) error {
+	_, err := s.run.ExecContext(ctx, `
+		DELETE FROM meal_plans WHERE id = $1 AND user_id = $2;`, mealPlanID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete meal plan: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) CreateIdempotencyRecord(ctx context.Context, record models.IdempotencyRecord) error {
+	res, err := s.run.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, method, path, request_hash)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, idempotency_key) DO NOTHING;
+	`, record.UserID, record.Key, record.Method, record.Path, record.RequestHash)
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency record: %w", err)
+	}
+	created, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency record: %w", err)
+	}
+	if created == 0 {
+		return ErrIdempotencyKeyExists
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetIdempotencyRecord(ctx context.Context, userID string, key string) (models.IdempotencyRecord, error) {
+	record := models.IdempotencyRecord{UserID: userID, Key: key}
+	var status sql.NullInt64
+	err := s.run.QueryRowContext(ctx, `
+		SELECT method, path, request_hash, status, content_type, body, created_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2;
+	`, userID, key).Scan(
+		&record.Method, &record.Path, &record.RequestHash, &status, &record.ContentType, &record.Body, &record.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return record, ErrNotFound
+		}
+		return record, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+	record.Status = int(status.Int64)
+	return record, nil
+}
+
+func (s *PostgresStore) CompleteIdempotencyRecord(ctx context.Context, record models.IdempotencyRecord) error {
+	_, err := s.run.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status = $1, content_type = $2, body = $3
+		WHERE user_id = $4 AND idempotency_key = $5;
+	`, record.Status, record.ContentType, record.Body, record.UserID, record.Key)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency record: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) DeleteIdempotencyRecord(ctx context.Context, userID string, key string) error {
+	_, err := s.run.ExecContext(ctx, `
+		DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2;
+	`, userID, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency record: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) WithTx(fn func(tx Store) error) error {
+	tx, err := s.run.(*sql.DB).BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	txStore := &PostgresStore{run: tx}
+	if err := fn(txStore); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func migratePostgres(db *sql.DB) error {
+	migrator, err := NewMigrator(db, DialectPostgres)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up(context.Background())
+	return err
+}
+
+// isPostgresUniqueViolation reports whether err is a violation of the named
+// unique constraint.
+func isPostgresUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
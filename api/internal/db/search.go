@@ -0,0 +1,152 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+// RecipeQuery filters a user's recipes. Results are ordered oldest first and
+// paged with a cursor on (created_at, id).
+type RecipeQuery struct {
+	// Text is matched against the title, description, ingredients and steps
+	Text string
+	// MaxTotalTimeMinutes excludes slower recipes, zero means no limit
+	MaxTotalTimeMinutes int
+	// Include and Exclude match ingredient names, ignoring case
+	Include       []string
+	Exclude       []string
+	FavoritesOnly bool
+	// CollectionID limits results to the recipes in one of the user's
+	// collections, it is ignored when empty
+	CollectionID string
+	// Tags limits results to recipes with every one of these tags
+	Tags []string
+	// After is the last recipe of the previous page
+	After *RecipeCursor
+	// Limit caps the number of results, zero means no limit
+	Limit int
+}
+
+// GlobalRecipeQuery filters the catalog of global recipes. Results are ordered
+// oldest first and paged with a cursor on (created_at, id).
+type GlobalRecipeQuery struct {
+	// Text is matched against the title, description, ingredients and steps
+	Text string
+	// After is the last recipe of the previous page
+	After *RecipeCursor
+	// Limit caps the number of results, zero means no limit
+	Limit int
+}
+
+type RecipeCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// ThreadQuery filters a user's threads. Results are ordered newest first and
+// paged with a cursor on (created_at, id).
+type ThreadQuery struct {
+	// Type and RecipeID are ignored when empty
+	Type     models.ThreadType
+	RecipeID string
+	// Before is the last thread of the previous page
+	Before *ThreadCursor
+	// Limit caps the number of results, zero means no limit
+	Limit int
+}
+
+type ThreadCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// ThreadEventQuery pages through the events of every user's threads, ordered
+// by thread and then by position in the thread.
+type ThreadEventQuery struct {
+	// After is the last event of the previous page
+	After *ThreadEventCursor
+	// Limit caps the number of results, zero means no limit
+	Limit int
+}
+
+type ThreadEventCursor struct {
+	ThreadID string
+	Index    int
+}
+
+// StoredThreadEvent is a thread event along with where it's stored.
+type StoredThreadEvent struct {
+	ID       string
+	ThreadID string
+	Index    int
+	Event    models.ThreadEvent
+}
+
+// searchDocument flattens the parts of a recipe that are searched but not
+// stored as text.
+func searchDocument(body models.RecipeBody) (ingredients string, steps string) {
+	names := make([]string, len(body.Ingredients))
+	for i, ingredient := range body.Ingredients {
+		names[i] = ingredient.Name
+	}
+	lines := make([]string, len(body.Steps))
+	for i, step := range body.Steps {
+		lines[i] = string(step)
+	}
+	return strings.Join(names, "\n"), strings.Join(lines, "\n")
+}
+
+// ftsQuery turns free text into an FTS5 query that matches recipes containing
+// every word, treating the words as prefixes so results show up while the
+// user is still typing. Quoting each word keeps FTS5 syntax in the input from
+// being interpreted.
+func ftsQuery(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		word = strings.ReplaceAll(word, `"`, "")
+		if word == "" {
+			continue
+		}
+		terms = append(terms, fmt.Sprintf(`"%s"*`, word))
+	}
+	return strings.Join(terms, " ")
+}
+
+// tsQuery is ftsQuery for PostgreSQL: every word must match, as a prefix.
+// Anything other than letters and digits separates words, which keeps
+// to_tsquery operators in the input from being interpreted.
+func tsQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+// likePattern matches values containing s, escaping LIKE wildcards with \.
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(s))
+	return "%" + s + "%"
+}
+
+// rebind rewrites ? placeholders into PostgreSQL's numbered form.
+func rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/ajohnston1219/eatme/api/internal/models"
 	"github.com/google/uuid"
@@ -27,6 +28,17 @@ type SQLiteStore struct {
 }
 
 func NewSQLiteStore(dsn string) (*SQLiteStore, error) {
+	db, err := openSQLite(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return &SQLiteStore{db}, nil
+}
+
+func openSQLite(dsn string) (*sql.DB, error) {
 	driver := "libsql"
 	if strings.HasPrefix(dsn, "file:") {
 		log.Println("Using SQLite for local file")
@@ -39,10 +51,7 @@ func NewSQLiteStore(dsn string) (*SQLiteStore, error) {
 	if _, err := db.Exec(`PRAGMA foreign_keys = ON;`); err != nil {
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
-	if err := migrate(db); err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
-	}
-	return &SQLiteStore{db}, nil
+	return db, nil
 }
 
 func NewSQLiteStoreWithDB(db *sql.DB) (*SQLiteStore, error) {
@@ -114,6 +123,70 @@ func (s *SQLiteStore) CheckPassword(ctx context.Context, userID string, password
 	return bcrypt.CompareHashAndPassword(hash, []byte(password))
 }
 
+func (s *SQLiteStore) SaveRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	_, err := s.run.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at)
+		VALUES (?, ?, ?, ?);
+	`, token.ID, token.UserID, token.TokenHash, token.ExpiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	var revokedAt sql.NullTime
+	err := s.run.QueryRowContext(ctx, `
+		SELECT id, user_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_tokens WHERE token_hash = ?;
+	`, tokenHash).Scan(
+		&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt,
+		&revokedAt, &token.ReplacedBy, &token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return token, ErrNotFound
+		}
+		return token, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+func (s *SQLiteStore) RevokeRefreshToken(ctx context.Context, tokenID string, replacedBy *string) error {
+	res, err := s.run.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = CURRENT_TIMESTAMP, replaced_by = ?
+		WHERE id = ? AND revoked_at IS NULL;
+	`, replacedBy, tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	revoked, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	if revoked == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore) RevokeAllRefreshTokens(ctx context.Context, userID string) error {
+	_, err := s.run.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND revoked_at IS NULL;
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
 func (s *SQLiteStore) SaveProfile(ctx context.Context, userID string, p models.Profile) error {
 	cuisines, err := json.Marshal(p.Cuisines)
 	if err != nil {
@@ -135,17 +208,18 @@ func (s *SQLiteStore) SaveProfile(ctx context.Context, userID string, p models.P
 	_, err = s.run.ExecContext(ctx, `
 		INSERT INTO profiles (
 		  user_id, setup_step, name, skill,
-		  cuisines, diets, equipment, allergies
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		  cuisines, diets, equipment, allergies, unit_system
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(user_id) DO UPDATE SET
-		  setup_step = excluded.setup_step,
-		  name       = excluded.name,
-		  skill      = excluded.skill,
-		  cuisines   = excluded.cuisines,
-		  diets      = excluded.diets,
-		  equipment  = excluded.equipment,
-		  allergies  = excluded.allergies
-	`, userID, p.SetupStep, p.Name, p.Skill, cuisines, diets, equipment, allergies)
+		  setup_step  = excluded.setup_step,
+		  name        = excluded.name,
+		  skill       = excluded.skill,
+		  cuisines    = excluded.cuisines,
+		  diets       = excluded.diets,
+		  equipment   = excluded.equipment,
+		  allergies   = excluded.allergies,
+		  unit_system = excluded.unit_system
+	`, userID, p.SetupStep, p.Name, p.Skill, cuisines, diets, equipment, allergies, p.UnitSystem)
 	if err != nil {
 		return fmt.Errorf("failed to save profile: %w", err)
 	}
@@ -161,11 +235,13 @@ func (s *SQLiteStore) GetProfile(ctx context.Context, userID string) (models.Pro
 			COALESCE(cuisines, '[]'),
 			COALESCE(diets, '[]'),
 			COALESCE(equipment, '[]'),
-			COALESCE(allergies, '[]')
+			COALESCE(allergies, '[]'),
+			unit_system
 		FROM profiles WHERE user_id = ?;
 	`, userID).Scan(
 		&p.SetupStep, &p.Name, &p.Skill,
 		&cuisines, &diets, &equipment, &allergies,
+		&p.UnitSystem,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -198,14 +274,15 @@ func (s *SQLiteStore) GetGlobalRecipe(ctx context.Context, id string) (models.Gl
 			COALESCE(ingredients, '[]'),
 			COALESCE(steps, '[]'),
 			source_type,
+			revision,
 			created_at,
 			updated_at
 		FROM global_recipes WHERE id = ?;
 	`, id).Scan(
 		&recipe.ID, &recipe.RecipeBody.Title, &recipe.RecipeBody.Description,
-		&recipe.RecipeBody.ImageURL, &recipe.RecipeBody.TotalTimeMinutes,
-		&recipe.RecipeBody.Servings, &recipe.RecipeBody.Ingredients,
-		&recipe.RecipeBody.Steps, &recipe.SourceType,
+		&recipe.RecipeBody.TotalTimeMinutes, &recipe.RecipeBody.Servings,
+		&recipe.RecipeBody.ImageURL, &recipe.RecipeBody.Ingredients,
+		&recipe.RecipeBody.Steps, &recipe.SourceType, &recipe.Revision,
 		&recipe.CreatedAt, &recipe.UpdatedAt,
 	)
 	if err != nil {
@@ -239,6 +316,7 @@ func (s *SQLiteStore) SaveGlobalRecipe(ctx context.Context, recipe models.Global
 			ingredients        = excluded.ingredients,
 			steps              = excluded.steps,
 			source_type        = excluded.source_type,
+			revision           = global_recipes.revision + 1,
 			updated_at         = CURRENT_TIMESTAMP;
 	`, recipe.ID, recipe.RecipeBody.Title, recipe.RecipeBody.Description,
 		recipe.RecipeBody.TotalTimeMinutes, recipe.RecipeBody.Servings, recipe.RecipeBody.ImageURL,
@@ -246,27 +324,108 @@ func (s *SQLiteStore) SaveGlobalRecipe(ctx context.Context, recipe models.Global
 	if err != nil {
 		return fmt.Errorf("failed to save global recipe: %w", err)
 	}
+	return s.indexGlobalRecipe(ctx, recipe.ID, recipe.RecipeBody)
+}
+
+func (s *SQLiteStore) indexGlobalRecipe(ctx context.Context, recipeID string, body models.RecipeBody) error {
+	_, err := s.run.ExecContext(ctx, `
+		DELETE FROM global_recipe_search WHERE recipe_id = ?;`, recipeID)
+	if err != nil {
+		return fmt.Errorf("failed to index global recipe: %w", err)
+	}
+	ingredients, steps := searchDocument(body)
+	_, err = s.run.ExecContext(ctx, `
+		INSERT INTO global_recipe_search (recipe_id, title, description, ingredients, steps)
+		VALUES (?, ?, ?, ?, ?);
+	`, recipeID, body.Title, body.Description, ingredients, steps)
+	if err != nil {
+		return fmt.Errorf("failed to index global recipe: %w", err)
+	}
 	return nil
 }
 
+func (s *SQLiteStore) SearchGlobalRecipes(ctx context.Context, query GlobalRecipeQuery) ([]models.GlobalRecipe, error) {
+	where := []string{"TRUE"}
+	var args []any
+	if text := ftsQuery(query.Text); text != "" {
+		where = append(where, "id IN (SELECT recipe_id FROM global_recipe_search WHERE global_recipe_search MATCH ?)")
+		args = append(args, text)
+	}
+	if query.After != nil {
+		// created_at holds CURRENT_TIMESTAMP text, so compare in that format
+		where = append(where, "(created_at, id) > (?, ?)")
+		args = append(args, query.After.CreatedAt.UTC().Format(time.DateTime), query.After.ID)
+	}
+	limit := ""
+	if query.Limit > 0 {
+		limit = "LIMIT ?"
+		args = append(args, query.Limit)
+	}
+
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT id, title, description, total_time_minutes, servings, image_url,
+			COALESCE(ingredients, '[]'),
+			COALESCE(steps, '[]'),
+			source_type,
+			revision,
+			created_at,
+			updated_at
+		FROM global_recipes
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY created_at ASC, id ASC
+		`+limit+`;
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search global recipes: %w", err)
+	}
+	defer rows.Close()
+
+	var recipes []models.GlobalRecipe
+	for rows.Next() {
+		var recipe models.GlobalRecipe
+		err := rows.Scan(
+			&recipe.ID, &recipe.RecipeBody.Title, &recipe.RecipeBody.Description,
+			&recipe.RecipeBody.TotalTimeMinutes, &recipe.RecipeBody.Servings,
+			&recipe.RecipeBody.ImageURL, &recipe.RecipeBody.Ingredients,
+			&recipe.RecipeBody.Steps, &recipe.SourceType, &recipe.Revision,
+			&recipe.CreatedAt, &recipe.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan global recipe: %w", err)
+		}
+		recipes = append(recipes, recipe)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search global recipes: %w", err)
+	}
+	return recipes, nil
+}
+
 func (s *SQLiteStore) CreateThread(ctx context.Context, userID string, thread models.Thread) error {
-	_, err := s.run.ExecContext(ctx, `
+	res, err := s.run.ExecContext(ctx, `
 		INSERT INTO threads (id, user_id, thread_type)
 		VALUES (?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
-			user_id            = excluded.user_id,
 			thread_type        = excluded.thread_type,
-			updated_at         = CURRENT_TIMESTAMP;
+			updated_at         = CURRENT_TIMESTAMP
+		WHERE threads.user_id = excluded.user_id;
 	`, thread.ID, userID, thread.Type)
 	if err != nil {
 		return fmt.Errorf("failed to create thread: %w", err)
 	}
+	created, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to create thread: %w", err)
+	}
+	if created == 0 {
+		return ErrNotFound
+	}
 	for i, event := range thread.Events {
 		eventId := uuid.NewString()
 		_, err = s.run.ExecContext(ctx, `
-			INSERT INTO thread_events (id, thread_id, event_index, event_type, payload)
-			VALUES (?, ?, ?, ?, ?)
-		`, eventId, thread.ID, i, event.Type, event.Payload)
+			INSERT INTO thread_events (id, thread_id, event_index, event_type, schema_version, payload)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, eventId, thread.ID, i, event.Type, max(event.SchemaVersion, 1), event.Payload)
 		if err != nil {
 			return fmt.Errorf("failed to append to thread: %w", err)
 		}
@@ -274,36 +433,119 @@ func (s *SQLiteStore) CreateThread(ctx context.Context, userID string, thread mo
 	return nil
 }
 
-func (s *SQLiteStore) AppendToThread(ctx context.Context, threadId string, events []models.ThreadEvent) error {
-	for _, event := range events {
-		eventId := uuid.NewString()
+func (s *SQLiteStore) AppendToThread(ctx context.Context, userID string, threadID string, expectedVersion int, events []models.ThreadEvent) error {
+	var version int
+	err := s.run.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM thread_events WHERE thread_id = t.id)
+		FROM threads t
+		WHERE t.id = ? AND t.user_id = ?;
+	`, threadID, userID).Scan(&version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to get thread version: %w", err)
+	}
+	if version != expectedVersion {
+		return fmt.Errorf("%w: thread is at version %d, expected %d", ErrVersionConflict, version, expectedVersion)
+	}
+
+	for i, event := range events {
 		_, err := s.run.ExecContext(ctx, `
-			WITH next_index AS (
-				SELECT MAX(event_index) + 1 AS next_index
-				FROM thread_events
-				WHERE thread_id = ?
-			)
-			INSERT INTO thread_events (thread_id, id, event_index, event_type, payload)
-			VALUES (?, ?, (SELECT next_index FROM next_index), ?, ?)
-		`, threadId, threadId, eventId, event.Type, event.Payload)
+			INSERT INTO thread_events (id, thread_id, event_index, event_type, schema_version, payload)
+			VALUES (?, ?, ?, ?, ?, ?);
+		`, uuid.NewString(), threadID, expectedVersion+i, event.Type, max(event.SchemaVersion, 1), event.Payload)
 		if err != nil {
+			// Another append got in between reading the version and writing
+			if isUniqueViolation(err, "thread_events.thread_id, thread_events.event_index") {
+				return fmt.Errorf("%w: thread changed while appending", ErrVersionConflict)
+			}
 			return fmt.Errorf("failed to append to thread: %w", err)
 		}
 	}
+	_, err = s.run.ExecContext(ctx, `
+		UPDATE threads SET updated_at = CURRENT_TIMESTAMP WHERE id = ?;
+	`, threadID)
+	if err != nil {
+		return fmt.Errorf("failed to append to thread: %w", err)
+	}
 	return nil
 }
 
-func (s *SQLiteStore) AssociateThreadWithRecipe(ctx context.Context, threadID string, recipeID string) error {
-	_, err := s.run.ExecContext(ctx, `
-		UPDATE threads SET recipe_id = ? WHERE id = ?;
-	`, recipeID, threadID)
+func (s *SQLiteStore) AssociateThreadWithRecipe(ctx context.Context, userID string, threadID string, recipeID string) error {
+	res, err := s.run.ExecContext(ctx, `
+		UPDATE threads SET recipe_id = ? WHERE id = ? AND user_id = ?;
+	`, recipeID, threadID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to associate recipe with thread: %w", err)
+	}
+	updated, err := res.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to associate recipe with thread: %w", err)
 	}
+	if updated == 0 {
+		return ErrNotFound
+	}
 	return nil
 }
 
-func (s *SQLiteStore) GetThread(ctx context.Context, threadID string) (models.Thread, error) {
+func (s *SQLiteStore) ListThreads(ctx context.Context, userID string, query ThreadQuery) ([]models.ThreadSummary, error) {
+	where := []string{"t.user_id = ?"}
+	args := []any{userID}
+	if query.Type != "" {
+		where = append(where, "t.thread_type = ?")
+		args = append(args, query.Type)
+	}
+	if query.RecipeID != "" {
+		where = append(where, "t.recipe_id = ?")
+		args = append(args, query.RecipeID)
+	}
+	if query.Before != nil {
+		// created_at holds CURRENT_TIMESTAMP text, so compare in that format
+		where = append(where, "(t.created_at, t.id) < (?, ?)")
+		args = append(args, query.Before.CreatedAt.UTC().Format(time.DateTime), query.Before.ID)
+	}
+	limit := ""
+	if query.Limit > 0 {
+		limit = "LIMIT ?"
+		args = append(args, query.Limit)
+	}
+
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT
+			t.id, t.thread_type, t.recipe_id,
+			(SELECT COUNT(*) FROM thread_events WHERE thread_id = t.id),
+			t.created_at, t.updated_at
+		FROM threads t
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY t.created_at DESC, t.id DESC
+		`+limit+`;
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list threads: %w", err)
+	}
+	defer rows.Close()
+
+	var threads []models.ThreadSummary
+	for rows.Next() {
+		var thread models.ThreadSummary
+		err := rows.Scan(&thread.ID, &thread.Type, &thread.RecipeID, &thread.Version, &thread.CreatedAt, &thread.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan thread: %w", err)
+		}
+		threads = append(threads, thread)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list threads: %w", err)
+	}
+	return threads, nil
+}
+
+func (s *SQLiteStore) GetThread(ctx context.Context, userID string, threadID string) (models.Thread, error) {
+	return s.GetThreadSince(ctx, userID, threadID, 0)
+}
+
+func (s *SQLiteStore) GetThreadSince(ctx context.Context, userID string, threadID string, version int) (models.Thread, error) {
 	var thread models.Thread
 	err := s.run.QueryRowContext(ctx, `
 		SELECT 
@@ -312,8 +554,8 @@ func (s *SQLiteStore) GetThread(ctx context.Context, threadID string) (models.Th
 			recipe_id,
 			created_at,
 			updated_at
-		FROM threads WHERE id = ?;
-	`, threadID).Scan(
+		FROM threads WHERE id = ? AND user_id = ?;
+	`, threadID, userID).Scan(
 		&thread.ID, &thread.Type, &thread.RecipeID, &thread.CreatedAt, &thread.UpdatedAt,
 	)
 	if err != nil {
@@ -326,10 +568,10 @@ func (s *SQLiteStore) GetThread(ctx context.Context, threadID string) (models.Th
 	var events []models.ThreadEvent
 	rows, err := s.run.QueryContext(ctx, `
 		SELECT 
-			event_type, payload, created_at
-		FROM thread_events WHERE thread_id = ?
+			event_type, schema_version, payload, created_at
+		FROM thread_events WHERE thread_id = ? AND event_index >= ?
 		ORDER BY event_index ASC;
-	`, threadID)
+	`, threadID, version)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return thread, ErrNotFound
@@ -341,7 +583,7 @@ func (s *SQLiteStore) GetThread(ctx context.Context, threadID string) (models.Th
 	for rows.Next() {
 		var event models.ThreadEvent
 		err := rows.Scan(
-			&event.Type, &event.Payload, &event.Timestamp,
+			&event.Type, &event.SchemaVersion, &event.Payload, &event.Timestamp,
 		)
 		if err != nil {
 			return thread, fmt.Errorf("failed to scan thread event: %w", err)
@@ -349,27 +591,135 @@ func (s *SQLiteStore) GetThread(ctx context.Context, threadID string) (models.Th
 		events = append(events, event)
 	}
 	thread.Events = events
+	thread.Version = version + len(events)
 
 	return thread, nil
 }
 
+func (s *SQLiteStore) GetThreadSnapshot(ctx context.Context, threadID string) (models.ThreadSnapshot, error) {
+	snapshot := models.ThreadSnapshot{ThreadID: threadID}
+	var state []byte
+	err := s.run.QueryRowContext(ctx, `
+		SELECT version, reducer_version, state, created_at
+		FROM thread_snapshots WHERE thread_id = ?;
+	`, threadID).Scan(&snapshot.Version, &snapshot.ReducerVersion, &state, &snapshot.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return snapshot, ErrNotFound
+		}
+		return snapshot, fmt.Errorf("failed to get thread snapshot: %w", err)
+	}
+	if err := json.Unmarshal(state, &snapshot.State); err != nil {
+		return snapshot, fmt.Errorf("failed to unmarshal thread snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+func (s *SQLiteStore) SaveThreadSnapshot(ctx context.Context, snapshot models.ThreadSnapshot) error {
+	state, err := json.Marshal(snapshot.State)
+	if err != nil {
+		return fmt.Errorf("failed to marshal thread snapshot: %w", err)
+	}
+	_, err = s.run.ExecContext(ctx, `
+		INSERT INTO thread_snapshots (thread_id, version, reducer_version, state)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (thread_id) DO UPDATE SET
+			version         = excluded.version,
+			reducer_version = excluded.reducer_version,
+			state           = excluded.state,
+			created_at      = CURRENT_TIMESTAMP
+		WHERE excluded.version > thread_snapshots.version
+			OR excluded.reducer_version <> thread_snapshots.reducer_version;
+	`, snapshot.ThreadID, snapshot.Version, snapshot.ReducerVersion, state)
+	if err != nil {
+		return fmt.Errorf("failed to save thread snapshot: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) ListAllThreadEvents(ctx context.Context, query ThreadEventQuery) ([]StoredThreadEvent, error) {
+	where := []string{"thread_id IS NOT NULL"}
+	args := []any{}
+	if query.After != nil {
+		where = append(where, "(thread_id, event_index) > (?, ?)")
+		args = append(args, query.After.ThreadID, query.After.Index)
+	}
+	limit := ""
+	if query.Limit > 0 {
+		limit = "LIMIT ?"
+		args = append(args, query.Limit)
+	}
+
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT
+			id, thread_id, event_index,
+			event_type, schema_version, payload, created_at
+		FROM thread_events
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY thread_id ASC, event_index ASC
+		`+limit+`;
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list thread events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []StoredThreadEvent
+	for rows.Next() {
+		var event StoredThreadEvent
+		err := rows.Scan(
+			&event.ID, &event.ThreadID, &event.Index,
+			&event.Event.Type, &event.Event.SchemaVersion, &event.Event.Payload, &event.Event.Timestamp,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan thread event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list thread events: %w", err)
+	}
+	return events, nil
+}
+
+func (s *SQLiteStore) ReplaceThreadEvent(ctx context.Context, eventID string, event models.ThreadEvent) error {
+	res, err := s.run.ExecContext(ctx, `
+		UPDATE thread_events
+		SET event_type = ?, schema_version = ?, payload = ?
+		WHERE id = ?;
+	`, event.Type, max(event.SchemaVersion, 1), event.Payload, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to replace thread event: %w", err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to replace thread event: %w", err)
+	}
+	if updated == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 func (s *SQLiteStore) GetUserRecipe(ctx context.Context, userID string, recipeID string) (models.UserRecipe, error) {
 	var recipe models.UserRecipe
 	err := s.run.QueryRowContext(ctx, `
 		SELECT 
-			ur.id, ur.user_id, ur.thread_id, ur.global_recipe_id,
+			ur.id, ur.user_id, ur.thread_id, ur.global_recipe_id, ur.global_recipe_revision,
+			COALESCE((SELECT gr.revision FROM global_recipes gr WHERE gr.id = ur.global_recipe_id) > ur.global_recipe_revision, FALSE),
 			ur.title, ur.description, ur.is_favorite, ur.image_url,
 			ur.latest_version_id, ur.created_at, ur.updated_at,
 			rv.total_time_minutes, rv.servings,
 			COALESCE(rv.ingredients, '[]'),
-			COALESCE(rv.steps, '[]')
+			COALESCE(rv.steps, '[]'),
+			(SELECT json_group_array(tag) FROM recipe_tags WHERE recipe_id = ur.id)
 		FROM user_recipes ur
 		JOIN recipe_versions rv ON ur.latest_version_id = rv.id
 		WHERE ur.id = ? AND ur.user_id = ?;
 	`, recipeID, userID).Scan(
-		&recipe.ID, &recipe.UserID, &recipe.ThreadID, &recipe.GlobalRecipeID, &recipe.Title, &recipe.Description, &recipe.IsFavorite,
+		&recipe.ID, &recipe.UserID, &recipe.ThreadID, &recipe.GlobalRecipeID, &recipe.GlobalRecipeRevision, &recipe.UpdateAvailable, &recipe.Title, &recipe.Description, &recipe.IsFavorite,
 		&recipe.ImageURL, &recipe.LatestVersionID, &recipe.CreatedAt, &recipe.UpdatedAt,
-		&recipe.RecipeBody.TotalTimeMinutes, &recipe.RecipeBody.Servings, &recipe.RecipeBody.Ingredients, &recipe.RecipeBody.Steps,
+		&recipe.RecipeBody.TotalTimeMinutes, &recipe.RecipeBody.Servings, &recipe.RecipeBody.Ingredients, &recipe.RecipeBody.Steps, &recipe.Tags,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -385,12 +735,14 @@ func (s *SQLiteStore) GetAllUserRecipes(ctx context.Context, userID string) ([]m
 	var recipes []models.UserRecipe
 	rows, err := s.run.QueryContext(ctx, `
 		SELECT 
-			ur.id, ur.user_id, ur.thread_id, ur.global_recipe_id,
+			ur.id, ur.user_id, ur.thread_id, ur.global_recipe_id, ur.global_recipe_revision,
+			COALESCE((SELECT gr.revision FROM global_recipes gr WHERE gr.id = ur.global_recipe_id) > ur.global_recipe_revision, FALSE),
 			ur.title, ur.description, ur.is_favorite, ur.image_url,
 			ur.latest_version_id, ur.created_at, ur.updated_at,
 			rv.total_time_minutes, rv.servings,
 			COALESCE(rv.ingredients, '[]'),
-			COALESCE(rv.steps, '[]')
+			COALESCE(rv.steps, '[]'),
+			(SELECT json_group_array(tag) FROM recipe_tags WHERE recipe_id = ur.id)
 		FROM user_recipes ur
 		JOIN recipe_versions rv ON ur.latest_version_id = rv.id
 		WHERE ur.user_id = ?;
@@ -406,9 +758,9 @@ func (s *SQLiteStore) GetAllUserRecipes(ctx context.Context, userID string) ([]m
 	for rows.Next() {
 		var recipe models.UserRecipe
 		err := rows.Scan(
-			&recipe.ID, &recipe.UserID, &recipe.ThreadID, &recipe.GlobalRecipeID, &recipe.Title, &recipe.Description, &recipe.IsFavorite,
+			&recipe.ID, &recipe.UserID, &recipe.ThreadID, &recipe.GlobalRecipeID, &recipe.GlobalRecipeRevision, &recipe.UpdateAvailable, &recipe.Title, &recipe.Description, &recipe.IsFavorite,
 			&recipe.ImageURL, &recipe.LatestVersionID, &recipe.CreatedAt, &recipe.UpdatedAt,
-			&recipe.RecipeBody.TotalTimeMinutes, &recipe.RecipeBody.Servings, &recipe.RecipeBody.Ingredients, &recipe.RecipeBody.Steps,
+			&recipe.RecipeBody.TotalTimeMinutes, &recipe.RecipeBody.Servings, &recipe.RecipeBody.Ingredients, &recipe.RecipeBody.Steps, &recipe.Tags,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user recipe: %w", err)
@@ -420,40 +772,62 @@ func (s *SQLiteStore) GetAllUserRecipes(ctx context.Context, userID string) ([]m
 
 func (s *SQLiteStore) SaveUserRecipe(ctx context.Context, recipe models.UserRecipe) error {
 	_, err := s.run.ExecContext(ctx, `
-		INSERT INTO user_recipes (id, user_id, global_recipe_id, thread_id, title, description, total_time_minutes, servings, is_favorite, image_url, latest_version_id)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO user_recipes (id, user_id, global_recipe_id, global_recipe_revision, thread_id, title, description, total_time_minutes, servings, is_favorite, image_url, latest_version_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
-			user_id            = excluded.user_id,
-			global_recipe_id   = excluded.global_recipe_id,
-			thread_id          = excluded.thread_id,
-			title              = excluded.title,
-			description        = excluded.description,
-			total_time_minutes = excluded.total_time_minutes,
-			servings           = excluded.servings,
-			is_favorite        = excluded.is_favorite,
-			image_url          = excluded.image_url,
-			latest_version_id  = excluded.latest_version_id,
-			updated_at         = CURRENT_TIMESTAMP;
-	`, recipe.ID, recipe.UserID, recipe.GlobalRecipeID, recipe.ThreadID, recipe.Title, recipe.Description,
+			user_id                = excluded.user_id,
+			global_recipe_id       = excluded.global_recipe_id,
+			global_recipe_revision = excluded.global_recipe_revision,
+			thread_id              = excluded.thread_id,
+			title                  = excluded.title,
+			description            = excluded.description,
+			total_time_minutes     = excluded.total_time_minutes,
+			servings               = excluded.servings,
+			is_favorite            = excluded.is_favorite,
+			image_url              = excluded.image_url,
+			latest_version_id      = excluded.latest_version_id,
+			updated_at             = CURRENT_TIMESTAMP;
+	`, recipe.ID, recipe.UserID, recipe.GlobalRecipeID, recipe.GlobalRecipeRevision, recipe.ThreadID, recipe.Title, recipe.Description,
 		recipe.RecipeBody.TotalTimeMinutes, recipe.RecipeBody.Servings, recipe.IsFavorite,
 		recipe.ImageURL, recipe.LatestVersionID)
 	if err != nil {
 		return fmt.Errorf("failed to save user recipe: %w", err)
 	}
-	return nil
+	return s.indexRecipe(ctx, recipe.ID, recipe.RecipeBody)
 }
 
 func (s *SQLiteStore) DeleteUserRecipe(ctx context.Context, userID string, recipeID string) error {
-	_, err := s.run.ExecContext(ctx, `
+	res, err := s.run.ExecContext(ctx, `
 		DELETE FROM user_recipes WHERE id = ? AND user_id = ?;`, recipeID, userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete user recipe: %w", err)
 	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete user recipe: %w", err)
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	_, err = s.run.ExecContext(ctx, `
+		DELETE FROM recipe_search
+		WHERE recipe_id = ? AND NOT EXISTS (SELECT 1 FROM user_recipes WHERE id = ?);
+	`, recipeID, recipeID)
+	if err != nil {
+		return fmt.Errorf("failed to remove user recipe from search: %w", err)
+	}
+	// Foreign keys are only enforced on the connection that enabled them, so
+	// don't rely on them to cascade
+	for _, table := range []string{"recipe_tags", "collection_recipes"} {
+		if _, err := s.run.ExecContext(ctx, `DELETE FROM `+table+` WHERE recipe_id = ?;`, recipeID); err != nil {
+			return fmt.Errorf("failed to remove user recipe from %s: %w", table, err)
+		}
+	}
 	return nil
 }
 
 func (s *SQLiteStore) UpdateUserRecipeVersion(ctx context.Context, userID string, recipeID string, version models.RecipeVersion) error {
-	_, err := s.run.ExecContext(ctx, `
+	res, err := s.run.ExecContext(ctx, `
 		UPDATE user_recipes
 		SET 
 		    title              = ?,
@@ -467,6 +841,324 @@ func (s *SQLiteStore) UpdateUserRecipeVersion(ctx context.Context, userID string
 	if err != nil {
 		return fmt.Errorf("failed to update user recipe version: %w", err)
 	}
+	if updated, err := res.RowsAffected(); err != nil || updated == 0 {
+		return err
+	}
+	return s.indexRecipe(ctx, recipeID, version.RecipeBody)
+}
+
+// indexRecipe replaces a recipe's entry in the full-text index.
+func (s *SQLiteStore) indexRecipe(ctx context.Context, recipeID string, body models.RecipeBody) error {
+	_, err := s.run.ExecContext(ctx, `
+		DELETE FROM recipe_search WHERE recipe_id = ?;`, recipeID)
+	if err != nil {
+		return fmt.Errorf("failed to index user recipe: %w", err)
+	}
+	ingredients, steps := searchDocument(body)
+	_, err = s.run.ExecContext(ctx, `
+		INSERT INTO recipe_search (recipe_id, title, description, ingredients, steps)
+		VALUES (?, ?, ?, ?, ?);
+	`, recipeID, body.Title, body.Description, ingredients, steps)
+	if err != nil {
+		return fmt.Errorf("failed to index user recipe: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SearchUserRecipes(ctx context.Context, userID string, query RecipeQuery) ([]models.UserRecipe, error) {
+	where := []string{"ur.user_id = ?"}
+	args := []any{userID}
+	if text := ftsQuery(query.Text); text != "" {
+		where = append(where, "ur.id IN (SELECT recipe_id FROM recipe_search WHERE recipe_search MATCH ?)")
+		args = append(args, text)
+	}
+	if query.MaxTotalTimeMinutes > 0 {
+		where = append(where, "rv.total_time_minutes <= ?")
+		args = append(args, query.MaxTotalTimeMinutes)
+	}
+	if query.FavoritesOnly {
+		where = append(where, "ur.is_favorite")
+	}
+	if query.CollectionID != "" {
+		where = append(where, `ur.id IN (
+			SELECT cr.recipe_id FROM collection_recipes cr
+			JOIN collections c ON c.id = cr.collection_id
+			WHERE c.id = ? AND c.user_id = ur.user_id
+		)`)
+		args = append(args, query.CollectionID)
+	}
+	for _, tag := range query.Tags {
+		where = append(where, "EXISTS (SELECT 1 FROM recipe_tags WHERE recipe_id = ur.id AND tag = ?)")
+		args = append(args, tag)
+	}
+	const hasIngredient = `EXISTS (
+		SELECT 1 FROM json_each(CAST(rv.ingredients AS TEXT))
+		WHERE lower(json_extract(value, '$.name')) LIKE ? ESCAPE '\'
+	)`
+	for _, name := range query.Include {
+		where = append(where, hasIngredient)
+		args = append(args, likePattern(name))
+	}
+	for _, name := range query.Exclude {
+		where = append(where, "NOT "+hasIngredient)
+		args = append(args, likePattern(name))
+	}
+	if query.After != nil {
+		// created_at holds CURRENT_TIMESTAMP text, so compare in that format
+		where = append(where, "(ur.created_at, ur.id) > (?, ?)")
+		args = append(args, query.After.CreatedAt.UTC().Format(time.DateTime), query.After.ID)
+	}
+	limit := ""
+	if query.Limit > 0 {
+		limit = "LIMIT ?"
+		args = append(args, query.Limit)
+	}
+
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT
+			ur.id, ur.user_id, ur.thread_id, ur.global_recipe_id, ur.global_recipe_revision,
+			COALESCE((SELECT gr.revision FROM global_recipes gr WHERE gr.id = ur.global_recipe_id) > ur.global_recipe_revision, FALSE),
+			ur.title, ur.description, ur.is_favorite, ur.image_url,
+			ur.latest_version_id, ur.created_at, ur.updated_at,
+			rv.total_time_minutes, rv.servings,
+			COALESCE(rv.ingredients, '[]'),
+			COALESCE(rv.steps, '[]'),
+			(SELECT json_group_array(tag) FROM recipe_tags WHERE recipe_id = ur.id)
+		FROM user_recipes ur
+		JOIN recipe_versions rv ON ur.latest_version_id = rv.id
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY ur.created_at ASC, ur.id ASC
+		`+limit+`;
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search user recipes: %w", err)
+	}
+	defer rows.Close()
+
+	var recipes []models.UserRecipe
+	for rows.Next() {
+		var recipe models.UserRecipe
+		err := rows.Scan(
+			&recipe.ID, &recipe.UserID, &recipe.ThreadID, &recipe.GlobalRecipeID, &recipe.GlobalRecipeRevision, &recipe.UpdateAvailable, &recipe.Title, &recipe.Description, &recipe.IsFavorite,
+			&recipe.ImageURL, &recipe.LatestVersionID, &recipe.CreatedAt, &recipe.UpdatedAt,
+			&recipe.RecipeBody.TotalTimeMinutes, &recipe.RecipeBody.Servings, &recipe.RecipeBody.Ingredients, &recipe.RecipeBody.Steps, &recipe.Tags,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user recipe: %w", err)
+		}
+		recipes = append(recipes, recipe)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search user recipes: %w", err)
+	}
+	return recipes, nil
+}
+
+func (s *SQLiteStore) SetRecipeFavorite(ctx context.Context, userID string, recipeID string, favorite bool) error {
+	res, err := s.run.ExecContext(ctx, `
+		UPDATE user_recipes SET is_favorite = ? WHERE id = ? AND user_id = ?;
+	`, favorite, recipeID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set recipe favorite: %w", err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set recipe favorite: %w", err)
+	}
+	if updated == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SetRecipeTags(ctx context.Context, userID string, recipeID string, tags []string) error {
+	var exists bool
+	err := s.run.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM user_recipes WHERE id = ? AND user_id = ?);
+	`, recipeID, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to get user recipe: %w", err)
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	if _, err := s.run.ExecContext(ctx, `DELETE FROM recipe_tags WHERE recipe_id = ?;`, recipeID); err != nil {
+		return fmt.Errorf("failed to clear recipe tags: %w", err)
+	}
+	for _, tag := range tags {
+		_, err := s.run.ExecContext(ctx, `
+			INSERT INTO recipe_tags (recipe_id, tag) VALUES (?, ?)
+			ON CONFLICT (recipe_id, tag) DO NOTHING;
+		`, recipeID, tag)
+		if err != nil {
+			return fmt.Errorf("failed to add recipe tag: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) ListRecipeTags(ctx context.Context, userID string) ([]models.RecipeTag, error) {
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT rt.tag, COUNT(*)
+		FROM recipe_tags rt
+		JOIN user_recipes ur ON ur.id = rt.recipe_id
+		WHERE ur.user_id = ?
+		GROUP BY rt.tag
+		ORDER BY rt.tag ASC;
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recipe tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []models.RecipeTag
+	for rows.Next() {
+		var tag models.RecipeTag
+		if err := rows.Scan(&tag.Tag, &tag.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan recipe tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list recipe tags: %w", err)
+	}
+	return tags, nil
+}
+
+func (s *SQLiteStore) GetAllCollections(ctx context.Context, userID string) ([]models.Collection, error) {
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT id, user_id, name, created_at, updated_at
+		FROM collections
+		WHERE user_id = ?
+		ORDER BY created_at ASC, id ASC;
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collections: %w", err)
+	}
+	defer rows.Close()
+
+	var collections []models.Collection
+	byID := make(map[string]int)
+	for rows.Next() {
+		collection := models.Collection{RecipeIDs: []string{}}
+		if err := rows.Scan(&collection.ID, &collection.UserID, &collection.Name, &collection.CreatedAt, &collection.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan collection: %w", err)
+		}
+		byID[collection.ID] = len(collections)
+		collections = append(collections, collection)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get collections: %w", err)
+	}
+
+	rows, err = s.run.QueryContext(ctx, `
+		SELECT cr.collection_id, cr.recipe_id
+		FROM collection_recipes cr
+		JOIN collections c ON c.id = cr.collection_id
+		WHERE c.user_id = ?
+		ORDER BY cr.collection_id, cr.position ASC;
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection recipes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var collectionID, recipeID string
+		if err := rows.Scan(&collectionID, &recipeID); err != nil {
+			return nil, fmt.Errorf("failed to scan collection recipe: %w", err)
+		}
+		if i, ok := byID[collectionID]; ok {
+			collections[i].RecipeIDs = append(collections[i].RecipeIDs, recipeID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get collection recipes: %w", err)
+	}
+	return collections, nil
+}
+
+func (s *SQLiteStore) GetCollection(ctx context.Context, userID string, collectionID string) (models.Collection, error) {
+	collection := models.Collection{RecipeIDs: []string{}}
+	err := s.run.QueryRowContext(ctx, `
+		SELECT id, user_id, name, created_at, updated_at
+		FROM collections
+		WHERE id = ? AND user_id = ?;
+	`, collectionID, userID).Scan(&collection.ID, &collection.UserID, &collection.Name, &collection.CreatedAt, &collection.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return collection, ErrNotFound
+		}
+		return collection, fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT recipe_id FROM collection_recipes
+		WHERE collection_id = ?
+		ORDER BY position ASC;
+	`, collectionID)
+	if err != nil {
+		return collection, fmt.Errorf("failed to get collection recipes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var recipeID string
+		if err := rows.Scan(&recipeID); err != nil {
+			return collection, fmt.Errorf("failed to scan collection recipe: %w", err)
+		}
+		collection.RecipeIDs = append(collection.RecipeIDs, recipeID)
+	}
+	if err := rows.Err(); err != nil {
+		return collection, fmt.Errorf("failed to get collection recipes: %w", err)
+	}
+	return collection, nil
+}
+
+func (s *SQLiteStore) SaveCollection(ctx context.Context, userID string, collection models.Collection) error {
+	res, err := s.run.ExecContext(ctx, `
+		INSERT INTO collections (id, user_id, name) VALUES (?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name       = excluded.name,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE collections.user_id = excluded.user_id;
+	`, collection.ID, userID, collection.Name)
+	if err != nil {
+		return fmt.Errorf("failed to save collection: %w", err)
+	}
+	saved, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to save collection: %w", err)
+	}
+	if saved == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := s.run.ExecContext(ctx, `DELETE FROM collection_recipes WHERE collection_id = ?;`, collection.ID); err != nil {
+		return fmt.Errorf("failed to clear collection recipes: %w", err)
+	}
+	for i, recipeID := range collection.RecipeIDs {
+		_, err := s.run.ExecContext(ctx, `
+			INSERT INTO collection_recipes (collection_id, recipe_id, position) VALUES (?, ?, ?);
+		`, collection.ID, recipeID, i)
+		if err != nil {
+			return fmt.Errorf("failed to add collection recipe: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) DeleteCollection(ctx context.Context, userID string, collectionID string) error {
+	res, err := s.run.ExecContext(ctx, `
+		DELETE FROM collections WHERE id = ? AND user_id = ?;`, collectionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
 	return nil
 }
 
@@ -474,8 +1166,9 @@ func (s *SQLiteStore) GetRecipeVersion(ctx context.Context, recipeVersionID stri
 	var recipeVersion models.RecipeVersion
 
 	err := s.run.QueryRowContext(ctx, `
-		SELECT 
+		SELECT
 			id, user_recipe_id, parent_id,
+			title, description,
 			total_time_minutes, servings,
 			image_url,
 			COALESCE(ingredients, '[]'),
@@ -484,6 +1177,7 @@ func (s *SQLiteStore) GetRecipeVersion(ctx context.Context, recipeVersionID stri
 		FROM recipe_versions WHERE id = ?;
 	`, recipeVersionID).Scan(
 		&recipeVersion.ID, &recipeVersion.UserRecipeID, &recipeVersion.ParentID,
+		&recipeVersion.Title, &recipeVersion.Description,
 		&recipeVersion.TotalTimeMinutes, &recipeVersion.Servings, &recipeVersion.ImageURL,
 		&recipeVersion.Ingredients, &recipeVersion.Steps,
 		&recipeVersion.Notes, &recipeVersion.CreatedAt,
@@ -498,6 +1192,47 @@ func (s *SQLiteStore) GetRecipeVersion(ctx context.Context, recipeVersionID stri
 	return recipeVersion, nil
 }
 
+func (s *SQLiteStore) ListRecipeVersions(ctx context.Context, userID string, recipeID string) ([]models.RecipeVersion, error) {
+	rows, err := s.run.QueryContext(ctx, `
+		SELECT
+			rv.id, rv.user_recipe_id, rv.parent_id,
+			rv.title, rv.description,
+			rv.total_time_minutes, rv.servings,
+			rv.image_url,
+			COALESCE(rv.ingredients, '[]'),
+			COALESCE(rv.steps, '[]'),
+			rv.notes, rv.created_at
+		FROM recipe_versions rv
+		JOIN user_recipes ur ON rv.user_recipe_id = ur.id
+		WHERE rv.user_recipe_id = ? AND ur.user_id = ?
+		ORDER BY rv.created_at ASC, rv.id ASC;
+	`, recipeID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recipe versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []models.RecipeVersion
+	for rows.Next() {
+		var version models.RecipeVersion
+		err := rows.Scan(
+			&version.ID, &version.UserRecipeID, &version.ParentID,
+			&version.Title, &version.Description,
+			&version.TotalTimeMinutes, &version.Servings, &version.ImageURL,
+			&version.Ingredients, &version.Steps,
+			&version.Notes, &version.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan recipe version: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list recipe versions: %w", err)
+	}
+	return versions, nil
+}
+
 func (s *SQLiteStore) AddRecipeVersion(ctx context.Context, recipeVersion models.RecipeVersion) error {
 	ingredients, err := json.Marshal(recipeVersion.Ingredients)
 	if err != nil {
@@ -509,18 +1244,20 @@ func (s *SQLiteStore) AddRecipeVersion(ctx context.Context, recipeVersion models
 	}
 
 	_, err = s.run.ExecContext(ctx, `
-		INSERT INTO recipe_versions (id, user_recipe_id, parent_id, total_time_minutes, servings, image_url, ingredients, steps, notes, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO recipe_versions (id, user_recipe_id, parent_id, title, description, total_time_minutes, servings, image_url, ingredients, steps, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			user_recipe_id     = excluded.user_recipe_id,
 			parent_id          = excluded.parent_id,
+			title              = excluded.title,
+			description        = excluded.description,
 			total_time_minutes = excluded.total_time_minutes,
 			servings           = excluded.servings,
 			image_url          = excluded.image_url,
 			ingredients        = excluded.ingredients,
 			steps              = excluded.steps,
 			notes              = excluded.notes;
-	`, recipeVersion.ID, recipeVersion.UserRecipeID, recipeVersion.ParentID,
+	`, recipeVersion.ID, recipeVersion.UserRecipeID, recipeVersion.ParentID, recipeVersion.Title, recipeVersion.Description,
 		recipeVersion.TotalTimeMinutes, recipeVersion.Servings, recipeVersion.ImageURL, ingredients, steps, recipeVersion.Notes, recipeVersion.CreatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to save recipe version: %w", err)
@@ -533,6 +1270,8 @@ func (s *SQLiteStore) UpdateRecipeVersion(ctx context.Context, newVersion models
 		UPDATE recipe_versions SET
 		    user_recipe_id     = ?,
 		    parent_id          = ?,
+		    title              = ?,
+		    description        = ?,
 		    total_time_minutes = ?,
 		    servings           = ?,
 		    image_url          = ?,
@@ -540,7 +1279,7 @@ func (s *SQLiteStore) UpdateRecipeVersion(ctx context.Context, newVersion models
 		    steps              = ?,
 		    notes              = ?
 		WHERE id = ? AND user_recipe_id = ?;
-	`, newVersion.UserRecipeID, newVersion.ParentID,
+	`, newVersion.UserRecipeID, newVersion.ParentID, newVersion.Title, newVersion.Description,
 		newVersion.TotalTimeMinutes, newVersion.Servings, newVersion.ImageURL, newVersion.Ingredients, newVersion.Steps,
 		newVersion.Notes, newVersion.ID, newVersion.UserRecipeID)
 	if err != nil {
@@ -556,9 +1295,12 @@ func (s *SQLiteStore) SaveMealPlan(ctx context.Context, userID string, mealPlan
 	}
 
 	_, err = s.run.ExecContext(ctx, `
-		INSERT INTO meal_plans (id, user_id, recipes) VALUES (?, ?, ?)
-		ON CONFLICT(id) DO UPDATE SET recipes = excluded.recipes;
-	`, mealPlan.ID, userID, recipes)
+		INSERT INTO meal_plans (id, name, user_id, recipes) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name    = excluded.name,
+			recipes = excluded.recipes
+		WHERE meal_plans.user_id = excluded.user_id;
+	`, mealPlan.ID, mealPlan.Name, userID, recipes)
 	if err != nil {
 		return fmt.Errorf("failed to save meal plan: %w", err)
 	}
@@ -570,7 +1312,8 @@ func (s *SQLiteStore) GetAllPlans(ctx context.Context, userID string) ([]models.
 	var recipes []byte
 
 	rows, err := s.run.QueryContext(ctx, `
-		SELECT id, user_id, recipes FROM meal_plans WHERE user_id = ?;
+		SELECT id, user_id, name, recipes FROM meal_plans WHERE user_id = ?
+		ORDER BY rowid ASC;
 	`, userID)
 	if err != nil {
 		return mealPlans, fmt.Errorf("failed to get meal plans: %w", err)
@@ -579,7 +1322,7 @@ func (s *SQLiteStore) GetAllPlans(ctx context.Context, userID string) ([]models.
 
 	for rows.Next() {
 		var mealPlan models.MealPlan
-		if err := rows.Scan(&mealPlan.ID, &mealPlan.UserID, &recipes); err != nil {
+		if err := rows.Scan(&mealPlan.ID, &mealPlan.UserID, &mealPlan.Name, &recipes); err != nil {
 			return mealPlans, fmt.Errorf("failed to scan meal plan: %w", err)
 		}
 		if err := json.Unmarshal(recipes, &mealPlan.Recipes); err != nil {
@@ -595,8 +1338,8 @@ func (s *SQLiteStore) GetMealPlan(ctx context.Context, userID string, mealPlanID
 	var recipes []byte
 
 	err := s.run.QueryRowContext(ctx, `
-		SELECT id, user_id, recipes FROM meal_plans WHERE id = ? AND user_id = ?;
-	`, mealPlanID, userID).Scan(&mealPlan.ID, &mealPlan.UserID, &recipes)
+		SELECT id, user_id, name, recipes FROM meal_plans WHERE id = ? AND user_id = ?;
+	`, mealPlanID, userID).Scan(&mealPlan.ID, &mealPlan.UserID, &mealPlan.Name, &recipes)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return mealPlan, ErrNotFound
@@ -610,6 +1353,76 @@ func (s *SQLiteStore) GetMealPlan(ctx context.Context, userID string, mealPlanID
 	return mealPlan, nil
 }
 
+func (s *SQLiteStore) DeleteMealPlan(ctx context.Context, userID string, mealPlanID string) error {
+	_, err := s.run.ExecContext(ctx, `
+		DELETE FROM meal_plans WHERE id = ? AND user_id = ?;`, mealPlanID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete meal plan: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) CreateIdempotencyRecord(ctx context.Context, record models.IdempotencyRecord) error {
+	res, err := s.run.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, method, path, request_hash)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, idempotency_key) DO NOTHING;
+	`, record.UserID, record.Key, record.Method, record.Path, record.RequestHash)
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency record: %w", err)
+	}
+	created, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency record: %w", err)
+	}
+	if created == 0 {
+		return ErrIdempotencyKeyExists
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetIdempotencyRecord(ctx context.Context, userID string, key string) (models.IdempotencyRecord, error) {
+	record := models.IdempotencyRecord{UserID: userID, Key: key}
+	var status sql.NullInt64
+	err := s.run.QueryRowContext(ctx, `
+		SELECT method, path, request_hash, status, content_type, body, created_at
+		FROM idempotency_keys
+		WHERE user_id = ? AND idempotency_key = ?;
+	`, userID, key).Scan(
+		&record.Method, &record.Path, &record.RequestHash, &status, &record.ContentType, &record.Body, &record.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return record, ErrNotFound
+		}
+		return record, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+	record.Status = int(status.Int64)
+	return record, nil
+}
+
+func (s *SQLiteStore) CompleteIdempotencyRecord(ctx context.Context, record models.IdempotencyRecord) error {
+	_, err := s.run.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status = ?, content_type = ?, body = ?
+		WHERE user_id = ? AND idempotency_key = ?;
+	`, record.Status, record.ContentType, record.Body, record.UserID, record.Key)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency record: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) DeleteIdempotencyRecord(ctx context.Context, userID string, key string) error {
+	_, err := s.run.ExecContext(ctx, `
+		DELETE FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ?;
+	`, userID, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency record: %w", err)
+	}
+	return nil
+}
+
 func (s *SQLiteStore) WithTx(fn func(tx Store) error) error {
 	tx, err := s.run.(*sql.DB).BeginTx(context.Background(), nil)
 	if err != nil {
@@ -625,126 +1438,65 @@ func (s *SQLiteStore) WithTx(fn func(tx Store) error) error {
 }
 
 func migrate(db *sql.DB) error {
-	const users = `
-	CREATE TABLE IF NOT EXISTS users (
-		id TEXT PRIMARY KEY,
-		email TEXT UNIQUE NOT NULL,
-		password BLOB NOT NULL
-	);`
-
-	const profiles = `
-	CREATE TABLE IF NOT EXISTS profiles (
-		user_id    TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
-		setup_step TEXT NOT NULL DEFAULT 'account',
-		name       TEXT NOT NULL DEFAULT '',
-		skill      TEXT NOT NULL DEFAULT 'beginner',
-		cuisines   JSON NOT NULL DEFAULT '[]',
-		diets      JSON NOT NULL DEFAULT '[]',
-		equipment  JSON NOT NULL DEFAULT '[]',
-		allergies  JSON NOT NULL DEFAULT '[]'
-	);`
-
-	const globalRecipes = `
-	CREATE TABLE IF NOT EXISTS global_recipes (
-		id                 TEXT PRIMARY KEY,
-		title              TEXT NOT NULL,
-		description        TEXT NOT NULL,
-		total_time_minutes INTEGER NOT NULL,
-		servings           INTEGER NOT NULL,
-		image_url          TEXT NULL,
-		ingredients        JSON NOT NULL DEFAULT '[]',
-		steps              JSON NOT NULL DEFAULT '[]',
-		source_type        TEXT NOT NULL DEFAULT 'generated',
-		created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		updated_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	const threads = `
-	CREATE TABLE IF NOT EXISTS threads (
-		id                 TEXT PRIMARY KEY,
-		thread_type        TEXT NOT NULL,
-		recipe_id          TEXT NULL,
-		user_id            TEXT REFERENCES users(id) ON DELETE CASCADE,
-		created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		updated_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	const threadEvents = `
-	CREATE TABLE IF NOT EXISTS thread_events (
-		id                 TEXT PRIMARY KEY,
-		thread_id          TEXT REFERENCES threads(id) ON DELETE CASCADE,
-		event_index        INTEGER NOT NULL,
-		event_type         TEXT NOT NULL,
-		payload            JSON NOT NULL,
-		created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	const userRecipes = `
-	CREATE TABLE IF NOT EXISTS user_recipes (
-		id                 TEXT PRIMARY KEY,
-		user_id            TEXT REFERENCES users(id) ON DELETE CASCADE,
-		thread_id          TEXT REFERENCES threads(id) ON DELETE CASCADE,
-		global_recipe_id   TEXT NULL REFERENCES global_recipes(id) ON DELETE SET NULL,
-		title              TEXT NOT NULL,
-		description        TEXT NOT NULL,
-		total_time_minutes INTEGER NOT NULL,
-		servings           INTEGER NOT NULL,
-		image_url          TEXT NULL,
-		is_favorite        BOOLEAN DEFAULT FALSE,
-		latest_version_id  TEXT NULL,
-		created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		updated_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	const recipeVersions = `
-	CREATE TABLE IF NOT EXISTS recipe_versions (
-		id                 TEXT PRIMARY KEY,
-		user_recipe_id     TEXT,
-		parent_id          TEXT,
-		created_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		total_time_minutes INTEGER NOT NULL,
-		servings           INTEGER NOT NULL,
-		image_url          TEXT NULL,
-		ingredients        JSON NOT NULL DEFAULT '[]',
-		steps              JSON NOT NULL DEFAULT '[]',
-		notes              TEXT NULL
-	);`
-
-	const mealPlans = `
-	CREATE TABLE IF NOT EXISTS meal_plans (
-		id         TEXT PRIMARY KEY,
-		name       TEXT NOT NULL,
-		user_id    TEXT REFERENCES users(id) ON DELETE CASCADE,
-		recipes    JSON NOT NULL DEFAULT '[]'
-	);`
-
-	if _, err := db.Exec(users); err != nil {
-		return fmt.Errorf("failed to create users table: %w", err)
-	}
-	if _, err := db.Exec(profiles); err != nil {
-		return fmt.Errorf("failed to create profiles table: %w", err)
-	}
-	if _, err := db.Exec(globalRecipes); err != nil {
-		return fmt.Errorf("failed to create global_recipes table: %w", err)
-	}
-	if _, err := db.Exec(threads); err != nil {
-		return fmt.Errorf("failed to create threads table: %w", err)
-	}
-	if _, err := db.Exec(threadEvents); err != nil {
-		return fmt.Errorf("failed to create thread_events table: %w", err)
-	}
-	if _, err := db.Exec(userRecipes); err != nil {
-		return fmt.Errorf("failed to create user_recipes table: %w", err)
-	}
-	if _, err := db.Exec(recipeVersions); err != nil {
-		return fmt.Errorf("failed to create recipe_versions table: %w", err)
-	}
-	if _, err := db.Exec(mealPlans); err != nil {
-		return fmt.Errorf("failed to create meal_plans table: %w", err)
+	ctx := context.Background()
+	migrator, err := NewMigrator(db, DialectSQLite)
+	if err != nil {
+		return err
 	}
-	return nil
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		if err := upgradeLegacySchema(db); err != nil {
+			return err
+		}
+	}
+	_, err = migrator.Up(ctx)
+	return err
+}
+
+// upgradeLegacySchema brings databases created before versioned migrations up
+// to the initial migration, which only creates the tables that are missing.
+func upgradeLegacySchema(db *sql.DB) error {
+	var profiles int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'profiles';`).Scan(&profiles)
+	if err != nil {
+		return fmt.Errorf("failed to check for legacy schema: %w", err)
+	}
+	if profiles == 0 {
+		return nil
+	}
+	return addColumn(db, "profiles", "unit_system", "TEXT NOT NULL DEFAULT 'imperial'")
 }
 
 func isUniqueViolation(err error, field string) bool {
 	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: "+field)
 }
+
+// addColumn adds a column to a table created before the column existed.
+// Databases that already have the column are left untouched.
+func addColumn(db *sql.DB, table string, column string, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", table))
+	if err != nil {
+		return fmt.Errorf("failed to get %s columns: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("failed to scan %s columns: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get %s columns: %w", table, err)
+	}
+	rows.Close()
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add %s.%s column: %w", table, column, err)
+	}
+	return nil
+}
@@ -12,29 +12,78 @@ type Store interface {
 	GetUserByEmail(ctx context.Context, email string) (models.User, error)
 	CheckPassword(ctx context.Context, userID string, password string) error
 
+	SaveRefreshToken(ctx context.Context, token models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error)
+	// RevokeRefreshToken fails with ErrNotFound if the token doesn't exist
+	// or was already revoked, so only one of two concurrent revocations wins
+	RevokeRefreshToken(ctx context.Context, tokenID string, replacedBy *string) error
+	RevokeAllRefreshTokens(ctx context.Context, userID string) error
+
 	GetProfile(ctx context.Context, userID string) (models.Profile, error)
 	SaveProfile(ctx context.Context, userID string, profile models.Profile) error
 
 	GetGlobalRecipe(ctx context.Context, id string) (models.GlobalRecipe, error)
 	SaveGlobalRecipe(ctx context.Context, recipe models.GlobalRecipe) error
+	SearchGlobalRecipes(ctx context.Context, query GlobalRecipeQuery) ([]models.GlobalRecipe, error)
 
 	CreateThread(ctx context.Context, userID string, thread models.Thread) error
-	GetThread(ctx context.Context, threadID string) (models.Thread, error)
-	AppendToThread(ctx context.Context, threadID string, events []models.ThreadEvent) error
-	AssociateThreadWithRecipe(ctx context.Context, threadID string, recipeID string) error
+	GetThread(ctx context.Context, userID string, threadID string) (models.Thread, error)
+	// GetThreadSince is GetThread with only the events from version onwards
+	GetThreadSince(ctx context.Context, userID string, threadID string, version int) (models.Thread, error)
+	// AppendToThread adds events to the end of a thread, failing with
+	// ErrVersionConflict unless the thread is still at expectedVersion.
+	AppendToThread(ctx context.Context, userID string, threadID string, expectedVersion int, events []models.ThreadEvent) error
+	AssociateThreadWithRecipe(ctx context.Context, userID string, threadID string, recipeID string) error
+	// ListThreads returns the user's threads without their events
+	ListThreads(ctx context.Context, userID string, query ThreadQuery) ([]models.ThreadSummary, error)
+	GetThreadSnapshot(ctx context.Context, threadID string) (models.ThreadSnapshot, error)
+	// SaveThreadSnapshot replaces the thread's snapshot unless the stored one
+	// is newer and taken by the same reducer version
+	SaveThreadSnapshot(ctx context.Context, snapshot models.ThreadSnapshot) error
+	// ListAllThreadEvents returns the events of every user's threads, for
+	// maintenance that has to look at the whole event log
+	ListAllThreadEvents(ctx context.Context, query ThreadEventQuery) ([]StoredThreadEvent, error)
+	// ReplaceThreadEvent rewrites a stored event in place, keeping its
+	// position in its thread
+	ReplaceThreadEvent(ctx context.Context, eventID string, event models.ThreadEvent) error
 
 	GetUserRecipe(ctx context.Context, userID string, recipeID string) (models.UserRecipe, error)
 	GetAllUserRecipes(ctx context.Context, userID string) ([]models.UserRecipe, error)
 	SaveUserRecipe(ctx context.Context, recipe models.UserRecipe) error
 	UpdateUserRecipeVersion(ctx context.Context, userID string, recipeID string, version models.RecipeVersion) error
 	DeleteUserRecipe(ctx context.Context, userID string, recipeID string) error
+	SearchUserRecipes(ctx context.Context, userID string, query RecipeQuery) ([]models.UserRecipe, error)
+	SetRecipeFavorite(ctx context.Context, userID string, recipeID string, favorite bool) error
+	// SetRecipeTags replaces the tags of the user's recipe
+	SetRecipeTags(ctx context.Context, userID string, recipeID string, tags []string) error
+	// ListRecipeTags returns every tag on the user's recipes, with how many
+	// recipes have it, in alphabetical order
+	ListRecipeTags(ctx context.Context, userID string) ([]models.RecipeTag, error)
+
+	GetAllCollections(ctx context.Context, userID string) ([]models.Collection, error)
+	GetCollection(ctx context.Context, userID string, collectionID string) (models.Collection, error)
+	// SaveCollection creates or renames the collection and replaces its
+	// recipes with RecipeIDs, in that order
+	SaveCollection(ctx context.Context, userID string, collection models.Collection) error
+	DeleteCollection(ctx context.Context, userID string, collectionID string) error
 
 	GetRecipeVersion(ctx context.Context, recipeVersionID string) (models.RecipeVersion, error)
+	// ListRecipeVersions returns every version of the user's recipe, oldest
+	// first
+	ListRecipeVersions(ctx context.Context, userID string, recipeID string) ([]models.RecipeVersion, error)
 	AddRecipeVersion(ctx context.Context, recipeVersion models.RecipeVersion) error
 
 	GetAllPlans(ctx context.Context, userID string) ([]models.MealPlan, error)
 	GetMealPlan(ctx context.Context, userID string, mealPlanID string) (models.MealPlan, error)
 	SaveMealPlan(ctx context.Context, userID string, mealPlan models.MealPlan) error
+	DeleteMealPlan(ctx context.Context, userID string, mealPlanID string) error
+
+	// CreateIdempotencyRecord claims a key, failing with
+	// ErrIdempotencyKeyExists if the user has already used it.
+	CreateIdempotencyRecord(ctx context.Context, record models.IdempotencyRecord) error
+	GetIdempotencyRecord(ctx context.Context, userID string, key string) (models.IdempotencyRecord, error)
+	CompleteIdempotencyRecord(ctx context.Context, record models.IdempotencyRecord) error
+	DeleteIdempotencyRecord(ctx context.Context, userID string, key string) error
 
 	WithTx(fn func(tx Store) error) error
 }
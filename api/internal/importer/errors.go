@@ -0,0 +1,7 @@
+package importer
+
+import "errors"
+
+var (
+	ErrNoRecipe = errors.New("no recipe found")
+)
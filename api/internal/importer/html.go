@@ -0,0 +1,413 @@
+// Package importer turns recipes published elsewhere, as schema.org Recipe
+// markup in a web page or as plain text, into draft recipes.
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+// schemaRecipe holds the schema.org Recipe properties a draft is made from,
+// as text, whichever markup they were read from.
+type schemaRecipe struct {
+	name         string
+	description  string
+	image        string
+	yields       []string
+	totalTime    string
+	prepTime     string
+	cookTime     string
+	ingredients  []string
+	instructions []string
+}
+
+// FromHTML reads a recipe from a web page's schema.org Recipe JSON-LD,
+// falling back to microdata when the page has no JSON-LD recipe. It returns
+// ErrNoRecipe when the page has neither.
+func FromHTML(r io.Reader) (models.RecipeBody, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return models.RecipeBody{}, fmt.Errorf("failed to parse html: %w", err)
+	}
+	recipe, ok := jsonLDRecipe(doc)
+	if !ok {
+		recipe, ok = microdataRecipe(doc)
+	}
+	if !ok {
+		return models.RecipeBody{}, ErrNoRecipe
+	}
+	return recipe.body(), nil
+}
+
+func (r schemaRecipe) body() models.RecipeBody {
+	body := models.RecipeBody{
+		Title:       r.name,
+		Description: r.description,
+		ImageURL:    r.image,
+		Ingredients: models.Ingredients{},
+		Steps:       models.Steps{},
+	}
+	for _, line := range r.ingredients {
+		if strings.TrimSpace(line) != "" {
+			body.Ingredients = append(body.Ingredients, ParseIngredient(line))
+		}
+	}
+	for _, instruction := range r.instructions {
+		for _, line := range strings.Split(instruction, "\n") {
+			if line = step(line); line != "" {
+				body.Steps = append(body.Steps, models.Step(line))
+			}
+		}
+	}
+	for _, yield := range r.yields {
+		if body.Servings = parseServings(yield); body.Servings > 0 {
+			break
+		}
+	}
+	if minutes, ok := ParseDuration(r.totalTime); ok {
+		body.TotalTimeMinutes = minutes
+	} else {
+		prep, _ := ParseDuration(r.prepTime)
+		cook, _ := ParseDuration(r.cookTime)
+		body.TotalTimeMinutes = prep + cook
+	}
+	return body
+}
+
+// jsonLDRecipe finds the first Recipe in the page's JSON-LD scripts, which
+// may be nested in a list or an @graph.
+func jsonLDRecipe(doc *html.Node) (schemaRecipe, bool) {
+	for _, script := range elements(doc) {
+		if script.DataAtom != atom.Script || !strings.EqualFold(attr(script, "type"), "application/ld+json") {
+			continue
+		}
+		var data strings.Builder
+		for child := script.FirstChild; child != nil; child = child.NextSibling {
+			data.WriteString(child.Data)
+		}
+		var v any
+		if err := json.Unmarshal([]byte(data.String()), &v); err != nil {
+			continue
+		}
+		if recipe, ok := findRecipe(v); ok {
+			return schemaRecipe{
+				name:         jsonText(recipe["name"]),
+				description:  jsonText(recipe["description"]),
+				image:        jsonImage(recipe["image"]),
+				yields:       jsonTexts(recipe["recipeYield"]),
+				totalTime:    jsonText(recipe["totalTime"]),
+				prepTime:     jsonText(recipe["prepTime"]),
+				cookTime:     jsonText(recipe["cookTime"]),
+				ingredients:  jsonTexts(first(recipe["recipeIngredient"], recipe["ingredients"])),
+				instructions: jsonInstructions(recipe["recipeInstructions"]),
+			}, true
+		}
+	}
+	return schemaRecipe{}, false
+}
+
+func findRecipe(v any) (map[string]any, bool) {
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			if recipe, ok := findRecipe(item); ok {
+				return recipe, true
+			}
+		}
+	case map[string]any:
+		if isRecipeType(v["@type"]) {
+			return v, true
+		}
+		for _, key := range []string{"@graph", "mainEntity"} {
+			if recipe, ok := findRecipe(v[key]); ok {
+				return recipe, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func isRecipeType(v any) bool {
+	switch v := v.(type) {
+	case string:
+		return v == "Recipe" || strings.HasSuffix(v, "schema.org/Recipe")
+	case []any:
+		for _, t := range v {
+			if isRecipeType(t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func first(values ...any) any {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// jsonText reads a text property, which may also be given as a list or as
+// a nested thing with a text or name.
+func jsonText(v any) string {
+	switch v := v.(type) {
+	case string:
+		return clean(v)
+	case float64:
+		return fmt.Sprint(v)
+	case []any:
+		for _, item := range v {
+			if text := jsonText(item); text != "" {
+				return text
+			}
+		}
+	case map[string]any:
+		return jsonText(first(v["text"], v["name"]))
+	}
+	return ""
+}
+
+func jsonTexts(v any) []string {
+	items, ok := v.([]any)
+	if !ok {
+		items = []any{v}
+	}
+	var texts []string
+	for _, item := range items {
+		if text := jsonText(item); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+func jsonImage(v any) string {
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			if url := jsonImage(item); url != "" {
+				return url
+			}
+		}
+	case map[string]any:
+		return jsonImage(first(v["url"], v["contentUrl"]))
+	case string:
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+// jsonInstructions flattens recipeInstructions, which may be text, a list
+// of HowToSteps or a list of HowToSections of HowToSteps.
+func jsonInstructions(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{cleanLines(v)}
+	case []any:
+		var steps []string
+		for _, item := range v {
+			steps = append(steps, jsonInstructions(item)...)
+		}
+		return steps
+	case map[string]any:
+		if items, ok := v["itemListElement"]; ok {
+			return jsonInstructions(items)
+		}
+		if text := jsonText(v); text != "" {
+			return []string{text}
+		}
+	}
+	return nil
+}
+
+// microdataRecipe reads the properties of the first element with a
+// schema.org Recipe itemtype.
+func microdataRecipe(doc *html.Node) (schemaRecipe, bool) {
+	for _, n := range elements(doc) {
+		if !hasAttr(n, "itemscope") || !strings.HasSuffix(attr(n, "itemtype"), "schema.org/Recipe") {
+			continue
+		}
+		props := map[string][]string{}
+		collectProps(n, props)
+		get := func(name string) string {
+			if values := props[name]; len(values) > 0 {
+				return values[0]
+			}
+			return ""
+		}
+		ingredients := props["recipeIngredient"]
+		if len(ingredients) == 0 {
+			ingredients = props["ingredients"]
+		}
+		var lines []string
+		for _, ingredient := range ingredients {
+			lines = append(lines, strings.Split(ingredient, "\n")...)
+		}
+		return schemaRecipe{
+			name:         get("name"),
+			description:  get("description"),
+			image:        get("image"),
+			yields:       props["recipeYield"],
+			totalTime:    get("totalTime"),
+			prepTime:     get("prepTime"),
+			cookTime:     get("cookTime"),
+			ingredients:  lines,
+			instructions: props["recipeInstructions"],
+		}, true
+	}
+	return schemaRecipe{}, false
+}
+
+// collectProps gathers the itemprop values under an item. Nested items,
+// such as HowToSteps, are values of their own and aren't searched.
+func collectProps(item *html.Node, props map[string][]string) {
+	for n := item.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		if names := strings.Fields(attr(n, "itemprop")); len(names) > 0 {
+			value := propValue(n)
+			for _, name := range names {
+				props[name] = append(props[name], value)
+			}
+		}
+		if !hasAttr(n, "itemscope") {
+			collectProps(n, props)
+		}
+	}
+}
+
+func propValue(n *html.Node) string {
+	if hasAttr(n, "itemscope") {
+		nested := map[string][]string{}
+		collectProps(n, nested)
+		if text := nested["text"]; len(text) > 0 {
+			return text[0]
+		}
+		return textContent(n)
+	}
+	switch n.DataAtom {
+	case atom.Meta:
+		return clean(attr(n, "content"))
+	case atom.Img, atom.Source:
+		return attr(n, "src")
+	case atom.A, atom.Link:
+		return attr(n, "href")
+	case atom.Time:
+		if datetime := attr(n, "datetime"); datetime != "" {
+			return datetime
+		}
+	case atom.Data, atom.Meter:
+		return attr(n, "value")
+	}
+	if content := attr(n, "content"); content != "" {
+		return clean(content)
+	}
+	return textContent(n)
+}
+
+// blocks are the elements that start a new line of text.
+var blocks = map[atom.Atom]bool{
+	atom.Br: true, atom.P: true, atom.Div: true, atom.Li: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+}
+
+// textContent is the text of an element with a line for each block within
+// it, e.g. each item of a list.
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.DataAtom == atom.Script || n.DataAtom == atom.Style:
+			return
+		case blocks[n.DataAtom]:
+			b.WriteString("\n")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if blocks[n.DataAtom] {
+			b.WriteString("\n")
+		}
+	}
+	walk(n)
+	return cleanLines(b.String())
+}
+
+// elements lists the elements of a document in document order.
+func elements(doc *html.Node) []*html.Node {
+	var nodes []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			nodes = append(nodes, n)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return nodes
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	tag      = regexp.MustCompile(`<[^>]*>`)
+	lineTag  = regexp.MustCompile(`(?i)<br\s*/?>|</(p|li|div)>`)
+	numbered = regexp.MustCompile(`(?i)^(?:step\s*\d+[.):]?|\d+[.):])\s+`)
+)
+
+// clean turns text that may hold markup or entities, as JSON-LD often
+// does, into a single line of plain text.
+func clean(s string) string {
+	s = html.UnescapeString(tag.ReplaceAllString(s, " "))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// cleanLines is clean for text with several lines, keeping the lines.
+func cleanLines(s string) string {
+	s = lineTag.ReplaceAllString(s, "\n")
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = clean(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// step removes the numbering from an instruction, since steps are ordered.
+func step(line string) string {
+	return strings.TrimSpace(numbered.ReplaceAllString(strings.TrimSpace(line), ""))
+}
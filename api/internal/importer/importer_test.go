@@ -0,0 +1,181 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestParseIngredient(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected models.Ingredient
+	}{
+		{"1 1/2 cups flour, sifted", models.Ingredient{Name: "flour, sifted", Quantity: 1.5, Unit: models.MeasurementUnitCup}},
+		{"1½ cups milk", models.Ingredient{Name: "milk", Quantity: 1.5, Unit: models.MeasurementUnitCup}},
+		{"¾ tsp salt", models.Ingredient{Name: "salt", Quantity: 0.75, Unit: models.MeasurementUnitTeaspoon}},
+		{"2 Tbsp. sugar", models.Ingredient{Name: "sugar", Quantity: 2, Unit: models.MeasurementUnitTablespoon}},
+		{"1 T butter", models.Ingredient{Name: "butter", Quantity: 1, Unit: models.MeasurementUnitTablespoon}},
+		{"4 fl. oz. cream", models.Ingredient{Name: "cream", Quantity: 4, Unit: models.MeasurementUnitFluidOunce}},
+		{"200g dark chocolate", models.Ingredient{Name: "dark chocolate", Quantity: 200, Unit: models.MeasurementUnitGram}},
+		{"0.5 kg potatoes", models.Ingredient{Name: "potatoes", Quantity: 0.5, Unit: models.MeasurementUnitKilogram}},
+		{"2-3 Pounds chicken thighs", models.Ingredient{Name: "chicken thighs", Quantity: 2, Unit: models.MeasurementUnitPound}},
+		{"1 to 2 cups of stock", models.Ingredient{Name: "stock", Quantity: 1, Unit: models.MeasurementUnitCup}},
+		{"3 large eggs", models.Ingredient{Name: "large eggs", Quantity: 3, Unit: models.MeasurementUnitCount}},
+		{"- 2 tomatoes", models.Ingredient{Name: "tomatoes", Quantity: 2, Unit: models.MeasurementUnitCount}},
+		{"a cup of rice", models.Ingredient{Name: "rice", Quantity: 1, Unit: models.MeasurementUnitCup}},
+		{"a pinch of salt", models.Ingredient{Name: "a pinch of salt", Unit: models.MeasurementUnitCount}},
+		{"Salt &amp; pepper to taste", models.Ingredient{Name: "Salt & pepper to taste", Unit: models.MeasurementUnitCount}},
+	}
+	for _, tt := range tests {
+		if got := ParseIngredient(tt.line); got != tt.expected {
+			t.Errorf("%q: expected %+v, got %+v", tt.line, tt.expected, got)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		duration string
+		expected int
+		ok       bool
+	}{
+		{"PT45M", 45, true},
+		{"PT1H30M", 90, true},
+		{"pt2h", 120, true},
+		{"P1DT2H", 26 * 60, true},
+		{"PT90S", 2, true},
+		{"P", 0, false},
+		{"45 minutes", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseDuration(tt.duration)
+		if got != tt.expected || ok != tt.ok {
+			t.Errorf("%q: expected %d %v, got %d %v", tt.duration, tt.expected, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestFromHTML(t *testing.T) {
+	testCases := []struct {
+		fixture  string
+		expected models.RecipeBody
+		err      error
+	}{
+		{
+			fixture: "jsonld.html",
+			expected: models.RecipeBody{
+				Title:       "Buttermilk Pancakes",
+				Description: "Fluffy pancakes & crisp edges.",
+				Ingredients: models.Ingredients{
+					{Name: "all-purpose flour, sifted", Quantity: 1.5, Unit: models.MeasurementUnitCup},
+					{Name: "sugar", Quantity: 2, Unit: models.MeasurementUnitTablespoon},
+					{Name: "buttermilk", Quantity: 1.25, Unit: models.MeasurementUnitCup},
+					{Name: "large egg", Quantity: 1, Unit: models.MeasurementUnitCount},
+					{Name: "Salt to taste", Unit: models.MeasurementUnitCount},
+				},
+				Steps: models.Steps{
+					"Whisk the flour and sugar.",
+					"Stir in the buttermilk and egg.",
+					"Fry on a hot griddle until golden.",
+				},
+				Servings:         4,
+				TotalTimeMinutes: 30,
+				ImageURL:         "https://example.com/pancakes.jpg",
+			},
+		},
+		{
+			fixture: "microdata.html",
+			expected: models.RecipeBody{
+				Title:       "Tomato Soup",
+				Description: "A quick weeknight soup.",
+				Ingredients: models.Ingredients{
+					{Name: "olive oil", Quantity: 2, Unit: models.MeasurementUnitTablespoon},
+					{Name: "onion, diced", Quantity: 0.5, Unit: models.MeasurementUnitCup},
+					{Name: "tomatoes", Quantity: 800, Unit: models.MeasurementUnitGram},
+					{Name: "stock", Quantity: 1, Unit: models.MeasurementUnitLiter},
+				},
+				Steps: models.Steps{
+					"Soften the onion in the oil.",
+					"Add the tomatoes and stock and simmer.",
+					"Blend until smooth.",
+				},
+				Servings:         6,
+				TotalTimeMinutes: 65,
+				ImageURL:         "https://example.com/soup.jpg",
+			},
+		},
+		{
+			fixture: "no_recipe.html",
+			err:     ErrNoRecipe,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			got, err := FromHTML(f)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected %v, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprintf("%+v", got) != fmt.Sprintf("%+v", tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestFromText(t *testing.T) {
+	text := `Weeknight Chili
+
+A pantry chili.
+Serves 4
+Time: 1 hour 15 minutes
+
+Ingredients:
+1 lb ground beef
+1 (15 oz) can beans
+2 tsp chili powder
+
+## Directions
+1. Brown the beef.
+Step 2: Add the beans and spices and simmer.
+`
+	got, err := FromText(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := models.RecipeBody{
+		Title:       "Weeknight Chili",
+		Description: "A pantry chili.",
+		Ingredients: models.Ingredients{
+			{Name: "ground beef", Quantity: 1, Unit: models.MeasurementUnitPound},
+			{Name: "(15 oz) can beans", Quantity: 1, Unit: models.MeasurementUnitCount},
+			{Name: "chili powder", Quantity: 2, Unit: models.MeasurementUnitTeaspoon},
+		},
+		Steps:            models.Steps{"Brown the beef.", "Add the beans and spices and simmer."},
+		Servings:         4,
+		TotalTimeMinutes: 75,
+	}
+	if fmt.Sprintf("%+v", got) != fmt.Sprintf("%+v", expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+
+	if _, err := FromText("Just a title\nand some words"); !errors.Is(err, ErrNoRecipe) {
+		t.Errorf("expected %v without ingredients, got %v", ErrNoRecipe, err)
+	}
+}
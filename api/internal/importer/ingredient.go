@@ -0,0 +1,124 @@
+package importer
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/units"
+)
+
+// vulgarFractions are the single character fractions recipes are written
+// with, e.g. "1½ cups".
+var vulgarFractions = strings.NewReplacer(
+	"½", " 1/2", "⅓", " 1/3", "⅔", " 2/3", "¼", " 1/4", "¾", " 3/4",
+	"⅕", " 1/5", "⅖", " 2/5", "⅗", " 3/5", "⅘", " 4/5", "⅙", " 1/6",
+	"⅚", " 5/6", "⅛", " 1/8", "⅜", " 3/8", "⅝", " 5/8", "⅞", " 7/8",
+	"⁄", "/",
+)
+
+const number = `\d+\s+\d+/\d+|\d+/\d+|\d+(?:\.\d+)?`
+
+// quantityPattern matches a leading quantity, which may be a mixed number
+// and may be a range such as "2-3" or "2 to 3".
+var quantityPattern = regexp.MustCompile(`^(` + number + `)(?:\s*(?:-|–|to)\s*(?:` + number + `))?`)
+
+var bullet = regexp.MustCompile(`^[-*•▢]\s*`)
+
+// ParseIngredient parses an ingredient line as it is written in a recipe,
+// e.g. "1 1/2 cups flour, sifted". The unit is normalized to a
+// MeasurementUnit and anything after it, including preparation notes, is
+// kept as the name. Ranges use their lower bound, and lines without a
+// quantity, such as "salt to taste", are kept whole with no quantity.
+func ParseIngredient(line string) models.Ingredient {
+	s := bullet.ReplaceAllString(strings.TrimSpace(vulgarFractions.Replace(clean(line))), "")
+	s = strings.Join(strings.Fields(s), " ")
+	ingredient := models.Ingredient{Name: s, Unit: models.MeasurementUnitCount}
+
+	rest := s
+	if m := quantityPattern.FindStringSubmatch(s); m != nil {
+		ingredient.Quantity = parseNumber(m[1])
+		rest = s[len(m[0]):]
+	} else if article, after, ok := strings.Cut(s, " "); ok && (strings.EqualFold(article, "a") || strings.EqualFold(article, "an")) {
+		// "a cup of sugar" has a quantity, "a pinch of salt" doesn't
+		if unit, after, ok := parseUnit(after); ok {
+			ingredient.Quantity = 1
+			ingredient.Unit = unit
+			rest = after
+		}
+	}
+	if rest == s {
+		return ingredient
+	}
+	if unit, after, ok := parseUnit(rest); ok && ingredient.Unit == models.MeasurementUnitCount {
+		ingredient.Unit = unit
+		rest = after
+	}
+
+	rest = strings.TrimSpace(rest)
+	if after, ok := strings.CutPrefix(rest, "of "); ok {
+		rest = after
+	}
+	if name := strings.TrimLeft(rest, " ,.-"); name != "" {
+		ingredient.Name = name
+	}
+	return ingredient
+}
+
+// parseUnit reads a unit, of one or two words, from the start of s.
+func parseUnit(s string) (models.MeasurementUnit, string, bool) {
+	words := strings.Fields(s)
+	for n := min(2, len(words)); n > 0; n-- {
+		if u, err := units.ParseUnit(strings.Join(words[:n], " ")); err == nil {
+			return u, strings.Join(words[n:], " "), true
+		}
+	}
+	return "", s, false
+}
+
+func parseNumber(s string) float64 {
+	var total float64
+	for _, part := range strings.Fields(s) {
+		if numerator, denominator, ok := strings.Cut(part, "/"); ok {
+			n, _ := strconv.ParseFloat(numerator, 64)
+			d, _ := strconv.ParseFloat(denominator, 64)
+			if d != 0 {
+				total += n / d
+			}
+			continue
+		}
+		n, _ := strconv.ParseFloat(part, 64)
+		total += n
+	}
+	return math.Round(total*1000) / 1000
+}
+
+var isoDuration = regexp.MustCompile(`(?i)^P(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// ParseDuration converts an ISO 8601 duration, e.g. "PT1H30M", to whole
+// minutes. It returns false for anything else.
+func ParseDuration(s string) (int, bool) {
+	m := isoDuration.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || strings.EqualFold(m[0], "P") {
+		return 0, false
+	}
+	var minutes float64
+	for i, perUnit := range []float64{24 * 60, 60, 1, 1.0 / 60} {
+		if m[i+1] != "" {
+			n, _ := strconv.ParseFloat(m[i+1], 64)
+			minutes += n * perUnit
+		}
+	}
+	return int(math.Round(minutes)), true
+}
+
+var firstNumber = regexp.MustCompile(`\d+`)
+
+// parseServings reads the number of servings from a yield such as
+// "Serves 4" or "4-6 servings", using the first number.
+func parseServings(yield string) int {
+	n, _ := strconv.Atoi(firstNumber.FindString(yield))
+	return n
+}
@@ -0,0 +1,87 @@
+package importer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+type section int
+
+const (
+	sectionIntro section = iota
+	sectionIngredients
+	sectionInstructions
+)
+
+// headings are the lines that start a section of a recipe, lowercase and
+// without punctuation.
+var headings = map[string]section{
+	"ingredients":  sectionIngredients,
+	"instructions": sectionInstructions,
+	"directions":   sectionInstructions,
+	"method":       sectionInstructions,
+	"steps":        sectionInstructions,
+	"preparation":  sectionInstructions,
+}
+
+var (
+	headingPunctuation = regexp.MustCompile(`[#*:_]`)
+	yieldLine          = regexp.MustCompile(`(?i)^(serves|servings|yield|yields|makes)\b`)
+	timeLine           = regexp.MustCompile(`(?i)^(?:total\s+)?time\b[:\s]*(?:(\d+)\s*(?:h|hr|hrs|hours?)\b)?\s*(?:(\d+)\s*(?:m|min|mins|minutes?)\b)?`)
+)
+
+// FromText reads a recipe written out as text: a title on the first line,
+// then optionally a description, servings ("Serves 4") and time
+// ("Time: 1 hour 10 minutes"), then an Ingredients heading with an
+// ingredient on each line and an Instructions, Directions or Method heading
+// with a step on each line. It returns ErrNoRecipe when there are no
+// ingredients.
+func FromText(text string) (models.RecipeBody, error) {
+	body := models.RecipeBody{
+		Ingredients: models.Ingredients{},
+		Steps:       models.Steps{},
+	}
+	var description []string
+	current := sectionIntro
+	for _, line := range strings.Split(text, "\n") {
+		line = clean(line)
+		if line == "" {
+			continue
+		}
+		heading := strings.ToLower(strings.TrimSpace(headingPunctuation.ReplaceAllString(line, "")))
+		if s, ok := headings[heading]; ok {
+			current = s
+			continue
+		}
+		switch current {
+		case sectionIntro:
+			switch {
+			case body.Title == "":
+				body.Title = line
+			case yieldLine.MatchString(line):
+				body.Servings = parseServings(line)
+			case timeLine.MatchString(line):
+				m := timeLine.FindStringSubmatch(line)
+				hours, _ := strconv.Atoi(m[1])
+				minutes, _ := strconv.Atoi(m[2])
+				body.TotalTimeMinutes = hours*60 + minutes
+			default:
+				description = append(description, line)
+			}
+		case sectionIngredients:
+			body.Ingredients = append(body.Ingredients, ParseIngredient(line))
+		case sectionInstructions:
+			if line = step(line); line != "" {
+				body.Steps = append(body.Steps, models.Step(line))
+			}
+		}
+	}
+	if len(body.Ingredients) == 0 {
+		return models.RecipeBody{}, ErrNoRecipe
+	}
+	body.Description = strings.Join(description, " ")
+	return body, nil
+}
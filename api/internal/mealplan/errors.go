@@ -0,0 +1,10 @@
+package mealplan
+
+import "errors"
+
+var (
+	ErrMealPlanNotFound       = errors.New("meal plan not found")
+	ErrMealPlanRecipeNotFound = errors.New("meal plan recipe not found")
+	ErrInvalidMealPlanName    = errors.New("invalid meal plan name")
+	ErrInvalidDay             = errors.New("invalid meal plan day")
+)
@@ -0,0 +1,351 @@
+package mealplan
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ajohnston1219/eatme/api/internal/api"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/recipe"
+	"github.com/ajohnston1219/eatme/api/internal/units"
+	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+type MealPlanHandler struct {
+	mealPlanService *MealPlanService
+}
+
+func NewMealPlanHandler(mealPlanService *MealPlanService) *MealPlanHandler {
+	return &MealPlanHandler{
+		mealPlanService: mealPlanService,
+	}
+}
+
+// @Summary Get all meal plans
+// @Description Get all meal plans for user
+// @ID getAllPlans
+// @Tags MealPlan
+// @Produce json
+// @Success 200 {array} models.MealPlan
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /plans [get]
+func (h *MealPlanHandler) GetAllPlans(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	plans, err := h.mealPlanService.GetAllPlans(r.Context(), userID)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to get meal plans", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, plans)
+}
+
+// @Summary Create a meal plan
+// @Description Create an empty meal plan
+// @ID createPlan
+// @Tags MealPlan
+// @Accept json
+// @Produce json
+// @Param request body models.CreateMealPlanRequest true "Create meal plan request"
+// @Success 201 {object} models.MealPlan
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /plans [post]
+func (h *MealPlanHandler) CreatePlan(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	var input models.CreateMealPlanRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode create meal plan request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+	plan, err := h.mealPlanService.CreatePlan(r.Context(), userID, input.Name)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to create meal plan", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusCreated, plan)
+}
+
+// @Summary Get a meal plan
+// @Description Get a meal plan by ID
+// @ID getPlan
+// @Tags MealPlan
+// @Produce json
+// @Param planId path string true "Meal plan ID"
+// @Success 200 {object} models.MealPlan
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Meal plan not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /plans/{planId} [get]
+func (h *MealPlanHandler) GetPlan(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	planID := chi.URLParam(r, "planId")
+	if planID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	plan, err := h.mealPlanService.GetPlan(r.Context(), userID, planID)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to get meal plan", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, plan)
+}
+
+// @Summary Update a meal plan
+// @Description Rename a meal plan
+// @ID updatePlan
+// @Tags MealPlan
+// @Accept json
+// @Produce json
+// @Param planId path string true "Meal plan ID"
+// @Param request body models.UpdateMealPlanRequest true "Update meal plan request"
+// @Success 200 {object} models.MealPlan
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Meal plan not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /plans/{planId} [put]
+func (h *MealPlanHandler) UpdatePlan(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	planID := chi.URLParam(r, "planId")
+	if planID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	var input models.UpdateMealPlanRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode update meal plan request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+	plan, err := h.mealPlanService.RenamePlan(r.Context(), userID, planID, input.Name)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to update meal plan", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, plan)
+}
+
+// @Summary Delete a meal plan
+// @Description Delete a meal plan
+// @ID deletePlan
+// @Tags MealPlan
+// @Param planId path string true "Meal plan ID"
+// @Success 204 "Meal plan deleted"
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Meal plan not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /plans/{planId} [delete]
+func (h *MealPlanHandler) DeletePlan(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	planID := chi.URLParam(r, "planId")
+	if planID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	if err := h.mealPlanService.DeletePlan(r.Context(), userID, planID); err != nil {
+		logger.Logger(r.Context()).Error("failed to delete meal plan", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Add a recipe to a meal plan
+// @Description Add a recipe to a day of a meal plan, pinned to the recipe's current version
+// @ID addPlanRecipe
+// @Tags MealPlan
+// @Accept json
+// @Produce json
+// @Param planId path string true "Meal plan ID"
+// @Param request body models.AddMealPlanRecipeRequest true "Add recipe request"
+// @Success 200 {object} models.MealPlan
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Meal plan or recipe not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /plans/{planId}/recipes [post]
+func (h *MealPlanHandler) AddRecipe(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	planID := chi.URLParam(r, "planId")
+	if planID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	var input models.AddMealPlanRecipeRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode add meal plan recipe request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+	plan, err := h.mealPlanService.AddRecipe(r.Context(), userID, planID, input)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to add recipe to meal plan", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, plan)
+}
+
+// @Summary Move a recipe within a meal plan
+// @Description Move a recipe to another day or position of a meal plan
+// @ID movePlanRecipe
+// @Tags MealPlan
+// @Accept json
+// @Produce json
+// @Param planId path string true "Meal plan ID"
+// @Param entryId path string true "Meal plan recipe ID"
+// @Param request body models.MoveMealPlanRecipeRequest true "Move recipe request"
+// @Success 200 {object} models.MealPlan
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Meal plan or recipe not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /plans/{planId}/recipes/{entryId} [put]
+func (h *MealPlanHandler) MoveRecipe(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	planID := chi.URLParam(r, "planId")
+	entryID := chi.URLParam(r, "entryId")
+	if planID == "" || entryID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	var input models.MoveMealPlanRecipeRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode move meal plan recipe request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+	plan, err := h.mealPlanService.MoveRecipe(r.Context(), userID, planID, entryID, input)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to move meal plan recipe", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, plan)
+}
+
+// @Summary Remove a recipe from a meal plan
+// @Description Remove a recipe from a meal plan
+// @ID removePlanRecipe
+// @Tags MealPlan
+// @Produce json
+// @Param planId path string true "Meal plan ID"
+// @Param entryId path string true "Meal plan recipe ID"
+// @Success 200 {object} models.MealPlan
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Meal plan or recipe not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /plans/{planId}/recipes/{entryId} [delete]
+func (h *MealPlanHandler) RemoveRecipe(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	planID := chi.URLParam(r, "planId")
+	entryID := chi.URLParam(r, "entryId")
+	if planID == "" || entryID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	plan, err := h.mealPlanService.RemoveRecipe(r.Context(), userID, planID, entryID)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to remove meal plan recipe", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, plan)
+}
+
+// @Summary Get the shopping list for a meal plan
+// @Description Get the ingredients of every recipe in a meal plan, merged and grouped by aisle
+// @ID getPlanShoppingList
+// @Tags MealPlan
+// @Produce json
+// @Param planId path string true "Meal plan ID"
+// @Param unit_system query string false "Measurement system to show quantities in, defaults to the user's preference" Enums(metric, imperial)
+// @Success 200 {object} models.ShoppingList
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Meal plan not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /plans/{planId}/shopping-list [get]
+func (h *MealPlanHandler) GetShoppingList(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+	planID := chi.URLParam(r, "planId")
+	if planID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	list, err := h.mealPlanService.GetShoppingList(r.Context(), userID, planID, r.URL.Query().Get("unit_system"))
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to get shopping list", zap.Error(err))
+		writeError(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, list)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrMealPlanNotFound):
+		api.ErrorJSON(w, http.StatusNotFound, models.ApiErrMealPlanNotFound)
+	case errors.Is(err, ErrMealPlanRecipeNotFound):
+		api.ErrorJSON(w, http.StatusNotFound, models.ApiErrMealPlanRecipeNotFound)
+	case errors.Is(err, recipe.ErrRecipeNotFound):
+		api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeNotFound)
+	case errors.Is(err, ErrInvalidMealPlanName):
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidMealPlanName)
+	case errors.Is(err, ErrInvalidDay):
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidMealPlanDay)
+	case errors.Is(err, units.ErrUnknownUnitSystem):
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidUnitSystem)
+	default:
+		api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+	}
+}
@@ -0,0 +1,249 @@
+package mealplan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/recipe"
+	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type MealPlanService struct {
+	store         db.Store
+	recipeService *recipe.RecipeService
+}
+
+func NewMealPlanService(store db.Store, recipeService *recipe.RecipeService) *MealPlanService {
+	return &MealPlanService{
+		store:         store,
+		recipeService: recipeService,
+	}
+}
+
+func (s *MealPlanService) getStore(ctx context.Context) db.Store {
+	if tx, ok := db.GetTx(ctx); ok {
+		return tx
+	}
+	return s.store
+}
+
+func (s *MealPlanService) GetAllPlans(ctx context.Context, userID string) ([]models.MealPlan, error) {
+	store := s.getStore(ctx)
+	plans, err := store.GetAllPlans(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get meal plans: %w", err)
+	}
+	if plans == nil {
+		plans = []models.MealPlan{}
+	}
+	for i := range plans {
+		if plans[i].Recipes == nil {
+			plans[i].Recipes = []*models.MealPlanRecipe{}
+		}
+	}
+	return plans, nil
+}
+
+func (s *MealPlanService) GetPlan(ctx context.Context, userID string, planID string) (*models.MealPlan, error) {
+	store := s.getStore(ctx)
+	plan, err := store.GetMealPlan(ctx, userID, planID)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return nil, ErrMealPlanNotFound
+		default:
+			return nil, fmt.Errorf("failed to get meal plan: %w", err)
+		}
+	}
+	if plan.Recipes == nil {
+		plan.Recipes = []*models.MealPlanRecipe{}
+	}
+	return &plan, nil
+}
+
+func (s *MealPlanService) CreatePlan(ctx context.Context, userID string, name string) (*models.MealPlan, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidMealPlanName
+	}
+	store := s.getStore(ctx)
+	plan := models.MealPlan{
+		ID:      uuid.New().String(),
+		UserID:  userID,
+		Name:    name,
+		Recipes: []*models.MealPlanRecipe{},
+	}
+	if err := store.SaveMealPlan(ctx, userID, plan); err != nil {
+		return nil, fmt.Errorf("failed to save meal plan: %w", err)
+	}
+	logger.Logger(ctx).Debug("created meal plan", zap.String("plan_id", plan.ID))
+	return &plan, nil
+}
+
+func (s *MealPlanService) RenamePlan(ctx context.Context, userID string, planID string, name string) (*models.MealPlan, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidMealPlanName
+	}
+	return s.updatePlan(ctx, userID, planID, func(ctx context.Context, plan *models.MealPlan) error {
+		plan.Name = name
+		return nil
+	})
+}
+
+func (s *MealPlanService) DeletePlan(ctx context.Context, userID string, planID string) error {
+	return s.store.WithTx(func(tx db.Store) error {
+		ctx := db.ContextWithTx(ctx, tx)
+		if _, err := s.GetPlan(ctx, userID, planID); err != nil {
+			return err
+		}
+		if err := tx.DeleteMealPlan(ctx, userID, planID); err != nil {
+			return fmt.Errorf("failed to delete meal plan: %w", err)
+		}
+		logger.Logger(ctx).Debug("deleted meal plan", zap.String("plan_id", planID))
+		return nil
+	})
+}
+
+// AddRecipe appends the recipe to the end of the given day. The entry keeps a
+// copy of the recipe's current version, later edits to the recipe create new
+// versions and leave the plan untouched.
+func (s *MealPlanService) AddRecipe(ctx context.Context, userID string, planID string, input models.AddMealPlanRecipeRequest) (*models.MealPlan, error) {
+	if input.Day < 0 {
+		return nil, ErrInvalidDay
+	}
+	return s.updatePlan(ctx, userID, planID, func(ctx context.Context, plan *models.MealPlan) error {
+		userRecipe, err := s.recipeService.GetUserRecipe(ctx, userID, input.RecipeID)
+		if err != nil {
+			return err
+		}
+		entry := &models.MealPlanRecipe{
+			ID:              uuid.New().String(),
+			PlanID:          plan.ID,
+			RecipeID:        userRecipe.ID,
+			RecipeVersionID: userRecipe.LatestVersionID,
+			Day:             input.Day,
+			Position:        len(recipesForDay(plan.Recipes, input.Day)),
+			RecipeBody:      userRecipe.RecipeBody,
+		}
+		plan.Recipes = append(plan.Recipes, entry)
+		logger.Logger(ctx).Debug("added recipe to meal plan",
+			zap.String("recipe_id", entry.RecipeID),
+			zap.String("recipe_version_id", entry.RecipeVersionID),
+		)
+		return nil
+	})
+}
+
+func (s *MealPlanService) RemoveRecipe(ctx context.Context, userID string, planID string, entryID string) (*models.MealPlan, error) {
+	return s.updatePlan(ctx, userID, planID, func(ctx context.Context, plan *models.MealPlan) error {
+		idx := slices.IndexFunc(plan.Recipes, func(r *models.MealPlanRecipe) bool { return r.ID == entryID })
+		if idx < 0 {
+			return ErrMealPlanRecipeNotFound
+		}
+		plan.Recipes = slices.Delete(plan.Recipes, idx, idx+1)
+		return nil
+	})
+}
+
+// MoveRecipe moves an entry to a position within a day, which covers both
+// reordering recipes within a day and moving them between days.
+func (s *MealPlanService) MoveRecipe(ctx context.Context, userID string, planID string, entryID string, input models.MoveMealPlanRecipeRequest) (*models.MealPlan, error) {
+	if input.Day < 0 {
+		return nil, ErrInvalidDay
+	}
+	return s.updatePlan(ctx, userID, planID, func(ctx context.Context, plan *models.MealPlan) error {
+		idx := slices.IndexFunc(plan.Recipes, func(r *models.MealPlanRecipe) bool { return r.ID == entryID })
+		if idx < 0 {
+			return ErrMealPlanRecipeNotFound
+		}
+		entry := plan.Recipes[idx]
+		rest := slices.Delete(slices.Clone(plan.Recipes), idx, idx+1)
+
+		day := recipesForDay(rest, input.Day)
+		slices.SortStableFunc(day, func(a, b *models.MealPlanRecipe) int { return a.Position - b.Position })
+		position := max(0, min(input.Position, len(day)))
+		day = slices.Insert(day, position, entry)
+		for i, r := range day {
+			r.Position = i
+		}
+		entry.Day = input.Day
+		plan.Recipes = append(rest, entry)
+		return nil
+	})
+}
+
+// GetShoppingList builds the shopping list for a plan in the user's preferred
+// measurement system, or in the given one when unitSystem is set.
+func (s *MealPlanService) GetShoppingList(ctx context.Context, userID string, planID string, unitSystem string) (*models.ShoppingList, error) {
+	system, err := s.recipeService.PreferredUnitSystem(ctx, userID, unitSystem)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := s.GetPlan(ctx, userID, planID)
+	if err != nil {
+		return nil, err
+	}
+	return BuildShoppingList(plan, system), nil
+}
+
+func (s *MealPlanService) updatePlan(ctx context.Context, userID string, planID string, fn func(ctx context.Context, plan *models.MealPlan) error) (*models.MealPlan, error) {
+	var plan *models.MealPlan
+	err := s.store.WithTx(func(tx db.Store) error {
+		var err error
+		ctx := db.ContextWithTx(ctx, tx)
+		plan, err = s.GetPlan(ctx, userID, planID)
+		if err != nil {
+			return err
+		}
+		if err := fn(ctx, plan); err != nil {
+			return err
+		}
+		normalizePositions(plan)
+		if err := tx.SaveMealPlan(ctx, userID, *plan); err != nil {
+			return fmt.Errorf("failed to save meal plan: %w", err)
+		}
+		logger.Logger(ctx).Debug("saved meal plan", zap.String("plan_id", plan.ID))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func recipesForDay(recipes []*models.MealPlanRecipe, day int) []*models.MealPlanRecipe {
+	var result []*models.MealPlanRecipe
+	for _, r := range recipes {
+		if r.Day == day {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// normalizePositions sorts entries by day and position and renumbers the
+// positions within each day so they are contiguous from 0.
+func normalizePositions(plan *models.MealPlan) {
+	slices.SortStableFunc(plan.Recipes, func(a, b *models.MealPlanRecipe) int {
+		if a.Day != b.Day {
+			return a.Day - b.Day
+		}
+		return a.Position - b.Position
+	})
+	position := 0
+	for i, r := range plan.Recipes {
+		if i > 0 && plan.Recipes[i-1].Day != r.Day {
+			position = 0
+		}
+		r.Position = position
+		position++
+	}
+}
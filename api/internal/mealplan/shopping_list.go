@@ -0,0 +1,203 @@
+package mealplan
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/units"
+)
+
+var aisleOrder = []models.Aisle{
+	models.AisleProduce,
+	models.AisleMeatSeafood,
+	models.AisleDairyEggs,
+	models.AisleBakery,
+	models.AislePantry,
+	models.AisleSpices,
+	models.AisleFrozen,
+	models.AisleBeverages,
+	models.AisleOther,
+}
+
+// aisleKeywords maps keywords to aisles. When several keywords match an
+// ingredient the longest wins, so "coconut milk" lands in the pantry rather
+// than with the dairy. Ties go to the aisle listed first.
+var aisleKeywords = []struct {
+	aisle    models.Aisle
+	keywords []string
+}{
+	{models.AisleSpices, []string{
+		"salt", "pepper", "black pepper", "cumin", "paprika", "smoked paprika", "cinnamon", "nutmeg", "turmeric",
+		"chili powder", "chili flake", "red pepper flake", "garlic powder", "onion powder", "cayenne",
+		"oregano", "dried basil", "dried thyme", "dried oregano", "bay leaf", "clove", "coriander", "cardamom",
+		"allspice", "curry powder", "garam masala", "vanilla", "vanilla extract", "seasoning", "spice",
+	}},
+	{models.AislePantry, []string{
+		"flour", "bread flour", "sugar", "brown sugar", "rice", "pasta", "spaghetti", "penne", "noodle", "oat",
+		"oil", "olive oil", "vinegar", "soy sauce", "sauce", "broth", "stock", "chicken broth", "chicken stock",
+		"beef broth", "vegetable broth", "canned", "crushed tomato", "tomato paste", "tomato sauce", "bean",
+		"lentil", "chickpea", "coconut milk", "peanut butter", "honey", "maple syrup", "baking soda",
+		"baking powder", "yeast", "cornstarch", "breadcrumb", "panko", "quinoa", "couscous", "nut", "almond",
+		"walnut", "pecan", "cashew", "mustard", "ketchup", "mayonnaise", "chocolate", "cocoa",
+	}},
+	{models.AisleBakery, []string{"bread", "bun", "roll", "tortilla", "pita", "baguette", "naan", "croissant"}},
+	{models.AisleFrozen, []string{"frozen", "ice cream", "frozen pea"}},
+	{models.AisleDairyEggs, []string{
+		"milk", "butter", "cheese", "parmesan", "mozzarella", "cheddar", "feta", "ricotta", "cream", "heavy cream",
+		"sour cream", "cream cheese", "yogurt", "egg", "ghee",
+	}},
+	{models.AisleMeatSeafood, []string{
+		"beef", "ground beef", "veal", "pork", "chicken", "chicken breast", "chicken thigh", "turkey", "lamb",
+		"bacon", "sausage", "ham", "prosciutto", "pancetta", "fish", "salmon", "tuna", "cod", "shrimp", "prawn",
+		"scallop", "mussel", "clam", "crab", "lobster", "steak", "tofu",
+	}},
+	{models.AisleProduce, []string{
+		"onion", "garlic", "tomato", "potato", "sweet potato", "carrot", "celery", "bell pepper", "jalapeno",
+		"lettuce", "spinach", "kale", "cabbage", "broccoli", "cauliflower", "zucchini", "eggplant", "cucumber",
+		"mushroom", "lemon", "lime", "orange", "apple", "banana", "berry", "avocado", "ginger", "shallot",
+		"scallion", "green onion", "leek", "basil", "parsley", "cilantro", "mint", "thyme", "rosemary", "dill",
+		"chive", "corn", "pea", "green bean", "asparagus", "squash", "pumpkin",
+	}},
+	{models.AisleBeverages, []string{"wine", "red wine", "white wine", "beer", "juice", "coffee", "tea", "water"}},
+}
+
+var (
+	parenthetical = regexp.MustCompile(`\([^)]*\)`)
+	nonWord       = regexp.MustCompile(`[^a-z0-9\s-]+`)
+	preparation   = map[string]bool{
+		"chopped": true, "diced": true, "minced": true, "sliced": true, "grated": true, "shredded": true,
+		"fresh": true, "freshly": true, "finely": true, "roughly": true, "thinly": true, "large": true,
+		"small": true, "medium": true, "peeled": true, "whole": true, "boneless": true,
+		"skinless": true, "cubed": true, "halved": true, "quartered": true, "softened": true, "melted": true,
+	}
+)
+
+type shoppingListKey struct {
+	name      string
+	dimension units.Dimension
+}
+
+type shoppingListEntry struct {
+	item     models.ShoppingListItem
+	base     float64
+	metric   bool
+	position int
+}
+
+// BuildShoppingList merges the ingredients of every recipe in the plan.
+// Ingredients are matched by normalized name and quantities in compatible
+// units are added up. An ingredient measured both by volume and by weight is
+// listed once per kind of measurement. Quantities are shown in the given
+// system, or when it is empty in metric only if every recipe measured the
+// ingredient in metric units.
+func BuildShoppingList(plan *models.MealPlan, system models.UnitSystem) *models.ShoppingList {
+	entries := map[shoppingListKey]*shoppingListEntry{}
+	for _, recipe := range plan.Recipes {
+		for _, ingredient := range recipe.Ingredients {
+			name := NormalizeIngredientName(ingredient.Name)
+			if name == "" {
+				continue
+			}
+			base, dim := units.ToBase(ingredient.Quantity, ingredient.Unit)
+			metric := units.SystemOf(ingredient.Unit) == models.UnitSystemMetric
+			key := shoppingListKey{name: name, dimension: dim}
+			entry, ok := entries[key]
+			if !ok {
+				entry = &shoppingListEntry{
+					item: models.ShoppingListItem{
+						Name:    name,
+						Recipes: []string{},
+					},
+					metric:   metric,
+					position: len(entries),
+				}
+				entries[key] = entry
+			}
+			entry.base += base
+			entry.metric = entry.metric && metric
+			if !slices.Contains(entry.item.Recipes, recipe.Title) {
+				entry.item.Recipes = append(entry.item.Recipes, recipe.Title)
+			}
+		}
+	}
+
+	sorted := make([]*shoppingListEntry, 0, len(entries))
+	for key, entry := range entries {
+		display := system
+		if display == "" {
+			display = models.UnitSystemImperial
+			if entry.metric {
+				display = models.UnitSystemMetric
+			}
+		}
+		entry.item.Quantity, entry.item.Unit = units.Best(entry.base, key.dimension, display)
+		sorted = append(sorted, entry)
+	}
+	slices.SortFunc(sorted, func(a, b *shoppingListEntry) int {
+		if c := strings.Compare(a.item.Name, b.item.Name); c != 0 {
+			return c
+		}
+		return a.position - b.position
+	})
+
+	byAisle := map[models.Aisle][]models.ShoppingListItem{}
+	for _, entry := range sorted {
+		aisle := AisleFor(entry.item.Name)
+		byAisle[aisle] = append(byAisle[aisle], entry.item)
+	}
+	list := &models.ShoppingList{
+		PlanID:     plan.ID,
+		Categories: []models.ShoppingListCategory{},
+	}
+	for _, aisle := range aisleOrder {
+		if items, ok := byAisle[aisle]; ok {
+			list.Categories = append(list.Categories, models.ShoppingListCategory{
+				Aisle: aisle,
+				Items: items,
+			})
+		}
+	}
+	return list
+}
+
+// NormalizeIngredientName reduces an ingredient name to a form that matches
+// other spellings of the same ingredient, e.g. "Onions, finely diced" and
+// "onion" both become "onion". Anything after a comma is treated as
+// preparation notes unless nothing is left before it.
+func NormalizeIngredientName(name string) string {
+	name = strings.ToLower(name)
+	name = parenthetical.ReplaceAllString(name, " ")
+	for _, part := range strings.Split(name, ",") {
+		part = nonWord.ReplaceAllString(part, " ")
+		words := []string{}
+		for _, word := range strings.Fields(part) {
+			if preparation[word] {
+				continue
+			}
+			words = append(words, word)
+		}
+		if len(words) > 0 {
+			words[len(words)-1] = units.Singular(words[len(words)-1])
+			return strings.Join(words, " ")
+		}
+	}
+	return ""
+}
+
+// AisleFor guesses the store aisle of a normalized ingredient name.
+func AisleFor(name string) models.Aisle {
+	padded := " " + name + " "
+	best := models.AisleOther
+	bestLen := 0
+	for _, group := range aisleKeywords {
+		for _, keyword := range group.keywords {
+			if len(keyword) > bestLen && strings.Contains(padded, " "+keyword+" ") {
+				best = group.aisle
+				bestLen = len(keyword)
+			}
+		}
+	}
+	return best
+}
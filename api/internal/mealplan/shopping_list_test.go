@@ -0,0 +1,164 @@
+package mealplan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func planWith(recipes ...models.RecipeBody) *models.MealPlan {
+	plan := &models.MealPlan{ID: "plan_1"}
+	for _, body := range recipes {
+		plan.Recipes = append(plan.Recipes, &models.MealPlanRecipe{RecipeBody: body})
+	}
+	return plan
+}
+
+func findItem(list *models.ShoppingList, name string) (models.Aisle, *models.ShoppingListItem) {
+	for _, category := range list.Categories {
+		for i := range category.Items {
+			if category.Items[i].Name == name {
+				return category.Aisle, &category.Items[i]
+			}
+		}
+	}
+	return "", nil
+}
+
+func TestBuildShoppingListMergesUnits(t *testing.T) {
+	plan := planWith(
+		models.RecipeBody{
+			Title: "Bolognese",
+			Ingredients: []models.Ingredient{
+				{Name: "Olive Oil", Quantity: 1, Unit: models.MeasurementUnitTablespoon},
+				{Name: "Ground Beef", Quantity: 8, Unit: models.MeasurementUnitOunce},
+				{Name: "Onions, finely diced", Quantity: 1, Unit: models.MeasurementUnitCount},
+				{Name: "Parmesan", Quantity: 50, Unit: models.MeasurementUnitGram},
+			},
+		},
+		models.RecipeBody{
+			Title: "Burgers",
+			Ingredients: []models.Ingredient{
+				{Name: "olive oil", Quantity: 3, Unit: models.MeasurementUnitTeaspoon},
+				{Name: "ground beef", Quantity: 1, Unit: models.MeasurementUnitPound},
+				{Name: "Onion", Quantity: 2, Unit: models.MeasurementUnitCount},
+				{Name: "Parmesan", Quantity: 25, Unit: models.MeasurementUnitGram},
+			},
+		},
+	)
+
+	list := BuildShoppingList(plan, "")
+	if list.PlanID != "plan_1" {
+		t.Fatalf("expected plan id %s, got %s", "plan_1", list.PlanID)
+	}
+
+	tests := []struct {
+		name     string
+		aisle    models.Aisle
+		quantity float64
+		unit     models.MeasurementUnit
+	}{
+		{"olive oil", models.AislePantry, 2, models.MeasurementUnitTablespoon},
+		{"ground beef", models.AisleMeatSeafood, 1.5, models.MeasurementUnitPound},
+		{"onion", models.AisleProduce, 3, models.MeasurementUnitCount},
+		{"parmesan", models.AisleDairyEggs, 75, models.MeasurementUnitGram},
+	}
+	for _, tt := range tests {
+		aisle, item := findItem(list, tt.name)
+		if item == nil {
+			t.Errorf("expected %s on the shopping list", tt.name)
+			continue
+		}
+		if aisle != tt.aisle {
+			t.Errorf("%s: expected aisle %s, got %s", tt.name, tt.aisle, aisle)
+		}
+		if item.Quantity != tt.quantity || item.Unit != tt.unit {
+			t.Errorf("%s: expected %v %s, got %v %s", tt.name, tt.quantity, tt.unit, item.Quantity, item.Unit)
+		}
+		if !reflect.DeepEqual(item.Recipes, []string{"Bolognese", "Burgers"}) {
+			t.Errorf("%s: unexpected recipes %v", tt.name, item.Recipes)
+		}
+	}
+}
+
+func TestBuildShoppingListKeepsIncompatibleUnitsApart(t *testing.T) {
+	plan := planWith(models.RecipeBody{
+		Title: "Cake",
+		Ingredients: []models.Ingredient{
+			{Name: "Flour", Quantity: 2, Unit: models.MeasurementUnitCup},
+			{Name: "Flour", Quantity: 100, Unit: models.MeasurementUnitGram},
+		},
+	})
+
+	list := BuildShoppingList(plan, "")
+	if len(list.Categories) != 1 || len(list.Categories[0].Items) != 2 {
+		t.Fatalf("expected two flour entries, got %+v", list.Categories)
+	}
+	items := list.Categories[0].Items
+	if items[0].Unit != models.MeasurementUnitCup || items[1].Unit != models.MeasurementUnitGram {
+		t.Fatalf("unexpected units %s and %s", items[0].Unit, items[1].Unit)
+	}
+}
+
+func TestBuildShoppingListAisleOrder(t *testing.T) {
+	plan := planWith(models.RecipeBody{
+		Title: "Everything",
+		Ingredients: []models.Ingredient{
+			{Name: "Red Wine", Quantity: 1, Unit: models.MeasurementUnitCup},
+			{Name: "Cumin", Quantity: 1, Unit: models.MeasurementUnitTeaspoon},
+			{Name: "Garlic", Quantity: 2, Unit: models.MeasurementUnitCount},
+			{Name: "Mystery Ingredient", Quantity: 1, Unit: models.MeasurementUnitCount},
+		},
+	})
+
+	list := BuildShoppingList(plan, "")
+	var aisles []models.Aisle
+	for _, category := range list.Categories {
+		aisles = append(aisles, category.Aisle)
+	}
+	expected := []models.Aisle{models.AisleProduce, models.AisleSpices, models.AisleBeverages, models.AisleOther}
+	if !reflect.DeepEqual(aisles, expected) {
+		t.Fatalf("expected aisles %v, got %v", expected, aisles)
+	}
+}
+
+func TestNormalizeIngredientName(t *testing.T) {
+	tests := map[string]string{
+		"Onions, finely diced":        "onion",
+		"2 Large Tomatoes":            "2 tomato",
+		"Garlic Cloves (minced)":      "garlic clove",
+		"Fresh Basil Leaves":          "basil leaf",
+		"Crushed Tomatoes":            "crushed tomato",
+		"Boneless, skinless chicken":  "chicken",
+		"Freshly Ground Black Pepper": "ground black pepper",
+		"Cherries":                    "cherry",
+		"Asparagus":                   "asparagus",
+		"Peas":                        "pea",
+	}
+	for input, expected := range tests {
+		if got := NormalizeIngredientName(input); got != expected {
+			t.Errorf("NormalizeIngredientName(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestAisleFor(t *testing.T) {
+	tests := map[string]models.Aisle{
+		"coconut milk":    models.AislePantry,
+		"milk":            models.AisleDairyEggs,
+		"garlic":          models.AisleProduce,
+		"garlic powder":   models.AisleSpices,
+		"green bean":      models.AisleProduce,
+		"black bean":      models.AislePantry,
+		"frozen pea":      models.AisleFrozen,
+		"chicken thigh":   models.AisleMeatSeafood,
+		"unsalted butter": models.AisleDairyEggs,
+		"unobtainium":     models.AisleOther,
+	}
+	for name, expected := range tests {
+		if got := AisleFor(name); got != expected {
+			t.Errorf("AisleFor(%q): expected %s, got %s", name, expected, got)
+		}
+	}
+}
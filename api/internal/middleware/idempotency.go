@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ajohnston1219/eatme/api/internal/api"
+	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotencyReplayedHeader is set on responses replayed for a retry
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+	MaxIdempotencyKeyLength   = 255
+	// IdempotencyKeyTTL is how long a key is remembered, after which it can
+	// be used again
+	IdempotencyKeyTTL = 24 * time.Hour
+)
+
+// Idempotency makes requests sent with an Idempotency-Key header safe to
+// retry: the first response for a key is stored and replayed to later
+// requests with the same key, so a retry doesn't repeat the work. Keys belong
+// to a user, so this has to run after AuthMiddleware.
+//
+// Server errors and conflicts are worth retrying, so their keys are released
+// rather than stored. That includes streamed responses that fail after they
+// started, which report the status they failed with through
+// api.FailureRecorder.
+func Idempotency(store db.Store) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > MaxIdempotencyKeyLength {
+				api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidIdempotencyKey)
+				return
+			}
+
+			ctx := r.Context()
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			hash := sha256.Sum256(body)
+			record := models.IdempotencyRecord{
+				UserID:      api.GetUserID(r),
+				Key:         key,
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				RequestHash: hex.EncodeToString(hash[:]),
+			}
+
+			existing, err := claimIdempotencyKey(r, store, record)
+			if err != nil {
+				logger.Logger(ctx).Error("failed to claim idempotency key", zap.Error(err))
+				api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+				return
+			}
+			if existing != nil {
+				switch {
+				case existing.Method != record.Method || existing.Path != record.Path || existing.RequestHash != record.RequestHash:
+					api.ErrorJSON(w, http.StatusUnprocessableEntity, models.ApiErrIdempotencyKeyReused)
+				case existing.Status == 0:
+					api.ErrorJSON(w, http.StatusConflict, models.ApiErrIdempotencyKeyInUse)
+				default:
+					if existing.ContentType != "" {
+						w.Header().Set("Content-Type", existing.ContentType)
+					}
+					w.Header().Set(IdempotencyReplayedHeader, "true")
+					w.WriteHeader(existing.Status)
+					w.Write(existing.Body)
+				}
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			status := rec.status
+			if rec.failedStatus != 0 {
+				status = rec.failedStatus
+			}
+			if status >= http.StatusInternalServerError || status == http.StatusConflict {
+				if err := store.DeleteIdempotencyRecord(ctx, record.UserID, record.Key); err != nil {
+					logger.Logger(ctx).Error("failed to release idempotency key", zap.Error(err))
+				}
+				return
+			}
+			record.Status = rec.status
+			record.ContentType = rec.Header().Get("Content-Type")
+			record.Body = rec.body.Bytes()
+			if err := store.CompleteIdempotencyRecord(ctx, record); err != nil {
+				logger.Logger(ctx).Error("failed to save idempotent response", zap.Error(err))
+			}
+		})
+	}
+}
+
+// claimIdempotencyKey records that a request is being handled for the key. If
+// the key has been used already, the earlier record is returned instead.
+func claimIdempotencyKey(r *http.Request, store db.Store, record models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	ctx := r.Context()
+	err := store.CreateIdempotencyRecord(ctx, record)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, db.ErrIdempotencyKeyExists) {
+		return nil, err
+	}
+
+	existing, err := store.GetIdempotencyRecord(ctx, record.UserID, record.Key)
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(existing.CreatedAt) < IdempotencyKeyTTL {
+		return &existing, nil
+	}
+	// The key has expired, so this is a new request
+	if err := store.DeleteIdempotencyRecord(ctx, record.UserID, record.Key); err != nil {
+		return nil, err
+	}
+	if err := store.CreateIdempotencyRecord(ctx, record); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// responseRecorder passes a response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	// failedStatus is the status a streamed response failed with
+	failedStatus int
+	body         bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(code int) {
+	rr.status = code
+	rr.ResponseWriter.WriteHeader(code)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
+
+func (rr *responseRecorder) RecordFailure(status int) {
+	rr.failedStatus = status
+}
+
+// Unwrap exposes the underlying writer so streamed responses can still be
+// flushed.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
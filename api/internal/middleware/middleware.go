@@ -2,32 +2,39 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/ajohnston1219/eatme/api/internal/api"
-	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/auth"
 	"github.com/ajohnston1219/eatme/api/internal/models"
 	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
 	"go.uber.org/zap"
 )
 
-func AuthMiddleware(store db.Store) func(next http.Handler) http.Handler {
+func AuthMiddleware(tokens *auth.TokenManager) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			token := r.Header.Get("Authorization")
-			if token == "" {
+			header := r.Header.Get("Authorization")
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok || token == "" {
 				api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
 				return
 			}
-			userID := strings.TrimPrefix(token, "Bearer ")
-			_, err := store.GetUser(r.Context(), userID)
+			claims, err := tokens.ParseAccessToken(token)
 			if err != nil {
-				zap.L().Debug("failed to get user", zap.Error(err))
-				api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+				zap.L().Debug("failed to parse access token", zap.Error(err))
+				switch {
+				case errors.Is(err, auth.ErrTokenExpired):
+					api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrTokenExpired)
+				default:
+					api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+				}
 				return
 			}
+			userID := claims.Subject
 			ctx := context.WithValue(r.Context(), api.UserIDKey{}, userID)
 			ctx = logger.SetLogger(ctx, zap.L().With(zap.String("user_id", userID)))
 			*r = *r.WithContext(ctx)
@@ -63,3 +70,9 @@ func (sr *statusRecorder) WriteHeader(code int) {
 	sr.status = code
 	sr.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap exposes the underlying writer so http.ResponseController can reach
+// optional interfaces like http.Flusher that streamed responses rely on.
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
@@ -40,17 +40,75 @@ func (e APIError) Error() string {
 var (
 	// Common
 	ApiErrUnauthorized = NewAPIError("UNAUTHORIZED", "You must be logged in")
+	ApiErrTokenExpired = NewAPIError("TOKEN_EXPIRED", "Access token has expired")
 	ApiErrBadRequest   = NewAPIError("BAD_REQUEST", "Invalid request")
 	ApiErrInternal     = NewAPIError("INTERNAL_SERVER_ERROR", "Internal server error")
+	// ApiErrValidationFailed is returned with the field that failed
+	// validation and why
+	ApiErrValidationFailed = NewAPIError("VALIDATION_FAILED", "Request is invalid")
+
+	// Units
+	ApiErrInvalidUnitSystem = NewAPIError("INVALID_UNIT_SYSTEM", "Unit system must be metric or imperial", WithField("unit_system"))
 
 	// User
 	ApiErrEmailExists     = NewAPIError("EMAIL_EXISTS", "Email already exists", WithField("email"))
 	ApiErrUserNotFound    = NewAPIError("USER_NOT_FOUND", "User not found")
 	ApiErrProfileNotFound = NewAPIError("PROFILE_NOT_FOUND", "Profile not found")
+	ApiErrInvalidRefresh  = NewAPIError("INVALID_REFRESH_TOKEN", "Refresh token is invalid or expired", WithField("refresh_token"))
 
 	// Recipe
-	ApiErrRecipeNotFound = NewAPIError("RECIPE_NOT_FOUND", "Recipe not found")
+	ApiErrRecipeNotFound        = NewAPIError("RECIPE_NOT_FOUND", "Recipe not found")
+	ApiErrInvalidServings       = NewAPIError("INVALID_SERVINGS", "Servings must be a positive number", WithField("servings"))
+	ApiErrRecipeNotScalable     = NewAPIError("RECIPE_NOT_SCALABLE", "Recipe has no servings to scale from")
+	ApiErrInvalidCursor         = NewAPIError("INVALID_CURSOR", "Cursor is invalid or expired", WithField("cursor"))
+	ApiErrInvalidLimit          = NewAPIError("INVALID_LIMIT", "Limit must be between 1 and 100", WithField("limit"))
+	ApiErrInvalidMaxTime        = NewAPIError("INVALID_MAX_TIME", "Max time must be a positive number of minutes", WithField("max_time"))
+	ApiErrInvalidFavorites      = NewAPIError("INVALID_FAVORITES", "Favorites must be true or false", WithField("favorites"))
+	ApiErrNothingToUndo         = NewAPIError("NOTHING_TO_UNDO", "The recipe has no earlier version to go back to")
+	ApiErrNothingToRedo         = NewAPIError("NOTHING_TO_REDO", "There is no undone change to redo")
+	ApiErrNoPendingModification = NewAPIError("NO_PENDING_MODIFICATION", "There is no recipe modification to accept")
+	ApiErrRecipeVersionNotFound = NewAPIError("RECIPE_VERSION_NOT_FOUND", "Recipe version not found")
+	ApiErrMissingDiffFrom       = NewAPIError("MISSING_DIFF_FROM", "From must be the ID of the version to compare from", WithField("from"))
+	ApiErrMissingMergeTheirs    = NewAPIError("MISSING_MERGE_THEIRS", "Theirs must be the ID of the version to merge", WithField("theirs_version_id"))
+	ApiErrNoCommonVersion       = NewAPIError("NO_COMMON_VERSION", "The versions were not made from a common version")
+	ApiErrUnknownMergeConflict  = NewAPIError("UNKNOWN_MERGE_CONFLICT", "A resolution does not match any conflict of the merge", WithField("resolutions"))
+	ApiErrInvalidMergeSide      = NewAPIError("INVALID_MERGE_SIDE", "Side must be base, ours or theirs", WithField("resolutions"))
+	ApiErrMergeConflict         = NewAPIError("MERGE_CONFLICT", "The merge has conflicts that must be resolved")
+	ApiErrInvalidPatch          = NewAPIError("INVALID_PATCH", "Patch could not be applied to the recipe")
+	ApiErrPatchTestFailed       = NewAPIError("PATCH_TEST_FAILED", "A test operation of the patch failed")
+	ApiErrInvalidTag            = NewAPIError("INVALID_TAG", "Tags must be between 1 and 50 characters", WithField("tags"))
+	ApiErrTooManyTags           = NewAPIError("TOO_MANY_TAGS", "A recipe can have at most 20 tags", WithField("tags"))
+	ApiErrInvalidImport         = NewAPIError("INVALID_IMPORT", "Either html or text is required, but not both", WithField("html"))
+	ApiErrNoRecipeFound         = NewAPIError("NO_RECIPE_FOUND", "No recipe was found to import")
+
+	// Collection
+	ApiErrCollectionNotFound       = NewAPIError("COLLECTION_NOT_FOUND", "Collection not found")
+	ApiErrCollectionRecipeNotFound = NewAPIError("COLLECTION_RECIPE_NOT_FOUND", "Recipe is not in the collection")
+	ApiErrInvalidCollectionName    = NewAPIError("INVALID_COLLECTION_NAME", "Collection name is required", WithField("name"))
+
+	// Catalog
+	ApiErrCatalogRecipeNotFound = NewAPIError("CATALOG_RECIPE_NOT_FOUND", "Catalog recipe not found")
+
+	// Meal Plan
+	ApiErrMealPlanNotFound       = NewAPIError("MEAL_PLAN_NOT_FOUND", "Meal plan not found")
+	ApiErrMealPlanRecipeNotFound = NewAPIError("MEAL_PLAN_RECIPE_NOT_FOUND", "Meal plan recipe not found")
+	ApiErrInvalidMealPlanName    = NewAPIError("INVALID_MEAL_PLAN_NAME", "Meal plan name is required", WithField("name"))
+	ApiErrInvalidMealPlanDay     = NewAPIError("INVALID_MEAL_PLAN_DAY", "Day must not be negative", WithField("day"))
 
 	// Thread
-	ApiErrThreadNotFound = NewAPIError("THREAD_NOT_FOUND", "Thread not found")
+	ApiErrThreadNotFound        = NewAPIError("THREAD_NOT_FOUND", "Thread not found")
+	ApiErrThreadVersionConflict = NewAPIError("THREAD_VERSION_CONFLICT", "The thread was changed by another request, reload it and try again")
+	ApiErrInvalidThreadType     = NewAPIError("INVALID_THREAD_TYPE", "Type must be a known thread type", WithField("type"))
+	ApiErrInvalidThreadAt       = NewAPIError("INVALID_THREAD_AT", "At must be a version of the thread or an RFC 3339 timestamp", WithField("at"))
+
+	// Idempotency
+	ApiErrInvalidIdempotencyKey = NewAPIError("INVALID_IDEMPOTENCY_KEY", "Idempotency-Key must be at most 255 characters", WithField("Idempotency-Key"))
+	ApiErrIdempotencyKeyReused  = NewAPIError("IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used for a different request", WithField("Idempotency-Key"))
+	ApiErrIdempotencyKeyInUse   = NewAPIError("IDEMPOTENCY_KEY_IN_USE", "A request with this Idempotency-Key is still being processed", WithField("Idempotency-Key"))
+
+	// Chat
+	ApiErrChatUnavailable = NewAPIError("CHAT_UNAVAILABLE", "The recipe assistant is unavailable, please try again later")
+	ApiErrChatTimeout     = NewAPIError("CHAT_TIMEOUT", "The recipe assistant took too long to respond")
+	ApiErrChatRejected    = NewAPIError("CHAT_REJECTED", "The recipe assistant could not handle this request")
+	ApiErrChatFailed      = NewAPIError("CHAT_FAILED", "The recipe assistant failed to respond")
 )
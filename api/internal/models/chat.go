@@ -67,3 +67,8 @@ type InternalGeneralChatRequest struct {
 type GeneralChatResponse struct {
 	ResponseText string `json:"response_text" binding:"required"`
 }
+
+// @Description StreamToken is a chunk of response text pushed while a chat response is being generated
+type StreamToken struct {
+	Text string `json:"text" binding:"required"`
+}
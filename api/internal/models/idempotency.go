@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// IdempotencyRecord is the response to a request sent with an
+// Idempotency-Key, kept so that a retry gets the same response instead of
+// running the request again.
+type IdempotencyRecord struct {
+	UserID string
+	Key    string
+	// Method, Path and RequestHash identify the request the key was first
+	// used for
+	Method      string
+	Path        string
+	RequestHash string
+	// Status is zero while the first request is still being handled
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+}
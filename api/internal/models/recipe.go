@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 )
 
@@ -10,15 +11,28 @@ type MeasurementUnit string
 
 const (
 	MeasurementUnitGram       MeasurementUnit = "g"
+	MeasurementUnitKilogram   MeasurementUnit = "kg"
 	MeasurementUnitMilliliter MeasurementUnit = "ml"
+	MeasurementUnitLiter      MeasurementUnit = "l"
 	MeasurementUnitTeaspoon   MeasurementUnit = "tsp"
 	MeasurementUnitTablespoon MeasurementUnit = "tbsp"
+	MeasurementUnitFluidOunce MeasurementUnit = "fl oz"
 	MeasurementUnitCup        MeasurementUnit = "cup"
+	MeasurementUnitPint       MeasurementUnit = "pint"
+	MeasurementUnitQuart      MeasurementUnit = "quart"
+	MeasurementUnitGallon     MeasurementUnit = "gallon"
 	MeasurementUnitOunce      MeasurementUnit = "oz"
 	MeasurementUnitPound      MeasurementUnit = "lb"
 	MeasurementUnitCount      MeasurementUnit = "count"
 )
 
+type UnitSystem string
+
+const (
+	UnitSystemMetric   UnitSystem = "metric"
+	UnitSystemImperial UnitSystem = "imperial"
+)
+
 // @Description Ingredient represents an ingredient in a recipe
 type Ingredient struct {
 	Name     string          `json:"name" example:"Flour" binding:"required"`
@@ -48,6 +62,23 @@ func (s *Steps) Scan(value interface{}) error {
 	return json.Unmarshal(b, s)
 }
 
+// Tags are free-form labels on a recipe, lowercase and sorted.
+type Tags []string
+
+func (t *Tags) Scan(value interface{}) error {
+	var err error
+	switch v := value.(type) {
+	case []byte:
+		err = json.Unmarshal(v, t)
+	case string:
+		err = json.Unmarshal([]byte(v), t)
+	default:
+		return fmt.Errorf("failed to scan tags: expected []byte or string, got %T", value)
+	}
+	slices.Sort(*t)
+	return err
+}
+
 // @Description RecipeBody represents the contents of a recipe
 type RecipeBody struct {
 	Title            string      `json:"title" example:"Veal Bolognese" binding:"required"`
@@ -59,6 +90,14 @@ type RecipeBody struct {
 	ImageURL         string      `json:"image_url,omitempty"`
 }
 
+// @Description PatchOperation is one operation of a JSON Patch (RFC 6902) on a recipe. Paths point into the recipe's JSON, e.g. /ingredients/0/quantity or /steps/- to append a step.
+type PatchOperation struct {
+	Op    string          `json:"op" example:"replace" binding:"required" enums:"add,remove,replace,move,copy,test"`
+	Path  string          `json:"path" example:"/ingredients/0/quantity" binding:"required"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+}
+
 type RecipeSource string
 
 const (
@@ -70,24 +109,59 @@ const (
 type GlobalRecipe struct {
 	ID         string       `json:"id" binding:"required"`
 	SourceType RecipeSource `json:"source_type" binding:"required"`
-	CreatedAt  time.Time    `json:"created_at" binding:"required"`
-	UpdatedAt  time.Time    `json:"updated_at" binding:"required"`
+	// Revision counts the times the recipe has been saved, starting at 1
+	Revision  int       `json:"revision" example:"1" binding:"required"`
+	CreatedAt time.Time `json:"created_at" binding:"required"`
+	UpdatedAt time.Time `json:"updated_at" binding:"required"`
 	RecipeBody
 }
 
 // @Description UserRecipe is the user's personal copy (favorites, edits).
 type UserRecipe struct {
-	ID              string    `json:"id" binding:"required"`
-	UserID          string    `json:"user_id" binding:"required"`
-	GlobalRecipeID  *string   `json:"global_recipe_id,omitempty"`
+	ID             string  `json:"id" binding:"required"`
+	UserID         string  `json:"user_id" binding:"required"`
+	GlobalRecipeID *string `json:"global_recipe_id,omitempty"`
+	// GlobalRecipeRevision is the revision of the global recipe this recipe
+	// was forked from
+	GlobalRecipeRevision *int `json:"global_recipe_revision,omitempty"`
+	// UpdateAvailable is set when the global recipe has changed since it was
+	// forked
+	UpdateAvailable bool      `json:"update_available" binding:"required"`
 	ThreadID        string    `json:"thread_id" binding:"required"`
 	IsFavorite      bool      `json:"is_favorite" binding:"required"`
+	Tags            Tags      `json:"tags" binding:"required"`
 	LatestVersionID string    `json:"latest_version_id" binding:"required"`
 	CreatedAt       time.Time `json:"created_at" binding:"required"`
 	UpdatedAt       time.Time `json:"updated_at" binding:"required"`
 	RecipeBody
 }
 
+// @Description SetRecipeTagsRequest represents a request to replace the tags of a recipe
+type SetRecipeTagsRequest struct {
+	// Tags are trimmed and lowercased, duplicates are dropped
+	Tags []string `json:"tags" example:"weeknight,vegetarian" binding:"required"`
+}
+
+// @Description RecipeTag is a tag and how many of the user's recipes have it
+type RecipeTag struct {
+	Tag   string `json:"tag" example:"weeknight" binding:"required"`
+	Count int    `json:"count" example:"3" binding:"required"`
+}
+
+// @Description ScaleRecipeRequest represents a request to rescale a recipe to a number of servings
+type ScaleRecipeRequest struct {
+	Servings int `json:"servings" example:"8" binding:"required"`
+}
+
+// @Description ImportRecipeRequest represents a recipe to import, either a saved recipe page or a recipe written out as text. Exactly one of html and text is required.
+type ImportRecipeRequest struct {
+	// HTML is a recipe page with schema.org Recipe JSON-LD or microdata
+	HTML string `json:"html,omitempty"`
+	// Text is a recipe with a title on its first line and Ingredients and
+	// Instructions headings
+	Text string `json:"text,omitempty" example:"Pancakes\nIngredients\n1 1/2 cups flour, sifted\nInstructions\nMix and fry."`
+}
+
 // @Description RecipeVersion is an immutable snapshot used inside meal plans.
 type RecipeVersion struct {
 	ID           string    `json:"id" binding:"required"`
@@ -98,6 +172,16 @@ type RecipeVersion struct {
 	RecipeBody
 }
 
+// @Description RecipeVersionSummary is one version in a recipe's version tree
+type RecipeVersionSummary struct {
+	ID        string    `json:"id" binding:"required"`
+	ParentID  *string   `json:"parent_id,omitempty"`
+	Title     string    `json:"title" binding:"required"`
+	Notes     *string   `json:"notes,omitempty"`
+	IsLatest  bool      `json:"is_latest" binding:"required"`
+	CreatedAt time.Time `json:"created_at" binding:"required"`
+}
+
 // @Description ModifiedIngredient represents a modification to an ingredient
 type ModifiedIngredient struct {
 	Index    int             `json:"index" binding:"required"`
@@ -117,7 +201,62 @@ type DiffStep struct {
 	IsNew bool `json:"is_new" binding:"required"`
 }
 
-// @Description RecipeDiff represents the difference between two recipe versions
+type IngredientChangeType string
+
+const (
+	IngredientChangeAdded           IngredientChangeType = "added"
+	IngredientChangeRemoved         IngredientChangeType = "removed"
+	IngredientChangeRenamed         IngredientChangeType = "renamed"
+	IngredientChangeMoved           IngredientChangeType = "moved"
+	IngredientChangeQuantityChanged IngredientChangeType = "quantity_changed"
+	IngredientChangeUnitChanged     IngredientChangeType = "unit_changed"
+	IngredientChangeAmountChanged   IngredientChangeType = "amount_changed"
+)
+
+// @Description IngredientChange describes how one ingredient changed. Moved is set when the ingredient also changed position; a type of moved means nothing else changed.
+type IngredientChange struct {
+	Type      IngredientChangeType `json:"type" binding:"required" enums:"added,removed,renamed,moved,quantity_changed,unit_changed,amount_changed"`
+	FromIndex *int                 `json:"from_index,omitempty"`
+	ToIndex   *int                 `json:"to_index,omitempty"`
+	Moved     bool                 `json:"moved" binding:"required"`
+	Before    *Ingredient          `json:"before,omitempty"`
+	After     *Ingredient          `json:"after,omitempty"`
+}
+
+type StepChangeType string
+
+const (
+	StepChangeAdded   StepChangeType = "added"
+	StepChangeRemoved StepChangeType = "removed"
+	StepChangeEdited  StepChangeType = "edited"
+	StepChangeMoved   StepChangeType = "moved"
+)
+
+type WordChangeType string
+
+const (
+	WordChangeEqual   WordChangeType = "equal"
+	WordChangeAdded   WordChangeType = "added"
+	WordChangeRemoved WordChangeType = "removed"
+)
+
+// @Description WordChange is a run of words that were kept, added or removed in an edited step
+type WordChange struct {
+	Type WordChangeType `json:"type" binding:"required" enums:"equal,added,removed"`
+	Text string         `json:"text" binding:"required"`
+}
+
+// @Description StepChange describes how one step changed. Edited steps have a word by word diff.
+type StepChange struct {
+	Type      StepChangeType `json:"type" binding:"required" enums:"added,removed,edited,moved"`
+	FromIndex *int           `json:"from_index,omitempty"`
+	ToIndex   *int           `json:"to_index,omitempty"`
+	Before    *Step          `json:"before,omitempty"`
+	After     *Step          `json:"after,omitempty"`
+	Words     []WordChange   `json:"words,omitempty"`
+}
+
+// @Description RecipeDiff represents the difference between two recipe versions. IngredientChanges and StepChanges list every change in the order of the proposed recipe, with removals where they used to be.
 type RecipeDiff struct {
 	NewTitle            *string              `json:"new_title,omitempty"`
 	NewDescription      *string              `json:"new_description,omitempty"`
@@ -128,6 +267,64 @@ type RecipeDiff struct {
 	RemovedIngredients  []RemovedIngredient  `json:"removed_ingredients" binding:"required"`
 	NewSteps            []DiffStep           `json:"new_steps" binding:"required"`
 	NewImageURL         *string              `json:"new_image_url,omitempty"`
+	IngredientChanges   []IngredientChange   `json:"ingredient_changes" binding:"required"`
+	StepChanges         []StepChange         `json:"step_changes" binding:"required"`
+}
+
+type MergeSide string
+
+const (
+	MergeSideBase   MergeSide = "base"
+	MergeSideOurs   MergeSide = "ours"
+	MergeSideTheirs MergeSide = "theirs"
+)
+
+type MergeConflictType string
+
+const (
+	MergeConflictField      MergeConflictType = "field"
+	MergeConflictIngredient MergeConflictType = "ingredient"
+	MergeConflictSteps      MergeConflictType = "steps"
+)
+
+// @Description MergeValue is one side of a merge conflict. Field conflicts set Text or Number, ingredient conflicts set Ingredient and step conflicts set Steps; an empty value means the side removed it.
+type MergeValue struct {
+	Text       *string     `json:"text,omitempty"`
+	Number     *int        `json:"number,omitempty"`
+	Ingredient *Ingredient `json:"ingredient,omitempty"`
+	Steps      []Step      `json:"steps,omitempty"`
+}
+
+// @Description MergeConflict is a part of the recipe both sides of a merge changed differently
+type MergeConflict struct {
+	ID     string            `json:"id" example:"field:title" binding:"required"`
+	Type   MergeConflictType `json:"type" binding:"required" enums:"field,ingredient,steps"`
+	Field  string            `json:"field,omitempty" example:"title"`
+	Base   MergeValue        `json:"base" binding:"required"`
+	Ours   MergeValue        `json:"ours" binding:"required"`
+	Theirs MergeValue        `json:"theirs" binding:"required"`
+}
+
+// @Description RecipeMerge is the result of merging two versions of a recipe. Recipe takes our side of every conflict.
+type RecipeMerge struct {
+	BaseVersionID   string          `json:"base_version_id" binding:"required"`
+	OursVersionID   string          `json:"ours_version_id" binding:"required"`
+	TheirsVersionID string          `json:"theirs_version_id" binding:"required"`
+	Recipe          RecipeBody      `json:"recipe" binding:"required"`
+	Conflicts       []MergeConflict `json:"conflicts" binding:"required"`
+}
+
+// @Description MergeResolution picks the side of a merge conflict to keep
+type MergeResolution struct {
+	ConflictID string    `json:"conflict_id" example:"field:title" binding:"required"`
+	Side       MergeSide `json:"side" binding:"required" enums:"base,ours,theirs"`
+}
+
+// @Description MergeRecipeVersionsRequest represents a request to merge a version into the recipe
+type MergeRecipeVersionsRequest struct {
+	OursVersionID   string            `json:"ours_version_id,omitempty"`
+	TheirsVersionID string            `json:"theirs_version_id" binding:"required"`
+	Resolutions     []MergeResolution `json:"resolutions"`
 }
 
 // @Description ModifyRecipeResponse represents the response to a recipe modification
@@ -137,10 +334,14 @@ type ModifyRecipeResponse struct {
 	ResponseText  string     `json:"response_text" binding:"required"`
 }
 
-// @Description MealPlanRecipe is a recipe in a meal plan
+// @Description MealPlanRecipe is a recipe in a meal plan, pinned to the recipe version it was added with
 type MealPlanRecipe struct {
-	PlanID string `json:"plan_id" binding:"required"`
-	Day    int    `json:"day,omitempty"`
+	ID              string `json:"id" binding:"required"`
+	PlanID          string `json:"plan_id" binding:"required"`
+	RecipeID        string `json:"recipe_id" binding:"required"`
+	RecipeVersionID string `json:"recipe_version_id" binding:"required"`
+	Day             int    `json:"day,omitempty"`
+	Position        int    `json:"position" binding:"required"`
 	RecipeBody
 }
 
@@ -151,3 +352,96 @@ type MealPlan struct {
 	Name    string            `json:"name" example:"My Meal Plan" binding:"required"`
 	Recipes []*MealPlanRecipe `json:"recipes" binding:"required"`
 }
+
+// @Description CreateMealPlanRequest represents a request to create a meal plan
+type CreateMealPlanRequest struct {
+	Name string `json:"name" example:"My Meal Plan" binding:"required"`
+}
+
+// @Description UpdateMealPlanRequest represents a request to update a meal plan
+type UpdateMealPlanRequest struct {
+	Name string `json:"name" example:"My Meal Plan" binding:"required"`
+}
+
+// @Description AddMealPlanRecipeRequest represents a request to add a recipe to a meal plan
+type AddMealPlanRecipeRequest struct {
+	// Recipe to add, the entry is pinned to its current version
+	RecipeID string `json:"recipe_id" binding:"required"`
+	// Day of the plan, 0 leaves the recipe unscheduled
+	Day int `json:"day,omitempty" example:"1"`
+}
+
+// @Description MoveMealPlanRecipeRequest represents a request to move a recipe within a meal plan
+type MoveMealPlanRecipeRequest struct {
+	// Day to move the recipe to, 0 leaves the recipe unscheduled
+	Day int `json:"day" example:"2"`
+	// Position within the day, positions past the end of the day append the recipe
+	Position int `json:"position" example:"0"`
+}
+
+// @Description Collection is a user-defined, ordered group of recipes
+type Collection struct {
+	ID     string `json:"id" example:"12345678-1234-1234-1234-123456789012" binding:"required"`
+	UserID string `json:"user_id" example:"12345678-1234-1234-1234-123456789012" binding:"required"`
+	Name   string `json:"name" example:"Weeknight Dinners" binding:"required"`
+	// IDs of the recipes in the collection, in order
+	RecipeIDs []string  `json:"recipe_ids" binding:"required"`
+	CreatedAt time.Time `json:"created_at" binding:"required"`
+	UpdatedAt time.Time `json:"updated_at" binding:"required"`
+}
+
+// @Description CreateCollectionRequest represents a request to create a collection
+type CreateCollectionRequest struct {
+	Name string `json:"name" example:"Weeknight Dinners" binding:"required"`
+}
+
+// @Description UpdateCollectionRequest represents a request to rename a collection
+type UpdateCollectionRequest struct {
+	Name string `json:"name" example:"Weeknight Dinners" binding:"required"`
+}
+
+// @Description AddCollectionRecipeRequest represents a request to add a recipe to the end of a collection
+type AddCollectionRecipeRequest struct {
+	RecipeID string `json:"recipe_id" binding:"required"`
+}
+
+// @Description MoveCollectionRecipeRequest represents a request to move a recipe within a collection
+type MoveCollectionRecipeRequest struct {
+	// Position in the collection, positions past the end move the recipe to the end
+	Position int `json:"position" example:"0"`
+}
+
+type Aisle string
+
+const (
+	AisleProduce     Aisle = "produce"
+	AisleMeatSeafood Aisle = "meat_seafood"
+	AisleDairyEggs   Aisle = "dairy_eggs"
+	AisleBakery      Aisle = "bakery"
+	AislePantry      Aisle = "pantry"
+	AisleSpices      Aisle = "spices"
+	AisleFrozen      Aisle = "frozen"
+	AisleBeverages   Aisle = "beverages"
+	AisleOther       Aisle = "other"
+)
+
+// @Description ShoppingListItem is an ingredient merged across the recipes of a meal plan
+type ShoppingListItem struct {
+	Name     string          `json:"name" example:"onion" binding:"required"`
+	Quantity float64         `json:"quantity" example:"3" binding:"required"`
+	Unit     MeasurementUnit `json:"unit" example:"count" binding:"required"`
+	// Titles of the recipes that use the ingredient
+	Recipes []string `json:"recipes" binding:"required"`
+}
+
+// @Description ShoppingListCategory groups shopping list items by store aisle
+type ShoppingListCategory struct {
+	Aisle Aisle              `json:"aisle" example:"produce" binding:"required"`
+	Items []ShoppingListItem `json:"items" binding:"required"`
+}
+
+// @Description ShoppingList is the aggregated list of ingredients needed for a meal plan
+type ShoppingList struct {
+	PlanID     string                 `json:"plan_id" binding:"required"`
+	Categories []ShoppingListCategory `json:"categories" binding:"required"`
+}
@@ -9,6 +9,7 @@ type ThreadType string
 
 const (
 	ThreadTypeSuggestion ThreadType = "Suggestion"
+	ThreadTypeManual     ThreadType = "Manual"
 )
 
 // @Description Thread represents a thread of events that occurred as part of a suggestion thread
@@ -17,15 +18,45 @@ type Thread struct {
 	Type      ThreadType    `json:"type" binding:"required"`
 	RecipeID  *string       `json:"recipe_id"`
 	Events    []ThreadEvent `json:"events" binding:"required"`
+	Version   int           `json:"version" binding:"required"`
 	CreatedAt time.Time     `json:"created_at" binding:"required"`
 	UpdatedAt time.Time     `json:"updated_at" binding:"required"`
 }
 
+// @Description ThreadSummary describes a thread without its events
+type ThreadSummary struct {
+	ID        string     `json:"id" binding:"required"`
+	Type      ThreadType `json:"type" binding:"required"`
+	RecipeID  *string    `json:"recipe_id"`
+	Version   int        `json:"version" binding:"required"`
+	CreatedAt time.Time  `json:"created_at" binding:"required"`
+	UpdatedAt time.Time  `json:"updated_at" binding:"required"`
+}
+
+// ThreadSnapshot is a thread's state after its first Version events, taken
+// by version ReducerVersion of the reducer.
+type ThreadSnapshot struct {
+	ThreadID       string
+	Version        int
+	ReducerVersion int
+	State          ThreadState
+	CreatedAt      time.Time
+}
+
 // @Description ThreadEvent represents an event that occurred as part of a suggestion thread
 type ThreadEvent struct {
-	Type      ThreadEventType `json:"type" binding:"required"`
-	Payload   json.RawMessage `json:"payload" binding:"required"`
-	Timestamp time.Time       `json:"timestamp" binding:"required"`
+	Type ThreadEventType `json:"type" binding:"required"`
+	// Version of the payload's schema, events written before payloads were
+	// versioned are version 1
+	SchemaVersion int             `json:"schema_version" binding:"required" example:"1"`
+	Payload       json.RawMessage `json:"payload" binding:"required" swaggertype:"object"`
+	Timestamp     time.Time       `json:"timestamp" binding:"required"`
+}
+
+// ThreadEventPayload is the payload of a thread event. Each payload struct
+// belongs to exactly one event type.
+type ThreadEventPayload interface {
+	ThreadEventType() ThreadEventType
 }
 
 type ThreadEventType string
@@ -39,7 +70,12 @@ const (
 	ThreadEventTypeRecipeModified             ThreadEventType = "RecipeModified"
 	ThreadEventTypeRecipeModificationAccepted ThreadEventType = "RecipeModificationAccepted"
 	ThreadEventTypeRecipeModificationRejected ThreadEventType = "RecipeModificationRejected"
+	ThreadEventTypeRecipeModificationUndone   ThreadEventType = "RecipeModificationUndone"
+	ThreadEventTypeRecipeModificationRedone   ThreadEventType = "RecipeModificationRedone"
+	ThreadEventTypeRecipeCreated              ThreadEventType = "RecipeCreated"
+	ThreadEventTypeRecipeEdited               ThreadEventType = "RecipeEdited"
 	ThreadEventTypeQuestionAnswered           ThreadEventType = "QuestionAnswered"
+	ThreadEventTypeEventDiscarded             ThreadEventType = "EventDiscarded"
 )
 
 // @Description PromptSetEvent represents setting the inital prompt
@@ -47,11 +83,19 @@ type PromptSetEvent struct {
 	Prompt string `json:"prompt" binding:"required"`
 }
 
+func (PromptSetEvent) ThreadEventType() ThreadEventType {
+	return ThreadEventTypePromptSet
+}
+
 // @Description PromptEditedEvent represents editing the prompt
 type PromptEditedEvent struct {
 	Prompt string `json:"prompt" binding:"required"`
 }
 
+func (PromptEditedEvent) ThreadEventType() ThreadEventType {
+	return ThreadEventTypePromptEdited
+}
+
 // @Description SuggestionGeneratedEvent represents generating a new recipe suggestion
 type SuggestionGeneratedEvent struct {
 	SuggestionID string     `json:"suggestion_id" binding:"required"`
@@ -59,17 +103,29 @@ type SuggestionGeneratedEvent struct {
 	ResponseText string     `json:"response_text" binding:"required"`
 }
 
+func (SuggestionGeneratedEvent) ThreadEventType() ThreadEventType {
+	return ThreadEventTypeSuggestionGenerated
+}
+
 // @Description SuggestionAcceptedEvent represents accepting a recipe suggestion
 type SuggestionAcceptedEvent struct {
 	SuggestionID string `json:"suggestion_id" binding:"required"`
 	RecipeID     string `json:"recipe_id" binding:"required"`
 }
 
+func (SuggestionAcceptedEvent) ThreadEventType() ThreadEventType {
+	return ThreadEventTypeSuggestionAccepted
+}
+
 // @Description SuggestionRejectedEvent represents rejecting a recipe suggestion
 type SuggestionRejectedEvent struct {
 	SuggestionID string `json:"suggestion_id" binding:"required"`
 }
 
+func (SuggestionRejectedEvent) ThreadEventType() ThreadEventType {
+	return ThreadEventTypeSuggestionRejected
+}
+
 // @Description RecipeSuggestion represents a suggestion for a recipe
 type RecipeSuggestion struct {
 	ID           string     `json:"id" binding:"required"`
@@ -87,20 +143,95 @@ type RecipeModifiedEvent struct {
 	Recipe RecipeBody `json:"recipe" binding:"required"`
 }
 
+func (RecipeModifiedEvent) ThreadEventType() ThreadEventType {
+	return ThreadEventTypeRecipeModified
+}
+
 // @Description RecipeModificationAcceptedEvent represents accepting a recipe modification
 type RecipeModificationAcceptedEvent struct {
 }
 
+func (RecipeModificationAcceptedEvent) ThreadEventType() ThreadEventType {
+	return ThreadEventTypeRecipeModificationAccepted
+}
+
 // @Description RecipeModificationRejectedEvent represents rejecting a recipe modification
 type RecipeModificationRejectedEvent struct {
 }
 
+func (RecipeModificationRejectedEvent) ThreadEventType() ThreadEventType {
+	return ThreadEventTypeRecipeModificationRejected
+}
+
+// @Description RecipeModificationUndoneEvent represents moving a recipe back to the version its latest version was made from
+type RecipeModificationUndoneEvent struct {
+	FromVersionID string     `json:"from_version_id" binding:"required"`
+	ToVersionID   string     `json:"to_version_id" binding:"required"`
+	Recipe        RecipeBody `json:"recipe" binding:"required"`
+}
+
+func (RecipeModificationUndoneEvent) ThreadEventType() ThreadEventType {
+	return ThreadEventTypeRecipeModificationUndone
+}
+
+// @Description RecipeModificationRedoneEvent represents moving a recipe forward to a version that was undone
+type RecipeModificationRedoneEvent struct {
+	FromVersionID string     `json:"from_version_id" binding:"required"`
+	ToVersionID   string     `json:"to_version_id" binding:"required"`
+	Recipe        RecipeBody `json:"recipe" binding:"required"`
+}
+
+func (RecipeModificationRedoneEvent) ThreadEventType() ThreadEventType {
+	return ThreadEventTypeRecipeModificationRedone
+}
+
+// @Description RecipeCreatedEvent represents entering a recipe by hand
+type RecipeCreatedEvent struct {
+	RecipeID  string     `json:"recipe_id" binding:"required"`
+	VersionID string     `json:"version_id" binding:"required"`
+	Recipe    RecipeBody `json:"recipe" binding:"required"`
+}
+
+func (RecipeCreatedEvent) ThreadEventType() ThreadEventType {
+	return ThreadEventTypeRecipeCreated
+}
+
+// @Description RecipeEditedEvent represents editing a recipe directly rather than through chat
+type RecipeEditedEvent struct {
+	FromVersionID string     `json:"from_version_id" binding:"required"`
+	ToVersionID   string     `json:"to_version_id" binding:"required"`
+	Recipe        RecipeBody `json:"recipe" binding:"required"`
+}
+
+func (RecipeEditedEvent) ThreadEventType() ThreadEventType {
+	return ThreadEventTypeRecipeEdited
+}
+
 // @Description QuestionAnsweredEvent represents answering a question
 type QuestionAnsweredEvent struct {
 	Question string `json:"question" binding:"required"`
 	Answer   string `json:"answer" binding:"required"`
 }
 
+func (QuestionAnsweredEvent) ThreadEventType() ThreadEventType {
+	return ThreadEventTypeQuestionAnswered
+}
+
+// @Description EventDiscardedEvent replaces a stored event that could neither be decoded nor repaired. It keeps the original event as it was written and changes nothing when reduced.
+type EventDiscardedEvent struct {
+	OriginalType          ThreadEventType `json:"original_type" binding:"required"`
+	OriginalSchemaVersion int             `json:"original_schema_version" binding:"required"`
+	// OriginalPayload is the payload as it was stored, which may not be
+	// valid JSON
+	OriginalPayload string `json:"original_payload" binding:"required"`
+	// Reason is why the original event didn't decode
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (EventDiscardedEvent) ThreadEventType() ThreadEventType {
+	return ThreadEventTypeEventDiscarded
+}
+
 // @Description ChatMessage represents a message in the chat history
 type ChatMessage struct {
 	Source  string `json:"source" binding:"required"`
@@ -117,8 +248,21 @@ type ThreadState struct {
 	ChatHistory    []*ChatMessage      `json:"chat_history" binding:"required"`
 	CurrentRecipe  *RecipeBody         `json:"current_recipe"`
 	ModifiedRecipe *RecipeBody         `json:"modified_recipe"`
-	CreatedAt      time.Time           `json:"created_at" binding:"required"`
-	UpdatedAt      time.Time           `json:"updated_at" binding:"required"`
+	RedoVersionIDs []string            `json:"redo_version_ids" binding:"required"`
+	// SkippedEvents are the events left out of the state because they
+	// couldn't be decoded or applied
+	SkippedEvents []SkippedThreadEvent `json:"skipped_events,omitempty"`
+	Version       int                  `json:"version" binding:"required"`
+	CreatedAt     time.Time            `json:"created_at" binding:"required"`
+	UpdatedAt     time.Time            `json:"updated_at" binding:"required"`
+}
+
+// @Description SkippedThreadEvent is an event of the thread that was left out of its state
+type SkippedThreadEvent struct {
+	// Index is the event's position in the thread's event log
+	Index int             `json:"index" binding:"required"`
+	Type  ThreadEventType `json:"type" binding:"required"`
+	Error string          `json:"error" binding:"required"`
 }
 
 // @Description StartSuggestionThreadRequest represents a request to start a suggestion thread
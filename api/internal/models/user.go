@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // @Description User represents a user in the system
 type User struct {
 	// User's unique identifier
@@ -41,6 +43,10 @@ type SignupRequest struct {
 type SignupResponse struct {
 	// Access token for user
 	Token string `json:"token" example:"<JWT_TOKEN>" binding:"required"`
+	// Refresh token used to obtain a new access token
+	RefreshToken string `json:"refresh_token" example:"<REFRESH_TOKEN>" binding:"required"`
+	// Expiry of the access token
+	ExpiresAt time.Time `json:"expires_at" binding:"required"`
 }
 
 // @Description LoginRequest represents the user login request payload
@@ -55,6 +61,52 @@ type LoginRequest struct {
 type LoginResponse struct {
 	// Access token for user
 	Token string `json:"token" example:"<JWT_TOKEN>" binding:"required"`
+	// Refresh token used to obtain a new access token
+	RefreshToken string `json:"refresh_token" example:"<REFRESH_TOKEN>" binding:"required"`
+	// Expiry of the access token
+	ExpiresAt time.Time `json:"expires_at" binding:"required"`
+}
+
+// @Description RefreshTokenRequest represents the token refresh request payload
+type RefreshTokenRequest struct {
+	// Refresh token issued at login, signup or a previous refresh
+	RefreshToken string `json:"refresh_token" example:"<REFRESH_TOKEN>" binding:"required"`
+}
+
+// @Description RefreshTokenResponse represents the token refresh response
+type RefreshTokenResponse struct {
+	// Access token for user
+	Token string `json:"token" example:"<JWT_TOKEN>" binding:"required"`
+	// Rotated refresh token, the one used in the request is no longer valid
+	RefreshToken string `json:"refresh_token" example:"<REFRESH_TOKEN>" binding:"required"`
+	// Expiry of the access token
+	ExpiresAt time.Time `json:"expires_at" binding:"required"`
+}
+
+// @Description LogoutRequest represents the user logout request payload
+type LogoutRequest struct {
+	// Refresh token to revoke
+	RefreshToken string `json:"refresh_token" example:"<REFRESH_TOKEN>" binding:"required"`
+}
+
+// AuthTokens is the pair of tokens issued to a user when a session is created
+// or refreshed.
+type AuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+// RefreshToken is the persisted form of an issued refresh token. Only the hash
+// of the token is stored.
+type RefreshToken struct {
+	ID         string
+	UserID     string
+	TokenHash  string
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy *string
+	CreatedAt  time.Time
 }
 
 // @Description Profile represents a user's profile information
@@ -73,6 +125,8 @@ type Profile struct {
 	Equipment []string `json:"equipment" binding:"required"`
 	// User's allergies
 	Allergies []string `json:"allergies" binding:"required"`
+	// Measurement system recipes are displayed in
+	UnitSystem UnitSystem `json:"unit_system" example:"imperial" binding:"required"`
 }
 
 // @Description ProfileUpdateRequest represents a user's profile update request payload
@@ -91,4 +145,6 @@ type ProfileUpdateRequest struct {
 	Equipment []string `json:"equipment,omitempty"`
 	// User's allergies
 	Allergies []string `json:"allergies,omitempty"`
+	// Measurement system recipes are displayed in
+	UnitSystem UnitSystem `json:"unit_system,omitempty" example:"metric"`
 }
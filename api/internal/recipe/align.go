@@ -0,0 +1,147 @@
+package recipe
+
+// lcs returns the index pairs of a longest common subsequence of two
+// sequences of length n and m, where equal reports whether the ith item of
+// the first matches the jth item of the second. Pairs are in order.
+func lcs(n int, m int, equal func(i, j int) bool) [][2]int {
+	// lengths[i][j] is the length of the longest common subsequence of the
+	// sequences from i and j onwards
+	lengths := make([][]int, n+1)
+	for i := range lengths {
+		lengths[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if equal(i, j) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case equal(i, j):
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// gap holds the indices of both sequences that fall between two anchors of
+// an alignment.
+type gap struct {
+	a []int
+	b []int
+}
+
+// gaps splits the indices that aren't anchors into the gap before each
+// anchor, followed by the gap after the last one.
+func gaps(n int, m int, anchors [][2]int) []gap {
+	result := make([]gap, 0, len(anchors)+1)
+	i, j := 0, 0
+	for k := 0; k <= len(anchors); k++ {
+		end := [2]int{n, m}
+		if k < len(anchors) {
+			end = anchors[k]
+		}
+		var g gap
+		for ; i < end[0]; i++ {
+			g.a = append(g.a, i)
+		}
+		for ; j < end[1]; j++ {
+			g.b = append(g.b, j)
+		}
+		result = append(result, g)
+		i, j = end[0]+1, end[1]+1
+	}
+	return result
+}
+
+// pairing records which items of two sequences have been matched up.
+type pairing struct {
+	a []int
+	b []int
+}
+
+func newPairing(n int, m int) pairing {
+	p := pairing{a: make([]int, n), b: make([]int, m)}
+	for i := range p.a {
+		p.a[i] = -1
+	}
+	for j := range p.b {
+		p.b[j] = -1
+	}
+	return p
+}
+
+func (p pairing) pair(i int, j int) {
+	p.a[i] = j
+	p.b[j] = i
+}
+
+// pairInGaps pairs the unpaired items in each gap for which match is true,
+// keeping pairs in order within the gap.
+func (p pairing) pairInGaps(gaps []gap, match func(i, j int) bool, paired func(j int)) {
+	for _, g := range gaps {
+		next := 0
+		for _, i := range g.a {
+			if p.a[i] != -1 {
+				continue
+			}
+			for k := next; k < len(g.b); k++ {
+				j := g.b[k]
+				if p.b[j] == -1 && match(i, j) {
+					p.pair(i, j)
+					paired(j)
+					next = k + 1
+					break
+				}
+			}
+		}
+	}
+}
+
+// pairMoved pairs the unpaired items for which equal is true wherever they
+// are, treating them as moved.
+func (p pairing) pairMoved(equal func(i, j int) bool, moved func(j int)) {
+	for i := range p.a {
+		if p.a[i] != -1 {
+			continue
+		}
+		for j := range p.b {
+			if p.b[j] == -1 && equal(i, j) {
+				p.pair(i, j)
+				moved(j)
+				break
+			}
+		}
+	}
+}
+
+// walk visits an alignment in the order of the second sequence. Unpaired
+// items of the first sequence are visited with removed at the start of the
+// gap they were in.
+func walk(gaps []gap, anchors [][2]int, p pairing, removed func(i int), visit func(j int)) {
+	for k, g := range gaps {
+		for _, i := range g.a {
+			if p.a[i] == -1 {
+				removed(i)
+			}
+		}
+		for _, j := range g.b {
+			visit(j)
+		}
+		if k < len(anchors) {
+			visit(anchors[k][1])
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package recipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+// CatalogSearchParams filters the catalog of global recipes. Zero values mean
+// no filter.
+type CatalogSearchParams struct {
+	Query string
+	// Cursor is the NextCursor of the previous page, empty for the first page
+	Cursor string
+	// Limit defaults to DefaultSearchLimit
+	Limit int
+}
+
+// SearchCatalog returns a page of global recipes matching params, oldest
+// first, along with the cursor for the next page. The cursor is empty on the
+// last page.
+func (s *RecipeService) SearchCatalog(ctx context.Context, params CatalogSearchParams) ([]models.GlobalRecipe, string, error) {
+	limit := params.Limit
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit < 0 || limit > MaxSearchLimit {
+		return nil, "", ErrInvalidSearchLimit
+	}
+	after, err := decodeCursor(params.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	store := s.getStore(ctx)
+	// Fetch one extra recipe to find out whether there is another page
+	recipes, err := store.SearchGlobalRecipes(ctx, db.GlobalRecipeQuery{
+		Text:  params.Query,
+		After: after,
+		Limit: limit + 1,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to search global recipes: %w", err)
+	}
+	if recipes == nil {
+		recipes = []models.GlobalRecipe{}
+	}
+	if len(recipes) <= limit {
+		return recipes, "", nil
+	}
+	recipes = recipes[:limit]
+	last := recipes[limit-1]
+	return recipes, encodeCursor(db.RecipeCursor{CreatedAt: last.CreatedAt, ID: last.ID}), nil
+}
+
+func (s *RecipeService) GetGlobalRecipe(ctx context.Context, id string) (*models.GlobalRecipe, error) {
+	store := s.getStore(ctx)
+	recipe, err := store.GetGlobalRecipe(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return nil, ErrGlobalRecipeNotFound
+		default:
+			return nil, fmt.Errorf("failed to get global recipe: %w", err)
+		}
+	}
+	return &recipe, nil
+}
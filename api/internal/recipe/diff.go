@@ -2,10 +2,15 @@ package recipe
 
 import (
 	"slices"
+	"strings"
 
 	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/units"
 )
 
+// GetRecipeDiff compares two recipes. Ingredients are matched by name and
+// steps by text, so reordering or inserting one doesn't make everything after
+// it look changed.
 func GetRecipeDiff(currentRecipe *models.RecipeBody, proposedRecipe *models.RecipeBody) *models.RecipeDiff {
 	diff := &models.RecipeDiff{
 		NewTitle:            nil,
@@ -17,6 +22,8 @@ func GetRecipeDiff(currentRecipe *models.RecipeBody, proposedRecipe *models.Reci
 		ModifiedIngredients: []models.ModifiedIngredient{},
 		NewSteps:            []models.DiffStep{},
 		NewImageURL:         nil,
+		IngredientChanges:   []models.IngredientChange{},
+		StepChanges:         []models.StepChange{},
 	}
 
 	if currentRecipe.Title != proposedRecipe.Title {
@@ -39,39 +46,30 @@ func GetRecipeDiff(currentRecipe *models.RecipeBody, proposedRecipe *models.Reci
 		diff.NewImageURL = &proposedRecipe.ImageURL
 	}
 
-	currIdx := 0
-	foundProposed := make([]bool, len(proposedRecipe.Ingredients))
-	for currIdx < len(currentRecipe.Ingredients) {
-		currentIngredient := currentRecipe.Ingredients[currIdx]
-		found := false
-		for j, proposedIngredient := range proposedRecipe.Ingredients {
-			if currentIngredient.Name == proposedIngredient.Name {
-				if currentIngredient.Quantity != proposedIngredient.Quantity || currentIngredient.Unit != proposedIngredient.Unit {
-					diff.ModifiedIngredients = append(diff.ModifiedIngredients, models.ModifiedIngredient{
-						Index:    currIdx,
-						Name:     proposedIngredient.Name,
-						Quantity: proposedIngredient.Quantity,
-						Unit:     proposedIngredient.Unit,
-					})
-				}
-				found = true
-				foundProposed[j] = true
-				break
-			}
-		}
-		if !found {
+	ingredients := alignIngredients(currentRecipe.Ingredients, proposedRecipe.Ingredients)
+	for i, j := range ingredients.pairs.a {
+		if j == -1 || ingredients.renamed[j] {
 			diff.RemovedIngredients = append(diff.RemovedIngredients, models.RemovedIngredient{
-				Index: currIdx,
+				Index: i,
+			})
+			continue
+		}
+		proposedIngredient := proposedRecipe.Ingredients[j]
+		if amountChange(currentRecipe.Ingredients[i], proposedIngredient) != "" {
+			diff.ModifiedIngredients = append(diff.ModifiedIngredients, models.ModifiedIngredient{
+				Index:    i,
+				Name:     proposedIngredient.Name,
+				Quantity: proposedIngredient.Quantity,
+				Unit:     proposedIngredient.Unit,
 			})
 		}
-		currIdx++
 	}
-
-	for j, proposedIngredient := range proposedRecipe.Ingredients {
-		if !foundProposed[j] {
-			diff.AddedIngredients = append(diff.AddedIngredients, proposedIngredient)
+	for j, i := range ingredients.pairs.b {
+		if i == -1 || ingredients.renamed[j] {
+			diff.AddedIngredients = append(diff.AddedIngredients, proposedRecipe.Ingredients[j])
 		}
 	}
+	diff.IngredientChanges = ingredients.changes()
 
 	for _, proposedStep := range proposedRecipe.Steps {
 		stepDiff := models.DiffStep{
@@ -85,6 +83,239 @@ func GetRecipeDiff(currentRecipe *models.RecipeBody, proposedRecipe *models.Reci
 
 		diff.NewSteps = append(diff.NewSteps, stepDiff)
 	}
+	diff.StepChanges = alignSteps(currentRecipe.Steps, proposedRecipe.Steps).changes()
 
 	return diff
 }
+
+// ingredientAlignment matches up the ingredients of two recipes. Ingredients
+// in the longest run that kept its order are anchors; the same ingredient
+// anywhere else moved, and a different ingredient with the same amount in
+// place of a removed one was renamed.
+type ingredientAlignment struct {
+	current  []models.Ingredient
+	proposed []models.Ingredient
+	anchors  [][2]int
+	gaps     []gap
+	pairs    pairing
+	// moved and renamed are indexed by proposed ingredient
+	moved   []bool
+	renamed []bool
+}
+
+func alignIngredients(current []models.Ingredient, proposed []models.Ingredient) ingredientAlignment {
+	sameName := func(i, j int) bool {
+		return ingredientKey(current[i].Name) == ingredientKey(proposed[j].Name)
+	}
+	a := ingredientAlignment{
+		current:  current,
+		proposed: proposed,
+		anchors:  lcs(len(current), len(proposed), sameName),
+		pairs:    newPairing(len(current), len(proposed)),
+		moved:    make([]bool, len(proposed)),
+		renamed:  make([]bool, len(proposed)),
+	}
+	a.gaps = gaps(len(current), len(proposed), a.anchors)
+	for _, anchor := range a.anchors {
+		a.pairs.pair(anchor[0], anchor[1])
+	}
+	a.pairs.pairMoved(sameName, func(j int) { a.moved[j] = true })
+	a.pairs.pairInGaps(a.gaps, func(i, j int) bool {
+		return amountChange(current[i], proposed[j]) == ""
+	}, func(j int) { a.renamed[j] = true })
+	return a
+}
+
+func (a ingredientAlignment) changes() []models.IngredientChange {
+	changes := []models.IngredientChange{}
+	removed := func(i int) {
+		changes = append(changes, models.IngredientChange{
+			Type:      models.IngredientChangeRemoved,
+			FromIndex: &i,
+			Before:    &a.current[i],
+		})
+	}
+	visit := func(j int) {
+		i := a.pairs.b[j]
+		if i == -1 {
+			changes = append(changes, models.IngredientChange{
+				Type:    models.IngredientChangeAdded,
+				ToIndex: &j,
+				After:   &a.proposed[j],
+			})
+			return
+		}
+		changeType := amountChange(a.current[i], a.proposed[j])
+		switch {
+		case a.renamed[j]:
+			changeType = models.IngredientChangeRenamed
+		case changeType == "" && a.moved[j]:
+			changeType = models.IngredientChangeMoved
+		case changeType == "":
+			return
+		}
+		changes = append(changes, models.IngredientChange{
+			Type:      changeType,
+			FromIndex: &i,
+			ToIndex:   &j,
+			Moved:     a.moved[j],
+			Before:    &a.current[i],
+			After:     &a.proposed[j],
+		})
+	}
+	walk(a.gaps, a.anchors, a.pairs, removed, visit)
+	return changes
+}
+
+// amountChange returns how the amount of an ingredient changed, or "" if it
+// didn't.
+func amountChange(current models.Ingredient, proposed models.Ingredient) models.IngredientChangeType {
+	quantityChanged := current.Quantity != proposed.Quantity
+	unitChanged := current.Unit != proposed.Unit
+	switch {
+	case quantityChanged && unitChanged:
+		return models.IngredientChangeAmountChanged
+	case quantityChanged:
+		return models.IngredientChangeQuantityChanged
+	case unitChanged:
+		return models.IngredientChangeUnitChanged
+	default:
+		return ""
+	}
+}
+
+// ingredientKey normalizes an ingredient name so that case, spacing and
+// plurals don't stop two names from matching.
+func ingredientKey(name string) string {
+	words := strings.Fields(strings.ToLower(name))
+	if len(words) == 0 {
+		return ""
+	}
+	words[len(words)-1] = units.Singular(words[len(words)-1])
+	return strings.Join(words, " ")
+}
+
+// minStepSimilarity is how much of two steps' wording has to be shared for
+// one to count as an edit of the other rather than a replacement.
+const minStepSimilarity = 0.5
+
+// stepAlignment matches up the steps of two recipes the same way as
+// ingredientAlignment, except that a step replaced by one with similar
+// wording was edited.
+type stepAlignment struct {
+	current  []models.Step
+	proposed []models.Step
+	anchors  [][2]int
+	gaps     []gap
+	pairs    pairing
+	// moved and edited are indexed by proposed step
+	moved  []bool
+	edited []bool
+}
+
+func alignSteps(current []models.Step, proposed []models.Step) stepAlignment {
+	sameText := func(i, j int) bool {
+		return stepKey(current[i]) == stepKey(proposed[j])
+	}
+	a := stepAlignment{
+		current:  current,
+		proposed: proposed,
+		anchors:  lcs(len(current), len(proposed), sameText),
+		pairs:    newPairing(len(current), len(proposed)),
+		moved:    make([]bool, len(proposed)),
+		edited:   make([]bool, len(proposed)),
+	}
+	a.gaps = gaps(len(current), len(proposed), a.anchors)
+	for _, anchor := range a.anchors {
+		a.pairs.pair(anchor[0], anchor[1])
+	}
+	a.pairs.pairMoved(sameText, func(j int) { a.moved[j] = true })
+	a.pairs.pairInGaps(a.gaps, func(i, j int) bool {
+		return stepSimilarity(current[i], proposed[j]) >= minStepSimilarity
+	}, func(j int) { a.edited[j] = true })
+	return a
+}
+
+func (a stepAlignment) changes() []models.StepChange {
+	changes := []models.StepChange{}
+	removed := func(i int) {
+		changes = append(changes, models.StepChange{
+			Type:      models.StepChangeRemoved,
+			FromIndex: &i,
+			Before:    &a.current[i],
+		})
+	}
+	visit := func(j int) {
+		i := a.pairs.b[j]
+		switch {
+		case i == -1:
+			changes = append(changes, models.StepChange{
+				Type:    models.StepChangeAdded,
+				ToIndex: &j,
+				After:   &a.proposed[j],
+			})
+		case a.edited[j]:
+			changes = append(changes, models.StepChange{
+				Type:      models.StepChangeEdited,
+				FromIndex: &i,
+				ToIndex:   &j,
+				Before:    &a.current[i],
+				After:     &a.proposed[j],
+				Words:     diffWords(string(a.current[i]), string(a.proposed[j])),
+			})
+		case a.moved[j]:
+			changes = append(changes, models.StepChange{
+				Type:      models.StepChangeMoved,
+				FromIndex: &i,
+				ToIndex:   &j,
+				Before:    &a.current[i],
+				After:     &a.proposed[j],
+			})
+		}
+	}
+	walk(a.gaps, a.anchors, a.pairs, removed, visit)
+	return changes
+}
+
+func stepKey(step models.Step) string {
+	return strings.Join(strings.Fields(string(step)), " ")
+}
+
+// stepSimilarity is the share of the two steps' words that they have in
+// common, in order, from 0 to 1.
+func stepSimilarity(current models.Step, proposed models.Step) float64 {
+	a, b := strings.Fields(string(current)), strings.Fields(string(proposed))
+	if len(a)+len(b) == 0 {
+		return 1
+	}
+	common := lcs(len(a), len(b), func(i, j int) bool { return a[i] == b[j] })
+	return 2 * float64(len(common)) / float64(len(a)+len(b))
+}
+
+// diffWords compares two steps word by word, merging neighbouring words with
+// the same change into one run.
+func diffWords(current string, proposed string) []models.WordChange {
+	a, b := strings.Fields(current), strings.Fields(proposed)
+	anchors := lcs(len(a), len(b), func(i, j int) bool { return a[i] == b[j] })
+	changes := []models.WordChange{}
+	add := func(changeType models.WordChangeType, word string) {
+		if n := len(changes); n > 0 && changes[n-1].Type == changeType {
+			changes[n-1].Text += " " + word
+			return
+		}
+		changes = append(changes, models.WordChange{Type: changeType, Text: word})
+	}
+	for _, g := range gaps(len(a), len(b), anchors) {
+		for _, i := range g.a {
+			add(models.WordChangeRemoved, a[i])
+		}
+		for _, j := range g.b {
+			add(models.WordChangeAdded, b[j])
+		}
+		if len(anchors) > 0 {
+			add(models.WordChangeEqual, b[anchors[0][1]])
+			anchors = anchors[1:]
+		}
+	}
+	return changes
+}
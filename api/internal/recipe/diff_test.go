@@ -1,6 +1,8 @@
 package recipe
 
 import (
+	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/ajohnston1219/eatme/api/internal/models"
@@ -126,6 +128,136 @@ func TestGetRecipeDiff(t *testing.T) {
 	}
 }
 
+func TestGetRecipeDiffChanges(t *testing.T) {
+	flour := models.Ingredient{Name: "Flour", Quantity: 2, Unit: models.MeasurementUnitCup}
+	sugar := models.Ingredient{Name: "Sugar", Quantity: 1, Unit: models.MeasurementUnitCup}
+	eggs := models.Ingredient{Name: "Eggs", Quantity: 2, Unit: models.MeasurementUnitCount}
+	salt := models.Ingredient{Name: "Salt", Quantity: 1, Unit: models.MeasurementUnitTeaspoon}
+	with := func(ingredient models.Ingredient, quantity float64, unit models.MeasurementUnit) models.Ingredient {
+		ingredient.Quantity = quantity
+		ingredient.Unit = unit
+		return ingredient
+	}
+	steps := []models.Step{"Preheat the oven", "Mix the flour and sugar", "Bake for 20 minutes"}
+
+	cases := []struct {
+		name                string
+		current             models.RecipeBody
+		proposed            models.RecipeBody
+		expectedIngredients []string
+		expectedSteps       []string
+	}{
+		{
+			name:                "bolognese",
+			current:             *testCases[0].currentRecipe,
+			proposed:            *testCases[0].proposedRecipe,
+			expectedIngredients: []string{"renamed 0->0 Veal->Beef", "quantity_changed 3->2 Sugar", "moved 1->3 Crushed Tomato", "added ->4 Onion"},
+			expectedSteps:       []string{"edited 1->1 [equal:Step 2] [added:Changed]", "added ->3"},
+		},
+		{
+			name:                "insert at the top",
+			current:             models.RecipeBody{Ingredients: []models.Ingredient{flour, sugar, eggs}, Steps: steps},
+			proposed:            models.RecipeBody{Ingredients: []models.Ingredient{salt, flour, sugar, eggs}, Steps: append([]models.Step{"Grease the pan"}, steps...)},
+			expectedIngredients: []string{"added ->0 Salt"},
+			expectedSteps:       []string{"added ->0"},
+		},
+		{
+			name:                "quantity and unit",
+			current:             models.RecipeBody{Ingredients: []models.Ingredient{flour, sugar, eggs}},
+			proposed:            models.RecipeBody{Ingredients: []models.Ingredient{with(flour, 3, flour.Unit), with(sugar, 1, models.MeasurementUnitTablespoon), with(eggs, 100, models.MeasurementUnitGram)}},
+			expectedIngredients: []string{"quantity_changed 0->0 Flour", "unit_changed 1->1 Sugar", "amount_changed 2->2 Eggs"},
+			expectedSteps:       []string{},
+		},
+		{
+			name:                "names are normalized",
+			current:             models.RecipeBody{Ingredients: []models.Ingredient{{Name: "Tomatoes", Quantity: 2, Unit: models.MeasurementUnitCount}, {Name: "Cherries", Quantity: 1, Unit: models.MeasurementUnitCup}, eggs}},
+			proposed:            models.RecipeBody{Ingredients: []models.Ingredient{{Name: " tomato ", Quantity: 2, Unit: models.MeasurementUnitCount}, {Name: "cherry", Quantity: 1, Unit: models.MeasurementUnitCup}, {Name: "egg", Quantity: 2, Unit: models.MeasurementUnitCount}}},
+			expectedIngredients: []string{},
+			expectedSteps:       []string{},
+		},
+		{
+			name:                "swap and remove",
+			current:             models.RecipeBody{Ingredients: []models.Ingredient{flour, sugar, eggs}, Steps: steps},
+			proposed:            models.RecipeBody{Ingredients: []models.Ingredient{eggs, flour}, Steps: []models.Step{steps[2], steps[0]}},
+			expectedIngredients: []string{"removed 1-> Sugar", "moved 0->1 Flour"},
+			expectedSteps:       []string{"removed 1->", "moved 0->1"},
+		},
+		{
+			name:                "replaced step",
+			current:             models.RecipeBody{Steps: steps},
+			proposed:            models.RecipeBody{Steps: []models.Step{steps[0], "Whisk the eggs until pale", steps[2]}},
+			expectedIngredients: []string{},
+			expectedSteps:       []string{"removed 1->", "added ->1"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			diff := GetRecipeDiff(&tc.current, &tc.proposed)
+			ingredients := []string{}
+			for _, change := range diff.IngredientChanges {
+				ingredients = append(ingredients, describeIngredientChange(change))
+			}
+			if !slices.Equal(ingredients, tc.expectedIngredients) {
+				t.Errorf("expected ingredient changes %q, got %q", tc.expectedIngredients, ingredients)
+			}
+			steps := []string{}
+			for _, change := range diff.StepChanges {
+				steps = append(steps, describeStepChange(change))
+			}
+			if !slices.Equal(steps, tc.expectedSteps) {
+				t.Errorf("expected step changes %q, got %q", tc.expectedSteps, steps)
+			}
+		})
+	}
+}
+
+func TestGetRecipeDiffInsertDoesNotModify(t *testing.T) {
+	current := &models.RecipeBody{Ingredients: []models.Ingredient{
+		{Name: "Flour", Quantity: 2, Unit: models.MeasurementUnitCup},
+		{Name: "Sugar", Quantity: 1, Unit: models.MeasurementUnitCup},
+	}}
+	proposed := &models.RecipeBody{Ingredients: append([]models.Ingredient{
+		{Name: "Butter", Quantity: 100, Unit: models.MeasurementUnitGram},
+	}, current.Ingredients...)}
+	diff := GetRecipeDiff(current, proposed)
+	if len(diff.ModifiedIngredients) != 0 || len(diff.RemovedIngredients) != 0 || len(diff.AddedIngredients) != 1 || diff.AddedIngredients[0].Name != "Butter" {
+		t.Errorf("expected only butter to be added, got %+v", diff)
+	}
+}
+
+func describeIngredientChange(change models.IngredientChange) string {
+	description := string(change.Type) + " " + describeIndexes(change.FromIndex, change.ToIndex)
+	switch {
+	case change.Type == models.IngredientChangeRenamed:
+		description += " " + change.Before.Name + "->" + change.After.Name
+	case change.After != nil:
+		description += " " + change.After.Name
+	default:
+		description += " " + change.Before.Name
+	}
+	return description
+}
+
+func describeStepChange(change models.StepChange) string {
+	description := string(change.Type) + " " + describeIndexes(change.FromIndex, change.ToIndex)
+	for _, word := range change.Words {
+		description += fmt.Sprintf(" [%s:%s]", word.Type, word.Text)
+	}
+	return description
+}
+
+func describeIndexes(from *int, to *int) string {
+	var description string
+	if from != nil {
+		description += fmt.Sprint(*from)
+	}
+	description += "->"
+	if to != nil {
+		description += fmt.Sprint(*to)
+	}
+	return description
+}
+
 func stringPointer(s string) *string {
 	return &s
 }
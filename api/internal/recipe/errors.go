@@ -4,7 +4,21 @@ import "errors"
 
 var (
 	ErrRecipeNotFound           = errors.New("recipe not found")
+	ErrGlobalRecipeNotFound     = errors.New("global recipe not found")
 	ErrRecipeVersionNotFound    = errors.New("recipe version not found")
 	ErrSuggestionThreadNotFound = errors.New("suggestion thread not found")
 	ErrSuggestionNotFound       = errors.New("suggestion not found")
+	ErrInvalidServings          = errors.New("servings must be positive")
+	ErrRecipeHasNoServings      = errors.New("recipe has no servings to scale from")
+	ErrInvalidCursor            = errors.New("invalid cursor")
+	ErrInvalidSearchLimit       = errors.New("invalid search limit")
+	ErrInvalidMaxTime           = errors.New("max time must not be negative")
+	ErrNoParentVersion          = errors.New("recipe version has no parent")
+	ErrNotChildVersion          = errors.New("recipe version was not made from the latest version")
+	ErrNoCommonVersion          = errors.New("recipe versions have no common ancestor")
+	ErrUnknownMergeConflict     = errors.New("resolution does not match a merge conflict")
+	ErrInvalidMergeSide         = errors.New("merge side must be base, ours or theirs")
+	ErrUnresolvedConflicts      = errors.New("merge has unresolved conflicts")
+	ErrInvalidPatch             = errors.New("invalid patch")
+	ErrPatchTestFailed          = errors.New("patch test failed")
 )
@@ -3,10 +3,15 @@ package recipe
 import (
 	"errors"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/ajohnston1219/eatme/api/internal/api"
 	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/importer"
 	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/units"
 	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
@@ -29,6 +34,7 @@ func NewRecipeHandler(recipeService *RecipeService) *RecipeHandler {
 // @Accept json
 // @Produce json
 // @Param recipeId path string true "Recipe ID"
+// @Param unit_system query string false "Measurement system to show ingredients in, defaults to the user's preference" Enums(metric, imperial)
 // @Success 200 {object} models.UserRecipe
 // @Failure 400 {object} models.APIError "Invalid input"
 // @Failure 401 {object} models.APIError "Unauthorized"
@@ -52,15 +58,23 @@ func (h *RecipeHandler) GetRecipe(w http.ResponseWriter, r *http.Request) {
 	err := h.recipeService.db.WithTx(func(tx db.Store) error {
 		var err error
 		ctx := db.ContextWithTx(r.Context(), tx)
+		system, err := h.recipeService.PreferredUnitSystem(ctx, userID, r.URL.Query().Get("unit_system"))
+		if err != nil {
+			logger.Logger(r.Context()).Error("failed to get unit system", zap.Error(err))
+			return err
+		}
 		recipe, err = h.recipeService.GetUserRecipe(ctx, userID, recipeId)
 		if err != nil {
 			logger.Logger(r.Context()).Error("failed to get user recipe", zap.Error(err))
 			return err
 		}
+		recipe.RecipeBody = units.LocalizeRecipe(recipe.RecipeBody, system)
 		return nil
 	})
 	if err != nil {
 		switch {
+		case errors.Is(err, units.ErrUnknownUnitSystem):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidUnitSystem)
 		case errors.Is(err, ErrRecipeNotFound):
 			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeNotFound)
 		default:
@@ -71,13 +85,71 @@ func (h *RecipeHandler) GetRecipe(w http.ResponseWriter, r *http.Request) {
 	api.WriteJSON(w, http.StatusOK, recipe)
 }
 
-// @Summary Get all recipes for user
-// @Description Get all recipes for user
+// @Summary Import recipe
+// @Description Read a recipe from a saved recipe page, using its schema.org Recipe JSON-LD or microdata, or from a recipe written out as text. The recipe isn't saved; the draft is returned for the user to review and create with POST /recipes.
+// @ID importRecipe
+// @Tags Recipe
+// @Accept json
+// @Produce json
+// @Param request body models.ImportRecipeRequest true "Import recipe request"
+// @Success 200 {object} models.RecipeBody
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 422 {object} models.APIError "No recipe found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/import [post]
+func (h *RecipeHandler) ImportRecipe(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	var input models.ImportRecipeRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode import recipe request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+
+	var draft models.RecipeBody
+	var err error
+	if strings.TrimSpace(input.HTML) != "" {
+		draft, err = importer.FromHTML(strings.NewReader(input.HTML))
+	} else {
+		draft, err = importer.FromText(input.Text)
+	}
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to import recipe", zap.Error(err))
+		switch {
+		case errors.Is(err, importer.ErrNoRecipe):
+			api.ErrorJSON(w, http.StatusUnprocessableEntity, models.ApiErrNoRecipeFound)
+		default:
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, draft)
+}
+
+// @Summary Search recipes
+// @Description Get the user's recipes, optionally filtered by a full-text query and facets. Results are ordered oldest first and paged; the X-Next-Cursor header holds the cursor for the next page and is absent on the last one.
 // @ID getAllRecipes
 // @Tags Recipe
 // @Accept json
 // @Produce json
+// @Param q query string false "Words to find in the title, description, ingredients or steps"
+// @Param max_time query int false "Only recipes that take at most this many minutes"
+// @Param include query []string false "Only recipes with an ingredient matching each of these names" collectionFormat(multi)
+// @Param exclude query []string false "Only recipes without an ingredient matching any of these names" collectionFormat(multi)
+// @Param favorites query bool false "Only favorite recipes"
+// @Param collection query string false "Only recipes in this collection"
+// @Param tag query []string false "Only recipes with each of these tags" collectionFormat(multi)
+// @Param limit query int false "Page size, 1 to 100, defaults to 50"
+// @Param cursor query string false "X-Next-Cursor from the previous page"
+// @Param unit_system query string false "Measurement system to show ingredients in, defaults to the user's preference" Enums(metric, imperial)
 // @Success 200 {array}  models.UserRecipe
+// @Header 200 {string} X-Next-Cursor "Cursor for the next page"
 // @Failure 400 {object} models.APIError "Invalid input"
 // @Failure 401 {object} models.APIError "Unauthorized"
 // @Failure 500 {object} models.APIError "Internal server error"
@@ -89,29 +161,103 @@ func (h *RecipeHandler) GetAllRecipes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	params, apiErr, ok := searchParams(r.URL.Query())
+	if !ok {
+		api.ErrorJSON(w, http.StatusBadRequest, apiErr)
+		return
+	}
+
 	var recipes []models.UserRecipe
+	var nextCursor string
 	err := h.recipeService.db.WithTx(func(tx db.Store) error {
 		var err error
 		ctx := db.ContextWithTx(r.Context(), tx)
-		recipes, err = h.recipeService.GetAllUserRecipes(ctx, userID)
+		system, err := h.recipeService.PreferredUnitSystem(ctx, userID, r.URL.Query().Get("unit_system"))
+		if err != nil {
+			logger.Logger(r.Context()).Error("failed to get unit system", zap.Error(err))
+			return err
+		}
+		recipes, nextCursor, err = h.recipeService.SearchUserRecipes(ctx, userID, params)
 		if err != nil {
-			logger.Logger(r.Context()).Error("failed to get all user recipes", zap.Error(err))
+			logger.Logger(r.Context()).Error("failed to search user recipes", zap.Error(err))
 			return err
 		}
+		for i := range recipes {
+			recipes[i].RecipeBody = units.LocalizeRecipe(recipes[i].RecipeBody, system)
+		}
 		return nil
 	})
 	if err != nil {
 		switch {
-		case errors.Is(err, ErrRecipeNotFound):
-			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeNotFound)
+		case errors.Is(err, units.ErrUnknownUnitSystem):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidUnitSystem)
+		case errors.Is(err, ErrInvalidCursor):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidCursor)
+		case errors.Is(err, ErrInvalidSearchLimit):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidLimit)
+		case errors.Is(err, ErrInvalidMaxTime):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidMaxTime)
 		default:
 			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
 		}
 		return
 	}
+	if recipes == nil {
+		recipes = []models.UserRecipe{}
+	}
+	if nextCursor != "" {
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
 	api.WriteJSON(w, http.StatusOK, recipes)
 }
 
+// searchParams reads the recipe search query parameters. Ingredient and tag
+// filters may be repeated or comma separated.
+func searchParams(query url.Values) (SearchParams, models.APIError, bool) {
+	params := SearchParams{
+		Query:        strings.TrimSpace(query.Get("q")),
+		Include:      listParam(query["include"]),
+		Exclude:      listParam(query["exclude"]),
+		CollectionID: query.Get("collection"),
+		Tags:         listParam(query["tag"]),
+		Cursor:       query.Get("cursor"),
+	}
+	if value := query.Get("max_time"); value != "" {
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes < 1 {
+			return params, models.ApiErrInvalidMaxTime, false
+		}
+		params.MaxTotalTimeMinutes = minutes
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxSearchLimit {
+			return params, models.ApiErrInvalidLimit, false
+		}
+		params.Limit = limit
+	}
+	if value := query.Get("favorites"); value != "" {
+		favorites, err := strconv.ParseBool(value)
+		if err != nil {
+			return params, models.ApiErrInvalidFavorites, false
+		}
+		params.FavoritesOnly = favorites
+	}
+	return params, models.APIError{}, true
+}
+
+func listParam(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
 // @Summary Delete recipe
 // @Description Delete recipe
 // @ID deleteRecipe
@@ -154,3 +300,495 @@ func (h *RecipeHandler) DeleteRecipe(w http.ResponseWriter, r *http.Request) {
 	}
 	api.WriteJSON(w, http.StatusOK, nil)
 }
+
+// @Summary Favorite recipe
+// @Description Mark a recipe as a favorite
+// @ID favoriteRecipe
+// @Tags Recipe
+// @Produce json
+// @Param recipeId path string true "Recipe ID"
+// @Success 200 {object} models.UserRecipe
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId}/favorite [post]
+func (h *RecipeHandler) FavoriteRecipe(w http.ResponseWriter, r *http.Request) {
+	h.setFavorite(w, r, true)
+}
+
+// @Summary Unfavorite recipe
+// @Description Unmark a recipe as a favorite
+// @ID unfavoriteRecipe
+// @Tags Recipe
+// @Produce json
+// @Param recipeId path string true "Recipe ID"
+// @Success 200 {object} models.UserRecipe
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId}/favorite [delete]
+func (h *RecipeHandler) UnfavoriteRecipe(w http.ResponseWriter, r *http.Request) {
+	h.setFavorite(w, r, false)
+}
+
+func (h *RecipeHandler) setFavorite(w http.ResponseWriter, r *http.Request, favorite bool) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	recipeId := chi.URLParam(r, "recipeId")
+	if recipeId == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+
+	var recipe *models.UserRecipe
+	err := h.recipeService.db.WithTx(func(tx db.Store) error {
+		var err error
+		ctx := db.ContextWithTx(r.Context(), tx)
+		recipe, err = h.recipeService.SetFavorite(ctx, userID, recipeId, favorite)
+		return err
+	})
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to set recipe favorite", zap.Error(err))
+		switch {
+		case errors.Is(err, ErrRecipeNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeNotFound)
+		default:
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, recipe)
+}
+
+// @Summary Set recipe tags
+// @Description Replace the tags of a recipe. Tags are trimmed and lowercased, duplicates are dropped.
+// @ID setRecipeTags
+// @Tags Recipe
+// @Accept json
+// @Produce json
+// @Param recipeId path string true "Recipe ID"
+// @Param request body models.SetRecipeTagsRequest true "Set recipe tags request"
+// @Success 200 {object} models.UserRecipe
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId}/tags [put]
+func (h *RecipeHandler) SetRecipeTags(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	recipeId := chi.URLParam(r, "recipeId")
+	if recipeId == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+
+	var input models.SetRecipeTagsRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode set recipe tags request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+
+	var recipe *models.UserRecipe
+	err := h.recipeService.db.WithTx(func(tx db.Store) error {
+		var err error
+		ctx := db.ContextWithTx(r.Context(), tx)
+		recipe, err = h.recipeService.SetTags(ctx, userID, recipeId, input.Tags)
+		return err
+	})
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to set recipe tags", zap.Error(err))
+		switch {
+		case errors.Is(err, ErrRecipeNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeNotFound)
+		default:
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, recipe)
+}
+
+// @Summary Get recipe tags
+// @Description Get every tag on the user's recipes with how many recipes have it, in alphabetical order
+// @ID getRecipeTags
+// @Tags Recipe
+// @Produce json
+// @Success 200 {array} models.RecipeTag
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/tags [get]
+func (h *RecipeHandler) GetRecipeTags(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	tags, err := h.recipeService.ListTags(r.Context(), userID)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to list recipe tags", zap.Error(err))
+		api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, tags)
+}
+
+// @Summary Get recipe versions
+// @Description Get every version of a recipe as a tree flattened so that each version comes after the version it was made from
+// @ID getRecipeVersions
+// @Tags Recipe
+// @Accept json
+// @Produce json
+// @Param recipeId path string true "Recipe ID"
+// @Success 200 {array} models.RecipeVersionSummary
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId}/versions [get]
+func (h *RecipeHandler) GetRecipeVersions(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	recipeId := chi.URLParam(r, "recipeId")
+	if recipeId == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+
+	versions, err := h.recipeService.ListRecipeVersions(r.Context(), userID, recipeId)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to list recipe versions", zap.Error(err))
+		switch {
+		case errors.Is(err, ErrRecipeNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeNotFound)
+		default:
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, versions)
+}
+
+// @Summary Get recipe version
+// @Description Get one version of a recipe
+// @ID getRecipeVersion
+// @Tags Recipe
+// @Accept json
+// @Produce json
+// @Param recipeId path string true "Recipe ID"
+// @Param versionId path string true "Version ID"
+// @Param unit_system query string false "Measurement system to show ingredients in, defaults to the user's preference" Enums(metric, imperial)
+// @Success 200 {object} models.RecipeVersion
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe or version not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId}/versions/{versionId} [get]
+func (h *RecipeHandler) GetRecipeVersion(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	recipeId := chi.URLParam(r, "recipeId")
+	versionId := chi.URLParam(r, "versionId")
+	if recipeId == "" || versionId == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+
+	var version *models.RecipeVersion
+	err := h.recipeService.db.WithTx(func(tx db.Store) error {
+		var err error
+		ctx := db.ContextWithTx(r.Context(), tx)
+		system, err := h.recipeService.PreferredUnitSystem(ctx, userID, r.URL.Query().Get("unit_system"))
+		if err != nil {
+			logger.Logger(r.Context()).Error("failed to get unit system", zap.Error(err))
+			return err
+		}
+		version, err = h.recipeService.GetRecipeVersion(ctx, userID, recipeId, versionId)
+		if err != nil {
+			logger.Logger(r.Context()).Error("failed to get recipe version", zap.Error(err))
+			return err
+		}
+		version.RecipeBody = units.LocalizeRecipe(version.RecipeBody, system)
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, units.ErrUnknownUnitSystem):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidUnitSystem)
+		case errors.Is(err, ErrRecipeNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeNotFound)
+		case errors.Is(err, ErrRecipeVersionNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeVersionNotFound)
+		default:
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, version)
+}
+
+// @Summary Diff recipe versions
+// @Description Compare two versions of a recipe
+// @ID diffRecipeVersions
+// @Tags Recipe
+// @Accept json
+// @Produce json
+// @Param recipeId path string true "Recipe ID"
+// @Param from query string true "ID of the version to compare from"
+// @Param to query string false "ID of the version to compare to, defaults to the latest version"
+// @Success 200 {object} models.RecipeDiff
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe or version not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId}/diff [get]
+func (h *RecipeHandler) GetRecipeDiff(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	recipeId := chi.URLParam(r, "recipeId")
+	if recipeId == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	from := query.Get("from")
+	if from == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrMissingDiffFrom)
+		return
+	}
+
+	diff, err := h.recipeService.DiffRecipeVersions(r.Context(), userID, recipeId, from, query.Get("to"))
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to diff recipe versions", zap.Error(err))
+		switch {
+		case errors.Is(err, ErrRecipeNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeNotFound)
+		case errors.Is(err, ErrRecipeVersionNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeVersionNotFound)
+		default:
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, diff)
+}
+
+// @Summary Preview recipe merge
+// @Description Merge the changes made in one version of a recipe with those made in another, starting from the newest version both were made from. Nothing is saved.
+// @ID getRecipeMerge
+// @Tags Recipe
+// @Accept json
+// @Produce json
+// @Param recipeId path string true "Recipe ID"
+// @Param theirs_version_id query string true "ID of the version to merge"
+// @Param ours_version_id query string false "ID of the version to merge into, defaults to the latest version"
+// @Success 200 {object} models.RecipeMerge
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe or version not found"
+// @Failure 422 {object} models.APIError "Versions have no common version"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId}/merge [get]
+func (h *RecipeHandler) GetRecipeMerge(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	recipeId := chi.URLParam(r, "recipeId")
+	if recipeId == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	theirs := query.Get("theirs_version_id")
+	if theirs == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrMissingMergeTheirs)
+		return
+	}
+
+	merge, err := h.recipeService.MergeVersions(r.Context(), userID, recipeId, query.Get("ours_version_id"), theirs, nil)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to merge recipe versions", zap.Error(err))
+		status, apiErr := mergeErrorResponse(err)
+		api.ErrorJSON(w, status, apiErr)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, merge)
+}
+
+// mergeErrorResponse maps a failed merge preview to the response returned to
+// the client.
+func mergeErrorResponse(err error) (int, models.APIError) {
+	switch {
+	case errors.Is(err, ErrRecipeNotFound):
+		return http.StatusNotFound, models.ApiErrRecipeNotFound
+	case errors.Is(err, ErrRecipeVersionNotFound):
+		return http.StatusNotFound, models.ApiErrRecipeVersionNotFound
+	case errors.Is(err, ErrNoCommonVersion):
+		return http.StatusUnprocessableEntity, models.ApiErrNoCommonVersion
+	case errors.Is(err, ErrUnknownMergeConflict):
+		return http.StatusBadRequest, models.ApiErrUnknownMergeConflict
+	case errors.Is(err, ErrInvalidMergeSide):
+		return http.StatusBadRequest, models.ApiErrInvalidMergeSide
+	default:
+		return http.StatusInternalServerError, models.ApiErrInternal
+	}
+}
+
+// @Summary Search the catalog
+// @Description Get the global recipes anyone can fork into their recipe book, optionally filtered by a full-text query. Results are ordered oldest first and paged; the X-Next-Cursor header holds the cursor for the next page and is absent on the last one.
+// @ID getCatalog
+// @Tags Catalog
+// @Produce json
+// @Param q query string false "Words to find in the title, description, ingredients or steps"
+// @Param limit query int false "Page size, 1 to 100, defaults to 50"
+// @Param cursor query string false "X-Next-Cursor from the previous page"
+// @Param unit_system query string false "Measurement system to show ingredients in, defaults to the user's preference" Enums(metric, imperial)
+// @Success 200 {array} models.GlobalRecipe
+// @Header 200 {string} X-Next-Cursor "Cursor for the next page"
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /catalog [get]
+func (h *RecipeHandler) GetCatalog(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	params := CatalogSearchParams{
+		Query:  strings.TrimSpace(query.Get("q")),
+		Cursor: query.Get("cursor"),
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxSearchLimit {
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidLimit)
+			return
+		}
+		params.Limit = limit
+	}
+
+	var recipes []models.GlobalRecipe
+	var nextCursor string
+	err := h.recipeService.db.WithTx(func(tx db.Store) error {
+		var err error
+		ctx := db.ContextWithTx(r.Context(), tx)
+		system, err := h.recipeService.PreferredUnitSystem(ctx, userID, query.Get("unit_system"))
+		if err != nil {
+			logger.Logger(r.Context()).Error("failed to get unit system", zap.Error(err))
+			return err
+		}
+		recipes, nextCursor, err = h.recipeService.SearchCatalog(ctx, params)
+		if err != nil {
+			logger.Logger(r.Context()).Error("failed to search catalog", zap.Error(err))
+			return err
+		}
+		for i := range recipes {
+			recipes[i].RecipeBody = units.LocalizeRecipe(recipes[i].RecipeBody, system)
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, units.ErrUnknownUnitSystem):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidUnitSystem)
+		case errors.Is(err, ErrInvalidCursor):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidCursor)
+		case errors.Is(err, ErrInvalidSearchLimit):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidLimit)
+		default:
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
+		return
+	}
+	if nextCursor != "" {
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
+	api.WriteJSON(w, http.StatusOK, recipes)
+}
+
+// @Summary Get catalog recipe
+// @Description Get a global recipe from the catalog by ID
+// @ID getCatalogRecipe
+// @Tags Catalog
+// @Produce json
+// @Param globalRecipeId path string true "Global recipe ID"
+// @Param unit_system query string false "Measurement system to show ingredients in, defaults to the user's preference" Enums(metric, imperial)
+// @Success 200 {object} models.GlobalRecipe
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Catalog recipe not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /catalog/{globalRecipeId} [get]
+func (h *RecipeHandler) GetCatalogRecipe(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	globalRecipeID := chi.URLParam(r, "globalRecipeId")
+	if globalRecipeID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+
+	var recipe *models.GlobalRecipe
+	err := h.recipeService.db.WithTx(func(tx db.Store) error {
+		var err error
+		ctx := db.ContextWithTx(r.Context(), tx)
+		system, err := h.recipeService.PreferredUnitSystem(ctx, userID, r.URL.Query().Get("unit_system"))
+		if err != nil {
+			logger.Logger(r.Context()).Error("failed to get unit system", zap.Error(err))
+			return err
+		}
+		recipe, err = h.recipeService.GetGlobalRecipe(ctx, globalRecipeID)
+		if err != nil {
+			logger.Logger(r.Context()).Error("failed to get global recipe", zap.Error(err))
+			return err
+		}
+		recipe.RecipeBody = units.LocalizeRecipe(recipe.RecipeBody, system)
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, units.ErrUnknownUnitSystem):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidUnitSystem)
+		case errors.Is(err, ErrGlobalRecipeNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrCatalogRecipeNotFound)
+		default:
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, recipe)
+}
@@ -0,0 +1,280 @@
+package recipe
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+// UnresolvedConflictsError is returned when a merge is committed with
+// conflicts left to resolve. It wraps ErrUnresolvedConflicts.
+type UnresolvedConflictsError struct {
+	ConflictIDs []string
+}
+
+func (e *UnresolvedConflictsError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrUnresolvedConflicts, strings.Join(e.ConflictIDs, ", "))
+}
+
+func (e *UnresolvedConflictsError) Unwrap() error {
+	return ErrUnresolvedConflicts
+}
+
+// Merge combines the changes ours and theirs each made to base. Parts of the
+// recipe that both sides changed differently are conflicts, and the merged
+// recipe takes our side of them.
+func Merge(base models.RecipeBody, ours models.RecipeBody, theirs models.RecipeBody) (models.RecipeBody, []models.MergeConflict) {
+	merged, conflicts, _ := MergeWith(base, ours, theirs, nil)
+	return merged, conflicts
+}
+
+// MergeWith is Merge with some of the conflicts resolved. resolutions maps
+// conflict IDs to the side to keep, and the conflicts returned are the ones
+// left unresolved. It fails with ErrUnknownMergeConflict if a resolution
+// doesn't match any conflict.
+func MergeWith(base models.RecipeBody, ours models.RecipeBody, theirs models.RecipeBody, resolutions map[string]models.MergeSide) (models.RecipeBody, []models.MergeConflict, error) {
+	for _, side := range resolutions {
+		switch side {
+		case models.MergeSideBase, models.MergeSideOurs, models.MergeSideTheirs:
+		default:
+			return models.RecipeBody{}, nil, ErrInvalidMergeSide
+		}
+	}
+	m := &merger{
+		resolutions: resolutions,
+		used:        make(map[string]bool),
+		conflicts:   []models.MergeConflict{},
+	}
+	merged := models.RecipeBody{
+		Title:            mergeField(m, "title", base.Title, ours.Title, theirs.Title, textValue),
+		Description:      mergeField(m, "description", base.Description, ours.Description, theirs.Description, textValue),
+		Servings:         mergeField(m, "servings", base.Servings, ours.Servings, theirs.Servings, numberValue),
+		TotalTimeMinutes: mergeField(m, "total_time_minutes", base.TotalTimeMinutes, ours.TotalTimeMinutes, theirs.TotalTimeMinutes, numberValue),
+		ImageURL:         mergeField(m, "image_url", base.ImageURL, ours.ImageURL, theirs.ImageURL, textValue),
+		Ingredients:      mergeIngredients(m, base.Ingredients, ours.Ingredients, theirs.Ingredients),
+		Steps:            mergeSteps(m, base.Steps, ours.Steps, theirs.Steps),
+	}
+	for id := range resolutions {
+		if !m.used[id] {
+			return models.RecipeBody{}, nil, fmt.Errorf("%w: %s", ErrUnknownMergeConflict, id)
+		}
+	}
+	return merged, m.conflicts, nil
+}
+
+// merger collects the conflicts of a merge, resolving the ones it has a
+// resolution for.
+type merger struct {
+	resolutions map[string]models.MergeSide
+	used        map[string]bool
+	conflicts   []models.MergeConflict
+}
+
+// conflict returns the side to take for a conflict, which is ours unless it
+// has been resolved.
+func (m *merger) conflict(conflict models.MergeConflict) models.MergeSide {
+	if side, ok := m.resolutions[conflict.ID]; ok {
+		m.used[conflict.ID] = true
+		return side
+	}
+	m.conflicts = append(m.conflicts, conflict)
+	return models.MergeSideOurs
+}
+
+// merge3 returns the merged value if at most one side changed it, or both
+// made the same change.
+func merge3[T comparable](base T, ours T, theirs T) (T, bool) {
+	switch {
+	case ours == theirs, theirs == base:
+		return ours, true
+	case ours == base:
+		return theirs, true
+	default:
+		return ours, false
+	}
+}
+
+func pick[T any](side models.MergeSide, base T, ours T, theirs T) T {
+	switch side {
+	case models.MergeSideBase:
+		return base
+	case models.MergeSideTheirs:
+		return theirs
+	default:
+		return ours
+	}
+}
+
+func mergeField[T comparable](m *merger, field string, base T, ours T, theirs T, value func(T) models.MergeValue) T {
+	if merged, ok := merge3(base, ours, theirs); ok {
+		return merged
+	}
+	side := m.conflict(models.MergeConflict{
+		ID:     "field:" + field,
+		Type:   models.MergeConflictField,
+		Field:  field,
+		Base:   value(base),
+		Ours:   value(ours),
+		Theirs: value(theirs),
+	})
+	return pick(side, base, ours, theirs)
+}
+
+func textValue(text string) models.MergeValue {
+	return models.MergeValue{Text: &text}
+}
+
+func numberValue(number int) models.MergeValue {
+	return models.MergeValue{Number: &number}
+}
+
+// maybeIngredient is an ingredient a recipe may not have.
+type maybeIngredient struct {
+	ingredient models.Ingredient
+	ok         bool
+}
+
+func (i maybeIngredient) value() models.MergeValue {
+	if !i.ok {
+		return models.MergeValue{}
+	}
+	return models.MergeValue{Ingredient: &i.ingredient}
+}
+
+// keyIngredients keys ingredients by normalized name, numbering the repeats
+// of a name so that every key is unique.
+func keyIngredients(ingredients []models.Ingredient) ([]string, map[string]maybeIngredient) {
+	keys := make([]string, len(ingredients))
+	byKey := make(map[string]maybeIngredient, len(ingredients))
+	seen := make(map[string]int)
+	for i, ingredient := range ingredients {
+		key := ingredientKey(ingredient.Name)
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			key = fmt.Sprintf("%s#%d", key, n)
+		}
+		keys[i] = key
+		byKey[key] = maybeIngredient{ingredient: ingredient, ok: true}
+	}
+	return keys, byKey
+}
+
+// mergeIngredients merges each ingredient on its own, matching them by name.
+// The result is in our order, with ingredients only they have placed after
+// the ingredient they follow in their recipe.
+func mergeIngredients(m *merger, base []models.Ingredient, ours []models.Ingredient, theirs []models.Ingredient) []models.Ingredient {
+	baseKeys, baseByKey := keyIngredients(base)
+	oursKeys, oursByKey := keyIngredients(ours)
+	theirsKeys, theirsByKey := keyIngredients(theirs)
+
+	merged := make(map[string]models.Ingredient)
+	seen := make(map[string]bool)
+	for _, key := range slices.Concat(oursKeys, theirsKeys, baseKeys) {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		b, o, t := baseByKey[key], oursByKey[key], theirsByKey[key]
+		result, ok := merge3(b, o, t)
+		if !ok {
+			side := m.conflict(models.MergeConflict{
+				ID:     "ingredient:" + key,
+				Type:   models.MergeConflictIngredient,
+				Base:   b.value(),
+				Ours:   o.value(),
+				Theirs: t.value(),
+			})
+			result = pick(side, b, o, t)
+		}
+		if result.ok {
+			merged[key] = result.ingredient
+		}
+	}
+
+	var order []string
+	for _, key := range oursKeys {
+		if _, ok := merged[key]; ok {
+			order = append(order, key)
+		}
+	}
+	for j, key := range theirsKeys {
+		if _, ok := merged[key]; !ok || slices.Contains(order, key) {
+			continue
+		}
+		at := 0
+		for k := j - 1; k >= 0; k-- {
+			if previous := slices.Index(order, theirsKeys[k]); previous != -1 {
+				at = previous + 1
+				break
+			}
+		}
+		order = slices.Insert(order, at, key)
+	}
+
+	ingredients := make([]models.Ingredient, 0, len(order))
+	for _, key := range order {
+		ingredients = append(ingredients, merged[key])
+	}
+	return ingredients
+}
+
+// mergeSteps merges steps the way diff3 merges lines: steps that both sides
+// kept from base split the rest into chunks, and each chunk is merged as a
+// whole.
+func mergeSteps(m *merger, base []models.Step, ours []models.Step, theirs []models.Step) []models.Step {
+	keptBy := func(steps []models.Step) map[int]int {
+		kept := make(map[int]int)
+		anchors := lcs(len(base), len(steps), func(i, j int) bool {
+			return stepKey(base[i]) == stepKey(steps[j])
+		})
+		for _, anchor := range anchors {
+			kept[anchor[0]] = anchor[1]
+		}
+		return kept
+	}
+	keptByOurs, keptByTheirs := keptBy(ours), keptBy(theirs)
+
+	merged := []models.Step{}
+	b, o, t := 0, 0, 0
+	for i := 0; i <= len(base); i++ {
+		oi, inOurs := keptByOurs[i]
+		ti, inTheirs := keptByTheirs[i]
+		if i == len(base) {
+			oi, ti = len(ours), len(theirs)
+		} else if !inOurs || !inTheirs {
+			continue
+		}
+		merged = append(merged, mergeStepChunk(m, b, base[b:i], ours[o:oi], theirs[t:ti])...)
+		if i < len(base) {
+			merged = append(merged, ours[oi])
+		}
+		b, o, t = i+1, oi+1, ti+1
+	}
+	return merged
+}
+
+// mergeStepChunk merges the steps between two that both sides kept. start is
+// where the chunk begins in base, which identifies its conflict.
+func mergeStepChunk(m *merger, start int, base []models.Step, ours []models.Step, theirs []models.Step) []models.Step {
+	same := func(a []models.Step, b []models.Step) bool {
+		return slices.EqualFunc(a, b, func(x, y models.Step) bool {
+			return stepKey(x) == stepKey(y)
+		})
+	}
+	switch {
+	case same(ours, theirs), same(theirs, base):
+		return ours
+	case same(ours, base):
+		return theirs
+	}
+	side := m.conflict(models.MergeConflict{
+		ID:     fmt.Sprintf("steps:%d", start),
+		Type:   models.MergeConflictSteps,
+		Base:   models.MergeValue{Steps: base},
+		Ours:   models.MergeValue{Steps: ours},
+		Theirs: models.MergeValue{Steps: theirs},
+	})
+	return pick(side, base, ours, theirs)
+}
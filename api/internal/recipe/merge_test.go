@@ -0,0 +1,169 @@
+package recipe
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func mergeBase() models.RecipeBody {
+	return models.RecipeBody{
+		Title:            "Pancakes",
+		Description:      "Fluffy pancakes",
+		Servings:         4,
+		TotalTimeMinutes: 20,
+		Ingredients: []models.Ingredient{
+			{Name: "Flour", Quantity: 2, Unit: models.MeasurementUnitCup},
+			{Name: "Milk", Quantity: 1, Unit: models.MeasurementUnitCup},
+			{Name: "Sugar", Quantity: 2, Unit: models.MeasurementUnitTablespoon},
+		},
+		Steps: []models.Step{"Mix the dry ingredients", "Whisk in the milk", "Cook on a hot griddle"},
+	}
+}
+
+func TestMerge(t *testing.T) {
+	cases := []struct {
+		name                string
+		ours                func(r *models.RecipeBody)
+		theirs              func(r *models.RecipeBody)
+		expected            func(r *models.RecipeBody)
+		expectedConflictIDs []string
+	}{
+		{
+			name: "separate changes",
+			ours: func(r *models.RecipeBody) {
+				r.Title = "Buttermilk Pancakes"
+				r.Ingredients[1].Name = "Buttermilk"
+				r.Steps[1] = "Whisk in the buttermilk"
+			},
+			theirs: func(r *models.RecipeBody) {
+				r.Servings = 8
+				r.Ingredients[0].Quantity = 4
+				r.Ingredients = slices.Insert(r.Ingredients, 1, models.Ingredient{Name: "Eggs", Quantity: 2, Unit: models.MeasurementUnitCount})
+				r.Steps = append(r.Steps, "Serve with syrup")
+			},
+			expected: func(r *models.RecipeBody) {
+				r.Title = "Buttermilk Pancakes"
+				r.Servings = 8
+				r.Ingredients = []models.Ingredient{
+					{Name: "Flour", Quantity: 4, Unit: models.MeasurementUnitCup},
+					{Name: "Eggs", Quantity: 2, Unit: models.MeasurementUnitCount},
+					{Name: "Buttermilk", Quantity: 1, Unit: models.MeasurementUnitCup},
+					{Name: "Sugar", Quantity: 2, Unit: models.MeasurementUnitTablespoon},
+				}
+				r.Steps = []models.Step{"Mix the dry ingredients", "Whisk in the buttermilk", "Cook on a hot griddle", "Serve with syrup"}
+			},
+			expectedConflictIDs: []string{},
+		},
+		{
+			name: "same change",
+			ours: func(r *models.RecipeBody) {
+				r.Title = "Crepes"
+				r.Ingredients = r.Ingredients[:2]
+			},
+			theirs: func(r *models.RecipeBody) {
+				r.Title = "Crepes"
+				r.Ingredients = r.Ingredients[:2]
+			},
+			expected: func(r *models.RecipeBody) {
+				r.Title = "Crepes"
+				r.Ingredients = r.Ingredients[:2]
+			},
+			expectedConflictIDs: []string{},
+		},
+		{
+			name: "conflicts",
+			ours: func(r *models.RecipeBody) {
+				r.Title = "Buttermilk Pancakes"
+				r.Ingredients = r.Ingredients[:2]
+				r.Steps[1] = "Whisk in the buttermilk"
+			},
+			theirs: func(r *models.RecipeBody) {
+				r.Title = "Vegan Pancakes"
+				r.Ingredients[2].Quantity = 1
+				r.Steps[1] = "Whisk in the oat milk"
+			},
+			expected: func(r *models.RecipeBody) {
+				r.Title = "Buttermilk Pancakes"
+				r.Ingredients = r.Ingredients[:2]
+				r.Steps[1] = "Whisk in the buttermilk"
+			},
+			expectedConflictIDs: []string{"field:title", "ingredient:sugar", "steps:1"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ours, theirs, expected := mergeBase(), mergeBase(), mergeBase()
+			tc.ours(&ours)
+			tc.theirs(&theirs)
+			tc.expected(&expected)
+
+			merged, conflicts := Merge(mergeBase(), ours, theirs)
+			assertSameRecipe(t, expected, merged)
+			ids := []string{}
+			for _, conflict := range conflicts {
+				ids = append(ids, conflict.ID)
+			}
+			if !slices.Equal(ids, tc.expectedConflictIDs) {
+				t.Errorf("expected conflicts %q, got %q", tc.expectedConflictIDs, ids)
+			}
+		})
+	}
+}
+
+func TestMergeWith(t *testing.T) {
+	base, ours, theirs := mergeBase(), mergeBase(), mergeBase()
+	ours.Title = "Buttermilk Pancakes"
+	ours.Ingredients = ours.Ingredients[:2]
+	ours.Steps[1] = "Whisk in the buttermilk"
+	theirs.Title = "Vegan Pancakes"
+	theirs.Ingredients[2].Quantity = 1
+	theirs.Steps[1] = "Whisk in the oat milk"
+
+	_, conflicts := Merge(base, ours, theirs)
+	if len(conflicts) != 3 {
+		t.Fatalf("expected 3 conflicts, got %+v", conflicts)
+	}
+	sugar := conflicts[1]
+	if sugar.Type != models.MergeConflictIngredient || sugar.Base.Ingredient == nil || sugar.Ours.Ingredient != nil || sugar.Theirs.Ingredient == nil || sugar.Theirs.Ingredient.Quantity != 1 {
+		t.Errorf("expected the sugar conflict to show it removed by us and changed by them, got %+v", sugar)
+	}
+
+	merged, unresolved, err := MergeWith(base, ours, theirs, map[string]models.MergeSide{
+		"field:title":      models.MergeSideTheirs,
+		"ingredient:sugar": models.MergeSideBase,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unresolved) != 1 || unresolved[0].ID != "steps:1" {
+		t.Errorf("expected only the steps to be unresolved, got %+v", unresolved)
+	}
+	expected := mergeBase()
+	expected.Title = "Vegan Pancakes"
+	expected.Steps[1] = "Whisk in the buttermilk"
+	assertSameRecipe(t, expected, merged)
+
+	if _, _, err := MergeWith(base, ours, theirs, map[string]models.MergeSide{"field:servings": models.MergeSideOurs}); !errors.Is(err, ErrUnknownMergeConflict) {
+		t.Errorf("expected ErrUnknownMergeConflict, got %v", err)
+	}
+	if _, _, err := MergeWith(base, ours, theirs, map[string]models.MergeSide{"field:title": "mine"}); !errors.Is(err, ErrInvalidMergeSide) {
+		t.Errorf("expected ErrInvalidMergeSide, got %v", err)
+	}
+}
+
+func assertSameRecipe(t *testing.T, expected models.RecipeBody, actual models.RecipeBody) {
+	t.Helper()
+	if expected.Title != actual.Title || expected.Description != actual.Description || expected.Servings != actual.Servings ||
+		expected.TotalTimeMinutes != actual.TotalTimeMinutes || expected.ImageURL != actual.ImageURL {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+	if !slices.Equal(expected.Ingredients, actual.Ingredients) {
+		t.Errorf("expected ingredients %+v, got %+v", expected.Ingredients, actual.Ingredients)
+	}
+	if !slices.Equal(expected.Steps, actual.Steps) {
+		t.Errorf("expected steps %q, got %q", expected.Steps, actual.Steps)
+	}
+}
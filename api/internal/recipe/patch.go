@@ -0,0 +1,286 @@
+package recipe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+// PatchError describes the operation of a patch that couldn't be applied. It
+// wraps ErrInvalidPatch, or ErrPatchTestFailed if a test operation failed.
+type PatchError struct {
+	// Index is the position of the operation in the patch
+	Index int
+	Op    string
+	Path  string
+	Err   error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("operation %d (%s %s): %s", e.Index, e.Op, e.Path, e.Err)
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyPatch applies a JSON Patch (RFC 6902) to a recipe. The patch works on
+// the recipe's JSON, so it can change any field, and the result must still be
+// a recipe: adding a field RecipeBody doesn't have is an error.
+func ApplyPatch(body models.RecipeBody, patch []models.PatchOperation) (models.RecipeBody, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return models.RecipeBody{}, fmt.Errorf("failed to marshal recipe: %w", err)
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return models.RecipeBody{}, fmt.Errorf("failed to unmarshal recipe: %w", err)
+	}
+	for i, op := range patch {
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			return models.RecipeBody{}, &PatchError{Index: i, Op: op.Op, Path: op.Path, Err: err}
+		}
+	}
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return models.RecipeBody{}, fmt.Errorf("failed to marshal patched recipe: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var patched models.RecipeBody
+	if err := decoder.Decode(&patched); err != nil {
+		return models.RecipeBody{}, fmt.Errorf("%w: the result is not a recipe: %s", ErrInvalidPatch, err)
+	}
+	return patched, nil
+}
+
+func applyOperation(doc any, op models.PatchOperation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	value := func() (any, error) {
+		if len(op.Value) == 0 {
+			return nil, fmt.Errorf("%w: value is required", ErrInvalidPatch)
+		}
+		var v any
+		if err := json.Unmarshal(op.Value, &v); err != nil {
+			return nil, fmt.Errorf("%w: invalid value: %s", ErrInvalidPatch, err)
+		}
+		return v, nil
+	}
+
+	switch op.Op {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, v)
+	case "remove":
+		doc, _, err := removeValue(doc, path)
+		return doc, err
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		doc, _, err := removeValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, v)
+	case "move":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) > len(from) && slices.Equal(path[:len(from)], from) {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+		doc, v, err := removeValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, v)
+	case "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		v, err := getValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		// Round trip the value so the copy doesn't share maps or slices
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy value: %w", err)
+		}
+		var copied any
+		if err := json.Unmarshal(data, &copied); err != nil {
+			return nil, fmt.Errorf("failed to copy value: %w", err)
+		}
+		return addValue(doc, path, copied)
+	case "test":
+		expected, err := value()
+		if err != nil {
+			return nil, err
+		}
+		actual, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			return nil, ErrPatchTestFailed
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+var errPathNotFound = fmt.Errorf("%w: path not found", ErrInvalidPatch)
+
+// arrayIndex parses an array index token. "-", the end of the array, is only
+// allowed when end is true.
+func arrayIndex(token string, length int, end bool) (int, error) {
+	if token == "-" && end {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || strconv.Itoa(i) != token || i < 0 {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	limit := length
+	if end {
+		limit++
+	}
+	if i >= limit {
+		return 0, errPathNotFound
+	}
+	return i, nil
+}
+
+func getValue(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch container := doc.(type) {
+		case map[string]any:
+			v, ok := container[token]
+			if !ok {
+				return nil, errPathNotFound
+			}
+			doc = v
+		case []any:
+			i, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = container[i]
+		default:
+			return nil, errPathNotFound
+		}
+	}
+	return doc, nil
+}
+
+// updateParent replaces the container at the end of path's parent with the
+// result of change, rebuilding every container on the way down since
+// changing a slice's length gives a new slice.
+func updateParent(doc any, path []string, change func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return change(doc, path[0])
+	}
+	child, err := getValue(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = updateParent(child, path[1:], change)
+	if err != nil {
+		return nil, err
+	}
+	switch container := doc.(type) {
+	case map[string]any:
+		container[path[0]] = child
+	case []any:
+		i, _ := arrayIndex(path[0], len(container), false)
+		container[i] = child
+	}
+	return doc, nil
+}
+
+func addValue(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, path, func(parent any, token string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			container[token] = value
+			return container, nil
+		case []any:
+			i, err := arrayIndex(token, len(container), true)
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[i+1:], container[i:])
+			container[i] = value
+			return container, nil
+		default:
+			return nil, errPathNotFound
+		}
+	})
+}
+
+func removeValue(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole recipe", ErrInvalidPatch)
+	}
+	var removed any
+	doc, err := updateParent(doc, path, func(parent any, token string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			v, ok := container[token]
+			if !ok {
+				return nil, errPathNotFound
+			}
+			removed = v
+			delete(container, token)
+			return container, nil
+		case []any:
+			i, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = container[i]
+			return append(container[:i], container[i+1:]...), nil
+		default:
+			return nil, errPathNotFound
+		}
+	})
+	return doc, removed, err
+}
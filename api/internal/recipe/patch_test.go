@@ -0,0 +1,119 @@
+package recipe
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func op(operation string, path string, value any) models.PatchOperation {
+	patch := models.PatchOperation{Op: operation, Path: path}
+	if value != nil {
+		patch.Value, _ = json.Marshal(value)
+	}
+	return patch
+}
+
+func moveOp(operation string, from string, path string) models.PatchOperation {
+	return models.PatchOperation{Op: operation, From: from, Path: path}
+}
+
+func TestApplyPatch(t *testing.T) {
+	cases := []struct {
+		name     string
+		patch    []models.PatchOperation
+		expected func(r *models.RecipeBody)
+	}{
+		{
+			name:     "replace a field",
+			patch:    []models.PatchOperation{op("replace", "/title", "Crepes")},
+			expected: func(r *models.RecipeBody) { r.Title = "Crepes" },
+		},
+		{
+			name: "add and remove ingredients",
+			patch: []models.PatchOperation{
+				op("add", "/ingredients/1", models.Ingredient{Name: "Eggs", Quantity: 2, Unit: models.MeasurementUnitCount}),
+				op("remove", "/ingredients/3", nil),
+				op("add", "/ingredients/-", models.Ingredient{Name: "Salt", Quantity: 1, Unit: models.MeasurementUnitTeaspoon}),
+			},
+			expected: func(r *models.RecipeBody) {
+				r.Ingredients = []models.Ingredient{
+					{Name: "Flour", Quantity: 2, Unit: models.MeasurementUnitCup},
+					{Name: "Eggs", Quantity: 2, Unit: models.MeasurementUnitCount},
+					{Name: "Milk", Quantity: 1, Unit: models.MeasurementUnitCup},
+					{Name: "Salt", Quantity: 1, Unit: models.MeasurementUnitTeaspoon},
+				}
+			},
+		},
+		{
+			name: "edit an ingredient",
+			patch: []models.PatchOperation{
+				op("test", "/ingredients/0/name", "Flour"),
+				op("replace", "/ingredients/0/quantity", 3),
+			},
+			expected: func(r *models.RecipeBody) { r.Ingredients[0].Quantity = 3 },
+		},
+		{
+			name:  "move and copy steps",
+			patch: []models.PatchOperation{moveOp("move", "/steps/2", "/steps/0"), moveOp("copy", "/steps/1", "/steps/-")},
+			expected: func(r *models.RecipeBody) {
+				r.Steps = []models.Step{"Cook on a hot griddle", "Mix the dry ingredients", "Whisk in the milk", "Mix the dry ingredients"}
+			},
+		},
+		{
+			name:     "replace every step",
+			patch:    []models.PatchOperation{op("replace", "/steps", []string{"Blend everything", "Cook"})},
+			expected: func(r *models.RecipeBody) { r.Steps = []models.Step{"Blend everything", "Cook"} },
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expected := mergeBase()
+			tc.expected(&expected)
+			patched, err := ApplyPatch(mergeBase(), tc.patch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertSameRecipe(t, expected, patched)
+		})
+	}
+}
+
+func TestApplyPatchErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		patch    []models.PatchOperation
+		index    int
+		expected error
+	}{
+		{"unknown op", []models.PatchOperation{op("merge", "/title", "Crepes")}, 0, ErrInvalidPatch},
+		{"missing value", []models.PatchOperation{op("replace", "/title", nil)}, 0, ErrInvalidPatch},
+		{"relative path", []models.PatchOperation{op("replace", "title", "Crepes")}, 0, ErrInvalidPatch},
+		{"index out of range", []models.PatchOperation{op("replace", "/title", "Crepes"), op("remove", "/steps/3", nil)}, 1, ErrInvalidPatch},
+		{"leading zero", []models.PatchOperation{op("remove", "/steps/01", nil)}, 0, ErrInvalidPatch},
+		{"remove missing field", []models.PatchOperation{op("remove", "/notes", nil)}, 0, ErrInvalidPatch},
+		{"move into itself", []models.PatchOperation{moveOp("move", "/ingredients", "/ingredients/0")}, 0, ErrInvalidPatch},
+		{"test failed", []models.PatchOperation{op("test", "/servings", 6), op("replace", "/servings", 8)}, 0, ErrPatchTestFailed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ApplyPatch(mergeBase(), tc.patch)
+			var patchErr *PatchError
+			if !errors.As(err, &patchErr) || patchErr.Index != tc.index {
+				t.Fatalf("expected operation %d to fail, got %v", tc.index, err)
+			}
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+
+	if _, err := ApplyPatch(mergeBase(), []models.PatchOperation{op("add", "/rating", 5)}); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("expected adding an unknown field to be invalid, got %v", err)
+	}
+	if _, err := ApplyPatch(mergeBase(), []models.PatchOperation{op("replace", "/servings", "four")}); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("expected a value of the wrong type to be invalid, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package recipe
+
+import (
+	"math"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/units"
+)
+
+// timeExponent controls how total time grows with the number of servings.
+// Cooking a bigger batch takes longer, but nowhere near proportionally so:
+// doubling a recipe adds roughly a quarter to its time.
+const timeExponent = 1.0 / 3
+
+// kitchenFractions are the fractional parts a unit can be measured in with
+// the cups and spoons found in a kitchen drawer.
+var kitchenFractions = map[models.MeasurementUnit][]float64{
+	models.MeasurementUnitCup:        {0, 1.0 / 4, 1.0 / 3, 1.0 / 2, 2.0 / 3, 3.0 / 4, 1},
+	models.MeasurementUnitTablespoon: {0, 1.0 / 2, 1},
+	models.MeasurementUnitTeaspoon:   {0, 1.0 / 8, 1.0 / 4, 1.0 / 2, 3.0 / 4, 1},
+	models.MeasurementUnitPound:      {0, 1.0 / 4, 1.0 / 2, 3.0 / 4, 1},
+	models.MeasurementUnitOunce:      {0, 1.0 / 4, 1.0 / 2, 3.0 / 4, 1},
+}
+
+// promotionTolerance is how far, relative to the exact quantity, rounding to
+// a kitchen fraction may move a quantity for a larger unit to be used.
+const promotionTolerance = 0.05
+
+// ScaleRecipe rescales a recipe to a new number of servings. Ingredient
+// quantities are scaled linearly, moved to the unit that reads best (48 tsp
+// becomes 1 cup) and rounded to fractions found in a kitchen drawer. Counted
+// ingredients are rounded to whole numbers and total time grows sub-linearly.
+func ScaleRecipe(recipe models.RecipeBody, servings int) (models.RecipeBody, error) {
+	if servings <= 0 {
+		return recipe, ErrInvalidServings
+	}
+	if recipe.Servings <= 0 {
+		return recipe, ErrRecipeHasNoServings
+	}
+	factor := float64(servings) / float64(recipe.Servings)
+
+	ingredients := make(models.Ingredients, len(recipe.Ingredients))
+	for i, ingredient := range recipe.Ingredients {
+		ingredients[i] = scaleIngredient(ingredient, factor)
+	}
+	recipe.Ingredients = ingredients
+	recipe.TotalTimeMinutes = scaleTime(recipe.TotalTimeMinutes, factor)
+	recipe.Servings = servings
+	return recipe, nil
+}
+
+func scaleIngredient(ingredient models.Ingredient, factor float64) models.Ingredient {
+	quantity := ingredient.Quantity * factor
+	if quantity <= 0 {
+		return ingredient
+	}
+
+	if units.DimensionOf(ingredient.Unit) == units.DimensionCount {
+		ingredient.Quantity = max(1, math.Round(quantity))
+		return ingredient
+	}
+
+	base, dim := units.ToBase(quantity, ingredient.Unit)
+	system := units.SystemOf(ingredient.Unit)
+	if system == models.UnitSystemMetric {
+		ingredient.Quantity, ingredient.Unit = units.Best(base, dim, system)
+		return ingredient
+	}
+
+	// Spoons are shared by both systems and promote to cups. The largest unit
+	// that can be measured out accurately wins, so 4 tsp stays 4 tsp rather
+	// than becoming 1 1/3 tbsp.
+	candidates := units.Candidates(base, dim, models.UnitSystemImperial)
+	for _, c := range candidates {
+		rounded := roundToKitchenFraction(c.Quantity, c.Unit)
+		if math.Abs(rounded-c.Quantity) <= promotionTolerance*c.Quantity {
+			ingredient.Quantity, ingredient.Unit = rounded, c.Unit
+			return ingredient
+		}
+	}
+	smallest := candidates[len(candidates)-1]
+	ingredient.Quantity = roundToKitchenFraction(smallest.Quantity, smallest.Unit)
+	ingredient.Unit = smallest.Unit
+	return ingredient
+}
+
+// roundToKitchenFraction rounds to the nearest fraction the unit can be
+// measured in. Quantities of ten or more are rounded to whole numbers, and
+// nothing rounds down to zero.
+func roundToKitchenFraction(quantity float64, unit models.MeasurementUnit) float64 {
+	if quantity >= 10 {
+		return math.Round(quantity)
+	}
+	fractions, ok := kitchenFractions[unit]
+	if !ok {
+		return units.Round(quantity, unit)
+	}
+	whole, frac := math.Modf(quantity)
+	best := fractions[0]
+	for _, f := range fractions[1:] {
+		if math.Abs(frac-f) < math.Abs(frac-best) {
+			best = f
+		}
+	}
+	return max(fractions[1], whole+best)
+}
+
+// scaleTime scales a duration in minutes, rounding to five minutes once it
+// is long enough for that precision not to matter.
+func scaleTime(minutes int, factor float64) int {
+	if minutes <= 0 {
+		return minutes
+	}
+	scaled := float64(minutes) * math.Pow(factor, timeExponent)
+	if scaled >= 20 {
+		return int(math.Round(scaled/5) * 5)
+	}
+	return max(1, int(math.Round(scaled)))
+}
@@ -0,0 +1,115 @@
+package recipe
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestScaleRecipe(t *testing.T) {
+	recipe := models.RecipeBody{
+		Title:            "Pancakes",
+		Servings:         4,
+		TotalTimeMinutes: 30,
+		Ingredients: []models.Ingredient{
+			{Name: "Flour", Quantity: 1.5, Unit: models.MeasurementUnitCup},
+			{Name: "Baking Powder", Quantity: 2, Unit: models.MeasurementUnitTeaspoon},
+			{Name: "Vanilla", Quantity: 12, Unit: models.MeasurementUnitTeaspoon},
+			{Name: "Sugar", Quantity: 5, Unit: models.MeasurementUnitTablespoon},
+			{Name: "Egg", Quantity: 3, Unit: models.MeasurementUnitCount},
+			{Name: "Bacon", Quantity: 12, Unit: models.MeasurementUnitOunce},
+			{Name: "Milk", Quantity: 300, Unit: models.MeasurementUnitMilliliter},
+		},
+	}
+
+	scaled, err := ScaleRecipe(recipe, 8)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	expected := []models.Ingredient{
+		{Name: "Flour", Quantity: 3, Unit: models.MeasurementUnitCup},
+		{Name: "Baking Powder", Quantity: 4, Unit: models.MeasurementUnitTeaspoon},
+		{Name: "Vanilla", Quantity: 0.5, Unit: models.MeasurementUnitCup},
+		{Name: "Sugar", Quantity: 10, Unit: models.MeasurementUnitTablespoon},
+		{Name: "Egg", Quantity: 6, Unit: models.MeasurementUnitCount},
+		{Name: "Bacon", Quantity: 1.5, Unit: models.MeasurementUnitPound},
+		{Name: "Milk", Quantity: 600, Unit: models.MeasurementUnitMilliliter},
+	}
+	for i, ingredient := range scaled.Ingredients {
+		if ingredient != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], ingredient)
+		}
+	}
+	if scaled.Servings != 8 {
+		t.Errorf("expected 8 servings, got %d", scaled.Servings)
+	}
+	if scaled.TotalTimeMinutes != 40 {
+		t.Errorf("expected 40 minutes, got %d", scaled.TotalTimeMinutes)
+	}
+	if recipe.Ingredients[0].Quantity != 1.5 {
+		t.Errorf("expected original recipe to be left untouched")
+	}
+}
+
+func TestScaleRecipeDown(t *testing.T) {
+	recipe := models.RecipeBody{
+		Servings:         6,
+		TotalTimeMinutes: 10,
+		Ingredients: []models.Ingredient{
+			{Name: "Butter", Quantity: 1, Unit: models.MeasurementUnitCup},
+			{Name: "Salt", Quantity: 0.25, Unit: models.MeasurementUnitTeaspoon},
+			{Name: "Egg", Quantity: 1, Unit: models.MeasurementUnitCount},
+			{Name: "Sugar", Quantity: 2, Unit: models.MeasurementUnitTablespoon},
+		},
+	}
+
+	scaled, err := ScaleRecipe(recipe, 2)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	expected := []models.Ingredient{
+		{Name: "Butter", Quantity: 1.0 / 3, Unit: models.MeasurementUnitCup},
+		{Name: "Salt", Quantity: 1.0 / 8, Unit: models.MeasurementUnitTeaspoon},
+		{Name: "Egg", Quantity: 1, Unit: models.MeasurementUnitCount},
+		{Name: "Sugar", Quantity: 2, Unit: models.MeasurementUnitTeaspoon},
+	}
+	for i, ingredient := range scaled.Ingredients {
+		if ingredient != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], ingredient)
+		}
+	}
+	if scaled.TotalTimeMinutes != 7 {
+		t.Errorf("expected 7 minutes, got %d", scaled.TotalTimeMinutes)
+	}
+}
+
+func TestScaleRecipeErrors(t *testing.T) {
+	if _, err := ScaleRecipe(models.RecipeBody{Servings: 4}, 0); !errors.Is(err, ErrInvalidServings) {
+		t.Errorf("expected %v, got %v", ErrInvalidServings, err)
+	}
+	if _, err := ScaleRecipe(models.RecipeBody{}, 2); !errors.Is(err, ErrRecipeHasNoServings) {
+		t.Errorf("expected %v, got %v", ErrRecipeHasNoServings, err)
+	}
+}
+
+func TestRoundToKitchenFraction(t *testing.T) {
+	tests := []struct {
+		quantity float64
+		unit     models.MeasurementUnit
+		expected float64
+	}{
+		{0.01, models.MeasurementUnitTeaspoon, 1.0 / 8},
+		{0.3, models.MeasurementUnitCup, 1.0 / 3},
+		{0.7, models.MeasurementUnitCup, 2.0 / 3},
+		{1.26, models.MeasurementUnitCup, 1.25},
+		{1.3, models.MeasurementUnitTablespoon, 1.5},
+		{2.95, models.MeasurementUnitTeaspoon, 3},
+		{12.4, models.MeasurementUnitOunce, 12},
+	}
+	for _, tt := range tests {
+		if got := roundToKitchenFraction(tt.quantity, tt.unit); got != tt.expected {
+			t.Errorf("roundToKitchenFraction(%v, %s): expected %v, got %v", tt.quantity, tt.unit, tt.expected, got)
+		}
+	}
+}
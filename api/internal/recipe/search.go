@@ -0,0 +1,103 @@
+package recipe
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 100
+)
+
+// SearchParams filters a user's recipe book. Zero values mean no filter.
+type SearchParams struct {
+	Query               string
+	MaxTotalTimeMinutes int
+	// Include and Exclude match ingredient names, ignoring case
+	Include       []string
+	Exclude       []string
+	FavoritesOnly bool
+	// CollectionID limits the search to one of the user's collections
+	CollectionID string
+	// Tags a recipe must all have
+	Tags []string
+	// Cursor is the NextCursor of the previous page, empty for the first page
+	Cursor string
+	// Limit defaults to DefaultSearchLimit
+	Limit int
+}
+
+type searchCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+}
+
+// SearchUserRecipes returns a page of the user's recipes matching params,
+// oldest first, along with the cursor for the next page. The cursor is empty
+// on the last page.
+func (s *RecipeService) SearchUserRecipes(ctx context.Context, userID string, params SearchParams) ([]models.UserRecipe, string, error) {
+	limit := params.Limit
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit < 0 || limit > MaxSearchLimit {
+		return nil, "", ErrInvalidSearchLimit
+	}
+	if params.MaxTotalTimeMinutes < 0 {
+		return nil, "", ErrInvalidMaxTime
+	}
+	after, err := decodeCursor(params.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	store := s.getStore(ctx)
+	// Fetch one extra recipe to find out whether there is another page
+	recipes, err := store.SearchUserRecipes(ctx, userID, db.RecipeQuery{
+		Text:                params.Query,
+		MaxTotalTimeMinutes: params.MaxTotalTimeMinutes,
+		Include:             params.Include,
+		Exclude:             params.Exclude,
+		FavoritesOnly:       params.FavoritesOnly,
+		CollectionID:        params.CollectionID,
+		Tags:                NormalizeTags(params.Tags),
+		After:               after,
+		Limit:               limit + 1,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to search user recipes: %w", err)
+	}
+	if len(recipes) <= limit {
+		return recipes, "", nil
+	}
+	recipes = recipes[:limit]
+	last := recipes[limit-1]
+	return recipes, encodeCursor(db.RecipeCursor{CreatedAt: last.CreatedAt, ID: last.ID}), nil
+}
+
+func encodeCursor(cursor db.RecipeCursor) string {
+	b, _ := json.Marshal(searchCursor{CreatedAt: cursor.CreatedAt, ID: cursor.ID})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*db.RecipeCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor searchCursor
+	if err := json.Unmarshal(b, &cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &db.RecipeCursor{CreatedAt: cursor.CreatedAt, ID: cursor.ID}, nil
+}
@@ -0,0 +1,31 @@
+package recipe
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ajohnston1219/eatme/api/internal/db"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := db.RecipeCursor{CreatedAt: time.Date(2025, 3, 1, 12, 30, 0, 500, time.UTC), ID: "recipe-1"}
+	decoded, err := decodeCursor(encodeCursor(cursor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+		t.Errorf("expected %+v, got %+v", cursor, decoded)
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	if cursor, err := decodeCursor(""); cursor != nil || err != nil {
+		t.Errorf("expected no cursor for the first page, got %+v (%v)", cursor, err)
+	}
+	for _, s := range []string{"not base64!", "bm90IGpzb24", "e30"} {
+		if _, err := decodeCursor(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%q: expected ErrInvalidCursor, got %v", s, err)
+		}
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/ajohnston1219/eatme/api/internal/db"
 	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/units"
 	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
 	"github.com/google/uuid"
 )
@@ -29,25 +31,40 @@ func (s *RecipeService) getStore(ctx context.Context) db.Store {
 }
 
 func (s *RecipeService) NewRecipe(ctx context.Context, userID string, threadID string, recipeBody models.RecipeBody) (*models.UserRecipe, error) {
-	store := s.getStore(ctx)
-	recipeID := uuid.New().String()
-	versionID := uuid.New().String()
+	return s.newRecipe(ctx, models.UserRecipe{
+		UserID:     userID,
+		RecipeBody: recipeBody,
+		ThreadID:   threadID,
+	})
+}
 
-	recipe := models.UserRecipe{
-		ID:              recipeID,
-		LatestVersionID: versionID,
-		UserID:          userID,
-		RecipeBody:      recipeBody,
-		ThreadID:        threadID,
-	}
+// ForkRecipe copies a global recipe into the user's recipe book, linked to
+// the global recipe's current revision.
+func (s *RecipeService) ForkRecipe(ctx context.Context, userID string, threadID string, global models.GlobalRecipe) (*models.UserRecipe, error) {
+	return s.newRecipe(ctx, models.UserRecipe{
+		UserID:               userID,
+		GlobalRecipeID:       &global.ID,
+		GlobalRecipeRevision: &global.Revision,
+		RecipeBody:           global.RecipeBody,
+		ThreadID:             threadID,
+	})
+}
+
+// newRecipe saves recipe along with its first version.
+func (s *RecipeService) newRecipe(ctx context.Context, recipe models.UserRecipe) (*models.UserRecipe, error) {
+	store := s.getStore(ctx)
+	recipe.ID = uuid.New().String()
+	recipe.LatestVersionID = uuid.New().String()
+	recipe.Tags = models.Tags{}
 	if err := store.SaveUserRecipe(ctx, recipe); err != nil {
 		return nil, fmt.Errorf("failed to save user recipe: %w", err)
 	}
 	logger.Logger(ctx).Debug("saved user recipe")
 	rv := models.RecipeVersion{
-		ID:           versionID,
-		UserRecipeID: recipeID,
-		RecipeBody:   recipeBody,
+		ID:           recipe.LatestVersionID,
+		UserRecipeID: recipe.ID,
+		CreatedAt:    time.Now(),
+		RecipeBody:   recipe.RecipeBody,
 	}
 	if err := store.AddRecipeVersion(ctx, rv); err != nil {
 		return nil, fmt.Errorf("failed to add recipe version: %w", err)
@@ -57,6 +74,12 @@ func (s *RecipeService) NewRecipe(ctx context.Context, userID string, threadID s
 }
 
 func (s *RecipeService) UpdateRecipe(ctx context.Context, userID string, recipeID string, recipeBody models.RecipeBody) error {
+	return s.addVersion(ctx, userID, recipeID, recipeBody, nil)
+}
+
+// addVersion saves recipeBody as a new version made from the recipe's latest
+// version and makes it the latest.
+func (s *RecipeService) addVersion(ctx context.Context, userID string, recipeID string, recipeBody models.RecipeBody, notes *string) error {
 	store := s.getStore(ctx)
 	current, err := store.GetUserRecipe(ctx, userID, recipeID)
 	if err != nil {
@@ -73,6 +96,8 @@ func (s *RecipeService) UpdateRecipe(ctx context.Context, userID string, recipeI
 		ID:           uuid.New().String(),
 		UserRecipeID: recipeID,
 		ParentID:     &current.LatestVersionID,
+		CreatedAt:    time.Now(),
+		Notes:        notes,
 		RecipeBody:   recipeBody,
 	}
 
@@ -116,7 +141,50 @@ func (s *RecipeService) DeleteUserRecipe(ctx context.Context, userID string, rec
 	store := s.getStore(ctx)
 	err := store.DeleteUserRecipe(ctx, userID, recipeID)
 	if err != nil {
-		return fmt.Errorf("failed to delete user recipe: %w", err)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return ErrRecipeNotFound
+		default:
+			return fmt.Errorf("failed to delete user recipe: %w", err)
+		}
 	}
 	return nil
 }
+
+// SetFavorite marks or unmarks a recipe as a favorite.
+func (s *RecipeService) SetFavorite(ctx context.Context, userID string, recipeID string, favorite bool) (*models.UserRecipe, error) {
+	store := s.getStore(ctx)
+	if err := store.SetRecipeFavorite(ctx, userID, recipeID, favorite); err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return nil, ErrRecipeNotFound
+		default:
+			return nil, fmt.Errorf("failed to set recipe favorite: %w", err)
+		}
+	}
+	logger.Logger(ctx).Debug("set recipe favorite")
+	return s.GetUserRecipe(ctx, userID, recipeID)
+}
+
+// PreferredUnitSystem resolves the measurement system recipes are shown in
+// for a user. An explicit override, e.g. from a query parameter, takes
+// precedence over the profile.
+func (s *RecipeService) PreferredUnitSystem(ctx context.Context, userID string, override string) (models.UnitSystem, error) {
+	if override != "" {
+		return units.ParseUnitSystem(override)
+	}
+	store := s.getStore(ctx)
+	profile, err := store.GetProfile(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return models.UnitSystemImperial, nil
+		default:
+			return "", fmt.Errorf("failed to get profile: %w", err)
+		}
+	}
+	if profile.UnitSystem == "" {
+		return models.UnitSystemImperial, nil
+	}
+	return profile.UnitSystem, nil
+}
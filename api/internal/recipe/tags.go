@@ -0,0 +1,55 @@
+package recipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
+)
+
+// NormalizeTags trims and lowercases tags, dropping empty and duplicate ones,
+// so that tags differing only in case or spacing are the same tag.
+func NormalizeTags(tags []string) []string {
+	normalized := []string{}
+	for _, tag := range tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+// SetTags replaces the tags of a recipe.
+func (s *RecipeService) SetTags(ctx context.Context, userID string, recipeID string, tags []string) (*models.UserRecipe, error) {
+	store := s.getStore(ctx)
+	if err := store.SetRecipeTags(ctx, userID, recipeID, NormalizeTags(tags)); err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return nil, ErrRecipeNotFound
+		default:
+			return nil, fmt.Errorf("failed to set recipe tags: %w", err)
+		}
+	}
+	logger.Logger(ctx).Debug("set recipe tags")
+	return s.GetUserRecipe(ctx, userID, recipeID)
+}
+
+// ListTags returns every tag on the user's recipes with how many recipes have
+// it, in alphabetical order.
+func (s *RecipeService) ListTags(ctx context.Context, userID string) ([]models.RecipeTag, error) {
+	store := s.getStore(ctx)
+	tags, err := store.ListRecipeTags(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recipe tags: %w", err)
+	}
+	if tags == nil {
+		tags = []models.RecipeTag{}
+	}
+	return tags, nil
+}
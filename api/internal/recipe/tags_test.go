@@ -0,0 +1,16 @@
+package recipe
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags := NormalizeTags([]string{" Weeknight", "vegetarian", "WEEKNIGHT", "  ", "Vegetarian "})
+	if !slices.Equal(tags, []string{"vegetarian", "weeknight"}) {
+		t.Errorf("expected normalized tags, got %v", tags)
+	}
+	if tags := NormalizeTags(nil); tags == nil || len(tags) != 0 {
+		t.Errorf("expected no tags, got %#v", tags)
+	}
+}
@@ -0,0 +1,271 @@
+package recipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
+)
+
+// GetRecipeVersion returns one of the recipe's versions.
+func (s *RecipeService) GetRecipeVersion(ctx context.Context, userID string, recipeID string, versionID string) (*models.RecipeVersion, error) {
+	if _, err := s.GetUserRecipe(ctx, userID, recipeID); err != nil {
+		return nil, err
+	}
+	store := s.getStore(ctx)
+	version, err := store.GetRecipeVersion(ctx, versionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return nil, ErrRecipeVersionNotFound
+		default:
+			return nil, fmt.Errorf("failed to get recipe version: %w", err)
+		}
+	}
+	// Versions are looked up by ID alone, so make sure it's one of this recipe's
+	if version.UserRecipeID != recipeID {
+		return nil, ErrRecipeVersionNotFound
+	}
+	return &version, nil
+}
+
+// ListRecipeVersions returns the recipe's version tree as a list in which
+// every version comes after the version it was made from. Versions made from
+// the same version are oldest first.
+func (s *RecipeService) ListRecipeVersions(ctx context.Context, userID string, recipeID string) ([]models.RecipeVersionSummary, error) {
+	current, err := s.GetUserRecipe(ctx, userID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	store := s.getStore(ctx)
+	versions, err := store.ListRecipeVersions(ctx, userID, recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recipe versions: %w", err)
+	}
+
+	exists := make(map[string]bool, len(versions))
+	for _, version := range versions {
+		exists[version.ID] = true
+	}
+	var roots []models.RecipeVersion
+	children := make(map[string][]models.RecipeVersion)
+	for _, version := range versions {
+		if version.ParentID == nil || !exists[*version.ParentID] {
+			roots = append(roots, version)
+			continue
+		}
+		children[*version.ParentID] = append(children[*version.ParentID], version)
+	}
+
+	tree := make([]models.RecipeVersionSummary, 0, len(versions))
+	var visit func(version models.RecipeVersion)
+	visit = func(version models.RecipeVersion) {
+		tree = append(tree, models.RecipeVersionSummary{
+			ID:        version.ID,
+			ParentID:  version.ParentID,
+			Title:     version.Title,
+			Notes:     version.Notes,
+			IsLatest:  version.ID == current.LatestVersionID,
+			CreatedAt: version.CreatedAt,
+		})
+		for _, child := range children[version.ID] {
+			visit(child)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	return tree, nil
+}
+
+// DiffRecipeVersions compares two of the recipe's versions. An empty toID
+// compares against the latest version.
+func (s *RecipeService) DiffRecipeVersions(ctx context.Context, userID string, recipeID string, fromID string, toID string) (*models.RecipeDiff, error) {
+	if toID == "" {
+		current, err := s.GetUserRecipe(ctx, userID, recipeID)
+		if err != nil {
+			return nil, err
+		}
+		toID = current.LatestVersionID
+	}
+	from, err := s.GetRecipeVersion(ctx, userID, recipeID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetRecipeVersion(ctx, userID, recipeID, toID)
+	if err != nil {
+		return nil, err
+	}
+	return GetRecipeDiff(&from.RecipeBody, &to.RecipeBody), nil
+}
+
+// RestoreVersion saves a copy of one of the recipe's versions as its new
+// latest version, so the versions in between stay in its history.
+func (s *RecipeService) RestoreVersion(ctx context.Context, userID string, recipeID string, versionID string) (*models.UserRecipe, error) {
+	version, err := s.GetRecipeVersion(ctx, userID, recipeID, versionID)
+	if err != nil {
+		return nil, err
+	}
+	notes := fmt.Sprintf("Restored from version %s", version.ID)
+	if err := s.addVersion(ctx, userID, recipeID, version.RecipeBody, &notes); err != nil {
+		return nil, err
+	}
+	logger.Logger(ctx).Debug("restored recipe version")
+	return s.GetUserRecipe(ctx, userID, recipeID)
+}
+
+// MergeVersions merges the version theirsID into oursID, which defaults to the
+// latest version, starting from the newest version both were made from. Any
+// resolutions are applied to the conflicts.
+func (s *RecipeService) MergeVersions(ctx context.Context, userID string, recipeID string, oursID string, theirsID string, resolutions []models.MergeResolution) (*models.RecipeMerge, error) {
+	current, err := s.GetUserRecipe(ctx, userID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	if oursID == "" {
+		oursID = current.LatestVersionID
+	}
+	store := s.getStore(ctx)
+	versions, err := store.ListRecipeVersions(ctx, userID, recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recipe versions: %w", err)
+	}
+	byID := make(map[string]models.RecipeVersion, len(versions))
+	for _, version := range versions {
+		byID[version.ID] = version
+	}
+	ours, ok := byID[oursID]
+	if !ok {
+		return nil, ErrRecipeVersionNotFound
+	}
+	theirs, ok := byID[theirsID]
+	if !ok {
+		return nil, ErrRecipeVersionNotFound
+	}
+	base, err := commonAncestor(byID, ours, theirs)
+	if err != nil {
+		return nil, err
+	}
+
+	sides := make(map[string]models.MergeSide, len(resolutions))
+	for _, resolution := range resolutions {
+		sides[resolution.ConflictID] = resolution.Side
+	}
+	merged, conflicts, err := MergeWith(base.RecipeBody, ours.RecipeBody, theirs.RecipeBody, sides)
+	if err != nil {
+		return nil, err
+	}
+	return &models.RecipeMerge{
+		BaseVersionID:   base.ID,
+		OursVersionID:   ours.ID,
+		TheirsVersionID: theirs.ID,
+		Recipe:          merged,
+		Conflicts:       conflicts,
+	}, nil
+}
+
+// CommitMerge saves a merge as the recipe's new latest version. Every
+// conflict must be resolved, otherwise it fails with an
+// UnresolvedConflictsError.
+func (s *RecipeService) CommitMerge(ctx context.Context, userID string, recipeID string, request models.MergeRecipeVersionsRequest) (*models.UserRecipe, error) {
+	merge, err := s.MergeVersions(ctx, userID, recipeID, request.OursVersionID, request.TheirsVersionID, request.Resolutions)
+	if err != nil {
+		return nil, err
+	}
+	if len(merge.Conflicts) > 0 {
+		ids := make([]string, len(merge.Conflicts))
+		for i, conflict := range merge.Conflicts {
+			ids[i] = conflict.ID
+		}
+		return nil, &UnresolvedConflictsError{ConflictIDs: ids}
+	}
+	notes := fmt.Sprintf("Merged version %s into version %s", merge.TheirsVersionID, merge.OursVersionID)
+	if err := s.addVersion(ctx, userID, recipeID, merge.Recipe, &notes); err != nil {
+		return nil, err
+	}
+	logger.Logger(ctx).Debug("merged recipe versions")
+	return s.GetUserRecipe(ctx, userID, recipeID)
+}
+
+// commonAncestor returns the newest version that both versions were made
+// from, which may be one of the versions themselves.
+func commonAncestor(byID map[string]models.RecipeVersion, ours models.RecipeVersion, theirs models.RecipeVersion) (models.RecipeVersion, error) {
+	ancestors := make(map[string]bool)
+	for version, ok := ours, true; ok && !ancestors[version.ID]; {
+		ancestors[version.ID] = true
+		if version.ParentID == nil {
+			break
+		}
+		version, ok = byID[*version.ParentID]
+	}
+	seen := make(map[string]bool)
+	for version, ok := theirs, true; ok && !seen[version.ID]; {
+		if ancestors[version.ID] {
+			return version, nil
+		}
+		seen[version.ID] = true
+		if version.ParentID == nil {
+			break
+		}
+		version, ok = byID[*version.ParentID]
+	}
+	return models.RecipeVersion{}, ErrNoCommonVersion
+}
+
+// UndoVersion moves the recipe back to the version its latest version was
+// made from and returns it. The newer version is kept, so RedoVersion can move
+// forward to it again.
+func (s *RecipeService) UndoVersion(ctx context.Context, userID string, recipeID string) (*models.RecipeVersion, error) {
+	current, err := s.GetUserRecipe(ctx, userID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := s.GetRecipeVersion(ctx, userID, recipeID, current.LatestVersionID)
+	if err != nil {
+		return nil, err
+	}
+	if latest.ParentID == nil {
+		return nil, ErrNoParentVersion
+	}
+	parent, err := s.GetRecipeVersion(ctx, userID, recipeID, *latest.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setLatestVersion(ctx, userID, recipeID, *parent); err != nil {
+		return nil, err
+	}
+	logger.Logger(ctx).Debug("moved recipe back to parent version")
+	return parent, nil
+}
+
+// RedoVersion moves the recipe forward to versionID, which must have been made
+// from the recipe's latest version, and returns it.
+func (s *RecipeService) RedoVersion(ctx context.Context, userID string, recipeID string, versionID string) (*models.RecipeVersion, error) {
+	current, err := s.GetUserRecipe(ctx, userID, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	child, err := s.GetRecipeVersion(ctx, userID, recipeID, versionID)
+	if err != nil {
+		return nil, err
+	}
+	if child.ParentID == nil || *child.ParentID != current.LatestVersionID {
+		return nil, ErrNotChildVersion
+	}
+	if err := s.setLatestVersion(ctx, userID, recipeID, *child); err != nil {
+		return nil, err
+	}
+	logger.Logger(ctx).Debug("moved recipe forward to child version")
+	return child, nil
+}
+
+func (s *RecipeService) setLatestVersion(ctx context.Context, userID string, recipeID string, version models.RecipeVersion) error {
+	store := s.getStore(ctx)
+	if err := store.UpdateUserRecipeVersion(ctx, userID, recipeID, version); err != nil {
+		return fmt.Errorf("failed to update user recipe version: %w", err)
+	}
+	return nil
+}
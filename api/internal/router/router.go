@@ -3,9 +3,12 @@ package router
 import (
 	"net/http"
 
+	"github.com/ajohnston1219/eatme/api/internal/auth"
 	"github.com/ajohnston1219/eatme/api/internal/chat"
 	"github.com/ajohnston1219/eatme/api/internal/clients"
+	"github.com/ajohnston1219/eatme/api/internal/collection"
 	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/mealplan"
 	"github.com/ajohnston1219/eatme/api/internal/middleware"
 	"github.com/ajohnston1219/eatme/api/internal/recipe"
 	"github.com/ajohnston1219/eatme/api/internal/thread"
@@ -19,12 +22,14 @@ import (
 type App struct {
 	store    db.Store
 	mlClient clients.MLClient
+	tokens   *auth.TokenManager
 }
 
-func NewApp(store db.Store, mlClient clients.MLClient) *App {
+func NewApp(store db.Store, mlClient clients.MLClient, tokens *auth.TokenManager) *App {
 	return &App{
 		store:    store,
 		mlClient: mlClient,
+		tokens:   tokens,
 	}
 }
 
@@ -44,15 +49,19 @@ func NewRouter(app *App) *chi.Mux {
 	r.Get("/images/*", http.StripPrefix("/images/", fs).ServeHTTP)
 
 	// Services
-	userService := user.NewUserService(app.store)
+	userService := user.NewUserService(app.store, app.tokens)
 	recipeService := recipe.NewRecipeService(app.store)
 	chatService := chat.NewChatService(app.mlClient)
-	threadService := thread.NewThreadService(app.store, recipeService, chatService)
+	threadService := thread.NewThreadService(app.store, userService, recipeService, chatService)
+	mealPlanService := mealplan.NewMealPlanService(app.store, recipeService)
+	collectionService := collection.NewCollectionService(app.store, recipeService)
 
 	// Handlers
 	userHandler := user.NewUserHandler(userService)
 	threadHandler := thread.NewThreadHandler(threadService)
 	recipeHandler := recipe.NewRecipeHandler(recipeService)
+	mealPlanHandler := mealplan.NewMealPlanHandler(mealPlanService)
+	collectionHandler := collection.NewCollectionHandler(collectionService)
 
 	// Swagger UI
 	r.Get("/swagger/*", httpSwagger.Handler(
@@ -62,32 +71,99 @@ func NewRouter(app *App) *chi.Mux {
 	// User
 	r.Post("/signup", userHandler.Signup)
 	r.Post("/login", userHandler.Login)
+	r.Post("/token/refresh", userHandler.RefreshToken)
+	r.Post("/logout", userHandler.Logout)
 	r.Route("/profile", func(r chi.Router) {
-		r.Use(middleware.AuthMiddleware(app.store))
+		r.Use(middleware.AuthMiddleware(app.tokens))
 		r.Put("/", userHandler.SaveProfile)
 		r.Get("/", userHandler.GetProfile)
 	})
 
 	// Recipe
 	r.Route("/recipes", func(r chi.Router) {
-		r.Use(middleware.AuthMiddleware(app.store))
+		r.Use(middleware.AuthMiddleware(app.tokens))
 		r.Get("/", recipeHandler.GetAllRecipes)
+		r.Get("/tags", recipeHandler.GetRecipeTags)
+		r.Post("/import", recipeHandler.ImportRecipe)
 		r.Get("/{recipeId}", recipeHandler.GetRecipe)
-		r.Post("/{recipeId}/modify/chat", threadHandler.ModifyRecipeViaChat)
-		r.Post("/{recipeId}/modify/accept", threadHandler.AcceptRecipeModification)
-		r.Post("/{recipeId}/modify/reject", threadHandler.RejectRecipeModification)
+		r.Post("/{recipeId}/favorite", recipeHandler.FavoriteRecipe)
+		r.Delete("/{recipeId}/favorite", recipeHandler.UnfavoriteRecipe)
+		r.Get("/{recipeId}/versions", recipeHandler.GetRecipeVersions)
+		r.Get("/{recipeId}/versions/{versionId}", recipeHandler.GetRecipeVersion)
+		r.Get("/{recipeId}/diff", recipeHandler.GetRecipeDiff)
+		r.Get("/{recipeId}/merge", recipeHandler.GetRecipeMerge)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.Idempotency(app.store))
+			r.Post("/{recipeId}/versions/{versionId}/restore", threadHandler.RestoreRecipeVersion)
+			r.Post("/{recipeId}/merge", threadHandler.MergeRecipeVersions)
+			r.Post("/", threadHandler.CreateRecipe)
+			r.Put("/{recipeId}", threadHandler.UpdateRecipe)
+			r.Patch("/{recipeId}", threadHandler.PatchRecipe)
+			r.Post("/{recipeId}/scale", threadHandler.ScaleRecipe)
+			r.Put("/{recipeId}/tags", recipeHandler.SetRecipeTags)
+			r.Post("/{recipeId}/modify/chat", threadHandler.ModifyRecipeViaChat)
+			r.Post("/{recipeId}/modify/chat/stream", threadHandler.ModifyRecipeViaChatStream)
+			r.Post("/{recipeId}/modify/accept", threadHandler.AcceptRecipeModification)
+			r.Post("/{recipeId}/modify/reject", threadHandler.RejectRecipeModification)
+			r.Post("/{recipeId}/modify/undo", threadHandler.UndoRecipeModification)
+			r.Post("/{recipeId}/modify/redo", threadHandler.RedoRecipeModification)
+		})
 		r.Delete("/{recipeId}", recipeHandler.DeleteRecipe)
 	})
 
+	// Catalog
+	r.Route("/catalog", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware(app.tokens))
+		r.Get("/", recipeHandler.GetCatalog)
+		r.Get("/{globalRecipeId}", recipeHandler.GetCatalogRecipe)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.Idempotency(app.store))
+			r.Post("/{globalRecipeId}/fork", threadHandler.ForkCatalogRecipe)
+		})
+	})
+
 	// Thread
 	r.Route("/thread", func(r chi.Router) {
-		r.Use(middleware.AuthMiddleware(app.store))
-		r.Post("/suggest", threadHandler.StartSuggestionThread)
-		r.Post("/{threadId}/suggest", threadHandler.GetNewSuggestions)
-		r.Post("/{threadId}/accept/{suggestionId}", threadHandler.AcceptSuggestion)
-		r.Post("/{threadId}/modify/chat", threadHandler.ModifyRecipeViaChat)
-		r.Post("/{threadId}/question", threadHandler.AnswerCookingQuestion)
+		r.Use(middleware.AuthMiddleware(app.tokens))
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.Idempotency(app.store))
+			r.Post("/suggest", threadHandler.StartSuggestionThread)
+			r.Post("/suggest/stream", threadHandler.StartSuggestionThreadStream)
+			r.Post("/{threadId}/suggest", threadHandler.GetNewSuggestions)
+			r.Post("/{threadId}/accept/{suggestionId}", threadHandler.AcceptSuggestion)
+			r.Post("/{threadId}/question", threadHandler.AnswerCookingQuestion)
+			r.Post("/{threadId}/question/stream", threadHandler.AnswerCookingQuestionStream)
+		})
+		r.Get("/", threadHandler.ListThreads)
 		r.Get("/{threadId}", threadHandler.GetThread)
+		r.Get("/{threadId}/events", threadHandler.GetThreadEvents)
+	})
+
+	// Meal Plan
+	r.Route("/plans", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware(app.tokens))
+		r.Get("/", mealPlanHandler.GetAllPlans)
+		r.Post("/", mealPlanHandler.CreatePlan)
+		r.Get("/{planId}", mealPlanHandler.GetPlan)
+		r.Put("/{planId}", mealPlanHandler.UpdatePlan)
+		r.Delete("/{planId}", mealPlanHandler.DeletePlan)
+		r.Get("/{planId}/shopping-list", mealPlanHandler.GetShoppingList)
+		r.Post("/{planId}/recipes", mealPlanHandler.AddRecipe)
+		r.Put("/{planId}/recipes/{entryId}", mealPlanHandler.MoveRecipe)
+		r.Delete("/{planId}/recipes/{entryId}", mealPlanHandler.RemoveRecipe)
+	})
+
+	// Collection
+	r.Route("/collections", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware(app.tokens))
+		r.Get("/", collectionHandler.GetAllCollections)
+		r.Post("/", collectionHandler.CreateCollection)
+		r.Get("/{collectionId}", collectionHandler.GetCollection)
+		r.Put("/{collectionId}", collectionHandler.UpdateCollection)
+		r.Delete("/{collectionId}", collectionHandler.DeleteCollection)
+		r.Post("/{collectionId}/recipes", collectionHandler.AddRecipe)
+		r.Put("/{collectionId}/recipes/{recipeId}", collectionHandler.MoveRecipe)
+		r.Delete("/{collectionId}/recipes/{recipeId}", collectionHandler.RemoveRecipe)
 	})
 
 	return r
@@ -7,5 +7,14 @@ var (
 	ErrThreadNotAssociatedWithRecipeVersion = errors.New("thread not associated with recipe version")
 	ErrInvalidThreadEventType               = errors.New("invalid thread event type")
 	ErrInvalidThreadEventPayload            = errors.New("invalid thread event payload")
+	ErrUnsupportedThreadEventSchema         = errors.New("unsupported thread event schema version")
 	ErrSuggestionNotFound                   = errors.New("suggestion not found")
+	ErrThreadVersionConflict                = errors.New("thread was changed by another request")
+	ErrInvalidThreadType                    = errors.New("invalid thread type")
+	ErrInvalidCursor                        = errors.New("invalid cursor")
+	ErrInvalidListLimit                     = errors.New("invalid list limit")
+	ErrThreadVersionOutOfRange              = errors.New("thread version out of range")
+	ErrNothingToUndo                        = errors.New("nothing to undo")
+	ErrNothingToRedo                        = errors.New("nothing to redo")
+	ErrNoPendingModification                = errors.New("no pending recipe modification")
 )
@@ -0,0 +1,182 @@
+package thread
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+// eventSchema describes how the payload of one type of event is encoded.
+type eventSchema struct {
+	// version is the schema version new payloads are written with. Bump it
+	// when the payload struct changes in a way older payloads don't decode
+	// into, and add an upcaster from the old version.
+	version int
+	decode  payloadDecoder
+	// upcasters migrate a payload from the version they're keyed by to the
+	// next one, so old payloads are upcast one version at a time until
+	// they're current
+	upcasters map[int]upcaster
+	// repairs fix payloads of the version they're keyed by that are known to
+	// have been written malformed
+	repairs map[int]payloadRepair
+}
+
+type payloadDecoder func(payload json.RawMessage) (models.ThreadEventPayload, error)
+
+type upcaster func(payload json.RawMessage) (json.RawMessage, error)
+
+// payloadRepair rewrites a malformed payload into one that decodes, or
+// reports false if the payload isn't malformed the way it knows how to fix.
+type payloadRepair func(payload json.RawMessage) (json.RawMessage, bool)
+
+func schema[T models.ThreadEventPayload](version int) eventSchema {
+	return eventSchema{version: version, decode: decodeAs[T]}
+}
+
+func decodeAs[T models.ThreadEventPayload](payload json.RawMessage) (models.ThreadEventPayload, error) {
+	var v T
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// eventSchemas registers the payload of every event type. Events of types
+// missing here can be neither written nor reduced.
+var eventSchemas = map[models.ThreadEventType]eventSchema{
+	models.ThreadEventTypePromptSet: {
+		version: 1,
+		decode:  decodeAs[models.PromptSetEvent],
+		repairs: map[int]payloadRepair{1: repairConcatenatedPrompt},
+	},
+	models.ThreadEventTypePromptEdited:               schema[models.PromptEditedEvent](1),
+	models.ThreadEventTypeSuggestionGenerated:        schema[models.SuggestionGeneratedEvent](1),
+	models.ThreadEventTypeSuggestionAccepted:         schema[models.SuggestionAcceptedEvent](1),
+	models.ThreadEventTypeSuggestionRejected:         schema[models.SuggestionRejectedEvent](1),
+	models.ThreadEventTypeRecipeModified:             schema[models.RecipeModifiedEvent](1),
+	models.ThreadEventTypeRecipeModificationAccepted: schema[models.RecipeModificationAcceptedEvent](1),
+	models.ThreadEventTypeRecipeModificationRejected: schema[models.RecipeModificationRejectedEvent](1),
+	models.ThreadEventTypeRecipeModificationUndone:   schema[models.RecipeModificationUndoneEvent](1),
+	models.ThreadEventTypeRecipeModificationRedone:   schema[models.RecipeModificationRedoneEvent](1),
+	models.ThreadEventTypeRecipeCreated:              schema[models.RecipeCreatedEvent](1),
+	models.ThreadEventTypeRecipeEdited:               schema[models.RecipeEditedEvent](1),
+	models.ThreadEventTypeQuestionAnswered:           schema[models.QuestionAnsweredEvent](1),
+	models.ThreadEventTypeEventDiscarded:             schema[models.EventDiscardedEvent](1),
+}
+
+// repairConcatenatedPrompt recovers the prompt from PromptSet payloads that
+// were built by pasting the prompt between quotes, which left any quotes,
+// backslashes or newlines in it unescaped.
+func repairConcatenatedPrompt(payload json.RawMessage) (json.RawMessage, bool) {
+	prompt, ok := strings.CutPrefix(string(payload), `{"prompt":"`)
+	if !ok {
+		return nil, false
+	}
+	prompt, ok = strings.CutSuffix(prompt, `"}`)
+	if !ok {
+		return nil, false
+	}
+	repaired, err := json.Marshal(models.PromptSetEvent{Prompt: prompt})
+	if err != nil {
+		return nil, false
+	}
+	return repaired, true
+}
+
+// NewThreadEvent builds an event of the type payload belongs to, encoding
+// the payload with the current version of its schema.
+func NewThreadEvent(payload models.ThreadEventPayload) (models.ThreadEvent, error) {
+	eventType := payload.ThreadEventType()
+	schema, ok := eventSchemas[eventType]
+	if !ok {
+		return models.ThreadEvent{}, fmt.Errorf("%w: %s", ErrInvalidThreadEventType, eventType)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return models.ThreadEvent{}, fmt.Errorf("%w: %s: %s", ErrInvalidThreadEventPayload, eventType, err)
+	}
+	return models.ThreadEvent{
+		Type:          eventType,
+		SchemaVersion: schema.version,
+		Payload:       data,
+		Timestamp:     time.Now(),
+	}, nil
+}
+
+// UpcastThreadEvent migrates an event's payload to the current version of
+// its schema. Events that are already current are returned as they are.
+func UpcastThreadEvent(event models.ThreadEvent) (models.ThreadEvent, error) {
+	schema, ok := eventSchemas[event.Type]
+	if !ok {
+		return event, fmt.Errorf("%w: %s", ErrInvalidThreadEventType, event.Type)
+	}
+	version := max(event.SchemaVersion, 1)
+	if version > schema.version {
+		return event, fmt.Errorf("%w: %s version %d", ErrUnsupportedThreadEventSchema, event.Type, version)
+	}
+	payload := event.Payload
+	for ; version < schema.version; version++ {
+		upcast, ok := schema.upcasters[version]
+		if !ok {
+			return event, fmt.Errorf("%w: no upcaster from %s version %d", ErrUnsupportedThreadEventSchema, event.Type, version)
+		}
+		upcastPayload, err := upcast(payload)
+		if err != nil {
+			return event, fmt.Errorf("%w: %s version %d: %s", ErrInvalidThreadEventPayload, event.Type, version, err)
+		}
+		payload = upcastPayload
+	}
+	event.SchemaVersion = version
+	event.Payload = payload
+	return event, nil
+}
+
+// DecodeThreadEvent decodes an event's payload into the struct registered
+// for its type, upcasting it first if it was written with an older version
+// of the schema.
+func DecodeThreadEvent(event models.ThreadEvent) (models.ThreadEventPayload, error) {
+	event, err := UpcastThreadEvent(event)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := eventSchemas[event.Type].decode(event.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidThreadEventPayload, event.Type, err)
+	}
+	return payload, nil
+}
+
+// RepairThreadEvent rewrites an event that doesn't decode into one that
+// does, upcast to the current version of its schema. It only knows how to
+// fix the ways payloads are known to have been written malformed, and fails
+// with the reason the event doesn't decode for anything else. Events that
+// already decode are returned as they are.
+func RepairThreadEvent(event models.ThreadEvent) (models.ThreadEvent, error) {
+	_, err := DecodeThreadEvent(event)
+	if err == nil || !errors.Is(err, ErrInvalidThreadEventPayload) {
+		return event, err
+	}
+	repair, ok := eventSchemas[event.Type].repairs[max(event.SchemaVersion, 1)]
+	if !ok {
+		return event, err
+	}
+	payload, ok := repair(event.Payload)
+	if !ok {
+		return event, err
+	}
+	repaired := event
+	repaired.Payload = payload
+	repaired, err = UpcastThreadEvent(repaired)
+	if err != nil {
+		return event, err
+	}
+	if _, err := DecodeThreadEvent(repaired); err != nil {
+		return event, err
+	}
+	return repaired, nil
+}
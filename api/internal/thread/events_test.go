@@ -0,0 +1,244 @@
+package thread
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestEventSchemas(t *testing.T) {
+	for eventType, schema := range eventSchemas {
+		payload, err := schema.decode(json.RawMessage(`{}`))
+		if err != nil {
+			t.Fatalf("failed to decode %s: %v", eventType, err)
+		}
+		if payload.ThreadEventType() != eventType {
+			t.Errorf("expected %s to decode into its own payload, got %T", eventType, payload)
+		}
+	}
+}
+
+func TestNewThreadEvent(t *testing.T) {
+	prompt := `a "quick" soup \ no onions`
+	event, err := NewThreadEvent(models.PromptSetEvent{Prompt: prompt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != models.ThreadEventTypePromptSet || event.SchemaVersion != 1 {
+		t.Fatalf("expected a version 1 PromptSet event, got %s version %d", event.Type, event.SchemaVersion)
+	}
+	state, err := ReduceThreadEvents(context.Background(), "thread", []models.ThreadEvent{event}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.OriginalPrompt != prompt {
+		t.Errorf("expected prompt %q, got %q", prompt, state.OriginalPrompt)
+	}
+}
+
+func TestDecodeThreadEvent(t *testing.T) {
+	// Version 2 renamed the prompt to text and version 3 renamed it back, so
+	// version 1 and 2 payloads are upcast to decode
+	original := eventSchemas[models.ThreadEventTypePromptSet]
+	t.Cleanup(func() { eventSchemas[models.ThreadEventTypePromptSet] = original })
+	rename := func(from, to string) upcaster {
+		return func(payload json.RawMessage) (json.RawMessage, error) {
+			var v map[string]any
+			if err := json.Unmarshal(payload, &v); err != nil {
+				return nil, err
+			}
+			v[to] = v[from]
+			delete(v, from)
+			return json.Marshal(v)
+		}
+	}
+	eventSchemas[models.ThreadEventTypePromptSet] = eventSchema{
+		version: 3,
+		decode:  original.decode,
+		upcasters: map[int]upcaster{
+			1: rename("prompt", "text"),
+			2: rename("text", "prompt"),
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		event    models.ThreadEvent
+		expected models.ThreadEventPayload
+		err      error
+	}{
+		{
+			name:     "current version",
+			event:    models.ThreadEvent{Type: models.ThreadEventTypePromptSet, SchemaVersion: 3, Payload: json.RawMessage(`{"prompt":"soup"}`)},
+			expected: models.PromptSetEvent{Prompt: "soup"},
+		},
+		{
+			name:     "previous version",
+			event:    models.ThreadEvent{Type: models.ThreadEventTypePromptSet, SchemaVersion: 2, Payload: json.RawMessage(`{"text":"soup"}`)},
+			expected: models.PromptSetEvent{Prompt: "soup"},
+		},
+		{
+			name:     "unversioned",
+			event:    models.ThreadEvent{Type: models.ThreadEventTypePromptSet, Payload: json.RawMessage(`{"prompt":"soup"}`)},
+			expected: models.PromptSetEvent{Prompt: "soup"},
+		},
+		{
+			name:  "newer version",
+			event: models.ThreadEvent{Type: models.ThreadEventTypePromptSet, SchemaVersion: 4, Payload: json.RawMessage(`{"prompt":"soup"}`)},
+			err:   ErrUnsupportedThreadEventSchema,
+		},
+		{
+			name:  "upcast fails",
+			event: models.ThreadEvent{Type: models.ThreadEventTypePromptSet, SchemaVersion: 2, Payload: json.RawMessage(`"soup"`)},
+			err:   ErrInvalidThreadEventPayload,
+		},
+		{
+			name:  "unknown type",
+			event: models.ThreadEvent{Type: "PromptDeleted", SchemaVersion: 1, Payload: json.RawMessage(`{}`)},
+			err:   ErrInvalidThreadEventType,
+		},
+		{
+			name:  "malformed payload",
+			event: models.ThreadEvent{Type: models.ThreadEventTypePromptSet, SchemaVersion: 3, Payload: json.RawMessage(`{"prompt":`)},
+			err:   ErrInvalidThreadEventPayload,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			payload, err := DecodeThreadEvent(tc.event)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected %v, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if payload != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, payload)
+			}
+		})
+	}
+}
+
+func TestRepairThreadEvent(t *testing.T) {
+	testCases := []struct {
+		name     string
+		event    models.ThreadEvent
+		expected string
+		err      error
+	}{
+		{
+			name:     "concatenated prompt",
+			event:    models.ThreadEvent{Type: models.ThreadEventTypePromptSet, Payload: json.RawMessage(`{"prompt":"a "quick" soup \ no onions"}`)},
+			expected: `{"prompt":"a \"quick\" soup \\ no onions"}`,
+		},
+		{
+			name:     "valid",
+			event:    models.ThreadEvent{Type: models.ThreadEventTypePromptSet, SchemaVersion: 1, Payload: json.RawMessage(`{"prompt":"soup"}`)},
+			expected: `{"prompt":"soup"}`,
+		},
+		{
+			name:  "no repair",
+			event: models.ThreadEvent{Type: models.ThreadEventTypePromptEdited, SchemaVersion: 1, Payload: json.RawMessage(`{"prompt":"a "quick" soup"}`)},
+			err:   ErrInvalidThreadEventPayload,
+		},
+		{
+			name:  "unknown type",
+			event: models.ThreadEvent{Type: "PromptDeleted", SchemaVersion: 1, Payload: json.RawMessage(`{}`)},
+			err:   ErrInvalidThreadEventType,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repaired, err := RepairThreadEvent(tc.event)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected %v, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(repaired.Payload) != tc.expected || repaired.SchemaVersion != 1 {
+				t.Errorf("expected version 1 payload %s, got version %d %s", tc.expected, repaired.SchemaVersion, repaired.Payload)
+			}
+		})
+	}
+}
+
+func TestUpcastStoredThreadEvents(t *testing.T) {
+	// Version 2 of PromptEdited stores the prompt as text, so stored version
+	// 1 events have to be upcast when the thread is read
+	original := eventSchemas[models.ThreadEventTypePromptEdited]
+	t.Cleanup(func() { eventSchemas[models.ThreadEventTypePromptEdited] = original })
+	eventSchemas[models.ThreadEventTypePromptEdited] = eventSchema{
+		version: 2,
+		decode: func(payload json.RawMessage) (models.ThreadEventPayload, error) {
+			var v struct {
+				Text string `json:"text"`
+			}
+			if err := json.Unmarshal(payload, &v); err != nil {
+				return nil, err
+			}
+			return models.PromptEditedEvent{Prompt: v.Text}, nil
+		},
+		upcasters: map[int]upcaster{
+			1: func(payload json.RawMessage) (json.RawMessage, error) {
+				var v models.PromptEditedEvent
+				if err := json.Unmarshal(payload, &v); err != nil {
+					return nil, err
+				}
+				return json.Marshal(map[string]string{"text": v.Prompt})
+			},
+		},
+	}
+
+	ctx := context.Background()
+	store := newTestStore(t)
+	service := NewThreadService(store, nil, nil, nil)
+	user, err := store.CreateUser(ctx, "cook@example.com", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	thread := models.Thread{
+		ID:   "thread",
+		Type: models.ThreadTypeSuggestion,
+		Events: []models.ThreadEvent{
+			{Type: models.ThreadEventTypePromptSet, SchemaVersion: 1, Payload: json.RawMessage(`{"prompt":"soup"}`)},
+			{Type: models.ThreadEventTypePromptEdited, SchemaVersion: 1, Payload: json.RawMessage(`{"prompt":"stew"}`)},
+			{Type: models.ThreadEventTypePromptEdited, SchemaVersion: 2, Payload: json.RawMessage(`{"text":"chili"}`)},
+		},
+	}
+	if err := store.CreateThread(ctx, user.ID, thread); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := service.GetThreadStateAtVersion(ctx, user.ID, thread.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.CurrentPrompt != "stew" || len(state.SkippedEvents) != 0 {
+		t.Errorf("expected the version 1 event to be upcast, got %+v", state)
+	}
+	state, err = service.GetThreadState(ctx, user.ID, thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.CurrentPrompt != "chili" || len(state.SkippedEvents) != 0 {
+		t.Errorf("expected the version 2 event to decode as it is, got %+v", state)
+	}
+
+	upcast, err := UpcastThreadEvent(thread.Events[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upcast.SchemaVersion != 2 || string(upcast.Payload) != `{"text":"stew"}` {
+		t.Errorf("expected a version 2 payload, got version %d %s", upcast.SchemaVersion, upcast.Payload)
+	}
+}
@@ -1,14 +1,20 @@
 package thread
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ajohnston1219/eatme/api/internal/api"
+	"github.com/ajohnston1219/eatme/api/internal/clients"
 	"github.com/ajohnston1219/eatme/api/internal/models"
 	recipeService "github.com/ajohnston1219/eatme/api/internal/recipe"
+	"github.com/ajohnston1219/eatme/api/internal/units"
 	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
+	"github.com/ajohnston1219/eatme/api/internal/validation"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
@@ -30,9 +36,16 @@ func NewThreadHandler(threadService *ThreadService) *ThreadHandler {
 // @Accept json
 // @Produce json
 // @Param request body models.StartSuggestionThreadRequest true "Suggestion thread request"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Param unit_system query string false "Measurement system to show ingredients in, defaults to the user's preference" Enums(metric, imperial)
 // @Success 200 {object} models.ThreadState
 // @Failure 400 {object} models.APIError "Invalid input"
 // @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 409 {object} models.APIError "A request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Request rejected by the recipe assistant, or Idempotency-Key reused for a different request"
+// @Failure 502 {object} models.APIError "Recipe assistant failed"
+// @Failure 503 {object} models.APIError "Recipe assistant unavailable"
+// @Failure 504 {object} models.APIError "Recipe assistant timed out"
 // @Failure 500 {object} models.APIError "Internal server error"
 // @Router /thread/suggest [post]
 func (h *ThreadHandler) StartSuggestionThread(w http.ResponseWriter, r *http.Request) {
@@ -43,23 +56,33 @@ func (h *ThreadHandler) StartSuggestionThread(w http.ResponseWriter, r *http.Req
 	}
 
 	var input models.StartSuggestionThreadRequest
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	if err := api.DecodeJSON(r, &input); err != nil {
 		logger.Logger(r.Context()).Error("failed to decode start suggestion thread request", zap.Error(err))
-		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+
+	system, ok := h.unitSystem(w, r, userID)
+	if !ok {
 		return
 	}
 
 	threadState, err := h.threadService.StartSuggestionThread(r.Context(), userID, input.Prompt)
 	if err != nil {
 		logger.Logger(r.Context()).Error("failed to start suggestion thread", zap.Error(err))
+		var mlErr *clients.MLError
 		switch {
 		case errors.Is(err, ErrThreadNotFound):
 			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrThreadNotFound)
+		case errors.As(err, &mlErr):
+			status, apiErr := mlErrorResponse(mlErr)
+			api.ErrorJSON(w, status, apiErr)
 		default:
 			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
 		}
 		return
 	}
+	localizeThreadState(threadState, system)
 	api.WriteJSON(w, http.StatusOK, threadState)
 }
 
@@ -71,9 +94,17 @@ func (h *ThreadHandler) StartSuggestionThread(w http.ResponseWriter, r *http.Req
 // @Produce json
 // @Param threadId path string true "Thread ID"
 // @Param request body models.GetNewSuggestionsRequest true "Get new suggestions request"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Param unit_system query string false "Measurement system to show ingredients in, defaults to the user's preference" Enums(metric, imperial)
 // @Success 200 {object} []models.RecipeSuggestion
+// @Failure 400 {object} models.APIError "Invalid input"
 // @Failure 401 {object} models.APIError "Unauthorized"
 // @Failure 404 {object} models.APIError "Thread not found"
+// @Failure 409 {object} models.APIError "Thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Request rejected by the recipe assistant, or Idempotency-Key reused for a different request"
+// @Failure 502 {object} models.APIError "Recipe assistant failed"
+// @Failure 503 {object} models.APIError "Recipe assistant unavailable"
+// @Failure 504 {object} models.APIError "Recipe assistant timed out"
 // @Failure 500 {object} models.APIError "Internal server error"
 // @Router /thread/{threadId}/suggest [post]
 func (h *ThreadHandler) GetNewSuggestions(w http.ResponseWriter, r *http.Request) {
@@ -90,23 +121,37 @@ func (h *ThreadHandler) GetNewSuggestions(w http.ResponseWriter, r *http.Request
 	}
 
 	var input models.GetNewSuggestionsRequest
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	if err := api.DecodeJSON(r, &input); err != nil {
 		logger.Logger(r.Context()).Error("failed to decode get new suggestions request", zap.Error(err))
-		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+
+	system, ok := h.unitSystem(w, r, userID)
+	if !ok {
 		return
 	}
 
 	suggestions, err := h.threadService.GetNewSuggestions(r.Context(), userID, threadID, input)
 	if err != nil {
 		logger.Logger(r.Context()).Error("failed to get new suggestions", zap.Error(err))
+		var mlErr *clients.MLError
 		switch {
 		case errors.Is(err, ErrThreadNotFound):
 			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrThreadNotFound)
+		case errors.Is(err, ErrThreadVersionConflict):
+			api.ErrorJSON(w, http.StatusConflict, models.ApiErrThreadVersionConflict)
+		case errors.As(err, &mlErr):
+			status, apiErr := mlErrorResponse(mlErr)
+			api.ErrorJSON(w, status, apiErr)
 		default:
 			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
 		}
 		return
 	}
+	for i := range suggestions {
+		suggestions[i].Suggestion = units.LocalizeRecipe(suggestions[i].Suggestion, system)
+	}
 	api.WriteJSON(w, http.StatusOK, suggestions)
 }
 
@@ -118,9 +163,12 @@ func (h *ThreadHandler) GetNewSuggestions(w http.ResponseWriter, r *http.Request
 // @Produce json
 // @Param threadId path string true "Thread ID"
 // @Param suggestionId path string true "Suggestion ID"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
 // @Success 200 {object} models.UserRecipe
 // @Failure 401 {object} models.APIError "Unauthorized"
 // @Failure 404 {object} models.APIError "Thread not found"
+// @Failure 409 {object} models.APIError "Thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Idempotency-Key reused for a different request"
 // @Failure 500 {object} models.APIError "Internal server error"
 // @Router /thread/{threadId}/accept/{suggestionId} [post]
 func (h *ThreadHandler) AcceptSuggestion(w http.ResponseWriter, r *http.Request) {
@@ -148,6 +196,8 @@ func (h *ThreadHandler) AcceptSuggestion(w http.ResponseWriter, r *http.Request)
 		switch {
 		case errors.Is(err, ErrThreadNotFound):
 			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrThreadNotFound)
+		case errors.Is(err, ErrThreadVersionConflict):
+			api.ErrorJSON(w, http.StatusConflict, models.ApiErrThreadVersionConflict)
 		default:
 			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
 		}
@@ -164,9 +214,17 @@ func (h *ThreadHandler) AcceptSuggestion(w http.ResponseWriter, r *http.Request)
 // @Produce json
 // @Param recipeId path string true "Recipe ID"
 // @Param request body models.ModifyRecipeViaChatRequest true "Modify recipe via chat request"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Param unit_system query string false "Measurement system to show ingredients in, defaults to the user's preference" Enums(metric, imperial)
 // @Success 200 {object} models.ModifyRecipeResponse
+// @Failure 400 {object} models.APIError "Invalid input"
 // @Failure 401 {object} models.APIError "Unauthorized"
-// @Failure 404 {object} models.APIError "Thread not found"
+// @Failure 404 {object} models.APIError "Recipe or thread not found"
+// @Failure 409 {object} models.APIError "Thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Request rejected by the recipe assistant, or Idempotency-Key reused for a different request"
+// @Failure 502 {object} models.APIError "Recipe assistant failed"
+// @Failure 503 {object} models.APIError "Recipe assistant unavailable"
+// @Failure 504 {object} models.APIError "Recipe assistant timed out"
 // @Failure 500 {object} models.APIError "Internal server error"
 // @Router /recipes/{recipeId}/modify/chat [post]
 func (h *ThreadHandler) ModifyRecipeViaChat(w http.ResponseWriter, r *http.Request) {
@@ -183,30 +241,38 @@ func (h *ThreadHandler) ModifyRecipeViaChat(w http.ResponseWriter, r *http.Reque
 	}
 
 	var input models.ModifyRecipeViaChatRequest
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	if err := api.DecodeJSON(r, &input); err != nil {
 		logger.Logger(r.Context()).Error("failed to decode modify recipe via chat request", zap.Error(err))
-		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+
+	system, ok := h.unitSystem(w, r, userID)
+	if !ok {
 		return
 	}
 
 	chatResponse, err := h.threadService.ModifyRecipeViaChat(r.Context(), userID, recipeID, input.Prompt)
 	if err != nil {
 		logger.Logger(r.Context()).Error("failed to modify recipe via chat", zap.Error(err))
+		var mlErr *clients.MLError
 		switch {
 		case errors.Is(err, ErrThreadNotFound):
 			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrThreadNotFound)
+		case errors.Is(err, ErrThreadVersionConflict):
+			api.ErrorJSON(w, http.StatusConflict, models.ApiErrThreadVersionConflict)
+		case errors.Is(err, recipeService.ErrRecipeNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeNotFound)
+		case errors.As(err, &mlErr):
+			status, apiErr := mlErrorResponse(mlErr)
+			api.ErrorJSON(w, status, apiErr)
 		default:
 			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
 		}
 		return
 	}
 
-	recipeDiff := recipeService.GetRecipeDiff(&chatResponse.OriginalRecipe, &chatResponse.NewRecipe)
-	response := models.ModifyRecipeResponse{
-		CurrentRecipe: chatResponse.OriginalRecipe,
-		Diff:          *recipeDiff,
-		ResponseText:  chatResponse.ResponseText,
-	}
+	response := modifyRecipeResponse(chatResponse, system)
 	api.WriteJSON(w, http.StatusOK, response)
 }
 
@@ -217,9 +283,12 @@ func (h *ThreadHandler) ModifyRecipeViaChat(w http.ResponseWriter, r *http.Reque
 // @Accept json
 // @Produce json
 // @Param recipeId path string true "Recipe ID"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
 // @Success 204 "Recipe modified"
 // @Failure 401 {object} models.APIError "Unauthorized"
-// @Failure 404 {object} models.APIError "Thread not found"
+// @Failure 404 {object} models.APIError "Recipe or thread not found"
+// @Failure 409 {object} models.APIError "No modification to accept, thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Idempotency-Key reused for a different request"
 // @Failure 500 {object} models.APIError "Internal server error"
 // @Router /recipes/{recipeId}/modify/accept [post]
 func (h *ThreadHandler) AcceptRecipeModification(w http.ResponseWriter, r *http.Request) {
@@ -244,6 +313,12 @@ func (h *ThreadHandler) AcceptRecipeModification(w http.ResponseWriter, r *http.
 		switch {
 		case errors.Is(err, ErrThreadNotFound):
 			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrThreadNotFound)
+		case errors.Is(err, ErrNoPendingModification):
+			api.ErrorJSON(w, http.StatusConflict, models.ApiErrNoPendingModification)
+		case errors.Is(err, ErrThreadVersionConflict):
+			api.ErrorJSON(w, http.StatusConflict, models.ApiErrThreadVersionConflict)
+		case errors.Is(err, recipeService.ErrRecipeNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeNotFound)
 		default:
 			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
 		}
@@ -260,9 +335,12 @@ func (h *ThreadHandler) AcceptRecipeModification(w http.ResponseWriter, r *http.
 // @Accept json
 // @Produce json
 // @Param recipeId path string true "Recipe ID"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
 // @Success 204 "Recipe modified"
 // @Failure 401 {object} models.APIError "Unauthorized"
-// @Failure 404 {object} models.APIError "Thread not found"
+// @Failure 404 {object} models.APIError "Recipe or thread not found"
+// @Failure 409 {object} models.APIError "Thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Idempotency-Key reused for a different request"
 // @Failure 500 {object} models.APIError "Internal server error"
 // @Router /recipes/{recipeId}/modify/reject [post]
 func (h *ThreadHandler) RejectRecipeModification(w http.ResponseWriter, r *http.Request) {
@@ -284,6 +362,10 @@ func (h *ThreadHandler) RejectRecipeModification(w http.ResponseWriter, r *http.
 		switch {
 		case errors.Is(err, ErrThreadNotFound):
 			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrThreadNotFound)
+		case errors.Is(err, ErrThreadVersionConflict):
+			api.ErrorJSON(w, http.StatusConflict, models.ApiErrThreadVersionConflict)
+		case errors.Is(err, recipeService.ErrRecipeNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeNotFound)
 		default:
 			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
 		}
@@ -292,6 +374,378 @@ func (h *ThreadHandler) RejectRecipeModification(w http.ResponseWriter, r *http.
 	api.WriteJSON(w, http.StatusNoContent, nil)
 }
 
+// @Summary Undo a recipe modification
+// @Description Move the recipe back to the version its latest version was made from. The newer version is kept and can be restored with redo.
+// @ID undoRecipeModification
+// @Tags thread
+// @Accept json
+// @Produce json
+// @Param recipeId path string true "Recipe ID"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Success 200 {object} models.UserRecipe
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe or thread not found"
+// @Failure 409 {object} models.APIError "Nothing to undo, thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Idempotency-Key reused for a different request"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId}/modify/undo [post]
+func (h *ThreadHandler) UndoRecipeModification(w http.ResponseWriter, r *http.Request) {
+	h.moveRecipeVersion(w, r, h.threadService.UndoRecipeModification)
+}
+
+// @Summary Redo a recipe modification
+// @Description Move the recipe forward to the version most recently undone. Accepting a modification after an undo discards what could be redone.
+// @ID redoRecipeModification
+// @Tags thread
+// @Accept json
+// @Produce json
+// @Param recipeId path string true "Recipe ID"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Success 200 {object} models.UserRecipe
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe or thread not found"
+// @Failure 409 {object} models.APIError "Nothing to redo, thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Idempotency-Key reused for a different request"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId}/modify/redo [post]
+func (h *ThreadHandler) RedoRecipeModification(w http.ResponseWriter, r *http.Request) {
+	h.moveRecipeVersion(w, r, h.threadService.RedoRecipeModification)
+}
+
+// moveRecipeVersion handles undo and redo, which differ only in the direction
+// they move the recipe.
+func (h *ThreadHandler) moveRecipeVersion(w http.ResponseWriter, r *http.Request, move func(ctx context.Context, userID string, recipeID string) (*models.UserRecipe, error)) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	recipeID := chi.URLParam(r, "recipeId")
+	if recipeID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+
+	recipe, err := move(r.Context(), userID, recipeID)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to move recipe version", zap.Error(err))
+		switch {
+		case errors.Is(err, ErrThreadNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrThreadNotFound)
+		case errors.Is(err, recipeService.ErrRecipeNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrRecipeNotFound)
+		case errors.Is(err, ErrNothingToUndo):
+			api.ErrorJSON(w, http.StatusConflict, models.ApiErrNothingToUndo)
+		case errors.Is(err, ErrNothingToRedo):
+			api.ErrorJSON(w, http.StatusConflict, models.ApiErrNothingToRedo)
+		case errors.Is(err, ErrThreadVersionConflict):
+			api.ErrorJSON(w, http.StatusConflict, models.ApiErrThreadVersionConflict)
+		default:
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, recipe)
+}
+
+// @Summary Create recipe
+// @Description Add a recipe entered by hand. It gets a thread of its own to record its history.
+// @ID createRecipe
+// @Tags Recipe
+// @Accept json
+// @Produce json
+// @Param request body models.RecipeBody true "Recipe"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Success 201 {object} models.UserRecipe
+// @Failure 400 {object} models.APIError "Invalid input, the field says which part of the recipe"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 409 {object} models.APIError "A request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Idempotency-Key reused for a different request"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes [post]
+func (h *ThreadHandler) CreateRecipe(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	var input models.RecipeBody
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode create recipe request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+
+	recipe, err := h.threadService.CreateRecipe(r.Context(), userID, input)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to create recipe", zap.Error(err))
+		status, apiErr := editErrorResponse(err)
+		api.ErrorJSON(w, status, apiErr)
+		return
+	}
+	api.WriteJSON(w, http.StatusCreated, recipe)
+}
+
+// @Summary Fork catalog recipe
+// @Description Copy a global recipe from the catalog into the user's recipe book. The copy stays linked to the global recipe and is flagged when an update is available.
+// @ID forkCatalogRecipe
+// @Tags Catalog
+// @Produce json
+// @Param globalRecipeId path string true "Global recipe ID"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Success 201 {object} models.UserRecipe
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Catalog recipe not found"
+// @Failure 409 {object} models.APIError "A request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Idempotency-Key reused for a different request"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /catalog/{globalRecipeId}/fork [post]
+func (h *ThreadHandler) ForkCatalogRecipe(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	globalRecipeID := chi.URLParam(r, "globalRecipeId")
+	if globalRecipeID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+
+	recipe, err := h.threadService.ForkGlobalRecipe(r.Context(), userID, globalRecipeID)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to fork global recipe", zap.Error(err))
+		switch {
+		case errors.Is(err, recipeService.ErrGlobalRecipeNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrCatalogRecipeNotFound)
+		default:
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
+		return
+	}
+	api.WriteJSON(w, http.StatusCreated, recipe)
+}
+
+// @Summary Update recipe
+// @Description Replace a recipe's contents, saving them as a new version
+// @ID updateRecipe
+// @Tags Recipe
+// @Accept json
+// @Produce json
+// @Param recipeId path string true "Recipe ID"
+// @Param request body models.RecipeBody true "Recipe"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Success 200 {object} models.UserRecipe
+// @Failure 400 {object} models.APIError "Invalid input, the field says which part of the recipe"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe or thread not found"
+// @Failure 409 {object} models.APIError "Thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Idempotency-Key reused for a different request"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId} [put]
+func (h *ThreadHandler) UpdateRecipe(w http.ResponseWriter, r *http.Request) {
+	var input models.RecipeBody
+	h.editRecipe(w, r, &input, func(ctx context.Context, userID string, recipeID string) (*models.UserRecipe, error) {
+		return h.threadService.EditRecipe(ctx, userID, recipeID, input)
+	})
+}
+
+// @Summary Patch recipe
+// @Description Apply a JSON Patch (RFC 6902) to a recipe, saving the result as a new version. Test operations can be used to make sure the recipe hasn't changed since it was read.
+// @ID patchRecipe
+// @Tags Recipe
+// @Accept json
+// @Produce json
+// @Param recipeId path string true "Recipe ID"
+// @Param request body []models.PatchOperation true "JSON Patch"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Success 200 {object} models.UserRecipe
+// @Failure 400 {object} models.APIError "Invalid input or patch, the field says which part of the recipe"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe or thread not found"
+// @Failure 409 {object} models.APIError "A test operation failed, thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Idempotency-Key reused for a different request"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId} [patch]
+func (h *ThreadHandler) PatchRecipe(w http.ResponseWriter, r *http.Request) {
+	var input []models.PatchOperation
+	h.editRecipe(w, r, &input, func(ctx context.Context, userID string, recipeID string) (*models.UserRecipe, error) {
+		return h.threadService.PatchRecipe(ctx, userID, recipeID, input)
+	})
+}
+
+// @Summary Scale recipe
+// @Description Rescale a recipe to a number of servings, saving the result as a new version
+// @ID scaleRecipe
+// @Tags Recipe
+// @Accept json
+// @Produce json
+// @Param recipeId path string true "Recipe ID"
+// @Param request body models.ScaleRecipeRequest true "Scale recipe request"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Success 200 {object} models.UserRecipe
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe or thread not found"
+// @Failure 409 {object} models.APIError "Thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Recipe has no servings to scale from, or Idempotency-Key reused for a different request"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId}/scale [post]
+func (h *ThreadHandler) ScaleRecipe(w http.ResponseWriter, r *http.Request) {
+	var input models.ScaleRecipeRequest
+	h.editRecipe(w, r, &input, func(ctx context.Context, userID string, recipeID string) (*models.UserRecipe, error) {
+		return h.threadService.ScaleRecipe(ctx, userID, recipeID, input.Servings)
+	})
+}
+
+// @Summary Restore recipe version
+// @Description Save a copy of an earlier version as the recipe's new latest version. The versions in between are kept.
+// @ID restoreRecipeVersion
+// @Tags Recipe
+// @Accept json
+// @Produce json
+// @Param recipeId path string true "Recipe ID"
+// @Param versionId path string true "Version ID"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Success 200 {object} models.UserRecipe
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe, version or thread not found"
+// @Failure 409 {object} models.APIError "Thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Idempotency-Key reused for a different request"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId}/versions/{versionId}/restore [post]
+func (h *ThreadHandler) RestoreRecipeVersion(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	recipeID := chi.URLParam(r, "recipeId")
+	versionID := chi.URLParam(r, "versionId")
+	if recipeID == "" || versionID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+
+	recipe, err := h.threadService.RestoreVersion(r.Context(), userID, recipeID, versionID)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to restore recipe version", zap.Error(err))
+		status, apiErr := editErrorResponse(err)
+		api.ErrorJSON(w, status, apiErr)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, recipe)
+}
+
+// @Summary Merge recipe versions
+// @Description Merge the changes made in one version of a recipe with those made in another and save the result as the recipe's new latest version. Every conflict of the merge must be resolved.
+// @ID mergeRecipeVersions
+// @Tags Recipe
+// @Accept json
+// @Produce json
+// @Param recipeId path string true "Recipe ID"
+// @Param request body models.MergeRecipeVersionsRequest true "Merge recipe versions request"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Success 200 {object} models.UserRecipe
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe, version or thread not found"
+// @Failure 409 {object} models.APIError "Merge has unresolved conflicts, listed in the details, thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Versions have no common version, or Idempotency-Key reused for a different request"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId}/merge [post]
+func (h *ThreadHandler) MergeRecipeVersions(w http.ResponseWriter, r *http.Request) {
+	var input models.MergeRecipeVersionsRequest
+	h.editRecipe(w, r, &input, func(ctx context.Context, userID string, recipeID string) (*models.UserRecipe, error) {
+		return h.threadService.CommitMerge(ctx, userID, recipeID, input)
+	})
+}
+
+// editRecipe handles updates, patches, scaling and merges, which differ only
+// in the request body decoded into input and how it's applied.
+func (h *ThreadHandler) editRecipe(w http.ResponseWriter, r *http.Request, input any, edit func(ctx context.Context, userID string, recipeID string) (*models.UserRecipe, error)) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	recipeID := chi.URLParam(r, "recipeId")
+	if recipeID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+
+	if err := api.DecodeJSON(r, input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode edit recipe request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+
+	recipe, err := edit(r.Context(), userID, recipeID)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to edit recipe", zap.Error(err))
+		status, apiErr := editErrorResponse(err)
+		api.ErrorJSON(w, status, apiErr)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, recipe)
+}
+
+// editErrorResponse maps a failed create, update, patch, scale, restore or
+// merge to the response returned to the client. Invalid recipes and patches
+// say what was wrong, and unresolved merge conflicts are listed in the
+// details.
+func editErrorResponse(err error) (int, models.APIError) {
+	var invalidRecipe *validation.Error
+	var invalidPatch *recipeService.PatchError
+	var unresolved *recipeService.UnresolvedConflictsError
+	switch {
+	case errors.As(err, &invalidRecipe):
+		return http.StatusBadRequest, invalidRecipe.APIError()
+	case errors.As(err, &invalidPatch) && errors.Is(err, recipeService.ErrPatchTestFailed):
+		apiErr := models.ApiErrPatchTestFailed
+		apiErr.Details = invalidPatch.Error()
+		return http.StatusConflict, apiErr
+	case errors.As(err, &invalidPatch):
+		apiErr := models.ApiErrInvalidPatch
+		apiErr.Details = invalidPatch.Error()
+		return http.StatusBadRequest, apiErr
+	case errors.Is(err, recipeService.ErrInvalidPatch):
+		return http.StatusBadRequest, models.ApiErrInvalidPatch
+	case errors.Is(err, recipeService.ErrInvalidServings):
+		return http.StatusBadRequest, models.ApiErrInvalidServings
+	case errors.Is(err, recipeService.ErrRecipeHasNoServings):
+		return http.StatusUnprocessableEntity, models.ApiErrRecipeNotScalable
+	case errors.As(err, &unresolved):
+		apiErr := models.ApiErrMergeConflict
+		apiErr.Details = strings.Join(unresolved.ConflictIDs, ", ")
+		return http.StatusConflict, apiErr
+	case errors.Is(err, recipeService.ErrNoCommonVersion):
+		return http.StatusUnprocessableEntity, models.ApiErrNoCommonVersion
+	case errors.Is(err, recipeService.ErrUnknownMergeConflict):
+		return http.StatusBadRequest, models.ApiErrUnknownMergeConflict
+	case errors.Is(err, recipeService.ErrInvalidMergeSide):
+		return http.StatusBadRequest, models.ApiErrInvalidMergeSide
+	case errors.Is(err, ErrThreadNotFound):
+		return http.StatusNotFound, models.ApiErrThreadNotFound
+	case errors.Is(err, recipeService.ErrRecipeNotFound):
+		return http.StatusNotFound, models.ApiErrRecipeNotFound
+	case errors.Is(err, recipeService.ErrRecipeVersionNotFound):
+		return http.StatusNotFound, models.ApiErrRecipeVersionNotFound
+	case errors.Is(err, ErrThreadVersionConflict):
+		return http.StatusConflict, models.ApiErrThreadVersionConflict
+	default:
+		return http.StatusInternalServerError, models.ApiErrInternal
+	}
+}
+
 // @Summary Answer a cooking question
 // @Description Answer a cooking question
 // @ID answerCookingQuestion
@@ -300,9 +754,15 @@ func (h *ThreadHandler) RejectRecipeModification(w http.ResponseWriter, r *http.
 // @Produce json
 // @Param threadId path string true "Thread ID"
 // @Param request body models.AnswerCookingQuestionRequest true "Answer cooking question request"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
 // @Success 200 {object} models.AnswerCookingQuestionResponse
 // @Failure 400 {object} models.APIError "Invalid input"
 // @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 409 {object} models.APIError "Thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Request rejected by the recipe assistant, or Idempotency-Key reused for a different request"
+// @Failure 502 {object} models.APIError "Recipe assistant failed"
+// @Failure 503 {object} models.APIError "Recipe assistant unavailable"
+// @Failure 504 {object} models.APIError "Recipe assistant timed out"
 // @Failure 500 {object} models.APIError "Internal server error"
 // @Router /thread/{threadId}/question [post]
 func (h *ThreadHandler) AnswerCookingQuestion(w http.ResponseWriter, r *http.Request) {
@@ -319,18 +779,24 @@ func (h *ThreadHandler) AnswerCookingQuestion(w http.ResponseWriter, r *http.Req
 	}
 
 	var input models.AnswerCookingQuestionRequest
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	if err := api.DecodeJSON(r, &input); err != nil {
 		logger.Logger(r.Context()).Error("failed to decode answer cooking question request", zap.Error(err))
-		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
 		return
 	}
 
 	response, err := h.threadService.AnswerCookingQuestion(r.Context(), userID, threadID, input.Question)
 	if err != nil {
 		logger.Logger(r.Context()).Error("failed to answer cooking question", zap.Error(err))
+		var mlErr *clients.MLError
 		switch {
 		case errors.Is(err, ErrThreadNotFound):
 			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrThreadNotFound)
+		case errors.Is(err, ErrThreadVersionConflict):
+			api.ErrorJSON(w, http.StatusConflict, models.ApiErrThreadVersionConflict)
+		case errors.As(err, &mlErr):
+			status, apiErr := mlErrorResponse(mlErr)
+			api.ErrorJSON(w, status, apiErr)
 		default:
 			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
 		}
@@ -339,13 +805,77 @@ func (h *ThreadHandler) AnswerCookingQuestion(w http.ResponseWriter, r *http.Req
 	api.WriteJSON(w, http.StatusOK, response)
 }
 
+// @Summary List threads
+// @Description Get the user's threads without their events, optionally filtered by type or linked recipe. Results are ordered newest first and paged; the X-Next-Cursor header holds the cursor for the next page and is absent on the last one.
+// @ID listThreads
+// @Tags thread
+// @Accept json
+// @Produce json
+// @Param type query string false "Only threads of this type" Enums(Suggestion)
+// @Param recipe_id query string false "Only threads linked to this recipe"
+// @Param limit query int false "Page size, 1 to 100, defaults to 50"
+// @Param cursor query string false "X-Next-Cursor from the previous page"
+// @Success 200 {array}  models.ThreadSummary
+// @Header 200 {string} X-Next-Cursor "Cursor for the next page"
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /thread [get]
+func (h *ThreadHandler) ListThreads(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	params := ListParams{
+		Type:     models.ThreadType(query.Get("type")),
+		RecipeID: query.Get("recipe_id"),
+		Cursor:   query.Get("cursor"),
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxListLimit {
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidLimit)
+			return
+		}
+		params.Limit = limit
+	}
+
+	threads, nextCursor, err := h.threadService.ListThreads(r.Context(), userID, params)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidThreadType):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidThreadType)
+		case errors.Is(err, ErrInvalidCursor):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidCursor)
+		case errors.Is(err, ErrInvalidListLimit):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidLimit)
+		default:
+			logger.Logger(r.Context()).Error("failed to list threads", zap.Error(err))
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
+		return
+	}
+	if threads == nil {
+		threads = []models.ThreadSummary{}
+	}
+	if nextCursor != "" {
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
+	api.WriteJSON(w, http.StatusOK, threads)
+}
+
 // @Summary Get a thread
-// @Description Get a thread
+// @Description Get a thread's current state, or with at, the state it had at an earlier version or time
 // @ID getThread
 // @Tags thread
 // @Accept json
 // @Produce json
 // @Param threadId path string true "Thread ID"
+// @Param at query string false "A version of the thread, counting events from 0, or an RFC 3339 timestamp"
+// @Param unit_system query string false "Measurement system to show ingredients in, defaults to the user's preference" Enums(metric, imperial)
 // @Success 200 {object} models.ThreadState
 // @Failure 400 {object} models.APIError "Invalid input"
 // @Failure 401 {object} models.APIError "Unauthorized"
@@ -365,16 +895,351 @@ func (h *ThreadHandler) GetThread(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	threadState, err := h.threadService.GetThreadState(r.Context(), threadID)
+	system, ok := h.unitSystem(w, r, userID)
+	if !ok {
+		return
+	}
+
+	at := r.URL.Query().Get("at")
+	version, versionErr := strconv.Atoi(at)
+	timestamp, timestampErr := time.Parse(time.RFC3339, at)
+	var threadState *models.ThreadState
+	var err error
+	switch {
+	case at == "":
+		threadState, err = h.threadService.GetThreadState(r.Context(), userID, threadID)
+	case versionErr == nil:
+		threadState, err = h.threadService.GetThreadStateAtVersion(r.Context(), userID, threadID, version)
+	case timestampErr == nil:
+		threadState, err = h.threadService.GetThreadStateAtTime(r.Context(), userID, threadID, timestamp)
+	default:
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidThreadAt)
+		return
+	}
 	if err != nil {
 		logger.Logger(r.Context()).Error("failed to get thread state", zap.Error(err))
 		switch {
 		case errors.Is(err, ErrThreadNotFound):
 			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrThreadNotFound)
+		case errors.Is(err, ErrThreadVersionOutOfRange):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidThreadAt)
 		default:
 			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
 		}
 		return
 	}
+	localizeThreadState(threadState, system)
 	api.WriteJSON(w, http.StatusOK, threadState)
 }
+
+// @Summary Get a thread's events
+// @Description Get every event in a thread, oldest first. An event's position in the list is its index, and the thread is at version n once it has its first n events.
+// @ID getThreadEvents
+// @Tags thread
+// @Accept json
+// @Produce json
+// @Param threadId path string true "Thread ID"
+// @Success 200 {array}  models.ThreadEvent
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Thread not found"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /thread/{threadId}/events [get]
+func (h *ThreadHandler) GetThreadEvents(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrUnauthorized)
+		return
+	}
+
+	threadID := chi.URLParam(r, "threadId")
+	if threadID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+
+	events, err := h.threadService.GetThreadEvents(r.Context(), userID, threadID)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to get thread events", zap.Error(err))
+		switch {
+		case errors.Is(err, ErrThreadNotFound):
+			api.ErrorJSON(w, http.StatusNotFound, models.ApiErrThreadNotFound)
+		default:
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, events)
+}
+
+// unitSystem resolves the measurement system recipes are shown in, from the
+// unit_system query parameter or the user's profile, and responds with an
+// error when it can't.
+func (h *ThreadHandler) unitSystem(w http.ResponseWriter, r *http.Request, userID string) (models.UnitSystem, bool) {
+	system, err := h.threadService.recipeService.PreferredUnitSystem(r.Context(), userID, r.URL.Query().Get("unit_system"))
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to get unit system", zap.Error(err))
+		switch {
+		case errors.Is(err, units.ErrUnknownUnitSystem):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidUnitSystem)
+		default:
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
+		return "", false
+	}
+	return system, true
+}
+
+// localizeThreadState shows the recipes of a thread in the given measurement
+// system. The thread's events keep the units the recipes were written in.
+func localizeThreadState(state *models.ThreadState, system models.UnitSystem) {
+	for _, suggestion := range state.Suggestions {
+		suggestion.Suggestion = units.LocalizeRecipe(suggestion.Suggestion, system)
+	}
+	if state.CurrentRecipe != nil {
+		recipe := units.LocalizeRecipe(*state.CurrentRecipe, system)
+		state.CurrentRecipe = &recipe
+	}
+	if state.ModifiedRecipe != nil {
+		recipe := units.LocalizeRecipe(*state.ModifiedRecipe, system)
+		state.ModifiedRecipe = &recipe
+	}
+}
+
+// modifyRecipeResponse diffs the modification in the given measurement
+// system, so the diff matches the recipe it is shown with.
+func modifyRecipeResponse(chatResponse *models.ModifyRecipeViaChatResponse, system models.UnitSystem) models.ModifyRecipeResponse {
+	original := units.LocalizeRecipe(chatResponse.OriginalRecipe, system)
+	modified := units.LocalizeRecipe(chatResponse.NewRecipe, system)
+	return models.ModifyRecipeResponse{
+		CurrentRecipe: original,
+		Diff:          *recipeService.GetRecipeDiff(&original, &modified),
+		ResponseText:  chatResponse.ResponseText,
+	}
+}
+
+// mlErrorResponse maps a failed call to the ML gateway to the response
+// returned to the client. Requests the gateway rejected carry its reason.
+func mlErrorResponse(err *clients.MLError) (int, models.APIError) {
+	switch {
+	case errors.Is(err, clients.ErrMLUnavailable):
+		return http.StatusServiceUnavailable, models.ApiErrChatUnavailable
+	case errors.Is(err, clients.ErrMLTimeout):
+		return http.StatusGatewayTimeout, models.ApiErrChatTimeout
+	case errors.Is(err, clients.ErrMLBadRequest):
+		apiErr := models.ApiErrChatRejected
+		apiErr.Details = err.Message
+		return http.StatusUnprocessableEntity, apiErr
+	default:
+		return http.StatusBadGateway, models.ApiErrChatFailed
+	}
+}
+
+// Events sent by the streaming endpoints. Clients receive "token" events with
+// partial response text, a "suggestion" event for every generated suggestion
+// and finally a "done" event carrying the same payload the non-streaming
+// endpoint responds with. Failures after the stream has started are reported
+// as an "error" event carrying an APIError.
+const (
+	streamEventToken      = "token"
+	streamEventSuggestion = "suggestion"
+	streamEventDone       = "done"
+)
+
+// streamHandlers forwards partial results to the client, with suggested
+// recipes in the given measurement system. Endpoints that don't stream
+// suggestions pass an empty system.
+func streamHandlers(stream *api.EventStream, system models.UnitSystem) StreamHandlers {
+	return StreamHandlers{
+		OnToken: func(text string) error {
+			return stream.Send(streamEventToken, models.StreamToken{Text: text})
+		},
+		OnSuggestion: func(suggestion models.SuggestionGeneratedEvent) error {
+			suggestion.Recipe = units.LocalizeRecipe(suggestion.Recipe, system)
+			return stream.Send(streamEventSuggestion, suggestion)
+		},
+	}
+}
+
+func streamError(stream *api.EventStream, err error) {
+	var mlErr *clients.MLError
+	switch {
+	case errors.Is(err, ErrThreadNotFound):
+		stream.Error(http.StatusNotFound, models.ApiErrThreadNotFound)
+	case errors.Is(err, ErrThreadVersionConflict):
+		stream.Error(http.StatusConflict, models.ApiErrThreadVersionConflict)
+	case errors.Is(err, recipeService.ErrRecipeNotFound):
+		stream.Error(http.StatusNotFound, models.ApiErrRecipeNotFound)
+	case errors.As(err, &mlErr):
+		stream.Error(mlErrorResponse(mlErr))
+	default:
+		stream.Error(http.StatusInternalServerError, models.ApiErrInternal)
+	}
+}
+
+// @Summary Start a new suggestion thread, streaming the response
+// @Description Start a new suggestion thread and stream the suggestions as Server-Sent Events.
+// @Description Emits "token" events with partial response text, a "suggestion" event per suggestion
+// @Description and a final "done" event with the thread state.
+// @ID startSuggestionThreadStream
+// @Tags thread
+// @Accept json
+// @Produce text/event-stream
+// @Param request body models.StartSuggestionThreadRequest true "Suggestion thread request"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Param unit_system query string false "Measurement system to show ingredients in, defaults to the user's preference" Enums(metric, imperial)
+// @Success 200 {object} models.ThreadState "Final done event"
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 409 {object} models.APIError "A request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Request rejected by the recipe assistant, or Idempotency-Key reused for a different request"
+// @Failure 502 {object} models.APIError "Recipe assistant failed"
+// @Failure 503 {object} models.APIError "Recipe assistant unavailable"
+// @Failure 504 {object} models.APIError "Recipe assistant timed out"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /thread/suggest/stream [post]
+func (h *ThreadHandler) StartSuggestionThreadStream(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrBadRequest)
+		return
+	}
+
+	var input models.StartSuggestionThreadRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode start suggestion thread request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+
+	system, ok := h.unitSystem(w, r, userID)
+	if !ok {
+		return
+	}
+
+	stream := api.NewEventStream(w)
+	threadState, err := h.threadService.StartSuggestionThreadStream(r.Context(), userID, input.Prompt, streamHandlers(stream, system))
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to stream suggestion thread", zap.Error(err))
+		streamError(stream, err)
+		return
+	}
+	localizeThreadState(threadState, system)
+	if err := stream.Send(streamEventDone, threadState); err != nil {
+		logger.Logger(r.Context()).Error("failed to send done event", zap.Error(err))
+	}
+}
+
+// @Summary Modify a recipe via chat, streaming the response
+// @Description Modify a recipe via chat and stream the response as Server-Sent Events.
+// @Description Emits "token" events with partial response text and a final "done" event with the modification.
+// @ID modifyRecipeStream
+// @Tags thread
+// @Accept json
+// @Produce text/event-stream
+// @Param recipeId path string true "Recipe ID"
+// @Param request body models.ModifyRecipeViaChatRequest true "Modify recipe via chat request"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Param unit_system query string false "Measurement system to show ingredients in, defaults to the user's preference" Enums(metric, imperial)
+// @Success 200 {object} models.ModifyRecipeResponse "Final done event"
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Recipe or thread not found"
+// @Failure 409 {object} models.APIError "Thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Request rejected by the recipe assistant, or Idempotency-Key reused for a different request"
+// @Failure 502 {object} models.APIError "Recipe assistant failed"
+// @Failure 503 {object} models.APIError "Recipe assistant unavailable"
+// @Failure 504 {object} models.APIError "Recipe assistant timed out"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /recipes/{recipeId}/modify/chat/stream [post]
+func (h *ThreadHandler) ModifyRecipeViaChatStream(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrBadRequest)
+		return
+	}
+
+	recipeID := chi.URLParam(r, "recipeId")
+	if recipeID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+
+	var input models.ModifyRecipeViaChatRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode modify recipe via chat request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+
+	system, ok := h.unitSystem(w, r, userID)
+	if !ok {
+		return
+	}
+
+	stream := api.NewEventStream(w)
+	chatResponse, err := h.threadService.ModifyRecipeViaChatStream(r.Context(), userID, recipeID, input.Prompt, streamHandlers(stream, ""))
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to stream recipe modification", zap.Error(err))
+		streamError(stream, err)
+		return
+	}
+
+	response := modifyRecipeResponse(chatResponse, system)
+	if err := stream.Send(streamEventDone, response); err != nil {
+		logger.Logger(r.Context()).Error("failed to send done event", zap.Error(err))
+	}
+}
+
+// @Summary Answer a cooking question, streaming the response
+// @Description Answer a cooking question and stream the answer as Server-Sent Events.
+// @Description Emits "token" events with partial answer text and a final "done" event with the full answer.
+// @ID answerCookingQuestionStream
+// @Tags thread
+// @Accept json
+// @Produce text/event-stream
+// @Param threadId path string true "Thread ID"
+// @Param request body models.AnswerCookingQuestionRequest true "Answer cooking question request"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response instead of repeating the request"
+// @Success 200 {object} models.AnswerCookingQuestionResponse "Final done event"
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Unauthorized"
+// @Failure 404 {object} models.APIError "Thread not found"
+// @Failure 409 {object} models.APIError "Thread changed by another request, or a request with this Idempotency-Key is in progress"
+// @Failure 422 {object} models.APIError "Request rejected by the recipe assistant, or Idempotency-Key reused for a different request"
+// @Failure 502 {object} models.APIError "Recipe assistant failed"
+// @Failure 503 {object} models.APIError "Recipe assistant unavailable"
+// @Failure 504 {object} models.APIError "Recipe assistant timed out"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /thread/{threadId}/question/stream [post]
+func (h *ThreadHandler) AnswerCookingQuestionStream(w http.ResponseWriter, r *http.Request) {
+	userID := api.GetUserID(r)
+	if userID == "" {
+		api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrBadRequest)
+		return
+	}
+
+	threadID := chi.URLParam(r, "threadId")
+	if threadID == "" {
+		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		return
+	}
+
+	var input models.AnswerCookingQuestionRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode answer cooking question request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+
+	stream := api.NewEventStream(w)
+	response, err := h.threadService.AnswerCookingQuestionStream(r.Context(), userID, threadID, input.Question, streamHandlers(stream, ""))
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to stream cooking question answer", zap.Error(err))
+		streamError(stream, err)
+		return
+	}
+	if err := stream.Send(streamEventDone, response); err != nil {
+		logger.Logger(r.Context()).Error("failed to send done event", zap.Error(err))
+	}
+}
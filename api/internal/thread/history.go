@@ -0,0 +1,134 @@
+package thread
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 100
+)
+
+// ListParams filters a user's threads. Zero values mean no filter.
+type ListParams struct {
+	Type     models.ThreadType
+	RecipeID string
+	// Cursor is the NextCursor of the previous page, empty for the first page
+	Cursor string
+	// Limit defaults to DefaultListLimit
+	Limit int
+}
+
+type listCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+}
+
+// ListThreads returns a page of the user's threads matching params, newest
+// first, along with the cursor for the next page. The cursor is empty on the
+// last page.
+func (s *ThreadService) ListThreads(ctx context.Context, userID string, params ListParams) ([]models.ThreadSummary, string, error) {
+	limit := params.Limit
+	if limit == 0 {
+		limit = DefaultListLimit
+	}
+	if limit < 0 || limit > MaxListLimit {
+		return nil, "", ErrInvalidListLimit
+	}
+	switch params.Type {
+	case "", models.ThreadTypeSuggestion, models.ThreadTypeManual:
+	default:
+		return nil, "", ErrInvalidThreadType
+	}
+	before, err := decodeCursor(params.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	store := s.getStore(ctx)
+	// Fetch one extra thread to find out whether there is another page
+	threads, err := store.ListThreads(ctx, userID, db.ThreadQuery{
+		Type:     params.Type,
+		RecipeID: params.RecipeID,
+		Before:   before,
+		Limit:    limit + 1,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list threads: %w", err)
+	}
+	if len(threads) <= limit {
+		return threads, "", nil
+	}
+	threads = threads[:limit]
+	last := threads[limit-1]
+	return threads, encodeCursor(db.ThreadCursor{CreatedAt: last.CreatedAt, ID: last.ID}), nil
+}
+
+// GetThreadEvents returns the thread's full event log, oldest first.
+func (s *ThreadService) GetThreadEvents(ctx context.Context, userID string, threadID string) ([]models.ThreadEvent, error) {
+	thread, err := s.getThread(ctx, userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if thread.Events == nil {
+		return []models.ThreadEvent{}, nil
+	}
+	return thread.Events, nil
+}
+
+// GetThreadStateAtVersion reduces only the thread's first version events, so
+// the state is what the thread looked like when it was at that version.
+// Like GetThreadState, events that can't be reduced are skipped and listed
+// in the state.
+func (s *ThreadService) GetThreadStateAtVersion(ctx context.Context, userID string, threadID string, version int) (*models.ThreadState, error) {
+	thread, err := s.getThread(ctx, userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if version < 0 || version > len(thread.Events) {
+		return nil, fmt.Errorf("%w: thread is at version %d", ErrThreadVersionOutOfRange, len(thread.Events))
+	}
+	state, _ := ReduceThreadEventsLenient(ctx, threadID, thread.Events[:version], nil)
+	return state, nil
+}
+
+// GetThreadStateAtTime reduces the events the thread had at the given time.
+func (s *ThreadService) GetThreadStateAtTime(ctx context.Context, userID string, threadID string, at time.Time) (*models.ThreadState, error) {
+	thread, err := s.getThread(ctx, userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	version := 0
+	for version < len(thread.Events) && !thread.Events[version].Timestamp.After(at) {
+		version++
+	}
+	state, _ := ReduceThreadEventsLenient(ctx, threadID, thread.Events[:version], nil)
+	return state, nil
+}
+
+func encodeCursor(cursor db.ThreadCursor) string {
+	b, _ := json.Marshal(listCursor{CreatedAt: cursor.CreatedAt, ID: cursor.ID})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*db.ThreadCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(b, &cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &db.ThreadCursor{CreatedAt: cursor.CreatedAt, ID: cursor.ID}, nil
+}
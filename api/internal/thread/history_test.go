@@ -0,0 +1,134 @@
+package thread
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestGetThreadStateAtVersion(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	service := NewThreadService(store, nil, nil, nil)
+	user, err := store.CreateUser(ctx, "cook@example.com", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	threadID := createLongThread(t, store, user.ID, 6)
+	events, err := service.GetThreadEvents(ctx, user.ID, threadID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 6 || events[0].Type != models.ThreadEventTypePromptSet {
+		t.Fatalf("expected the full event log, got %+v", events)
+	}
+
+	// An empty state is timestamped when it's reduced, so only compare
+	// versions with events
+	for version := 1; version <= len(events); version++ {
+		state, err := service.GetThreadStateAtVersion(ctx, user.ID, threadID, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := ReduceThreadEvents(ctx, threadID, events[:version], nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSameState(t, expected, state)
+		if state.Version != version {
+			t.Errorf("expected version %d, got %d", version, state.Version)
+		}
+	}
+	state, err := service.GetThreadStateAtVersion(ctx, user.ID, threadID, 0)
+	if err != nil || state.Version != 0 || state.OriginalPrompt != "" {
+		t.Errorf("expected an empty state at version 0, got %+v (%v)", state, err)
+	}
+	// Suggestion 1 was rejected by the event after it
+	state, err = service.GetThreadStateAtVersion(ctx, user.ID, threadID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Suggestions) != 1 || state.Suggestions[0].Rejected {
+		t.Errorf("expected the suggestion before it was rejected, got %+v", state.Suggestions)
+	}
+
+	for _, version := range []int{-1, 7} {
+		if _, err := service.GetThreadStateAtVersion(ctx, user.ID, threadID, version); !errors.Is(err, ErrThreadVersionOutOfRange) {
+			t.Errorf("expected version %d to be ErrThreadVersionOutOfRange, got %v", version, err)
+		}
+	}
+
+	state, err = service.GetThreadStateAtTime(ctx, user.ID, threadID, time.Now().Add(-time.Hour))
+	if err != nil || state.Version != 0 {
+		t.Errorf("expected no events before the thread was created, got %+v (%v)", state, err)
+	}
+	state, err = service.GetThreadStateAtTime(ctx, user.ID, threadID, time.Now().Add(time.Hour))
+	if err != nil || state.Version != 6 {
+		t.Errorf("expected every event by now, got %+v (%v)", state, err)
+	}
+
+	other, err := store.CreateUser(ctx, "other@example.com", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.GetThreadEvents(ctx, other.ID, threadID); !errors.Is(err, ErrThreadNotFound) {
+		t.Errorf("expected another user's events to be ErrThreadNotFound, got %v", err)
+	}
+	if _, err := service.GetThreadStateAtVersion(ctx, other.ID, threadID, 1); !errors.Is(err, ErrThreadNotFound) {
+		t.Errorf("expected another user's thread to be ErrThreadNotFound, got %v", err)
+	}
+}
+
+func TestListThreads(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	service := NewThreadService(store, nil, nil, nil)
+	user, err := store.CreateUser(ctx, "cook@example.com", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 1; n <= 5; n++ {
+		createLongThread(t, store, user.ID, n)
+	}
+
+	seen := map[string]bool{}
+	params := ListParams{Limit: 2}
+	pages := 0
+	for {
+		threads, cursor, err := service.ListThreads(ctx, user.ID, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		for _, thread := range threads {
+			if seen[thread.ID] {
+				t.Errorf("thread %s was listed twice", thread.ID)
+			}
+			seen[thread.ID] = true
+		}
+		if cursor == "" {
+			break
+		}
+		params.Cursor = cursor
+	}
+	if len(seen) != 5 || pages != 3 {
+		t.Errorf("expected 5 threads over 3 pages, got %d over %d", len(seen), pages)
+	}
+
+	invalid := []struct {
+		params   ListParams
+		expected error
+	}{
+		{ListParams{Limit: MaxListLimit + 1}, ErrInvalidListLimit},
+		{ListParams{Type: "Unknown"}, ErrInvalidThreadType},
+		{ListParams{Cursor: "not a cursor"}, ErrInvalidCursor},
+	}
+	for _, tc := range invalid {
+		if _, _, err := service.ListThreads(ctx, user.ID, tc.params); !errors.Is(err, tc.expected) {
+			t.Errorf("expected %+v to be %v, got %v", tc.params, tc.expected, err)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package thread
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
@@ -11,134 +10,177 @@ import (
 	"go.uber.org/zap"
 )
 
+// ReducerVersion identifies how ReduceThreadEvents interprets events. Bump it
+// whenever that changes so snapshots taken by the old reducer are rebuilt.
+const ReducerVersion = 2
+
 func ReduceThreadEvents(ctx context.Context, threadID string, events []models.ThreadEvent, originalState *models.ThreadState) (*models.ThreadState, error) {
+	thread := newThreadState(threadID, originalState, len(events))
+	logger.Logger(ctx).Debug("reducing thread events", zap.Int("event_count", len(events)))
+	for _, event := range events {
+		logger.Logger(ctx).Debug("reducing thread event", zap.String("event_type", string(event.Type)), zap.Any("event", event))
+		if err := applyThreadEvent(thread, event); err != nil {
+			logger.Logger(ctx).Error("failed to reduce thread event", zap.Error(err))
+			return nil, err
+		}
+	}
+	return thread, nil
+}
+
+// SkippedEvent is an event ReduceThreadEventsLenient left out of a thread's
+// state.
+type SkippedEvent struct {
+	// Index is the event's position in the thread's event log
+	Index int
+	Type  models.ThreadEventType
+	Err   error
+}
+
+// ReduceThreadEventsLenient is ReduceThreadEvents for threads with corrupt
+// events. Events that can't be decoded or applied are skipped and reported
+// rather than failing the whole thread, so the state is whatever the rest of
+// the events add up to. The skipped events are also listed in the state's
+// SkippedEvents.
+func ReduceThreadEventsLenient(ctx context.Context, threadID string, events []models.ThreadEvent, originalState *models.ThreadState) (*models.ThreadState, []SkippedEvent) {
+	thread := newThreadState(threadID, originalState, len(events))
+	start := thread.Version - len(events)
+	skipped := []SkippedEvent{}
+	for i, event := range events {
+		if err := applyThreadEvent(thread, event); err != nil {
+			logger.Logger(ctx).Warn("skipping thread event", zap.Int("index", start+i), zap.Error(err))
+			skipped = append(skipped, SkippedEvent{Index: start + i, Type: event.Type, Err: err})
+			thread.SkippedEvents = append(thread.SkippedEvents, models.SkippedThreadEvent{Index: start + i, Type: event.Type, Error: err.Error()})
+		}
+	}
+	return thread, skipped
+}
+
+// newThreadState is the state events are reduced onto, picking up from
+// originalState when there is one.
+func newThreadState(threadID string, originalState *models.ThreadState, eventCount int) *models.ThreadState {
 	thread := &models.ThreadState{
-		ID:          threadID,
-		Suggestions: []*models.RecipeSuggestion{},
-		RecipeID:    nil,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:             threadID,
+		Suggestions:    []*models.RecipeSuggestion{},
+		RecipeID:       nil,
+		RedoVersionIDs: []string{},
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}
 	if originalState != nil {
+		thread.RecipeID = originalState.RecipeID
 		thread.OriginalPrompt = originalState.OriginalPrompt
 		thread.CurrentPrompt = originalState.CurrentPrompt
 		thread.Suggestions = originalState.Suggestions
 		thread.ChatHistory = originalState.ChatHistory
 		thread.CurrentRecipe = originalState.CurrentRecipe
 		thread.ModifiedRecipe = originalState.ModifiedRecipe
+		thread.RedoVersionIDs = originalState.RedoVersionIDs
 		thread.CreatedAt = originalState.CreatedAt
 		thread.UpdatedAt = originalState.UpdatedAt
+		thread.Version = originalState.Version
 	}
-	logger.Logger(ctx).Debug("reducing thread events", zap.Int("event_count", len(events)))
-	for _, event := range events {
-		logger.Logger(ctx).Debug("reducing thread event", zap.String("event_type", string(event.Type)), zap.Any("event", event))
-		thread.UpdatedAt = event.Timestamp
-		switch event.Type {
-		case models.ThreadEventTypePromptSet:
-			var p models.PromptSetEvent
-			err := json.Unmarshal(event.Payload, &p)
-			if err != nil {
-				logger.Logger(ctx).Error("failed to unmarshal prompt set event", zap.Error(err))
-				return nil, ErrInvalidThreadEventPayload
-			}
-			thread.OriginalPrompt = p.Prompt
-			thread.CurrentPrompt = p.Prompt
-			thread.CreatedAt = event.Timestamp
-		case models.ThreadEventTypePromptEdited:
-			var p models.PromptEditedEvent
-			err := json.Unmarshal(event.Payload, &p)
-			if err != nil {
-				logger.Logger(ctx).Error("failed to unmarshal prompt edited event", zap.Error(err))
-				return nil, ErrInvalidThreadEventPayload
-			}
-			thread.CurrentPrompt = p.Prompt
-		case models.ThreadEventTypeSuggestionGenerated:
-			suggestionEvent := models.SuggestionGeneratedEvent{}
-			err := json.Unmarshal(event.Payload, &suggestionEvent)
-			if err != nil {
-				logger.Logger(ctx).Error("failed to unmarshal suggestion generated event", zap.Error(err))
-				return nil, ErrInvalidThreadEventPayload
-			}
-			suggestion := &models.RecipeSuggestion{
-				ID:           suggestionEvent.SuggestionID,
-				ThreadID:     threadID,
-				Suggestion:   suggestionEvent.Recipe,
-				ResponseText: suggestionEvent.ResponseText,
-				Accepted:     false,
-			}
-			thread.Suggestions = append(thread.Suggestions, suggestion)
-		case models.ThreadEventTypeSuggestionAccepted:
-			suggestionEvent := models.SuggestionAcceptedEvent{}
-			err := json.Unmarshal(event.Payload, &suggestionEvent)
-			if err != nil {
-				logger.Logger(ctx).Error("failed to unmarshal suggestion accepted event", zap.Error(err))
-				return nil, ErrInvalidThreadEventPayload
-			}
-			found := false
-			for i, suggestion := range thread.Suggestions {
-				if suggestion.ID == suggestionEvent.SuggestionID {
-					thread.Suggestions[i].Accepted = true
-					thread.Suggestions[i].UpdatedAt = event.Timestamp
-					thread.CurrentRecipe = &suggestion.Suggestion
-					found = true
-					break
-				}
-			}
-			if !found {
-				return nil, ErrSuggestionNotFound
-			}
-		case models.ThreadEventTypeSuggestionRejected:
-			suggestionEvent := models.SuggestionRejectedEvent{}
-			err := json.Unmarshal(event.Payload, &suggestionEvent)
-			if err != nil {
-				logger.Logger(ctx).Error("failed to unmarshal suggestion rejected event", zap.Error(err))
-				return nil, ErrInvalidThreadEventPayload
-			}
-			found := false
-			for i, suggestion := range thread.Suggestions {
-				if suggestion.ID == suggestionEvent.SuggestionID {
-					thread.Suggestions[i].Rejected = true
-					thread.Suggestions[i].UpdatedAt = event.Timestamp
-					found = true
-					break
-				}
-			}
-			if !found {
-				return nil, ErrSuggestionNotFound
-			}
-		case models.ThreadEventTypeRecipeModified:
-			var recipeEvent models.RecipeModifiedEvent
-			err := json.Unmarshal(event.Payload, &recipeEvent)
-			if err != nil {
-				logger.Logger(ctx).Error("failed to unmarshal recipe modified event", zap.Error(err))
-				return nil, ErrInvalidThreadEventPayload
+	thread.Version += eventCount
+	return thread
+}
+
+// applyThreadEvent reduces one event onto the thread. The thread is left
+// untouched when the event can't be applied.
+func applyThreadEvent(thread *models.ThreadState, event models.ThreadEvent) error {
+	payload, err := DecodeThreadEvent(event)
+	if err != nil {
+		return err
+	}
+	switch payload := payload.(type) {
+	case models.PromptSetEvent:
+		thread.OriginalPrompt = payload.Prompt
+		thread.CurrentPrompt = payload.Prompt
+		thread.CreatedAt = event.Timestamp
+	case models.PromptEditedEvent:
+		thread.CurrentPrompt = payload.Prompt
+	case models.SuggestionGeneratedEvent:
+		suggestion := &models.RecipeSuggestion{
+			ID:           payload.SuggestionID,
+			ThreadID:     thread.ID,
+			Suggestion:   payload.Recipe,
+			ResponseText: payload.ResponseText,
+			Accepted:     false,
+		}
+		thread.Suggestions = append(thread.Suggestions, suggestion)
+	case models.SuggestionAcceptedEvent:
+		found := false
+		for i, suggestion := range thread.Suggestions {
+			if suggestion.ID == payload.SuggestionID {
+				thread.Suggestions[i].Accepted = true
+				thread.Suggestions[i].UpdatedAt = event.Timestamp
+				thread.CurrentRecipe = &suggestion.Suggestion
+				thread.RedoVersionIDs = []string{}
+				found = true
+				break
 			}
-			thread.ModifiedRecipe = &recipeEvent.Recipe
-		case models.ThreadEventTypeRecipeModificationAccepted:
-			thread.CurrentRecipe = thread.ModifiedRecipe
-			thread.ModifiedRecipe = nil
-		case models.ThreadEventTypeRecipeModificationRejected:
-			thread.ModifiedRecipe = nil
-		case models.ThreadEventTypeQuestionAnswered:
-			questionEvent := models.QuestionAnsweredEvent{}
-			err := json.Unmarshal(event.Payload, &questionEvent)
-			if err != nil {
-				logger.Logger(ctx).Error("failed to unmarshal question answered event", zap.Error(err))
-				return nil, ErrInvalidThreadEventPayload
+		}
+		if !found {
+			return ErrSuggestionNotFound
+		}
+	case models.SuggestionRejectedEvent:
+		found := false
+		for i, suggestion := range thread.Suggestions {
+			if suggestion.ID == payload.SuggestionID {
+				thread.Suggestions[i].Rejected = true
+				thread.Suggestions[i].UpdatedAt = event.Timestamp
+				found = true
+				break
 			}
-			thread.ChatHistory = append(thread.ChatHistory, &models.ChatMessage{
-				Source:  "user",
-				Message: questionEvent.Question,
-			})
-			thread.ChatHistory = append(thread.ChatHistory, &models.ChatMessage{
-				Source:  "assistant",
-				Message: questionEvent.Answer,
-			})
-		default:
-			err := fmt.Errorf("%w: invalid thread event type: %s", ErrInvalidThreadEventType, event.Type)
-			logger.Logger(ctx).Error("failed to reduce thread events", zap.Error(err))
-			return nil, err
 		}
+		if !found {
+			return ErrSuggestionNotFound
+		}
+	case models.RecipeModifiedEvent:
+		thread.ModifiedRecipe = &payload.Recipe
+	case models.RecipeModificationAcceptedEvent:
+		thread.CurrentRecipe = thread.ModifiedRecipe
+		thread.ModifiedRecipe = nil
+		// The new version branches off the current one, so anything
+		// undone can no longer be redone
+		thread.RedoVersionIDs = []string{}
+	case models.RecipeModificationRejectedEvent:
+		thread.ModifiedRecipe = nil
+	case models.RecipeModificationUndoneEvent:
+		// A pending modification was made from the recipe being undone
+		thread.ModifiedRecipe = nil
+		thread.CurrentRecipe = &payload.Recipe
+		thread.RedoVersionIDs = append(thread.RedoVersionIDs, payload.FromVersionID)
+	case models.RecipeModificationRedoneEvent:
+		last := len(thread.RedoVersionIDs) - 1
+		if last < 0 || thread.RedoVersionIDs[last] != payload.ToVersionID {
+			return ErrNothingToRedo
+		}
+		thread.ModifiedRecipe = nil
+		thread.CurrentRecipe = &payload.Recipe
+		thread.RedoVersionIDs = thread.RedoVersionIDs[:last]
+	case models.RecipeCreatedEvent:
+		thread.RecipeID = &payload.RecipeID
+		thread.CurrentRecipe = &payload.Recipe
+		thread.CreatedAt = event.Timestamp
+	case models.RecipeEditedEvent:
+		// Like accepting a modification, the edit branches off the current
+		// version and replaces anything pending
+		thread.ModifiedRecipe = nil
+		thread.CurrentRecipe = &payload.Recipe
+		thread.RedoVersionIDs = []string{}
+	case models.QuestionAnsweredEvent:
+		thread.ChatHistory = append(thread.ChatHistory, &models.ChatMessage{
+			Source:  "user",
+			Message: payload.Question,
+		})
+		thread.ChatHistory = append(thread.ChatHistory, &models.ChatMessage{
+			Source:  "assistant",
+			Message: payload.Answer,
+		})
+	case models.EventDiscardedEvent:
+		// Stands in for an event that was lost, so there is nothing to apply
+	default:
+		return fmt.Errorf("%w: no reducer for %s", ErrInvalidThreadEventType, event.Type)
 	}
-	return thread, nil
+	thread.UpdatedAt = event.Timestamp
+	return nil
 }
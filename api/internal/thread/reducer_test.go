@@ -3,6 +3,8 @@ package thread
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -151,6 +153,143 @@ func TestReduceThreadEvents(t *testing.T) {
 	}
 }
 
+func TestReduceUndoRedo(t *testing.T) {
+	ctx := context.Background()
+	recipe := func(title string) models.RecipeBody {
+		return models.RecipeBody{Title: title, Ingredients: []models.Ingredient{{Name: title}}, Steps: []models.Step{"Cook"}}
+	}
+	// Version IDs are the titles of the recipes they hold
+	accepted := []threadEventOpt{
+		withEvent(models.ThreadEventTypePromptSet, models.PromptSetEvent{Prompt: "soup"}),
+		withEvent(models.ThreadEventTypeSuggestionGenerated, models.SuggestionGeneratedEvent{SuggestionID: "s1", Recipe: recipe("v1")}),
+		withEvent(models.ThreadEventTypeSuggestionAccepted, models.SuggestionAcceptedEvent{SuggestionID: "s1"}),
+	}
+	modify := func(title string) []threadEventOpt {
+		return []threadEventOpt{
+			withEvent(models.ThreadEventTypeRecipeModified, models.RecipeModifiedEvent{Recipe: recipe(title)}),
+			withEvent(models.ThreadEventTypeRecipeModificationAccepted, models.RecipeModificationAcceptedEvent{}),
+		}
+	}
+	undo := func(from, to string) []threadEventOpt {
+		return []threadEventOpt{withEvent(models.ThreadEventTypeRecipeModificationUndone, models.RecipeModificationUndoneEvent{
+			FromVersionID: from, ToVersionID: to, Recipe: recipe(to),
+		})}
+	}
+	redo := func(from, to string) []threadEventOpt {
+		return []threadEventOpt{withEvent(models.ThreadEventTypeRecipeModificationRedone, models.RecipeModificationRedoneEvent{
+			FromVersionID: from, ToVersionID: to, Recipe: recipe(to),
+		})}
+	}
+	edit := func(from, to string) []threadEventOpt {
+		return []threadEventOpt{withEvent(models.ThreadEventTypeRecipeEdited, models.RecipeEditedEvent{
+			FromVersionID: from, ToVersionID: to, Recipe: recipe(to),
+		})}
+	}
+	created := func(title string) threadEventOpt {
+		return withEvent(models.ThreadEventTypeRecipeCreated, models.RecipeCreatedEvent{RecipeID: "recipe", VersionID: title, Recipe: recipe(title)})
+	}
+	sequence := func(steps ...[]threadEventOpt) []threadEventOpt {
+		events := append([]threadEventOpt{}, accepted...)
+		for _, step := range steps {
+			events = append(events, step...)
+		}
+		return events
+	}
+
+	testCases := []struct {
+		name     string
+		events   []threadEventOpt
+		current  string
+		redo     []string
+		modified bool
+	}{
+		{"undo", sequence(modify("v2"), undo("v2", "v1")), "v1", []string{"v2"}, false},
+		{"redo", sequence(modify("v2"), undo("v2", "v1"), redo("v1", "v2")), "v2", []string{}, false},
+		{"undo twice, redo once", sequence(modify("v2"), modify("v3"), undo("v3", "v2"), undo("v2", "v1"), redo("v1", "v2")), "v2", []string{"v3"}, false},
+		{"modify after undo", sequence(modify("v2"), undo("v2", "v1"), modify("v4")), "v4", []string{}, false},
+		{"undo past a modification", sequence(modify("v2"), undo("v2", "v1"), modify("v4"), undo("v4", "v1")), "v1", []string{"v4"}, false},
+		{"undo drops a pending modification", sequence(modify("v2"), modify("v3")[:1], undo("v2", "v1")), "v1", []string{"v2"}, false},
+		{"modify after redo", sequence(modify("v2"), undo("v2", "v1"), redo("v1", "v2"), modify("v3")[:1]), "v2", []string{}, true},
+		{"accepting a suggestion", append(sequence(modify("v2"), undo("v2", "v1")), accepted[1:]...), "v1", []string{}, false},
+		{"edit after undo", append(sequence(modify("v2"), undo("v2", "v1")), edit("v1", "v3")...), "v3", []string{}, false},
+		{"edit drops a pending modification", append(sequence(modify("v2")[:1]), edit("v1", "v3")...), "v3", []string{}, false},
+		{"created by hand", append([]threadEventOpt{created("v1")}, modify("v2")...), "v2", []string{}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			thread, err := ReduceThreadEvents(ctx, "thread", createThreadEvents(t, tc.events...), nil)
+			if err != nil {
+				t.Fatalf("failed to reduce thread events: %v", err)
+			}
+			if thread.CurrentRecipe == nil || thread.CurrentRecipe.Title != tc.current {
+				t.Errorf("expected current recipe %s, got %+v", tc.current, thread.CurrentRecipe)
+			}
+			if fmt.Sprint(thread.RedoVersionIDs) != fmt.Sprint(tc.redo) {
+				t.Errorf("expected redo versions %v, got %v", tc.redo, thread.RedoVersionIDs)
+			}
+			if (thread.ModifiedRecipe != nil) != tc.modified {
+				t.Errorf("expected pending modification %t, got %+v", tc.modified, thread.ModifiedRecipe)
+			}
+		})
+	}
+
+	invalid := map[string][]threadEventOpt{
+		"redo without undo":      sequence(modify("v2"), redo("v1", "v2")),
+		"redo after modifying":   sequence(modify("v2"), undo("v2", "v1"), modify("v3"), redo("v3", "v2")),
+		"redo the wrong version": sequence(modify("v2"), modify("v3"), undo("v3", "v2"), undo("v2", "v1"), redo("v1", "v3")),
+	}
+	for name, events := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ReduceThreadEvents(ctx, "thread", createThreadEvents(t, events...), nil); !errors.Is(err, ErrNothingToRedo) {
+				t.Errorf("expected ErrNothingToRedo, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReduceThreadEventsLenient(t *testing.T) {
+	ctx := context.Background()
+	corrupt := func(eventType models.ThreadEventType, payload string) threadEventOpt {
+		return func(t *testing.T, event *models.ThreadEvent) {
+			event.Type = eventType
+			event.Payload = json.RawMessage(payload)
+			event.Timestamp = time.Now()
+		}
+	}
+	events := createThreadEvents(t,
+		withEvent(models.ThreadEventTypePromptSet, models.PromptSetEvent{Prompt: "soup"}),
+		corrupt(models.ThreadEventTypeSuggestionGenerated, `{"suggestion_id":`),
+		withEvent(models.ThreadEventTypeSuggestionAccepted, models.SuggestionAcceptedEvent{SuggestionID: "s1"}),
+		corrupt("PromptDeleted", `{}`),
+		withEvent(models.ThreadEventTypePromptEdited, models.PromptEditedEvent{Prompt: "stew"}),
+	)
+	if _, err := ReduceThreadEvents(ctx, "thread", events, nil); !errors.Is(err, ErrInvalidThreadEventPayload) {
+		t.Fatalf("expected ErrInvalidThreadEventPayload, got %v", err)
+	}
+
+	thread, skipped := ReduceThreadEventsLenient(ctx, "thread", events[1:], &models.ThreadState{OriginalPrompt: "soup", CurrentPrompt: "soup", Version: 1})
+	if thread.CurrentPrompt != "stew" || thread.Version != 5 || len(thread.Suggestions) != 0 {
+		t.Errorf("expected the rest of the events to be reduced, got %+v", thread)
+	}
+	expected := []struct {
+		index int
+		err   error
+	}{
+		{1, ErrInvalidThreadEventPayload},
+		// The suggestion it accepts was skipped
+		{2, ErrSuggestionNotFound},
+		{3, ErrInvalidThreadEventType},
+	}
+	if len(skipped) != len(expected) {
+		t.Fatalf("expected %d skipped events, got %+v", len(expected), skipped)
+	}
+	for i, e := range expected {
+		if skipped[i].Index != e.index || !errors.Is(skipped[i].Err, e.err) {
+			t.Errorf("expected event %d to be skipped with %v, got %d %v", e.index, e.err, skipped[i].Index, skipped[i].Err)
+		}
+	}
+}
+
 type threadEventOpt func(t *testing.T, event *models.ThreadEvent)
 
 func withEvent(eventType models.ThreadEventType, payload any) threadEventOpt {
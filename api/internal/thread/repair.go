@@ -0,0 +1,170 @@
+package thread
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+// repairBatchSize is how many events RepairThreadEvents reads at a time.
+const repairBatchSize = 500
+
+// MalformedEvent is a stored event that didn't decode.
+type MalformedEvent struct {
+	db.StoredThreadEvent
+	// Err is why the event didn't decode
+	Err error
+	// Repaired is what the event was rewritten to, nil if it was left as it
+	// is
+	Repaired *models.ThreadEvent
+	// Discarded is set when the event couldn't be repaired and was replaced
+	// by an EventDiscarded event keeping the original
+	Discarded bool
+}
+
+// RepairReport is what RepairThreadEvents found.
+type RepairReport struct {
+	// Scanned is how many events were checked
+	Scanned   int
+	Malformed []MalformedEvent
+	// Skipped lists, for each thread that had malformed events, the events
+	// the lenient reducer still skips after the repairs
+	Skipped map[string][]SkippedEvent
+}
+
+// Unrepaired counts the malformed events that were left as they are, which
+// are only events with a newer schema version than this server knows.
+func (r *RepairReport) Unrepaired() int {
+	n := 0
+	for _, event := range r.Malformed {
+		if event.Repaired == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Discarded counts the malformed events that were replaced by EventDiscarded
+// events.
+func (r *RepairReport) Discarded() int {
+	n := 0
+	for _, event := range r.Malformed {
+		if event.Discarded {
+			n++
+		}
+	}
+	return n
+}
+
+// RepairThreadEvents checks that every stored thread event decodes and
+// rewrites the malformed ones, all in one transaction. Events are repaired
+// when their schema knows how the payload was malformed, and otherwise
+// replaced by an EventDiscarded event that keeps the original, so every
+// rewritten row decodes.
+//
+// Events with a schema version newer than this server's are left alone:
+// they were written by a newer server, which reads them fine, and
+// discarding them would lose them for good. With dryRun nothing is written,
+// and the report is of what would have been repaired.
+func RepairThreadEvents(ctx context.Context, store db.Store, dryRun bool) (*RepairReport, error) {
+	report := &RepairReport{
+		Malformed: []MalformedEvent{},
+		Skipped:   make(map[string][]SkippedEvent),
+	}
+	err := store.WithTx(func(tx db.Store) error {
+		// Threads are checked whole, and a thread's events can span pages
+		var thread []db.StoredThreadEvent
+		var query db.ThreadEventQuery
+		for {
+			query.Limit = repairBatchSize
+			page, err := tx.ListAllThreadEvents(ctx, query)
+			if err != nil {
+				return err
+			}
+			for _, event := range page {
+				if len(thread) > 0 && thread[0].ThreadID != event.ThreadID {
+					if err := repairThread(ctx, tx, thread, report, dryRun); err != nil {
+						return err
+					}
+					thread = nil
+				}
+				thread = append(thread, event)
+			}
+			if len(page) < repairBatchSize {
+				break
+			}
+			last := page[len(page)-1]
+			query.After = &db.ThreadEventCursor{ThreadID: last.ThreadID, Index: last.Index}
+		}
+		if len(thread) > 0 {
+			return repairThread(ctx, tx, thread, report, dryRun)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to repair thread events: %w", err)
+	}
+	return report, nil
+}
+
+// repairThread repairs the malformed events of one thread, then reduces it
+// leniently to report any events that still can't be applied. Those decode
+// but don't fit the rest of the thread, e.g. accepting a suggestion whose
+// event was discarded, so they are valid rows and are left for the lenient
+// reducer to skip.
+func repairThread(ctx context.Context, tx db.Store, thread []db.StoredThreadEvent, report *RepairReport, dryRun bool) error {
+	report.Scanned += len(thread)
+	malformed := false
+	events := make([]models.ThreadEvent, len(thread))
+	for i, stored := range thread {
+		events[i] = stored.Event
+		_, err := DecodeThreadEvent(stored.Event)
+		if err == nil {
+			continue
+		}
+		malformed = true
+		entry := MalformedEvent{StoredThreadEvent: stored, Err: err}
+		repaired, err := RepairThreadEvent(stored.Event)
+		if err != nil && !errors.Is(err, ErrUnsupportedThreadEventSchema) {
+			repaired, err = discardThreadEvent(stored.Event, err)
+			entry.Discarded = err == nil
+		}
+		if err == nil {
+			if !dryRun {
+				if err := tx.ReplaceThreadEvent(ctx, stored.ID, repaired); err != nil {
+					return err
+				}
+			}
+			entry.Repaired = &repaired
+			events[i] = repaired
+		}
+		report.Malformed = append(report.Malformed, entry)
+	}
+	if !malformed {
+		return nil
+	}
+	if _, skipped := ReduceThreadEventsLenient(ctx, thread[0].ThreadID, events, nil); len(skipped) > 0 {
+		report.Skipped[thread[0].ThreadID] = skipped
+	}
+	return nil
+}
+
+// discardThreadEvent replaces an event that can't be repaired with an
+// EventDiscarded event, keeping its timestamp so the thread's history still
+// lines up.
+func discardThreadEvent(event models.ThreadEvent, reason error) (models.ThreadEvent, error) {
+	discarded, err := NewThreadEvent(models.EventDiscardedEvent{
+		OriginalType:          event.Type,
+		OriginalSchemaVersion: max(event.SchemaVersion, 1),
+		OriginalPayload:       string(event.Payload),
+		Reason:                reason.Error(),
+	})
+	if err != nil {
+		return event, err
+	}
+	discarded.Timestamp = event.Timestamp
+	return discarded, nil
+}
@@ -0,0 +1,122 @@
+package thread
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestRepairThreadEvents(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	service := NewThreadService(store, nil, nil, nil)
+	user, err := store.CreateUser(ctx, "cook@example.com", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	healthyID := createLongThread(t, store, user.ID, repairBatchSize+10)
+	// Prompts used to be pasted into the payload unescaped
+	repairable := models.Thread{
+		ID:   "repairable",
+		Type: models.ThreadTypeSuggestion,
+		Events: []models.ThreadEvent{
+			{Type: models.ThreadEventTypePromptSet, Payload: json.RawMessage(`{"prompt":"a "quick" soup"}`)},
+		},
+	}
+	// The suggestion can't be recovered, so the event is discarded and
+	// accepting it no longer applies
+	unrepairable := models.Thread{
+		ID:   "unrepairable",
+		Type: models.ThreadTypeSuggestion,
+		Events: []models.ThreadEvent{
+			{Type: models.ThreadEventTypePromptSet, Payload: json.RawMessage(`{"prompt":"soup"}`)},
+			{Type: models.ThreadEventTypeSuggestionGenerated, Payload: json.RawMessage(`{"suggestion_id":`)},
+			{Type: models.ThreadEventTypeSuggestionAccepted, Payload: json.RawMessage(`{"suggestion_id":"s1"}`)},
+		},
+	}
+	// Written by a newer server
+	newer := models.Thread{
+		ID:   "unsupported",
+		Type: models.ThreadTypeSuggestion,
+		Events: []models.ThreadEvent{
+			{Type: models.ThreadEventTypePromptSet, Payload: json.RawMessage(`{"prompt":"soup"}`)},
+			{Type: models.ThreadEventTypePromptEdited, SchemaVersion: 2, Payload: json.RawMessage(`{"text":"stew"}`)},
+		},
+	}
+	for _, thread := range []models.Thread{repairable, unrepairable, newer} {
+		if err := store.CreateThread(ctx, user.ID, thread); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := RepairThreadEvents(ctx, store, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Scanned != repairBatchSize+16 || len(report.Malformed) != 3 || report.Discarded() != 1 || report.Unrepaired() != 1 {
+		t.Fatalf("expected 3 malformed events of %d with 1 discarded and 1 left, got %+v", repairBatchSize+16, report)
+	}
+	state, err := service.GetThreadState(ctx, user.ID, repairable.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.SkippedEvents) != 1 {
+		t.Errorf("expected a dry run not to repair anything, got %+v", state)
+	}
+
+	report, err = RepairThreadEvents(ctx, store, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Malformed) != 3 || report.Malformed[0].ThreadID != repairable.ID || report.Malformed[0].Repaired == nil || report.Malformed[0].Discarded {
+		t.Fatalf("expected the prompt to be repaired, got %+v", report.Malformed)
+	}
+	bad := report.Malformed[1]
+	if bad.ThreadID != unrepairable.ID || bad.Index != 1 || !bad.Discarded || !errors.Is(bad.Err, ErrInvalidThreadEventPayload) {
+		t.Fatalf("expected the suggestion to be discarded, got %+v", bad)
+	}
+	if bad.Repaired == nil || bad.Repaired.Type != models.ThreadEventTypeEventDiscarded {
+		t.Fatalf("expected an EventDiscarded event in its place, got %+v", bad.Repaired)
+	}
+	payload, err := DecodeThreadEvent(*bad.Repaired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if discarded := payload.(models.EventDiscardedEvent); discarded.OriginalType != models.ThreadEventTypeSuggestionGenerated || discarded.OriginalPayload != `{"suggestion_id":` {
+		t.Errorf("expected the original event to be kept, got %+v", discarded)
+	}
+	if left := report.Malformed[2]; left.ThreadID != newer.ID || left.Repaired != nil || !errors.Is(left.Err, ErrUnsupportedThreadEventSchema) {
+		t.Errorf("expected the newer event to be left alone, got %+v", left)
+	}
+	if skipped := report.Skipped[unrepairable.ID]; len(skipped) != 1 || skipped[0].Index != 2 || !errors.Is(skipped[0].Err, ErrSuggestionNotFound) {
+		t.Errorf("expected accepting the discarded suggestion to be skipped, got %+v", report.Skipped)
+	}
+	if _, ok := report.Skipped[healthyID]; ok {
+		t.Errorf("expected the healthy thread not to skip events, got %+v", report.Skipped)
+	}
+
+	state, err = service.GetThreadState(ctx, user.ID, repairable.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.OriginalPrompt != `a "quick" soup` || len(state.SkippedEvents) != 0 {
+		t.Errorf("expected the prompt to be recovered, got %+v", state)
+	}
+	state, err = service.GetThreadState(ctx, user.ID, unrepairable.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.SkippedEvents) != 1 || state.SkippedEvents[0].Index != 2 || state.OriginalPrompt != "soup" {
+		t.Errorf("expected only the accept to be skipped, got %+v", state)
+	}
+	report, err = RepairThreadEvents(ctx, store, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Malformed) != 1 || report.Malformed[0].ThreadID != newer.ID {
+		t.Errorf("expected only the newer event to be left, got %+v", report.Malformed)
+	}
+}
@@ -2,7 +2,6 @@ package thread
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -13,6 +12,7 @@ import (
 	"github.com/ajohnston1219/eatme/api/internal/recipe"
 	"github.com/ajohnston1219/eatme/api/internal/user"
 	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
+	"github.com/ajohnston1219/eatme/api/internal/validation"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
@@ -24,9 +24,10 @@ type ThreadService struct {
 	chatService   *chat.ChatService
 }
 
-func NewThreadService(store db.Store, recipeService *recipe.RecipeService, chatService *chat.ChatService) *ThreadService {
+func NewThreadService(store db.Store, userService *user.UserService, recipeService *recipe.RecipeService, chatService *chat.ChatService) *ThreadService {
 	return &ThreadService{
 		store:         store,
+		userService:   userService,
 		recipeService: recipeService,
 		chatService:   chatService,
 	}
@@ -48,13 +49,11 @@ func (s *ThreadService) StartSuggestionThread(ctx context.Context, userID string
 			return fmt.Errorf("failed to get profile: %w", err)
 		}
 		logger.Logger(ctx).Debug("got profile")
-		events := []models.ThreadEvent{
-			{
-				Type:      models.ThreadEventTypePromptSet,
-				Payload:   []byte(`{"prompt":"` + prompt + `"}`),
-				Timestamp: time.Now(),
-			},
+		promptEvent, err := NewThreadEvent(models.PromptSetEvent{Prompt: prompt})
+		if err != nil {
+			return err
 		}
+		events := []models.ThreadEvent{promptEvent}
 		suggestionRequest := &models.SuggestChatRequest{
 			Profile: *profile,
 			Message: prompt,
@@ -72,16 +71,11 @@ func (s *ThreadService) StartSuggestionThread(ctx context.Context, userID string
 				Recipe:       suggestion.Recipe,
 				ResponseText: suggestion.ResponseText,
 			}
-			payload, err := json.Marshal(event)
-			logger.Logger(ctx).Debug("generated recipe suggestion event", zap.String("payload", string(payload)))
+			threadEvent, err := NewThreadEvent(event)
 			if err != nil {
-				return ErrInvalidThreadEventPayload
+				return err
 			}
-			events = append(events, models.ThreadEvent{
-				Type:      models.ThreadEventTypeSuggestionGenerated,
-				Payload:   payload,
-				Timestamp: time.Now(),
-			})
+			events = append(events, threadEvent)
 		}
 		threadID := uuid.New().String()
 		thread := models.Thread{
@@ -116,14 +110,9 @@ func (s *ThreadService) GetNewSuggestions(ctx context.Context, userID string, th
 			return fmt.Errorf("failed to get profile: %w", err)
 		}
 		logger.Logger(ctx).Debug("got profile")
-		thread, err := tx.GetThread(ctx, threadID)
+		thread, err := s.getThread(ctx, userID, threadID)
 		if err != nil {
-			switch {
-			case errors.Is(err, db.ErrNotFound):
-				return ErrThreadNotFound
-			default:
-				return fmt.Errorf("failed to get thread: %w", err)
-			}
+			return err
 		}
 		state, err := ReduceThreadEvents(ctx, threadID, thread.Events, nil)
 		if err != nil {
@@ -134,19 +123,13 @@ func (s *ThreadService) GetNewSuggestions(ctx context.Context, userID string, th
 			promptEvent := models.PromptEditedEvent{
 				Prompt: *input.Prompt,
 			}
-			payload, err := json.Marshal(promptEvent)
+			event, err := NewThreadEvent(promptEvent)
 			if err != nil {
-				return ErrInvalidThreadEventPayload
-			}
-			event := models.ThreadEvent{
-				Type:      models.ThreadEventTypePromptEdited,
-				Payload:   payload,
-				Timestamp: time.Now(),
+				return err
 			}
-			if err := s.AppendEventsToThread(ctx, threadID, []models.ThreadEvent{event}); err != nil {
+			if err := s.AppendEventsToThread(ctx, userID, &thread, []models.ThreadEvent{event}); err != nil {
 				return fmt.Errorf("failed to append events to thread: %w", err)
 			}
-			thread.Events = append(thread.Events, event)
 			state.CurrentPrompt = *input.Prompt
 		}
 
@@ -170,17 +153,13 @@ func (s *ThreadService) GetNewSuggestions(ctx context.Context, userID string, th
 				Recipe:       suggestion.Recipe,
 				ResponseText: suggestion.ResponseText,
 			}
-			payload, err := json.Marshal(event)
+			threadEvent, err := NewThreadEvent(event)
 			if err != nil {
-				return ErrInvalidThreadEventPayload
-			}
-			suggestionEvents[i] = models.ThreadEvent{
-				Type:      models.ThreadEventTypeSuggestionGenerated,
-				Payload:   payload,
-				Timestamp: time.Now(),
+				return err
 			}
+			suggestionEvents[i] = threadEvent
 		}
-		if err := s.AppendEventsToThread(ctx, threadID, suggestionEvents); err != nil {
+		if err := s.AppendEventsToThread(ctx, userID, &thread, suggestionEvents); err != nil {
 			return fmt.Errorf("failed to append events to thread: %w", err)
 		}
 		logger.Logger(ctx).Debug("appended events to thread")
@@ -210,18 +189,18 @@ func (s *ThreadService) AcceptSuggestion(ctx context.Context, userID string, thr
 	var recipe *models.UserRecipe
 	err := s.store.WithTx(func(tx db.Store) error {
 		ctx = db.ContextWithTx(ctx, tx)
-		thread, err := tx.GetThread(ctx, threadID)
+		thread, err := s.getThread(ctx, userID, threadID)
 		if err != nil {
-			return fmt.Errorf("failed to get thread: %w", err)
+			return err
 		}
 		found := false
 		for _, event := range thread.Events {
 			if event.Type == models.ThreadEventTypeSuggestionGenerated {
-				payload := event.Payload
-				var suggestionEvent models.SuggestionGeneratedEvent
-				if err := json.Unmarshal(payload, &suggestionEvent); err != nil {
-					return fmt.Errorf("failed to unmarshal suggestion generated event: %w", err)
+				payload, err := DecodeThreadEvent(event)
+				if err != nil {
+					return fmt.Errorf("failed to decode suggestion generated event: %w", err)
 				}
+				suggestionEvent := payload.(models.SuggestionGeneratedEvent)
 				if suggestionEvent.SuggestionID == suggestionID {
 					recipe, err = s.recipeService.NewRecipe(ctx, userID, threadID, suggestionEvent.Recipe)
 					if err != nil {
@@ -239,19 +218,14 @@ func (s *ThreadService) AcceptSuggestion(ctx context.Context, userID string, thr
 			SuggestionID: suggestionID,
 			RecipeID:     recipe.ID,
 		}
-		payload, err := json.Marshal(acceptedEvent)
+		event, err := NewThreadEvent(acceptedEvent)
 		if err != nil {
-			return ErrInvalidThreadEventPayload
-		}
-		event := models.ThreadEvent{
-			Type:      models.ThreadEventTypeSuggestionAccepted,
-			Payload:   payload,
-			Timestamp: time.Now(),
+			return err
 		}
-		if err := s.AppendEventsToThread(ctx, threadID, []models.ThreadEvent{event}); err != nil {
+		if err := s.AppendEventsToThread(ctx, userID, &thread, []models.ThreadEvent{event}); err != nil {
 			return fmt.Errorf("failed to append events to thread: %w", err)
 		}
-		if err := tx.AssociateThreadWithRecipe(ctx, threadID, recipe.ID); err != nil {
+		if err := tx.AssociateThreadWithRecipe(ctx, userID, threadID, recipe.ID); err != nil {
 			return fmt.Errorf("failed to associate thread with recipe: %w", err)
 		}
 		logger.Logger(ctx).Debug("associated thread with recipe")
@@ -275,9 +249,9 @@ func (s *ThreadService) ModifyRecipeViaChat(ctx context.Context, userID string,
 		if err != nil {
 			return fmt.Errorf("failed to get recipe: %w", err)
 		}
-		thread, err := tx.GetThread(ctx, recipe.ThreadID)
+		thread, err := s.getThread(ctx, userID, recipe.ThreadID)
 		if err != nil {
-			return fmt.Errorf("failed to get thread: %w", err)
+			return err
 		}
 		modifyRequest := &models.ModifyChatRequest{
 			Message: prompt,
@@ -297,16 +271,11 @@ func (s *ThreadService) ModifyRecipeViaChat(ctx context.Context, userID string,
 		modifyEvent := models.RecipeModifiedEvent{
 			Recipe: modifyResponse.NewRecipe,
 		}
-		payload, err := json.Marshal(modifyEvent)
+		event, err := NewThreadEvent(modifyEvent)
 		if err != nil {
-			return ErrInvalidThreadEventPayload
-		}
-		event := models.ThreadEvent{
-			Type:      models.ThreadEventTypeRecipeModified,
-			Payload:   payload,
-			Timestamp: time.Now(),
+			return err
 		}
-		if err := s.AppendEventsToThread(ctx, thread.ID, []models.ThreadEvent{event}); err != nil {
+		if err := s.AppendEventsToThread(ctx, userID, &thread, []models.ThreadEvent{event}); err != nil {
 			return fmt.Errorf("failed to append events to thread: %w", err)
 		}
 		logger.Logger(ctx).Debug("appended events to thread")
@@ -328,31 +297,32 @@ func (s *ThreadService) AcceptRecipeModification(ctx context.Context, userID str
 			return fmt.Errorf("failed to get recipe: %w", err)
 		}
 
-		thread, err := tx.GetThread(ctx, recipe.ThreadID)
+		thread, err := s.getThread(ctx, userID, recipe.ThreadID)
 		if err != nil {
-			return fmt.Errorf("failed to get thread: %w", err)
+			return err
 		}
 
-		acceptedEvent := models.RecipeModificationAcceptedEvent{}
-		payload, err := json.Marshal(acceptedEvent)
+		// Undoing or accepting clears the pending modification, so there may
+		// be nothing left to accept
+		threadState, err := ReduceThreadEvents(ctx, thread.ID, thread.Events, nil)
 		if err != nil {
-			return ErrInvalidThreadEventPayload
+			return fmt.Errorf("failed to reduce thread events: %w", err)
+		}
+		if threadState.ModifiedRecipe == nil {
+			return ErrNoPendingModification
 		}
-		event := models.ThreadEvent{
-			Type:      models.ThreadEventTypeRecipeModificationAccepted,
-			Payload:   payload,
-			Timestamp: time.Now(),
+		modified := *threadState.ModifiedRecipe
+
+		acceptedEvent := models.RecipeModificationAcceptedEvent{}
+		event, err := NewThreadEvent(acceptedEvent)
+		if err != nil {
+			return err
 		}
-		thread.Events = append(thread.Events, event)
-		if err := s.AppendEventsToThread(ctx, thread.ID, []models.ThreadEvent{event}); err != nil {
+		if err := s.AppendEventsToThread(ctx, userID, &thread, []models.ThreadEvent{event}); err != nil {
 			return fmt.Errorf("failed to append events to thread: %w", err)
 		}
 		logger.Logger(ctx).Debug("appended event to thread")
-		threadState, err := ReduceThreadEvents(ctx, thread.ID, thread.Events, nil)
-		if err != nil {
-			return fmt.Errorf("failed to reduce thread events: %w", err)
-		}
-		if err = s.recipeService.UpdateRecipe(ctx, userID, recipeID, *threadState.CurrentRecipe); err != nil {
+		if err = s.recipeService.UpdateRecipe(ctx, userID, recipeID, modified); err != nil {
 			return fmt.Errorf("failed to update recipe: %w", err)
 		}
 		logger.Logger(ctx).Debug("updated recipe")
@@ -372,21 +342,16 @@ func (s *ThreadService) RejectRecipeModification(ctx context.Context, userID str
 		if err != nil {
 			return fmt.Errorf("failed to get recipe: %w", err)
 		}
-		thread, err := tx.GetThread(ctx, recipe.ThreadID)
+		thread, err := s.getThread(ctx, userID, recipe.ThreadID)
 		if err != nil {
-			return fmt.Errorf("failed to get thread: %w", err)
+			return err
 		}
 		rejectedEvent := models.RecipeModificationRejectedEvent{}
-		payload, err := json.Marshal(rejectedEvent)
+		event, err := NewThreadEvent(rejectedEvent)
 		if err != nil {
-			return ErrInvalidThreadEventPayload
-		}
-		event := models.ThreadEvent{
-			Type:      models.ThreadEventTypeRecipeModificationRejected,
-			Payload:   payload,
-			Timestamp: time.Now(),
+			return err
 		}
-		if err := s.AppendEventsToThread(ctx, thread.ID, []models.ThreadEvent{event}); err != nil {
+		if err := s.AppendEventsToThread(ctx, userID, &thread, []models.ThreadEvent{event}); err != nil {
 			return fmt.Errorf("failed to append events to thread: %w", err)
 		}
 		logger.Logger(ctx).Debug("appended event to thread")
@@ -398,6 +363,275 @@ func (s *ThreadService) RejectRecipeModification(ctx context.Context, userID str
 	return nil
 }
 
+// UndoRecipeModification moves the recipe back to the version its latest
+// version was made from. The move is recorded in the recipe's thread so it can
+// be redone.
+func (s *ThreadService) UndoRecipeModification(ctx context.Context, userID string, recipeID string) (*models.UserRecipe, error) {
+	var updated *models.UserRecipe
+	err := s.store.WithTx(func(tx db.Store) error {
+		ctx = db.ContextWithTx(ctx, tx)
+		current, err := s.recipeService.GetUserRecipe(ctx, userID, recipeID)
+		if err != nil {
+			return fmt.Errorf("failed to get recipe: %w", err)
+		}
+		thread, err := s.getThread(ctx, userID, current.ThreadID)
+		if err != nil {
+			return err
+		}
+		version, err := s.recipeService.UndoVersion(ctx, userID, recipeID)
+		if err != nil {
+			switch {
+			case errors.Is(err, recipe.ErrNoParentVersion):
+				return ErrNothingToUndo
+			default:
+				return fmt.Errorf("failed to undo recipe version: %w", err)
+			}
+		}
+		event, err := NewThreadEvent(models.RecipeModificationUndoneEvent{
+			FromVersionID: current.LatestVersionID,
+			ToVersionID:   version.ID,
+			Recipe:        version.RecipeBody,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.AppendEventsToThread(ctx, userID, &thread, []models.ThreadEvent{event}); err != nil {
+			return fmt.Errorf("failed to append events to thread: %w", err)
+		}
+		logger.Logger(ctx).Debug("appended event to thread")
+		updated, err = s.recipeService.GetUserRecipe(ctx, userID, recipeID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to undo recipe modification: %w", err)
+	}
+	return updated, nil
+}
+
+// RedoRecipeModification moves the recipe forward to the version most
+// recently undone, as long as nothing has been accepted since.
+func (s *ThreadService) RedoRecipeModification(ctx context.Context, userID string, recipeID string) (*models.UserRecipe, error) {
+	var updated *models.UserRecipe
+	err := s.store.WithTx(func(tx db.Store) error {
+		ctx = db.ContextWithTx(ctx, tx)
+		current, err := s.recipeService.GetUserRecipe(ctx, userID, recipeID)
+		if err != nil {
+			return fmt.Errorf("failed to get recipe: %w", err)
+		}
+		thread, err := s.getThread(ctx, userID, current.ThreadID)
+		if err != nil {
+			return err
+		}
+		// Only the redo stack is needed, so corrupt events elsewhere in the
+		// thread don't stop the redo
+		threadState, _ := ReduceThreadEventsLenient(ctx, thread.ID, thread.Events, nil)
+		if len(threadState.RedoVersionIDs) == 0 {
+			return ErrNothingToRedo
+		}
+		versionID := threadState.RedoVersionIDs[len(threadState.RedoVersionIDs)-1]
+		version, err := s.recipeService.RedoVersion(ctx, userID, recipeID, versionID)
+		if err != nil {
+			switch {
+			// The recipe was changed outside the thread since the undo
+			case errors.Is(err, recipe.ErrNotChildVersion):
+				return ErrNothingToRedo
+			default:
+				return fmt.Errorf("failed to redo recipe version: %w", err)
+			}
+		}
+		event, err := NewThreadEvent(models.RecipeModificationRedoneEvent{
+			FromVersionID: current.LatestVersionID,
+			ToVersionID:   version.ID,
+			Recipe:        version.RecipeBody,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.AppendEventsToThread(ctx, userID, &thread, []models.ThreadEvent{event}); err != nil {
+			return fmt.Errorf("failed to append events to thread: %w", err)
+		}
+		logger.Logger(ctx).Debug("appended event to thread")
+		updated, err = s.recipeService.GetUserRecipe(ctx, userID, recipeID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to redo recipe modification: %w", err)
+	}
+	return updated, nil
+}
+
+// CreateRecipe adds a recipe entered by hand to the user's recipes. It gets a
+// thread of its own so that its history is recorded like any other recipe's.
+func (s *ThreadService) CreateRecipe(ctx context.Context, userID string, body models.RecipeBody) (*models.UserRecipe, error) {
+	if err := validation.RecipeBody(body); err != nil {
+		return nil, err
+	}
+	created, err := s.createRecipe(ctx, userID, func(ctx context.Context, threadID string) (*models.UserRecipe, error) {
+		return s.recipeService.NewRecipe(ctx, userID, threadID, body)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recipe: %w", err)
+	}
+	return created, nil
+}
+
+// ForkGlobalRecipe copies a recipe from the catalog into the user's recipe
+// book. Like a recipe entered by hand it gets a thread of its own, and it
+// stays linked to the global recipe to tell when that changes.
+func (s *ThreadService) ForkGlobalRecipe(ctx context.Context, userID string, globalRecipeID string) (*models.UserRecipe, error) {
+	forked, err := s.createRecipe(ctx, userID, func(ctx context.Context, threadID string) (*models.UserRecipe, error) {
+		global, err := s.recipeService.GetGlobalRecipe(ctx, globalRecipeID)
+		if err != nil {
+			return nil, err
+		}
+		return s.recipeService.ForkRecipe(ctx, userID, threadID, *global)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fork global recipe: %w", err)
+	}
+	return forked, nil
+}
+
+// createRecipe creates a manual thread along with the recipe made by create,
+// recording the recipe's creation in the thread.
+func (s *ThreadService) createRecipe(ctx context.Context, userID string, create func(ctx context.Context, threadID string) (*models.UserRecipe, error)) (*models.UserRecipe, error) {
+	var created *models.UserRecipe
+	err := s.store.WithTx(func(tx db.Store) error {
+		var err error
+		ctx = db.ContextWithTx(ctx, tx)
+		thread := models.Thread{
+			ID:        uuid.New().String(),
+			Type:      models.ThreadTypeManual,
+			Events:    []models.ThreadEvent{},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		if err := tx.CreateThread(ctx, userID, thread); err != nil {
+			return fmt.Errorf("failed to save thread: %w", err)
+		}
+		created, err = create(ctx, thread.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create new recipe: %w", err)
+		}
+		event, err := NewThreadEvent(models.RecipeCreatedEvent{
+			RecipeID:  created.ID,
+			VersionID: created.LatestVersionID,
+			Recipe:    created.RecipeBody,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.AppendEventsToThread(ctx, userID, &thread, []models.ThreadEvent{event}); err != nil {
+			return fmt.Errorf("failed to append events to thread: %w", err)
+		}
+		if err := tx.AssociateThreadWithRecipe(ctx, userID, thread.ID, created.ID); err != nil {
+			return fmt.Errorf("failed to associate thread with recipe: %w", err)
+		}
+		logger.Logger(ctx).Debug("created recipe")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// EditRecipe replaces the recipe's contents with body, saving it as a new
+// version.
+func (s *ThreadService) EditRecipe(ctx context.Context, userID string, recipeID string, body models.RecipeBody) (*models.UserRecipe, error) {
+	return s.editRecipe(ctx, userID, recipeID, func(models.RecipeBody) (models.RecipeBody, error) {
+		return body, nil
+	})
+}
+
+// PatchRecipe applies a JSON Patch to the recipe's latest version, saving the
+// result as a new version.
+func (s *ThreadService) PatchRecipe(ctx context.Context, userID string, recipeID string, patch []models.PatchOperation) (*models.UserRecipe, error) {
+	return s.editRecipe(ctx, userID, recipeID, func(current models.RecipeBody) (models.RecipeBody, error) {
+		return recipe.ApplyPatch(current, patch)
+	})
+}
+
+// ScaleRecipe rescales the recipe's latest version to a number of servings,
+// saving the result as a new version.
+func (s *ThreadService) ScaleRecipe(ctx context.Context, userID string, recipeID string, servings int) (*models.UserRecipe, error) {
+	return s.editRecipe(ctx, userID, recipeID, func(current models.RecipeBody) (models.RecipeBody, error) {
+		return recipe.ScaleRecipe(current, servings)
+	})
+}
+
+// RestoreVersion saves a copy of one of the recipe's versions as its new
+// latest version and records it in the recipe's thread.
+func (s *ThreadService) RestoreVersion(ctx context.Context, userID string, recipeID string, versionID string) (*models.UserRecipe, error) {
+	return s.recordEdit(ctx, userID, recipeID, func(ctx context.Context, current *models.UserRecipe) (*models.UserRecipe, error) {
+		return s.recipeService.RestoreVersion(ctx, userID, recipeID, versionID)
+	})
+}
+
+// CommitMerge saves a merge of two of the recipe's versions as its new latest
+// version and records it in the recipe's thread.
+func (s *ThreadService) CommitMerge(ctx context.Context, userID string, recipeID string, request models.MergeRecipeVersionsRequest) (*models.UserRecipe, error) {
+	return s.recordEdit(ctx, userID, recipeID, func(ctx context.Context, current *models.UserRecipe) (*models.UserRecipe, error) {
+		return s.recipeService.CommitMerge(ctx, userID, recipeID, request)
+	})
+}
+
+// editRecipe saves the result of edit as the recipe's new version and records
+// it in the recipe's thread.
+func (s *ThreadService) editRecipe(ctx context.Context, userID string, recipeID string, edit func(current models.RecipeBody) (models.RecipeBody, error)) (*models.UserRecipe, error) {
+	return s.recordEdit(ctx, userID, recipeID, func(ctx context.Context, current *models.UserRecipe) (*models.UserRecipe, error) {
+		body, err := edit(current.RecipeBody)
+		if err != nil {
+			return nil, err
+		}
+		if err := validation.RecipeBody(body); err != nil {
+			return nil, err
+		}
+		if err := s.recipeService.UpdateRecipe(ctx, userID, recipeID, body); err != nil {
+			return nil, fmt.Errorf("failed to update recipe: %w", err)
+		}
+		return s.recipeService.GetUserRecipe(ctx, userID, recipeID)
+	})
+}
+
+// recordEdit runs save, which adds a new latest version to the recipe, and
+// records the new version in the recipe's thread in the same transaction.
+func (s *ThreadService) recordEdit(ctx context.Context, userID string, recipeID string, save func(ctx context.Context, current *models.UserRecipe) (*models.UserRecipe, error)) (*models.UserRecipe, error) {
+	var updated *models.UserRecipe
+	err := s.store.WithTx(func(tx db.Store) error {
+		ctx = db.ContextWithTx(ctx, tx)
+		current, err := s.recipeService.GetUserRecipe(ctx, userID, recipeID)
+		if err != nil {
+			return fmt.Errorf("failed to get recipe: %w", err)
+		}
+		thread, err := s.getThread(ctx, userID, current.ThreadID)
+		if err != nil {
+			return err
+		}
+		updated, err = save(ctx, current)
+		if err != nil {
+			return err
+		}
+		event, err := NewThreadEvent(models.RecipeEditedEvent{
+			FromVersionID: current.LatestVersionID,
+			ToVersionID:   updated.LatestVersionID,
+			Recipe:        updated.RecipeBody,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.AppendEventsToThread(ctx, userID, &thread, []models.ThreadEvent{event}); err != nil {
+			return fmt.Errorf("failed to append events to thread: %w", err)
+		}
+		logger.Logger(ctx).Debug("appended event to thread")
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit recipe: %w", err)
+	}
+	return updated, nil
+}
+
 func (s *ThreadService) AnswerCookingQuestion(ctx context.Context, userID string, threadID string, question string) (*models.AnswerCookingQuestionResponse, error) {
 	var response *models.AnswerCookingQuestionResponse
 	err := s.store.WithTx(func(tx db.Store) error {
@@ -406,9 +640,9 @@ func (s *ThreadService) AnswerCookingQuestion(ctx context.Context, userID string
 		if err != nil {
 			return fmt.Errorf("failed to get profile: %w", err)
 		}
-		thread, err := tx.GetThread(ctx, threadID)
+		thread, err := s.getThread(ctx, userID, threadID)
 		if err != nil {
-			return fmt.Errorf("failed to get thread: %w", err)
+			return err
 		}
 		recipeID := thread.RecipeID
 		if recipeID == nil {
@@ -431,16 +665,11 @@ func (s *ThreadService) AnswerCookingQuestion(ctx context.Context, userID string
 			Question: question,
 			Answer:   generalChatResponse.ResponseText,
 		}
-		payload, err := json.Marshal(questionEvent)
+		event, err := NewThreadEvent(questionEvent)
 		if err != nil {
-			return ErrInvalidThreadEventPayload
+			return err
 		}
-		event := models.ThreadEvent{
-			Type:      models.ThreadEventTypeQuestionAnswered,
-			Payload:   payload,
-			Timestamp: time.Now(),
-		}
-		if err := s.AppendEventsToThread(ctx, threadID, []models.ThreadEvent{event}); err != nil {
+		if err := s.AppendEventsToThread(ctx, userID, &thread, []models.ThreadEvent{event}); err != nil {
 			return fmt.Errorf("failed to append events to thread: %w", err)
 		}
 		response = &models.AnswerCookingQuestionResponse{
@@ -455,19 +684,47 @@ func (s *ThreadService) AnswerCookingQuestion(ctx context.Context, userID string
 	return response, nil
 }
 
-func (s *ThreadService) AppendEventsToThread(ctx context.Context, threadID string, events []models.ThreadEvent) error {
-	store := s.getStore(ctx)
-	for _, event := range events {
-		if err := store.AppendToThread(ctx, threadID, []models.ThreadEvent{event}); err != nil {
+// AppendEventsToThread adds events to a thread read earlier in the request,
+// failing with ErrThreadVersionConflict if anything else has been appended
+// since. The thread is updated to include the new events.
+func (s *ThreadService) AppendEventsToThread(ctx context.Context, userID string, thread *models.Thread, events []models.ThreadEvent) error {
+	if err := s.getStore(ctx).AppendToThread(ctx, userID, thread.ID, thread.Version, events); err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return ErrThreadNotFound
+		case errors.Is(err, db.ErrVersionConflict):
+			return ErrThreadVersionConflict
+		default:
 			return fmt.Errorf("failed to append to thread: %w", err)
 		}
 	}
+	thread.Events = append(thread.Events, events...)
+	thread.Version += len(events)
 	logger.Logger(ctx).Debug("appended events to thread")
 	return nil
 }
 
-func (s *ThreadService) GetThreadState(ctx context.Context, threadID string) (*models.ThreadState, error) {
-	thread, err := s.store.GetThread(ctx, threadID)
+// SnapshotInterval is how many events a read replays before it saves a new
+// snapshot of the thread.
+const SnapshotInterval = 50
+
+// GetThreadState reduces the thread's events, starting from its snapshot
+// when there is one the current reducer can build on. Events that can't be
+// reduced are skipped and listed in the state's SkippedEvents.
+func (s *ThreadService) GetThreadState(ctx context.Context, userID string, threadID string) (*models.ThreadState, error) {
+	store := s.getStore(ctx)
+	var base *models.ThreadState
+	snapshot, err := store.GetThreadSnapshot(ctx, threadID)
+	switch {
+	case err == nil && snapshot.ReducerVersion == ReducerVersion:
+		base = &snapshot.State
+	case err != nil && !errors.Is(err, db.ErrNotFound):
+		return nil, fmt.Errorf("failed to get thread snapshot: %w", err)
+	default:
+		snapshot.Version = 0
+	}
+
+	thread, err := store.GetThreadSince(ctx, userID, threadID, snapshot.Version)
 	if err != nil {
 		switch {
 		case errors.Is(err, db.ErrNotFound):
@@ -476,6 +733,24 @@ func (s *ThreadService) GetThreadState(ctx context.Context, threadID string) (*m
 			return nil, fmt.Errorf("failed to get thread: %w", err)
 		}
 	}
-	logger.Logger(ctx).Debug("got thread")
-	return ReduceThreadEvents(ctx, threadID, thread.Events, nil)
+	logger.Logger(ctx).Debug("got thread", zap.Int("snapshot_version", snapshot.Version), zap.Int("events", len(thread.Events)))
+	// A corrupt event shouldn't take the whole thread down, so it's skipped
+	// and listed in the state instead
+	state, skipped := ReduceThreadEventsLenient(ctx, threadID, thread.Events, base)
+
+	// Threads with skipped events aren't snapshotted, so repairing the events
+	// takes effect on the next read
+	if len(thread.Events) >= SnapshotInterval && len(skipped) == 0 {
+		err := store.SaveThreadSnapshot(ctx, models.ThreadSnapshot{
+			ThreadID:       threadID,
+			Version:        state.Version,
+			ReducerVersion: ReducerVersion,
+			State:          *state,
+		})
+		if err != nil {
+			// The state is still correct, the next read just replays more
+			logger.Logger(ctx).Warn("failed to save thread snapshot", zap.Error(err))
+		}
+	}
+	return state, nil
 }
@@ -0,0 +1,237 @@
+package thread
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func newTestStore(tb testing.TB) db.Store {
+	tb.Helper()
+	sqlDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { sqlDB.Close() })
+	// Every connection to :memory: is a new database
+	sqlDB.SetMaxOpenConns(1)
+	store, err := db.NewSQLiteStoreWithDB(sqlDB)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return store
+}
+
+// longThreadEvents is a suggestion thread with n events: a prompt, then
+// rounds of suggestions, edits and questions.
+func longThreadEvents(tb testing.TB, from, n int) []models.ThreadEvent {
+	tb.Helper()
+	events := make([]models.ThreadEvent, 0, n)
+	add := func(eventType models.ThreadEventType, payload any) {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		events = append(events, models.ThreadEvent{Type: eventType, Payload: data})
+	}
+	for i := from; i < from+n; i++ {
+		switch {
+		case i == 0:
+			add(models.ThreadEventTypePromptSet, models.PromptSetEvent{Prompt: "soup"})
+		case i%4 == 1:
+			add(models.ThreadEventTypeSuggestionGenerated, models.SuggestionGeneratedEvent{
+				SuggestionID: fmt.Sprintf("suggestion-%d", i),
+				Recipe: models.RecipeBody{
+					Title:       fmt.Sprintf("Soup %d", i),
+					Ingredients: []models.Ingredient{{Name: "stock"}, {Name: "onion"}},
+					Steps:       []models.Step{"Simmer"},
+				},
+			})
+		case i%4 == 2:
+			add(models.ThreadEventTypeSuggestionRejected, models.SuggestionRejectedEvent{SuggestionID: fmt.Sprintf("suggestion-%d", i-1)})
+		case i%4 == 3:
+			add(models.ThreadEventTypePromptEdited, models.PromptEditedEvent{Prompt: fmt.Sprintf("soup %d", i)})
+		default:
+			add(models.ThreadEventTypeQuestionAnswered, models.QuestionAnsweredEvent{Question: "Why?", Answer: "Because"})
+		}
+	}
+	return events
+}
+
+func createLongThread(tb testing.TB, store db.Store, userID string, n int) string {
+	tb.Helper()
+	thread := models.Thread{
+		ID:     fmt.Sprintf("thread-%d", n),
+		Type:   models.ThreadTypeSuggestion,
+		Events: longThreadEvents(tb, 0, n),
+	}
+	if err := store.CreateThread(context.Background(), userID, thread); err != nil {
+		tb.Fatal(err)
+	}
+	return thread.ID
+}
+
+// replayThreadState is GetThreadState without snapshots.
+func replayThreadState(tb testing.TB, store db.Store, userID, threadID string) *models.ThreadState {
+	tb.Helper()
+	ctx := context.Background()
+	thread, err := store.GetThread(ctx, userID, threadID)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	state, err := ReduceThreadEvents(ctx, threadID, thread.Events, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return state
+}
+
+func assertSameState(t *testing.T, expected, got *models.ThreadState) {
+	t.Helper()
+	want, _ := json.Marshal(expected)
+	have, _ := json.Marshal(got)
+	if string(want) != string(have) {
+		t.Errorf("expected state\n%s\ngot\n%s", want, have)
+	}
+}
+
+func TestGetThreadStateFromSnapshot(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	service := NewThreadService(store, nil, nil, nil)
+	user, err := store.CreateUser(ctx, "cook@example.com", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	threadID := createLongThread(t, store, user.ID, SnapshotInterval+10)
+
+	state, err := service.GetThreadState(ctx, user.ID, threadID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameState(t, replayThreadState(t, store, user.ID, threadID), state)
+	snapshot, err := store.GetThreadSnapshot(ctx, threadID)
+	if err != nil {
+		t.Fatalf("expected a snapshot after replaying %d events, got %v", SnapshotInterval+10, err)
+	}
+	if snapshot.Version != SnapshotInterval+10 || snapshot.ReducerVersion != ReducerVersion {
+		t.Errorf("unexpected snapshot version %d, reducer version %d", snapshot.Version, snapshot.ReducerVersion)
+	}
+
+	if err := store.AppendToThread(ctx, user.ID, threadID, snapshot.Version, longThreadEvents(t, snapshot.Version, 3)); err != nil {
+		t.Fatal(err)
+	}
+	state, err = service.GetThreadState(ctx, user.ID, threadID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameState(t, replayThreadState(t, store, user.ID, threadID), state)
+	if state.Version != SnapshotInterval+13 {
+		t.Errorf("expected version %d, got %d", SnapshotInterval+13, state.Version)
+	}
+
+	// A snapshot from another reducer version can't be trusted
+	bogus := snapshot
+	bogus.ReducerVersion = ReducerVersion + 1
+	bogus.State.CurrentPrompt = "not from this reducer"
+	if err := store.SaveThreadSnapshot(ctx, bogus); err != nil {
+		t.Fatal(err)
+	}
+	state, err = service.GetThreadState(ctx, user.ID, threadID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameState(t, replayThreadState(t, store, user.ID, threadID), state)
+	if snapshot, _ := store.GetThreadSnapshot(ctx, threadID); snapshot.ReducerVersion != ReducerVersion {
+		t.Errorf("expected the snapshot to be rebuilt, got reducer version %d", snapshot.ReducerVersion)
+	}
+
+	other, err := store.CreateUser(ctx, "other@example.com", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.GetThreadState(ctx, other.ID, threadID); err != ErrThreadNotFound {
+		t.Errorf("expected another user's snapshot to be ErrThreadNotFound, got %v", err)
+	}
+}
+
+func TestGetThreadStateSkipsCorruptEvents(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	service := NewThreadService(store, nil, nil, nil)
+	user, err := store.CreateUser(ctx, "cook@example.com", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := longThreadEvents(t, 0, SnapshotInterval+10)
+	events[3] = models.ThreadEvent{Type: models.ThreadEventTypePromptEdited, Payload: json.RawMessage(`{"prompt":5}`)}
+	thread := models.Thread{ID: "corrupt", Type: models.ThreadTypeSuggestion, Events: events}
+	if err := store.CreateThread(ctx, user.ID, thread); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := service.GetThreadState(ctx, user.ID, thread.ID)
+	if err != nil {
+		t.Fatalf("expected the corrupt event to be skipped, got %v", err)
+	}
+	if len(state.SkippedEvents) != 1 || state.SkippedEvents[0].Index != 3 || state.SkippedEvents[0].Type != models.ThreadEventTypePromptEdited || state.SkippedEvents[0].Error == "" {
+		t.Errorf("expected event 3 to be listed as skipped, got %+v", state.SkippedEvents)
+	}
+	if state.Version != SnapshotInterval+10 || state.OriginalPrompt != "soup" {
+		t.Errorf("expected the rest of the events to be reduced, got %+v", state)
+	}
+	if _, err := store.GetThreadSnapshot(ctx, thread.ID); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("expected a thread with skipped events not to be snapshotted, got %v", err)
+	}
+
+	for _, version := range []int{2, 4} {
+		state, err := service.GetThreadStateAtVersion(ctx, user.ID, thread.ID, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if skipped := len(state.SkippedEvents); skipped != version/4 {
+			t.Errorf("expected %d skipped events at version %d, got %d", version/4, version, skipped)
+		}
+	}
+}
+
+// BenchmarkGetThreadState compares replaying every event against starting
+// from a snapshot with a few events appended since.
+func BenchmarkGetThreadState(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range []int{100, 500, 1000} {
+		store := newTestStore(b)
+		service := NewThreadService(store, nil, nil, nil)
+		user, err := store.CreateUser(ctx, "cook@example.com", "password")
+		if err != nil {
+			b.Fatal(err)
+		}
+		threadID := createLongThread(b, store, user.ID, n)
+
+		b.Run(fmt.Sprintf("replay/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				replayThreadState(b, store, user.ID, threadID)
+			}
+		})
+
+		if _, err := service.GetThreadState(ctx, user.ID, threadID); err != nil {
+			b.Fatal(err)
+		}
+		if err := store.AppendToThread(ctx, user.ID, threadID, n, longThreadEvents(b, n, 5)); err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("snapshot/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := service.GetThreadState(ctx, user.ID, threadID); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package thread
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ajohnston1219/eatme/api/internal/clients"
+	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// StreamHandlers receive partial results while a chat response is streamed.
+// Suggestions are passed as the events they will be persisted as, so the
+// suggestion IDs a client sees are the ones it can later accept.
+type StreamHandlers struct {
+	OnToken      func(text string) error
+	OnSuggestion func(suggestion models.SuggestionGeneratedEvent) error
+}
+
+// The streamed variants below read everything they need up front, stream the
+// ML response without holding a transaction open, and only persist thread
+// events once the stream has completed. A stream that fails or is cancelled
+// leaves the thread untouched.
+
+func (s *ThreadService) StartSuggestionThreadStream(ctx context.Context, userID string, prompt string, handlers StreamHandlers) (*models.ThreadState, error) {
+	profile, err := s.userService.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+	logger.Logger(ctx).Debug("got profile")
+
+	promptEvent, err := NewThreadEvent(models.PromptSetEvent{Prompt: prompt})
+	if err != nil {
+		return nil, err
+	}
+	events := []models.ThreadEvent{promptEvent}
+	suggestionRequest := &models.SuggestChatRequest{
+		Profile: *profile,
+		Message: prompt,
+		History: []string{},
+	}
+	_, err = s.chatService.GenerateSuggestionsStream(ctx, suggestionRequest, clients.StreamHandlers{
+		OnToken: handlers.OnToken,
+		OnSuggestion: func(suggestion *models.Suggestion) error {
+			event := models.SuggestionGeneratedEvent{
+				SuggestionID: uuid.New().String(),
+				Recipe:       suggestion.Recipe,
+				ResponseText: suggestion.ResponseText,
+			}
+			threadEvent, err := NewThreadEvent(event)
+			if err != nil {
+				return err
+			}
+			events = append(events, threadEvent)
+			if handlers.OnSuggestion == nil {
+				return nil
+			}
+			return handlers.OnSuggestion(event)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recipe suggestions: %w", err)
+	}
+	logger.Logger(ctx).Debug("streamed recipe suggestions", zap.Int("suggestions", len(events)-1))
+
+	thread := models.Thread{
+		ID:        uuid.New().String(),
+		Type:      models.ThreadTypeSuggestion,
+		Events:    events,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	err = s.store.WithTx(func(tx db.Store) error {
+		return tx.CreateThread(db.ContextWithTx(ctx, tx), userID, thread)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save thread: %w", err)
+	}
+	logger.Logger(ctx).Debug("saved thread")
+
+	state, err := ReduceThreadEvents(ctx, thread.ID, events, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reduce thread events: %w", err)
+	}
+	return state, nil
+}
+
+func (s *ThreadService) ModifyRecipeViaChatStream(ctx context.Context, userID string, recipeID string, prompt string, handlers StreamHandlers) (*models.ModifyRecipeViaChatResponse, error) {
+	profile, err := s.userService.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+	recipe, err := s.recipeService.GetUserRecipe(ctx, userID, recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipe: %w", err)
+	}
+	thread, err := s.getThread(ctx, userID, recipe.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+
+	modifyRequest := &models.ModifyChatRequest{
+		Message: prompt,
+		Recipe:  recipe.RecipeBody,
+		Profile: *profile,
+	}
+	chatResponse, err := s.chatService.ModifyRecipeViaChatStream(ctx, modifyRequest, clients.StreamHandlers{
+		OnToken: handlers.OnToken,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to modify recipe: %w", err)
+	}
+	logger.Logger(ctx).Debug("modified recipe")
+
+	event, err := NewThreadEvent(models.RecipeModifiedEvent{Recipe: chatResponse.NewRecipe})
+	if err != nil {
+		return nil, err
+	}
+	err = s.store.WithTx(func(tx db.Store) error {
+		return s.AppendEventsToThread(db.ContextWithTx(ctx, tx), userID, &thread, []models.ThreadEvent{event})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to append events to thread: %w", err)
+	}
+
+	return &models.ModifyRecipeViaChatResponse{
+		OriginalRecipe: recipe.RecipeBody,
+		NewRecipe:      chatResponse.NewRecipe,
+		ResponseText:   chatResponse.ResponseText,
+	}, nil
+}
+
+func (s *ThreadService) AnswerCookingQuestionStream(ctx context.Context, userID string, threadID string, question string, handlers StreamHandlers) (*models.AnswerCookingQuestionResponse, error) {
+	profile, err := s.userService.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+	thread, err := s.getThread(ctx, userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if thread.RecipeID == nil {
+		return nil, ErrThreadNotAssociatedWithRecipeVersion
+	}
+	recipe, err := s.recipeService.GetUserRecipe(ctx, userID, *thread.RecipeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipe: %w", err)
+	}
+
+	generalChatRequest := &models.GeneralChatRequest{
+		Message: question,
+		Recipe:  recipe.RecipeBody,
+		Profile: *profile,
+	}
+	generalChatResponse, err := s.chatService.AnswerCookingQuestionStream(ctx, generalChatRequest, clients.StreamHandlers{
+		OnToken: handlers.OnToken,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to answer cooking question: %w", err)
+	}
+
+	event, err := NewThreadEvent(models.QuestionAnsweredEvent{
+		Question: question,
+		Answer:   generalChatResponse.ResponseText,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = s.store.WithTx(func(tx db.Store) error {
+		return s.AppendEventsToThread(db.ContextWithTx(ctx, tx), userID, &thread, []models.ThreadEvent{event})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to append events to thread: %w", err)
+	}
+	logger.Logger(ctx).Debug("answered cooking question")
+
+	return &models.AnswerCookingQuestionResponse{
+		Answer: generalChatResponse.ResponseText,
+	}, nil
+}
+
+func (s *ThreadService) getThread(ctx context.Context, userID string, threadID string) (models.Thread, error) {
+	thread, err := s.getStore(ctx).GetThread(ctx, userID, threadID)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return thread, ErrThreadNotFound
+		default:
+			return thread, fmt.Errorf("failed to get thread: %w", err)
+		}
+	}
+	return thread, nil
+}
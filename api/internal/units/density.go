@@ -0,0 +1,97 @@
+package units
+
+import (
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+// Density describes how much an ingredient weighs by volume.
+type Density struct {
+	gramsPerMilliliter *big.Rat
+	// weighed is set for dry ingredients that metric recipes measure by
+	// weight rather than by volume
+	weighed bool
+}
+
+// GramsPerCup returns the weight of one US cup of the ingredient.
+func (d Density) GramsPerCup() float64 {
+	g, _ := new(big.Rat).Mul(d.gramsPerMilliliter, table[models.MeasurementUnitCup].base).Float64()
+	return g
+}
+
+func perCup(grams string, weighed bool) Density {
+	d := new(big.Rat).Quo(rat(grams), fluidOunces("8"))
+	return Density{gramsPerMilliliter: d, weighed: weighed}
+}
+
+// densities is keyed by ingredient name in singular form. Weights per cup
+// follow common baking references for spooned and leveled measurements.
+var densities = map[string]Density{
+	"water":              perCup("236.5882365", false),
+	"milk":               perCup("242", false),
+	"buttermilk":         perCup("242", false),
+	"heavy cream":        perCup("232", false),
+	"cream":              perCup("232", false),
+	"yogurt":             perCup("227", false),
+	"sour cream":         perCup("227", false),
+	"oil":                perCup("218", false),
+	"olive oil":          perCup("216", false),
+	"honey":              perCup("336", false),
+	"maple syrup":        perCup("312", false),
+	"vinegar":            perCup("239", false),
+	"broth":              perCup("236.5882365", false),
+	"stock":              perCup("236.5882365", false),
+	"butter":             perCup("227", true),
+	"peanut butter":      perCup("270", true),
+	"flour":              perCup("120", true),
+	"all-purpose flour":  perCup("120", true),
+	"bread flour":        perCup("120", true),
+	"whole wheat flour":  perCup("113", true),
+	"almond flour":       perCup("96", true),
+	"sugar":              perCup("200", true),
+	"granulated sugar":   perCup("200", true),
+	"brown sugar":        perCup("213", true),
+	"powdered sugar":     perCup("113", true),
+	"confectioner sugar": perCup("113", true),
+	"cocoa powder":       perCup("84", true),
+	"cornstarch":         perCup("112", true),
+	"rolled oat":         perCup("89", true),
+	"oat":                perCup("89", true),
+	"rice":               perCup("198", true),
+	"chocolate chip":     perCup("170", true),
+	"parmesan":           perCup("100", true),
+	"shredded cheese":    perCup("113", true),
+	"salt":               perCup("288", false),
+	"baking soda":        perCup("288", false),
+	"baking powder":      perCup("192", false),
+}
+
+var nonLetter = regexp.MustCompile(`[^a-z\s-]+`)
+
+// DensityOf looks up the density of an ingredient by name. The longest
+// known name contained in the ingredient name wins, so "brown sugar" is
+// preferred over "sugar". Ties are broken alphabetically.
+func DensityOf(name string) (Density, bool) {
+	words := strings.Fields(nonLetter.ReplaceAllString(strings.ToLower(name), " "))
+	for i, word := range words {
+		words[i] = Singular(word)
+	}
+	padded := " " + strings.Join(words, " ") + " "
+
+	bestKey := ""
+	for key := range densities {
+		if len(key) < len(bestKey) || (len(key) == len(bestKey) && key > bestKey) {
+			continue
+		}
+		if strings.Contains(padded, " "+key+" ") {
+			bestKey = key
+		}
+	}
+	if bestKey == "" {
+		return Density{}, false
+	}
+	return densities[bestKey], true
+}
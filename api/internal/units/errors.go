@@ -0,0 +1,11 @@
+package units
+
+import "errors"
+
+var (
+	ErrUnknownUnit       = errors.New("unknown unit")
+	ErrUnknownUnitSystem = errors.New("unknown unit system")
+	ErrIncompatibleUnits = errors.New("incompatible units")
+	ErrUnknownDensity    = errors.New("unknown ingredient density")
+	ErrInvalidQuantity   = errors.New("invalid quantity")
+)
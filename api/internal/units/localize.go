@@ -0,0 +1,127 @@
+package units
+
+import (
+	"math"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+type rung struct {
+	unit models.MeasurementUnit
+	// min is the smallest quantity, in this unit, that is shown in it
+	min float64
+}
+
+// ladders list the display units of each system from largest to smallest.
+var ladders = map[models.UnitSystem]map[Dimension][]rung{
+	models.UnitSystemImperial: {
+		DimensionVolume: {
+			{models.MeasurementUnitCup, 0.25},
+			{models.MeasurementUnitTablespoon, 1},
+			{models.MeasurementUnitTeaspoon, 0},
+		},
+		DimensionMass: {
+			{models.MeasurementUnitPound, 1},
+			{models.MeasurementUnitOunce, 0},
+		},
+	},
+	models.UnitSystemMetric: {
+		DimensionVolume: {
+			{models.MeasurementUnitLiter, 1},
+			{models.MeasurementUnitMilliliter, 0},
+		},
+		DimensionMass: {
+			{models.MeasurementUnitKilogram, 1},
+			{models.MeasurementUnitGram, 0},
+		},
+	},
+}
+
+// Quantity is an amount in a specific unit.
+type Quantity struct {
+	Quantity float64
+	Unit     models.MeasurementUnit
+}
+
+// Candidates expresses a quantity, given in the base unit of its dimension,
+// in every display unit of a system that it is large enough for, largest
+// unit first. The smallest unit is always included.
+func Candidates(base float64, dim Dimension, system models.UnitSystem) []Quantity {
+	ladder, ok := ladders[system][dim]
+	if !ok {
+		return []Quantity{{base, models.MeasurementUnitCount}}
+	}
+	var candidates []Quantity
+	for i, r := range ladder {
+		q := base / baseOf(r.unit)
+		if q >= r.min || i == len(ladder)-1 {
+			candidates = append(candidates, Quantity{q, r.unit})
+		}
+	}
+	return candidates
+}
+
+// Best picks the unit of a system that reads best for a quantity given in
+// the base unit of its dimension, and returns the rounded quantity in that
+// unit.
+func Best(base float64, dim Dimension, system models.UnitSystem) (float64, models.MeasurementUnit) {
+	best := Candidates(base, dim, system)[0]
+	return Round(best.Quantity, best.Unit), best.Unit
+}
+
+// Round rounds a quantity to the precision that makes sense for its unit.
+// Grams and milliliters are rounded to whole numbers once they reach ten,
+// everything else to two decimal places.
+func Round(quantity float64, u models.MeasurementUnit) float64 {
+	switch u {
+	case models.MeasurementUnitGram, models.MeasurementUnitMilliliter:
+		if quantity >= 10 {
+			return math.Round(quantity)
+		}
+		return math.Round(quantity*10) / 10
+	}
+	return math.Round(quantity*100) / 100
+}
+
+// Localize converts an ingredient into the given measurement system.
+// Ingredients already measured in the system are left as written, as are
+// counts and spoon measurements which both systems share. For metric, dry
+// ingredients measured by the cup are converted to weight when their density
+// is known.
+func Localize(ingredient models.Ingredient, system models.UnitSystem) models.Ingredient {
+	info, err := lookup(ingredient.Unit)
+	if err != nil || system == "" || info.dimension == DimensionCount {
+		return ingredient
+	}
+	if info.system == "" || info.system == system {
+		return ingredient
+	}
+	if system == models.UnitSystemMetric && info.dimension == DimensionVolume {
+		if d, ok := DensityOf(ingredient.Name); ok && d.weighed {
+			grams, err := ConvertIngredient(ingredient, models.MeasurementUnitGram)
+			if err == nil {
+				ingredient.Quantity, ingredient.Unit = Best(grams.Quantity, DimensionMass, system)
+				return ingredient
+			}
+		}
+	}
+	base, dim := ToBase(ingredient.Quantity, ingredient.Unit)
+	ingredient.Quantity, ingredient.Unit = Best(base, dim, system)
+	return ingredient
+}
+
+// LocalizeRecipe converts every ingredient of a recipe into the given
+// measurement system.
+func LocalizeRecipe(recipe models.RecipeBody, system models.UnitSystem) models.RecipeBody {
+	ingredients := make(models.Ingredients, len(recipe.Ingredients))
+	for i, ingredient := range recipe.Ingredients {
+		ingredients[i] = Localize(ingredient, system)
+	}
+	recipe.Ingredients = ingredients
+	return recipe
+}
+
+func baseOf(u models.MeasurementUnit) float64 {
+	b, _ := table[u].base.Float64()
+	return b
+}
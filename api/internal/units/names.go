@@ -0,0 +1,37 @@
+package units
+
+import "strings"
+
+var irregularPlurals = map[string]string{
+	"leaves": "leaf",
+	"loaves": "loaf",
+	"halves": "half",
+}
+
+// Singular returns the singular form of a lowercase word in an ingredient
+// name, e.g. "tomatoes" becomes "tomato" and "cherries" becomes "cherry".
+// Short words and words that only look plural, like "asparagus", are kept.
+func Singular(word string) string {
+	if s, ok := irregularPlurals[word]; ok {
+		return s
+	}
+	switch {
+	case len(word) <= 3:
+		return word
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"),
+		strings.HasSuffix(word, "ches"),
+		strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "sses"),
+		strings.HasSuffix(word, "xes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"),
+		strings.HasSuffix(word, "us"),
+		strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
@@ -0,0 +1,233 @@
+package units
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+type Dimension int
+
+const (
+	DimensionCount Dimension = iota
+	DimensionVolume
+	DimensionMass
+)
+
+type unit struct {
+	dimension Dimension
+	// base is the size of one of the unit in the base unit of its dimension,
+	// milliliters for volume and grams for mass
+	base *big.Rat
+	// system is empty for units that are used in both systems
+	system models.UnitSystem
+}
+
+func rat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("units: invalid rational " + s)
+	}
+	return r
+}
+
+// fluidOunce is the US customary fluid ounce, every other US volume is
+// defined in terms of it.
+var fluidOunce = rat("29.5735295625")
+
+func fluidOunces(n string) *big.Rat {
+	return new(big.Rat).Mul(fluidOunce, rat(n))
+}
+
+var table = map[models.MeasurementUnit]unit{
+	models.MeasurementUnitMilliliter: {DimensionVolume, rat("1"), models.UnitSystemMetric},
+	models.MeasurementUnitLiter:      {DimensionVolume, rat("1000"), models.UnitSystemMetric},
+	models.MeasurementUnitTeaspoon:   {DimensionVolume, fluidOunces("1/6"), ""},
+	models.MeasurementUnitTablespoon: {DimensionVolume, fluidOunces("1/2"), ""},
+	models.MeasurementUnitFluidOunce: {DimensionVolume, fluidOunces("1"), models.UnitSystemImperial},
+	models.MeasurementUnitCup:        {DimensionVolume, fluidOunces("8"), models.UnitSystemImperial},
+	models.MeasurementUnitPint:       {DimensionVolume, fluidOunces("16"), models.UnitSystemImperial},
+	models.MeasurementUnitQuart:      {DimensionVolume, fluidOunces("32"), models.UnitSystemImperial},
+	models.MeasurementUnitGallon:     {DimensionVolume, fluidOunces("128"), models.UnitSystemImperial},
+	models.MeasurementUnitGram:       {DimensionMass, rat("1"), models.UnitSystemMetric},
+	models.MeasurementUnitKilogram:   {DimensionMass, rat("1000"), models.UnitSystemMetric},
+	models.MeasurementUnitOunce:      {DimensionMass, rat("28.349523125"), models.UnitSystemImperial},
+	models.MeasurementUnitPound:      {DimensionMass, rat("453.59237"), models.UnitSystemImperial},
+	models.MeasurementUnitCount:      {DimensionCount, rat("1"), ""},
+}
+
+var aliases = func() map[string]models.MeasurementUnit {
+	spellings := map[models.MeasurementUnit][]string{
+		models.MeasurementUnitGram:       {"g", "gram", "grams", "gr"},
+		models.MeasurementUnitKilogram:   {"kg", "kgs", "kilogram", "kilograms"},
+		models.MeasurementUnitMilliliter: {"ml", "milliliter", "milliliters", "millilitre", "millilitres"},
+		models.MeasurementUnitLiter:      {"l", "liter", "liters", "litre", "litres"},
+		models.MeasurementUnitTeaspoon:   {"tsp", "tsps", "teaspoon", "teaspoons", "t"},
+		models.MeasurementUnitTablespoon: {"tbsp", "tbsps", "tbs", "tablespoon", "tablespoons", "T"},
+		models.MeasurementUnitFluidOunce: {"fl oz", "floz", "fluid ounce", "fluid ounces"},
+		models.MeasurementUnitCup:        {"cup", "cups", "c"},
+		models.MeasurementUnitPint:       {"pint", "pints", "pt"},
+		models.MeasurementUnitQuart:      {"quart", "quarts", "qt"},
+		models.MeasurementUnitGallon:     {"gallon", "gallons", "gal"},
+		models.MeasurementUnitOunce:      {"oz", "ounce", "ounces"},
+		models.MeasurementUnitPound:      {"lb", "lbs", "pound", "pounds"},
+		models.MeasurementUnitCount:      {"", "count", "whole", "piece", "pieces"},
+	}
+	aliases := map[string]models.MeasurementUnit{}
+	for u, names := range spellings {
+		for _, name := range names {
+			aliases[name] = u
+		}
+	}
+	return aliases
+}()
+
+// ParseUnit resolves a unit as it is commonly written, e.g. "Tablespoons" or
+// "fl. oz.", to a MeasurementUnit. A lone "T" is a tablespoon and a lone "t"
+// a teaspoon, following the usual recipe shorthand.
+func ParseUnit(s string) (models.MeasurementUnit, error) {
+	key := strings.ReplaceAll(strings.TrimSpace(s), ".", "")
+	key = strings.Join(strings.Fields(key), " ")
+	if key == "T" || key == "t" {
+		return aliases[key], nil
+	}
+	if u, ok := aliases[strings.ToLower(key)]; ok {
+		return u, nil
+	}
+	return "", ErrUnknownUnit
+}
+
+// IsKnown reports whether u is one of the units recipes are stored in.
+func IsKnown(u models.MeasurementUnit) bool {
+	_, err := lookup(u)
+	return err == nil
+}
+
+// ParseUnitSystem validates a unit system preference.
+func ParseUnitSystem(s string) (models.UnitSystem, error) {
+	switch system := models.UnitSystem(strings.ToLower(s)); system {
+	case models.UnitSystemMetric, models.UnitSystemImperial:
+		return system, nil
+	}
+	return "", ErrUnknownUnitSystem
+}
+
+func lookup(u models.MeasurementUnit) (unit, error) {
+	info, ok := table[u]
+	if !ok {
+		return unit{}, ErrUnknownUnit
+	}
+	return info, nil
+}
+
+// DimensionOf returns what a unit measures. Unknown units are treated as
+// counts.
+func DimensionOf(u models.MeasurementUnit) Dimension {
+	info, err := lookup(u)
+	if err != nil {
+		return DimensionCount
+	}
+	return info.dimension
+}
+
+// SystemOf returns the measurement system a unit belongs to, or an empty
+// system for units like teaspoons that are used in both.
+func SystemOf(u models.MeasurementUnit) models.UnitSystem {
+	info, err := lookup(u)
+	if err != nil {
+		return ""
+	}
+	return info.system
+}
+
+// ConvertRat converts an exact quantity between two units of the same
+// dimension.
+func ConvertRat(quantity *big.Rat, from, to models.MeasurementUnit) (*big.Rat, error) {
+	fromInfo, err := lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	toInfo, err := lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	if fromInfo.dimension != toInfo.dimension {
+		return nil, ErrIncompatibleUnits
+	}
+	result := new(big.Rat).Mul(quantity, fromInfo.base)
+	return result.Quo(result, toInfo.base), nil
+}
+
+// Convert converts a quantity between two units of the same dimension. The
+// conversion factors are exact, the only rounding is the final conversion
+// back to a float.
+func Convert(quantity float64, from, to models.MeasurementUnit) (float64, error) {
+	q := new(big.Rat)
+	if q.SetFloat64(quantity) == nil {
+		return 0, ErrInvalidQuantity
+	}
+	result, err := ConvertRat(q, from, to)
+	if err != nil {
+		return 0, err
+	}
+	f, _ := result.Float64()
+	return f, nil
+}
+
+// ConvertIngredient converts an ingredient to another unit. Conversions
+// between volume and mass use the density of the ingredient and fail with
+// ErrUnknownDensity when the ingredient isn't in the density table.
+func ConvertIngredient(ingredient models.Ingredient, to models.MeasurementUnit) (models.Ingredient, error) {
+	fromInfo, err := lookup(ingredient.Unit)
+	if err != nil {
+		return ingredient, err
+	}
+	toInfo, err := lookup(to)
+	if err != nil {
+		return ingredient, err
+	}
+	q := new(big.Rat)
+	if q.SetFloat64(ingredient.Quantity) == nil {
+		return ingredient, ErrInvalidQuantity
+	}
+
+	var result *big.Rat
+	switch {
+	case fromInfo.dimension == toInfo.dimension:
+		result, err = ConvertRat(q, ingredient.Unit, to)
+		if err != nil {
+			return ingredient, err
+		}
+	case fromInfo.dimension == DimensionVolume && toInfo.dimension == DimensionMass,
+		fromInfo.dimension == DimensionMass && toInfo.dimension == DimensionVolume:
+		d, ok := DensityOf(ingredient.Name)
+		if !ok {
+			return ingredient, ErrUnknownDensity
+		}
+		result = new(big.Rat).Mul(q, fromInfo.base)
+		if fromInfo.dimension == DimensionVolume {
+			result.Mul(result, d.gramsPerMilliliter)
+		} else {
+			result.Quo(result, d.gramsPerMilliliter)
+		}
+		result.Quo(result, toInfo.base)
+	default:
+		return ingredient, ErrIncompatibleUnits
+	}
+
+	ingredient.Quantity, _ = result.Float64()
+	ingredient.Unit = to
+	return ingredient, nil
+}
+
+// ToBase converts a quantity into the base unit of its dimension, milliliters
+// for volume, grams for mass and counts for everything else.
+func ToBase(quantity float64, u models.MeasurementUnit) (float64, Dimension) {
+	info, err := lookup(u)
+	if err != nil {
+		info = table[models.MeasurementUnitCount]
+	}
+	base, _ := info.base.Float64()
+	return quantity * base, info.dimension
+}
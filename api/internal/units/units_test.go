@@ -0,0 +1,218 @@
+package units
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		quantity float64
+		from     models.MeasurementUnit
+		to       models.MeasurementUnit
+		expected float64
+	}{
+		{3, models.MeasurementUnitTeaspoon, models.MeasurementUnitTablespoon, 1},
+		{16, models.MeasurementUnitTablespoon, models.MeasurementUnitCup, 1},
+		{2, models.MeasurementUnitCup, models.MeasurementUnitPint, 1},
+		{4, models.MeasurementUnitQuart, models.MeasurementUnitGallon, 1},
+		{1, models.MeasurementUnitCup, models.MeasurementUnitFluidOunce, 8},
+		{1, models.MeasurementUnitCup, models.MeasurementUnitMilliliter, 236.5882365},
+		{1.5, models.MeasurementUnitLiter, models.MeasurementUnitMilliliter, 1500},
+		{16, models.MeasurementUnitOunce, models.MeasurementUnitPound, 1},
+		{1, models.MeasurementUnitPound, models.MeasurementUnitGram, 453.59237},
+		{1, models.MeasurementUnitKilogram, models.MeasurementUnitPound, 1000 / 453.59237},
+	}
+	for _, tt := range tests {
+		got, err := Convert(tt.quantity, tt.from, tt.to)
+		if err != nil {
+			t.Errorf("%v %s to %s: unexpected error %v", tt.quantity, tt.from, tt.to, err)
+			continue
+		}
+		if !approxEqual(got, tt.expected) {
+			t.Errorf("%v %s to %s: expected %v, got %v", tt.quantity, tt.from, tt.to, tt.expected, got)
+		}
+	}
+}
+
+func TestConvertRatIsExact(t *testing.T) {
+	cups := big.NewRat(1, 3)
+	tsp, err := ConvertRat(cups, models.MeasurementUnitCup, models.MeasurementUnitTeaspoon)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if tsp.Cmp(big.NewRat(16, 1)) != 0 {
+		t.Fatalf("expected 16 tsp, got %s", tsp.RatString())
+	}
+	back, err := ConvertRat(tsp, models.MeasurementUnitTeaspoon, models.MeasurementUnitCup)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if back.Cmp(cups) != 0 {
+		t.Fatalf("expected round trip to give %s, got %s", cups.RatString(), back.RatString())
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	if _, err := Convert(1, models.MeasurementUnitCup, models.MeasurementUnitGram); !errors.Is(err, ErrIncompatibleUnits) {
+		t.Errorf("expected %v, got %v", ErrIncompatibleUnits, err)
+	}
+	if _, err := Convert(1, "handful", models.MeasurementUnitGram); !errors.Is(err, ErrUnknownUnit) {
+		t.Errorf("expected %v, got %v", ErrUnknownUnit, err)
+	}
+	if _, err := Convert(math.NaN(), models.MeasurementUnitCup, models.MeasurementUnitTeaspoon); !errors.Is(err, ErrInvalidQuantity) {
+		t.Errorf("expected %v, got %v", ErrInvalidQuantity, err)
+	}
+}
+
+func TestConvertIngredientWithDensity(t *testing.T) {
+	flour, err := ConvertIngredient(models.Ingredient{Name: "All-Purpose Flour", Quantity: 2, Unit: models.MeasurementUnitCup}, models.MeasurementUnitGram)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !approxEqual(flour.Quantity, 240) || flour.Unit != models.MeasurementUnitGram {
+		t.Fatalf("expected 240 g, got %v %s", flour.Quantity, flour.Unit)
+	}
+
+	sugar, err := ConvertIngredient(models.Ingredient{Name: "brown sugar, packed", Quantity: 213, Unit: models.MeasurementUnitGram}, models.MeasurementUnitCup)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !approxEqual(sugar.Quantity, 1) {
+		t.Fatalf("expected 1 cup, got %v %s", sugar.Quantity, sugar.Unit)
+	}
+
+	_, err = ConvertIngredient(models.Ingredient{Name: "Kale", Quantity: 1, Unit: models.MeasurementUnitCup}, models.MeasurementUnitGram)
+	if !errors.Is(err, ErrUnknownDensity) {
+		t.Fatalf("expected %v, got %v", ErrUnknownDensity, err)
+	}
+}
+
+func TestDensityOf(t *testing.T) {
+	tests := map[string]float64{
+		"Flour":                      120,
+		"bread flour":                120,
+		"Brown Sugar":                213,
+		"Rolled Oats":                89,
+		"semi-sweet chocolate chips": 170,
+		"rice vinegar":               239,
+		"unsalted butter":            227,
+	}
+	for name, expected := range tests {
+		d, ok := DensityOf(name)
+		if !ok {
+			t.Errorf("%s: expected a density", name)
+			continue
+		}
+		if !approxEqual(d.GramsPerCup(), expected) {
+			t.Errorf("%s: expected %v g per cup, got %v", name, expected, d.GramsPerCup())
+		}
+	}
+	if _, ok := DensityOf("butternut squash"); ok {
+		t.Errorf("expected no density for butternut squash")
+	}
+}
+
+func TestSingular(t *testing.T) {
+	tests := map[string]string{
+		"tomatoes":  "tomato",
+		"cherries":  "cherry",
+		"peaches":   "peach",
+		"radishes":  "radish",
+		"leaves":    "leaf",
+		"oats":      "oat",
+		"asparagus": "asparagus",
+		"peas":      "pea",
+		"bass":      "bass",
+		"egg":       "egg",
+	}
+	for input, expected := range tests {
+		if got := Singular(input); got != expected {
+			t.Errorf("Singular(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestParseUnit(t *testing.T) {
+	tests := map[string]models.MeasurementUnit{
+		"Tablespoons": models.MeasurementUnitTablespoon,
+		"T":           models.MeasurementUnitTablespoon,
+		"t":           models.MeasurementUnitTeaspoon,
+		"fl. oz.":     models.MeasurementUnitFluidOunce,
+		"LBS":         models.MeasurementUnitPound,
+		"litres":      models.MeasurementUnitLiter,
+		"":            models.MeasurementUnitCount,
+	}
+	for input, expected := range tests {
+		got, err := ParseUnit(input)
+		if err != nil || got != expected {
+			t.Errorf("ParseUnit(%q): expected %s, got %s (%v)", input, expected, got, err)
+		}
+	}
+	if _, err := ParseUnit("handful"); !errors.Is(err, ErrUnknownUnit) {
+		t.Errorf("expected %v, got %v", ErrUnknownUnit, err)
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	tests := []struct {
+		ingredient models.Ingredient
+		system     models.UnitSystem
+		expected   models.Ingredient
+	}{
+		{
+			models.Ingredient{Name: "Flour", Quantity: 2, Unit: models.MeasurementUnitCup},
+			models.UnitSystemMetric,
+			models.Ingredient{Name: "Flour", Quantity: 240, Unit: models.MeasurementUnitGram},
+		},
+		{
+			models.Ingredient{Name: "Milk", Quantity: 1, Unit: models.MeasurementUnitCup},
+			models.UnitSystemMetric,
+			models.Ingredient{Name: "Milk", Quantity: 237, Unit: models.MeasurementUnitMilliliter},
+		},
+		{
+			models.Ingredient{Name: "Stock", Quantity: 6, Unit: models.MeasurementUnitCup},
+			models.UnitSystemMetric,
+			models.Ingredient{Name: "Stock", Quantity: 1.42, Unit: models.MeasurementUnitLiter},
+		},
+		{
+			models.Ingredient{Name: "Salt", Quantity: 1, Unit: models.MeasurementUnitTeaspoon},
+			models.UnitSystemMetric,
+			models.Ingredient{Name: "Salt", Quantity: 1, Unit: models.MeasurementUnitTeaspoon},
+		},
+		{
+			models.Ingredient{Name: "Beef", Quantity: 500, Unit: models.MeasurementUnitGram},
+			models.UnitSystemImperial,
+			models.Ingredient{Name: "Beef", Quantity: 1.1, Unit: models.MeasurementUnitPound},
+		},
+		{
+			models.Ingredient{Name: "Olive Oil", Quantity: 30, Unit: models.MeasurementUnitMilliliter},
+			models.UnitSystemImperial,
+			models.Ingredient{Name: "Olive Oil", Quantity: 2.03, Unit: models.MeasurementUnitTablespoon},
+		},
+		{
+			models.Ingredient{Name: "Beef", Quantity: 8, Unit: models.MeasurementUnitOunce},
+			models.UnitSystemImperial,
+			models.Ingredient{Name: "Beef", Quantity: 8, Unit: models.MeasurementUnitOunce},
+		},
+		{
+			models.Ingredient{Name: "Egg", Quantity: 2, Unit: models.MeasurementUnitCount},
+			models.UnitSystemMetric,
+			models.Ingredient{Name: "Egg", Quantity: 2, Unit: models.MeasurementUnitCount},
+		},
+	}
+	for _, tt := range tests {
+		got := Localize(tt.ingredient, tt.system)
+		if got != tt.expected {
+			t.Errorf("Localize(%+v, %s): expected %+v, got %+v", tt.ingredient, tt.system, tt.expected, got)
+		}
+	}
+}
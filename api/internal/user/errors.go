@@ -6,4 +6,7 @@ var (
 	ErrEmailExists     = errors.New("email already exists")
 	ErrUserNotFound    = errors.New("user not found")
 	ErrProfileNotFound = errors.New("profile not found")
+
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
 )
@@ -1,13 +1,13 @@
 package user
 
 import (
-	"encoding/json"
 	"errors"
 	"net/http"
 
 	"github.com/ajohnston1219/eatme/api/internal/api"
 	"github.com/ajohnston1219/eatme/api/internal/db"
 	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/units"
 	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
 	"go.uber.org/zap"
 )
@@ -34,21 +34,25 @@ func NewUserHandler(service *UserService) *UserHandler {
 // @Router /signup [post]
 func (h *UserHandler) Signup(w http.ResponseWriter, r *http.Request) {
 	var input models.SignupRequest
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	if err := api.DecodeJSON(r, &input); err != nil {
 		logger.Logger(r.Context()).Error("failed to decode signup request", zap.Error(err))
-		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
 		return
 	}
 
-	var user *models.User
+	var tokens *models.AuthTokens
 	err := h.service.store.WithTx(func(tx db.Store) error {
-		var err error
 		ctx := db.ContextWithTx(r.Context(), tx)
-		user, err = h.service.CreateUser(ctx, input.Email, input.Password)
+		user, err := h.service.CreateUser(ctx, input.Email, input.Password)
 		if err != nil {
 			logger.Logger(r.Context()).Error("failed to create user", zap.Error(err))
 			return err
 		}
+		tokens, err = h.service.IssueTokens(ctx, user.ID)
+		if err != nil {
+			logger.Logger(r.Context()).Error("failed to issue tokens", zap.Error(err))
+			return err
+		}
 		return nil
 	})
 	if err != nil {
@@ -60,7 +64,11 @@ func (h *UserHandler) Signup(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	api.WriteJSON(w, http.StatusOK, models.SignupResponse{Token: user.ID})
+	api.WriteJSON(w, http.StatusOK, models.SignupResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.ExpiresAt,
+	})
 }
 
 // @Summary Log in
@@ -77,9 +85,9 @@ func (h *UserHandler) Signup(w http.ResponseWriter, r *http.Request) {
 // @Router /login [post]
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var input models.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	if err := api.DecodeJSON(r, &input); err != nil {
 		logger.Logger(r.Context()).Error("failed to decode login request", zap.Error(err))
-		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
 		return
 	}
 
@@ -96,7 +104,99 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	api.WriteJSON(w, http.StatusOK, models.LoginResponse{Token: user.ID})
+	tokens, err := h.service.IssueTokens(r.Context(), user.ID)
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to issue tokens", zap.Error(err))
+		api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, models.LoginResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.ExpiresAt,
+	})
+}
+
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and a rotated refresh token
+// @ID refreshToken
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} models.RefreshTokenResponse
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 401 {object} models.APIError "Invalid or expired refresh token"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /token/refresh [post]
+func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var input models.RefreshTokenRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode refresh token request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+
+	var tokens *models.AuthTokens
+	var reuseErr error
+	err := h.service.store.WithTx(func(tx db.Store) error {
+		var err error
+		ctx := db.ContextWithTx(r.Context(), tx)
+		tokens, err = h.service.RefreshTokens(ctx, input.RefreshToken)
+		if errors.Is(err, ErrRefreshTokenReused) {
+			// Commit the revocation of the user's sessions
+			reuseErr = err
+			return nil
+		}
+		return err
+	})
+	if err == nil {
+		err = reuseErr
+	}
+	if err != nil {
+		logger.Logger(r.Context()).Error("failed to refresh tokens", zap.Error(err))
+		switch {
+		case errors.Is(err, ErrInvalidRefreshToken), errors.Is(err, ErrRefreshTokenReused):
+			api.ErrorJSON(w, http.StatusUnauthorized, models.ApiErrInvalidRefresh)
+		default:
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, models.RefreshTokenResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.ExpiresAt,
+	})
+}
+
+// @Summary Log out
+// @Description Revoke a refresh token, ending the session it belongs to
+// @ID logout
+// @Tags users
+// @Accept json
+// @Param request body models.LogoutRequest true "Refresh token to revoke"
+// @Success 204 "Logged out"
+// @Failure 400 {object} models.APIError "Invalid input"
+// @Failure 500 {object} models.APIError "Internal server error"
+// @Router /logout [post]
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var input models.LogoutRequest
+	if err := api.DecodeJSON(r, &input); err != nil {
+		logger.Logger(r.Context()).Error("failed to decode logout request", zap.Error(err))
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
+		return
+	}
+
+	if err := h.service.RevokeRefreshToken(r.Context(), input.RefreshToken); err != nil {
+		logger.Logger(r.Context()).Error("failed to revoke refresh token", zap.Error(err))
+		api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // @Summary Save user profile
@@ -119,9 +219,9 @@ func (h *UserHandler) SaveProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var profile models.ProfileUpdateRequest
-	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+	if err := api.DecodeJSON(r, &profile); err != nil {
 		logger.Logger(r.Context()).Error("failed to decode profile update request", zap.Error(err))
-		api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrBadRequest)
+		api.ErrorJSON(w, http.StatusBadRequest, api.RequestError(err))
 		return
 	}
 
@@ -137,7 +237,12 @@ func (h *UserHandler) SaveProfile(w http.ResponseWriter, r *http.Request) {
 		return nil
 	})
 	if err != nil {
-		api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		switch {
+		case errors.Is(err, units.ErrUnknownUnitSystem):
+			api.ErrorJSON(w, http.StatusBadRequest, models.ApiErrInvalidUnitSystem)
+		default:
+			api.ErrorJSON(w, http.StatusInternalServerError, models.ApiErrInternal)
+		}
 		return
 	}
 
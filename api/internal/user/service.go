@@ -5,17 +5,22 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/ajohnston1219/eatme/api/internal/auth"
 	"github.com/ajohnston1219/eatme/api/internal/db"
 	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/units"
 	"github.com/ajohnston1219/eatme/api/internal/utils/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 type UserService struct {
-	store db.Store
+	store  db.Store
+	tokens *auth.TokenManager
 }
 
-func NewUserService(store db.Store) *UserService {
-	return &UserService{store: store}
+func NewUserService(store db.Store, tokens *auth.TokenManager) *UserService {
+	return &UserService{store: store, tokens: tokens}
 }
 
 func (s *UserService) getStore(ctx context.Context) db.Store {
@@ -40,13 +45,14 @@ func (s *UserService) CreateUser(ctx context.Context, email string, password str
 	}
 	logger.Logger(ctx).Debug("created user")
 	defaultProfile := models.Profile{
-		SetupStep: models.SetupStepProfile,
-		Name:      "",
-		Skill:     models.SkillBeginner,
-		Cuisines:  []string{},
-		Diets:     []string{},
-		Equipment: []string{},
-		Allergies: []string{},
+		SetupStep:  models.SetupStepProfile,
+		Name:       "",
+		Skill:      models.SkillBeginner,
+		Cuisines:   []string{},
+		Diets:      []string{},
+		Equipment:  []string{},
+		Allergies:  []string{},
+		UnitSystem: models.UnitSystemImperial,
 	}
 
 	err = store.SaveProfile(ctx, user.ID, defaultProfile)
@@ -76,6 +82,122 @@ func (s *UserService) CheckPassword(ctx context.Context, userID string, password
 	return store.CheckPassword(ctx, userID, password)
 }
 
+// IssueTokens starts a new session for the user, returning a signed access
+// token and a refresh token that is persisted for later rotation.
+func (s *UserService) IssueTokens(ctx context.Context, userID string) (*models.AuthTokens, error) {
+	store := s.getStore(ctx)
+	accessToken, expiresAt, err := s.tokens.IssueAccessToken(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue access token: %w", err)
+	}
+	refreshToken, hash, refreshExpiresAt, err := s.tokens.NewRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue refresh token: %w", err)
+	}
+	err = store.SaveRefreshToken(ctx, models.RefreshToken{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		TokenHash: hash,
+		ExpiresAt: refreshExpiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	logger.Logger(ctx).Debug("issued tokens")
+	return &models.AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new token pair. The presented
+// refresh token is revoked, and presenting an already revoked token revokes
+// every session of its user since the token has most likely leaked.
+func (s *UserService) RefreshTokens(ctx context.Context, refreshToken string) (*models.AuthTokens, error) {
+	store := s.getStore(ctx)
+	current, err := store.GetRefreshToken(ctx, auth.HashRefreshToken(refreshToken))
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return nil, ErrInvalidRefreshToken
+		default:
+			return nil, fmt.Errorf("failed to get refresh token: %w", err)
+		}
+	}
+	if current.RevokedAt != nil {
+		return nil, s.revokeReusedToken(ctx, current.UserID)
+	}
+	if !s.tokens.Now().Before(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	accessToken, expiresAt, err := s.tokens.IssueAccessToken(current.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue access token: %w", err)
+	}
+	newRefreshToken, hash, refreshExpiresAt, err := s.tokens.NewRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue refresh token: %w", err)
+	}
+	next := models.RefreshToken{
+		ID:        uuid.NewString(),
+		UserID:    current.UserID,
+		TokenHash: hash,
+		ExpiresAt: refreshExpiresAt,
+	}
+	// Revoking only succeeds for the first of two requests racing with the
+	// same token, and the other is treated as reuse
+	if err := store.RevokeRefreshToken(ctx, current.ID, &next.ID); err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return nil, s.revokeReusedToken(ctx, current.UserID)
+		default:
+			return nil, fmt.Errorf("failed to revoke refresh token: %w", err)
+		}
+	}
+	if err := store.SaveRefreshToken(ctx, next); err != nil {
+		return nil, fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	logger.Logger(ctx).Debug("rotated refresh token")
+	return &models.AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// revokeReusedToken revokes every session of a user whose revoked refresh
+// token was presented again, since it has most likely leaked, and returns
+// ErrRefreshTokenReused.
+func (s *UserService) revokeReusedToken(ctx context.Context, userID string) error {
+	logger.Logger(ctx).Warn("revoked refresh token reused, revoking all sessions", zap.String("user_id", userID))
+	if err := s.getStore(ctx).RevokeAllRefreshTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return ErrRefreshTokenReused
+}
+
+// RevokeRefreshToken ends the session the refresh token belongs to. Unknown
+// tokens are ignored so logging out is idempotent.
+func (s *UserService) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	store := s.getStore(ctx)
+	current, err := store.GetRefreshToken(ctx, auth.HashRefreshToken(refreshToken))
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return nil
+		default:
+			return fmt.Errorf("failed to get refresh token: %w", err)
+		}
+	}
+	if err := store.RevokeRefreshToken(ctx, current.ID, nil); err != nil && !errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	logger.Logger(ctx).Debug("revoked refresh token")
+	return nil
+}
+
 func (s *UserService) SaveProfile(ctx context.Context, userID string, profile models.ProfileUpdateRequest) (*models.Profile, error) {
 	if userID == "" {
 		return nil, errors.New("user ID is required")
@@ -114,6 +236,13 @@ func (s *UserService) SaveProfile(ctx context.Context, userID string, profile mo
 	if profile.Allergies != nil {
 		currentProfile.Allergies = profile.Allergies
 	}
+	if profile.UnitSystem != "" {
+		system, err := units.ParseUnitSystem(string(profile.UnitSystem))
+		if err != nil {
+			return nil, err
+		}
+		currentProfile.UnitSystem = system
+	}
 
 	err = store.SaveProfile(ctx, userID, currentProfile)
 	if err != nil {
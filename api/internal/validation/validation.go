@@ -0,0 +1,268 @@
+// Package validation checks request and ML gateway models against the
+// constraints their binding tags and documentation promise, since nothing
+// enforces them when they are decoded.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/units"
+)
+
+var ErrInvalid = errors.New("validation failed")
+
+// Error describes the first invalid field of a model. It wraps ErrInvalid.
+type Error struct {
+	// Field is the JSON path of the invalid field, e.g. ingredients[2].unit
+	Field  string
+	Reason string
+	// apiErr replaces the generic validation error for fields that had an
+	// error code of their own before they were validated here
+	apiErr *models.APIError
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s %s", ErrInvalid, e.Field, e.Reason)
+}
+
+func (e *Error) Unwrap() error {
+	return ErrInvalid
+}
+
+// APIError is the error returned to clients for the invalid field.
+func (e *Error) APIError() models.APIError {
+	if e.apiErr != nil {
+		return *e.apiErr
+	}
+	return models.NewAPIError(
+		models.ApiErrValidationFailed.Code,
+		models.ApiErrValidationFailed.Message,
+		models.WithField(e.Field),
+		models.WithDetails(e.Field+" "+e.Reason),
+	)
+}
+
+// Validate checks a decoded model, returning an *Error for the first invalid
+// field. v must be a pointer; models without constraints are always valid.
+func Validate(v any) error {
+	switch v := v.(type) {
+	case *models.RecipeBody:
+		return RecipeBody(*v)
+	case *[]models.PatchOperation:
+		return patch(*v)
+	case *models.ScaleRecipeRequest:
+		if v.Servings <= 0 {
+			return invalidWith("servings", "must be positive", models.ApiErrInvalidServings)
+		}
+	case *models.MergeRecipeVersionsRequest:
+		if strings.TrimSpace(v.TheirsVersionID) == "" {
+			return invalidWith("theirs_version_id", "is required", models.ApiErrMissingMergeTheirs)
+		}
+		for i, resolution := range v.Resolutions {
+			if err := required(fmt.Sprintf("resolutions[%d].conflict_id", i), resolution.ConflictID); err != nil {
+				return err
+			}
+		}
+	case *models.StartSuggestionThreadRequest:
+		return required("prompt", v.Prompt)
+	case *models.GetNewSuggestionsRequest:
+		if v.Prompt != nil {
+			return required("prompt", *v.Prompt)
+		}
+	case *models.ModifyRecipeViaChatRequest:
+		return required("prompt", v.Prompt)
+	case *models.AnswerCookingQuestionRequest:
+		return required("question", v.Question)
+	case *models.SignupRequest:
+		return first(required("email", v.Email), email("email", v.Email), required("password", v.Password))
+	case *models.LoginRequest:
+		return first(required("email", v.Email), required("password", v.Password))
+	case *models.RefreshTokenRequest:
+		return required("refresh_token", v.RefreshToken)
+	case *models.LogoutRequest:
+		return required("refresh_token", v.RefreshToken)
+	case *models.CreateMealPlanRequest:
+		return mealPlanName(v.Name)
+	case *models.UpdateMealPlanRequest:
+		return mealPlanName(v.Name)
+	case *models.AddMealPlanRecipeRequest:
+		return first(required("recipe_id", v.RecipeID), mealPlanDay(v.Day))
+	case *models.MoveMealPlanRecipeRequest:
+		return mealPlanDay(v.Day)
+	case *models.SetRecipeTagsRequest:
+		return tags(v.Tags)
+	case *models.ImportRecipeRequest:
+		if (strings.TrimSpace(v.HTML) == "") == (strings.TrimSpace(v.Text) == "") {
+			return invalidWith("html", "or text is required, but not both", models.ApiErrInvalidImport)
+		}
+	case *models.CreateCollectionRequest:
+		return collectionName(v.Name)
+	case *models.UpdateCollectionRequest:
+		return collectionName(v.Name)
+	case *models.AddCollectionRecipeRequest:
+		return required("recipe_id", v.RecipeID)
+	case *models.SuggestChatResponse:
+		for i, suggestion := range v.Suggestions {
+			if err := Suggestion(suggestion); err != nil {
+				return nested(fmt.Sprintf("suggestions[%d]", i), err)
+			}
+		}
+	case *models.ModifyChatResponse:
+		// The gateway reports modifications it refused in Error rather than
+		// with a recipe
+		if v.Error == "" {
+			return nested("new_recipe", RecipeBody(v.NewRecipe))
+		}
+	}
+	return nil
+}
+
+// RecipeBody checks that a recipe has a title, ingredients and steps,
+// positive servings, and that every ingredient has a name, a quantity that
+// isn't negative and a known unit.
+func RecipeBody(body models.RecipeBody) error {
+	switch {
+	case strings.TrimSpace(body.Title) == "":
+		return invalid("title", "must not be empty")
+	case body.Servings <= 0:
+		return invalid("servings", "must be positive")
+	case body.TotalTimeMinutes < 0:
+		return invalid("total_time_minutes", "must not be negative")
+	case len(body.Ingredients) == 0:
+		return invalid("ingredients", "must not be empty")
+	case len(body.Steps) == 0:
+		return invalid("steps", "must not be empty")
+	}
+	for i, ingredient := range body.Ingredients {
+		field := fmt.Sprintf("ingredients[%d]", i)
+		switch {
+		case strings.TrimSpace(ingredient.Name) == "":
+			return invalid(field+".name", "must not be empty")
+		case ingredient.Quantity < 0 || math.IsNaN(ingredient.Quantity) || math.IsInf(ingredient.Quantity, 0):
+			return invalid(field+".quantity", "must not be negative")
+		case !units.IsKnown(ingredient.Unit):
+			return invalid(field+".unit", "must be a known unit")
+		}
+	}
+	for i, step := range body.Steps {
+		if strings.TrimSpace(string(step)) == "" {
+			return invalid(fmt.Sprintf("steps[%d]", i), "must not be empty")
+		}
+	}
+	return nil
+}
+
+// Suggestion checks a suggestion generated by the ML gateway.
+func Suggestion(suggestion *models.Suggestion) error {
+	if suggestion == nil {
+		return invalid("", "must not be null")
+	}
+	return nested("recipe", RecipeBody(suggestion.Recipe))
+}
+
+func patch(operations []models.PatchOperation) error {
+	for i, operation := range operations {
+		field := fmt.Sprintf("[%d]", i)
+		switch operation.Op {
+		case "add", "remove", "replace", "move", "copy", "test":
+		default:
+			return invalid(field+".op", "must be add, remove, replace, move, copy or test")
+		}
+		if (operation.Op == "move" || operation.Op == "copy") && operation.From == "" {
+			return invalid(field+".from", "is required")
+		}
+	}
+	return nil
+}
+
+func mealPlanName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return invalidWith("name", "is required", models.ApiErrInvalidMealPlanName)
+	}
+	return nil
+}
+
+func mealPlanDay(day int) error {
+	if day < 0 {
+		return invalidWith("day", "must not be negative", models.ApiErrInvalidMealPlanDay)
+	}
+	return nil
+}
+
+const (
+	maxTags      = 20
+	maxTagLength = 50
+)
+
+func tags(tags []string) error {
+	if len(tags) > maxTags {
+		return invalidWith("tags", fmt.Sprintf("must have at most %d tags", maxTags), models.ApiErrTooManyTags)
+	}
+	for i, tag := range tags {
+		if n := len([]rune(strings.TrimSpace(tag))); n == 0 || n > maxTagLength {
+			return invalidWith("tags", fmt.Sprintf("tag %d must be between 1 and %d characters", i, maxTagLength), models.ApiErrInvalidTag)
+		}
+	}
+	return nil
+}
+
+func collectionName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return invalidWith("name", "is required", models.ApiErrInvalidCollectionName)
+	}
+	return nil
+}
+
+func email(field string, value string) error {
+	at := strings.Index(value, "@")
+	if at <= 0 || at == len(value)-1 {
+		return invalid(field, "must be an email address")
+	}
+	return nil
+}
+
+func required(field string, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return invalid(field, "is required")
+	}
+	return nil
+}
+
+func invalid(field string, reason string) error {
+	return &Error{Field: field, Reason: reason}
+}
+
+func invalidWith(field string, reason string, apiErr models.APIError) error {
+	return &Error{Field: field, Reason: reason, apiErr: &apiErr}
+}
+
+// nested prefixes the field of a validation error with the path of the model
+// it was found in.
+func nested(prefix string, err error) error {
+	var invalid *Error
+	if !errors.As(err, &invalid) {
+		return err
+	}
+	field := prefix
+	switch {
+	case invalid.Field == "":
+	case strings.HasPrefix(invalid.Field, "["):
+		field += invalid.Field
+	default:
+		field += "." + invalid.Field
+	}
+	return &Error{Field: field, Reason: invalid.Reason, apiErr: invalid.apiErr}
+}
+
+func first(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func pancakes() models.RecipeBody {
+	return models.RecipeBody{
+		Title:            "Pancakes",
+		Description:      "Fluffy pancakes",
+		Servings:         4,
+		TotalTimeMinutes: 20,
+		Ingredients: []models.Ingredient{
+			{Name: "Flour", Quantity: 2, Unit: models.MeasurementUnitCup},
+			{Name: "Milk", Quantity: 1, Unit: models.MeasurementUnitCup},
+			{Name: "Sugar", Quantity: 2, Unit: models.MeasurementUnitTablespoon},
+		},
+		Steps: []models.Step{"Mix the dry ingredients", "Whisk in the milk", "Cook on a hot griddle"},
+	}
+}
+
+func TestRecipeBody(t *testing.T) {
+	if err := RecipeBody(pancakes()); err != nil {
+		t.Fatalf("expected a valid recipe, got %v", err)
+	}
+	cases := map[string]struct {
+		change func(r *models.RecipeBody)
+		field  string
+	}{
+		"blank title":        {func(r *models.RecipeBody) { r.Title = "  " }, "title"},
+		"no servings":        {func(r *models.RecipeBody) { r.Servings = 0 }, "servings"},
+		"negative time":      {func(r *models.RecipeBody) { r.TotalTimeMinutes = -5 }, "total_time_minutes"},
+		"no ingredients":     {func(r *models.RecipeBody) { r.Ingredients = nil }, "ingredients"},
+		"no steps":           {func(r *models.RecipeBody) { r.Steps = []models.Step{} }, "steps"},
+		"unnamed ingredient": {func(r *models.RecipeBody) { r.Ingredients[1].Name = "" }, "ingredients[1].name"},
+		"negative quantity":  {func(r *models.RecipeBody) { r.Ingredients[0].Quantity = -1 }, "ingredients[0].quantity"},
+		"unknown unit":       {func(r *models.RecipeBody) { r.Ingredients[2].Unit = "handful" }, "ingredients[2].unit"},
+		"blank step":         {func(r *models.RecipeBody) { r.Steps[1] = "" }, "steps[1]"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			body := pancakes()
+			tc.change(&body)
+			assertInvalid(t, RecipeBody(body), tc.field)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	blank := " "
+	invalidRecipe := pancakes()
+	invalidRecipe.Ingredients[1].Unit = ""
+	cases := []struct {
+		name  string
+		v     any
+		field string
+		code  string
+	}{
+		{"valid request", &models.StartSuggestionThreadRequest{Prompt: "soup"}, "", ""},
+		{"models without constraints", &models.ProfileUpdateRequest{}, "", ""},
+		{"missing prompt", &models.StartSuggestionThreadRequest{}, "prompt", "VALIDATION_FAILED"},
+		{"blank optional prompt", &models.GetNewSuggestionsRequest{Prompt: &blank}, "prompt", "VALIDATION_FAILED"},
+		{"missing question", &models.AnswerCookingQuestionRequest{}, "question", "VALIDATION_FAILED"},
+		{"invalid email", &models.SignupRequest{Email: "cook", Password: "secret"}, "email", "VALIDATION_FAILED"},
+		{"missing password", &models.LoginRequest{Email: "cook@example.com"}, "password", "VALIDATION_FAILED"},
+		{"missing refresh token", &models.LogoutRequest{}, "refresh_token", "VALIDATION_FAILED"},
+		{"recipe", &invalidRecipe, "ingredients[1].unit", "VALIDATION_FAILED"},
+		{"patch", &[]models.PatchOperation{{Op: "replace", Path: "/title"}, {Op: "merge", Path: "/"}}, "[1].op", "VALIDATION_FAILED"},
+		{"move without from", &[]models.PatchOperation{{Op: "move", Path: "/steps/0"}}, "[0].from", "VALIDATION_FAILED"},
+		{"merge resolution", &models.MergeRecipeVersionsRequest{TheirsVersionID: "v2", Resolutions: []models.MergeResolution{{Side: models.MergeSideOurs}}}, "resolutions[0].conflict_id", "VALIDATION_FAILED"},
+		{"servings", &models.ScaleRecipeRequest{Servings: -2}, "servings", "INVALID_SERVINGS"},
+		{"merge theirs", &models.MergeRecipeVersionsRequest{}, "theirs_version_id", "MISSING_MERGE_THEIRS"},
+		{"meal plan name", &models.CreateMealPlanRequest{Name: " "}, "name", "INVALID_MEAL_PLAN_NAME"},
+		{"meal plan day", &models.AddMealPlanRecipeRequest{RecipeID: "r1", Day: -1}, "day", "INVALID_MEAL_PLAN_DAY"},
+		{"blank tag", &models.SetRecipeTagsRequest{Tags: []string{"quick", " "}}, "tags", "INVALID_TAG"},
+		{"too many tags", &models.SetRecipeTagsRequest{Tags: make([]string, 21)}, "tags", "TOO_MANY_TAGS"},
+		{"collection name", &models.UpdateCollectionRequest{}, "name", "INVALID_COLLECTION_NAME"},
+		{"import nothing", &models.ImportRecipeRequest{Text: " "}, "html", "INVALID_IMPORT"},
+		{"import both", &models.ImportRecipeRequest{HTML: "<html></html>", Text: "Soup"}, "html", "INVALID_IMPORT"},
+		{"collection recipe", &models.AddCollectionRecipeRequest{}, "recipe_id", "VALIDATION_FAILED"},
+		{"suggestion", &models.SuggestChatResponse{Suggestions: []*models.Suggestion{{Recipe: pancakes()}, {Recipe: invalidRecipe}}}, "suggestions[1].recipe.ingredients[1].unit", "VALIDATION_FAILED"},
+		{"missing suggestion", &models.SuggestChatResponse{Suggestions: []*models.Suggestion{nil}}, "suggestions[0]", "VALIDATION_FAILED"},
+		{"modified recipe", &models.ModifyChatResponse{NewRecipe: invalidRecipe}, "new_recipe.ingredients[1].unit", "VALIDATION_FAILED"},
+		{"refused modification", &models.ModifyChatResponse{Error: "contains peanuts"}, "", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.v)
+			if tc.field == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			invalid := assertInvalid(t, err, tc.field)
+			if apiErr := invalid.APIError(); apiErr.Code != tc.code || apiErr.Field != tc.field {
+				t.Errorf("expected %s on %s, got %+v", tc.code, tc.field, apiErr)
+			}
+		})
+	}
+}
+
+func assertInvalid(t *testing.T, err error, field string) *Error {
+	t.Helper()
+	var invalid *Error
+	if !errors.As(err, &invalid) || !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if invalid.Field != field {
+		t.Errorf("expected field %s, got %s", field, invalid.Field)
+	}
+	return invalid
+}
@@ -0,0 +1,203 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ajohnston1219/eatme/api/internal/auth"
+	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/ajohnston1219/eatme/api/internal/user"
+)
+
+func TestAuthFlow(t *testing.T) {
+	ts, store := NewTestServer(t, &MLStub{})
+	defer ts.Close()
+	if _, err := createUser(store, "cook@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(method string, path string, auth string, body any, expected int, v any) {
+		t.Helper()
+		status, data := doRequest(t, method, ts.URL+path, auth, body)
+		if status != expected {
+			t.Fatalf("%s %s: expected %d, got %d: %s", method, path, expected, status, data)
+		}
+		if v != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	var apiErr struct {
+		Error models.APIError `json:"error"`
+	}
+	refresh := func(token string, expected int) models.RefreshTokenResponse {
+		t.Helper()
+		var res models.RefreshTokenResponse
+		if expected == http.StatusOK {
+			call(http.MethodPost, "/token/refresh", "", models.RefreshTokenRequest{RefreshToken: token}, expected, &res)
+			return res
+		}
+		call(http.MethodPost, "/token/refresh", "", models.RefreshTokenRequest{RefreshToken: token}, expected, &apiErr)
+		if apiErr.Error.Code != models.ApiErrInvalidRefresh.Code {
+			t.Errorf("expected %s, got %+v", models.ApiErrInvalidRefresh.Code, apiErr.Error)
+		}
+		return res
+	}
+	login := func() models.LoginResponse {
+		t.Helper()
+		var res models.LoginResponse
+		call(http.MethodPost, "/login", "", models.LoginRequest{Email: "cook@example.com", Password: "password"}, http.StatusOK, &res)
+		if res.Token == "" || res.RefreshToken == "" {
+			t.Fatalf("expected an access and refresh token, got %+v", res)
+		}
+		return res
+	}
+
+	// Login returns a working access token and a refresh token
+	session := login()
+	call(http.MethodGet, "/recipes", "Bearer "+session.Token, nil, http.StatusOK, nil)
+
+	// Refreshing rotates the refresh token and the old one stops working
+	rotated := refresh(session.RefreshToken, http.StatusOK)
+	if rotated.RefreshToken == session.RefreshToken {
+		t.Fatal("expected the refresh token to be rotated")
+	}
+	call(http.MethodGet, "/recipes", "Bearer "+rotated.Token, nil, http.StatusOK, nil)
+	refresh(session.RefreshToken, http.StatusUnauthorized)
+
+	// Presenting the rotated token again revoked the whole family, so the
+	// latest token is rejected too
+	refresh(rotated.RefreshToken, http.StatusUnauthorized)
+
+	// Other sessions are revoked along with the family of the reused token
+	session = login()
+	other := login()
+	next := refresh(session.RefreshToken, http.StatusOK)
+	refresh(session.RefreshToken, http.StatusUnauthorized)
+	refresh(next.RefreshToken, http.StatusUnauthorized)
+	refresh(other.RefreshToken, http.StatusUnauthorized)
+
+	// Logging out invalidates the refresh token, and logging out again is
+	// harmless
+	session = login()
+	call(http.MethodPost, "/logout", "", models.LogoutRequest{RefreshToken: session.RefreshToken}, http.StatusNoContent, nil)
+	refresh(session.RefreshToken, http.StatusUnauthorized)
+	call(http.MethodPost, "/logout", "", models.LogoutRequest{RefreshToken: session.RefreshToken}, http.StatusNoContent, nil)
+}
+
+// racingStore lets another request rotate a refresh token between it being
+// read and being revoked.
+type racingStore struct {
+	*db.SQLiteStore
+}
+
+func (s racingStore) GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	token, err := s.SQLiteStore.GetRefreshToken(ctx, tokenHash)
+	if err != nil {
+		return token, err
+	}
+	return token, s.SQLiteStore.RevokeRefreshToken(ctx, token.ID, nil)
+}
+
+func TestRefreshTokenRace(t *testing.T) {
+	ts, store := NewTestServer(t, &MLStub{})
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	svc := user.NewUserService(store, testTokens)
+	session, err := svc.IssueTokens(ctx, cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := svc.IssueTokens(ctx, cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The request that loses the race is treated as reuse rather than
+	// rotating the token a second time
+	racing := user.NewUserService(racingStore{store}, testTokens)
+	if _, err := racing.RefreshTokens(ctx, session.RefreshToken); !errors.Is(err, user.ErrRefreshTokenReused) {
+		t.Fatalf("expected %v, got %v", user.ErrRefreshTokenReused, err)
+	}
+	if _, err := svc.RefreshTokens(ctx, other.RefreshToken); !errors.Is(err, user.ErrRefreshTokenReused) {
+		t.Errorf("expected every session to be revoked, got %v", err)
+	}
+}
+
+func TestAuthMiddlewareRejectsBadAccessTokens(t *testing.T) {
+	ts, store := NewTestServer(t, &MLStub{})
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired, _, err := auth.NewTokenManager([]byte("test-secret"), auth.WithClock(func() time.Time {
+		return time.Now().Add(-2 * auth.DefaultAccessTokenTTL)
+	})).IssueAccessToken(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, _, err := auth.NewTokenManager([]byte("another-secret")).IssueAccessToken(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, _, err := testTokens.IssueAccessToken(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := testTokens.IssueAccessToken("someone-else")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, ".")
+	// Claim to be someone else while keeping the original signature
+	tamperedPayload := parts[0] + "." + strings.Split(other, ".")[1] + "." + parts[2]
+	tamperedSignature := parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2]))
+
+	testCases := []struct {
+		name     string
+		auth     string
+		expected string
+	}{
+		{"missing", "", models.ApiErrUnauthorized.Code},
+		{"malformed", "Bearer not-a-token", models.ApiErrUnauthorized.Code},
+		{"expired", "Bearer " + expired, models.ApiErrTokenExpired.Code},
+		{"wrong secret", "Bearer " + forged, models.ApiErrUnauthorized.Code},
+		{"tampered signature", "Bearer " + tamperedSignature, models.ApiErrUnauthorized.Code},
+		{"tampered payload", "Bearer " + tamperedPayload, models.ApiErrUnauthorized.Code},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, data := doRequest(t, http.MethodGet, ts.URL+"/recipes", tc.auth, nil)
+			if status != http.StatusUnauthorized {
+				t.Fatalf("expected 401, got %d: %s", status, data)
+			}
+			var apiErr struct {
+				Error models.APIError `json:"error"`
+			}
+			if err := json.Unmarshal(data, &apiErr); err != nil {
+				t.Fatal(err)
+			}
+			if apiErr.Error.Code != tc.expected {
+				t.Errorf("expected %s, got %+v", tc.expected, apiErr.Error)
+			}
+		})
+	}
+
+	status, data := doRequest(t, http.MethodGet, ts.URL+"/recipes", "Bearer "+valid, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 for a valid token, got %d: %s", status, data)
+	}
+}
@@ -0,0 +1,284 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+// authorizationFixture is one user's thread, recipe, meal plan and collection,
+// along with a second user who should not be able to see or touch any of them.
+type authorizationFixture struct {
+	url          string
+	ownerAuth    string
+	intruderAuth string
+	threadID     string
+	suggestionID string
+	recipeID     string
+	versionID    string
+	planID       string
+	entryID      string
+	intruderPlan string
+	collectionID string
+}
+
+func newAuthorizationFixture(t *testing.T) *authorizationFixture {
+	t.Helper()
+	ts, store := NewTestServer(t, &MLStub{})
+	t.Cleanup(ts.Close)
+	ctx := context.Background()
+
+	owner, err := createUser(store, "owner@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	intruder, err := createUser(store, "intruder@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &authorizationFixture{url: ts.URL, suggestionID: "suggestion-1"}
+	if f.ownerAuth, err = authHeader(owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	if f.intruderAuth, err = authHeader(intruder.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	suggestion, err := json.Marshal(models.SuggestionGeneratedEvent{
+		SuggestionID: f.suggestionID,
+		Recipe:       makeFakeRecipe("Soup"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	thread := models.Thread{
+		ID:   "owner-thread",
+		Type: models.ThreadTypeSuggestion,
+		Events: []models.ThreadEvent{
+			{Type: models.ThreadEventTypePromptSet, Payload: []byte(`{"prompt":"soup"}`)},
+			{Type: models.ThreadEventTypeSuggestionGenerated, Payload: suggestion},
+		},
+	}
+	if err := store.CreateThread(ctx, owner.ID, thread); err != nil {
+		t.Fatal(err)
+	}
+	f.threadID = thread.ID
+
+	recipe, err := createRecipe(store, owner.ID, f.threadID, makeFakeRecipe("Stew"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AssociateThreadWithRecipe(ctx, owner.ID, f.threadID, recipe.ID); err != nil {
+		t.Fatal(err)
+	}
+	f.recipeID = recipe.ID
+	f.versionID = recipe.LatestVersionID
+
+	var plan models.MealPlan
+	f.mustDo(t, f.ownerAuth, http.MethodPost, "/plans", models.CreateMealPlanRequest{Name: "Week"}, &plan)
+	f.planID = plan.ID
+	f.mustDo(t, f.ownerAuth, http.MethodPost, "/plans/"+f.planID+"/recipes", models.AddMealPlanRecipeRequest{RecipeID: f.recipeID, Day: 1}, &plan)
+	f.entryID = plan.Recipes[0].ID
+
+	f.mustDo(t, f.intruderAuth, http.MethodPost, "/plans", models.CreateMealPlanRequest{Name: "Mine"}, &plan)
+	f.intruderPlan = plan.ID
+
+	var collection models.Collection
+	f.mustDo(t, f.ownerAuth, http.MethodPost, "/collections", models.CreateCollectionRequest{Name: "Favorites"}, &collection)
+	f.collectionID = collection.ID
+	f.mustDo(t, f.ownerAuth, http.MethodPost, "/collections/"+f.collectionID+"/recipes", models.AddCollectionRecipeRequest{RecipeID: f.recipeID}, &collection)
+	return f
+}
+
+func (f *authorizationFixture) do(t *testing.T, auth, method, path string, body any) (int, []byte) {
+	t.Helper()
+	return doRequest(t, method, f.url+path, auth, body)
+}
+
+func (f *authorizationFixture) mustDo(t *testing.T, auth, method, path string, body any, v any) {
+	t.Helper()
+	status, data := f.do(t, auth, method, path, body)
+	if status != http.StatusOK && status != http.StatusCreated {
+		t.Fatalf("%s %s: expected success, got %d: %s", method, path, status, data)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type authorizationCase struct {
+	method string
+	path   string
+	body   any
+	// status and code are what a user gets for someone else's resource
+	status int
+	code   string
+}
+
+func (f *authorizationFixture) protectedRoutes() []authorizationCase {
+	question := models.AnswerCookingQuestionRequest{Question: "Can I freeze it?"}
+	modify := models.ModifyRecipeViaChatRequest{Prompt: "Make it vegan"}
+	move := models.MoveMealPlanRecipeRequest{Day: 2}
+	plan := "/plans/" + f.planID
+	collection := "/collections/" + f.collectionID
+	return []authorizationCase{
+		{http.MethodGet, "/thread/" + f.threadID, nil, http.StatusNotFound, "THREAD_NOT_FOUND"},
+		{http.MethodGet, "/thread/" + f.threadID + "?at=1", nil, http.StatusNotFound, "THREAD_NOT_FOUND"},
+		{http.MethodGet, "/thread/" + f.threadID + "/events", nil, http.StatusNotFound, "THREAD_NOT_FOUND"},
+		{http.MethodPost, "/thread/" + f.threadID + "/suggest", models.GetNewSuggestionsRequest{}, http.StatusNotFound, "THREAD_NOT_FOUND"},
+		{http.MethodPost, "/thread/" + f.threadID + "/accept/" + f.suggestionID, nil, http.StatusNotFound, "THREAD_NOT_FOUND"},
+		{http.MethodPost, "/thread/" + f.threadID + "/question", question, http.StatusNotFound, "THREAD_NOT_FOUND"},
+		{http.MethodPost, "/thread/" + f.threadID + "/question/stream", question, http.StatusNotFound, "THREAD_NOT_FOUND"},
+
+		{http.MethodGet, "/recipes/" + f.recipeID, nil, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodPost, "/recipes/" + f.recipeID + "/scale", models.ScaleRecipeRequest{Servings: 8}, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodPost, "/recipes/" + f.recipeID + "/modify/chat", modify, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodPost, "/recipes/" + f.recipeID + "/modify/chat/stream", modify, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodPost, "/recipes/" + f.recipeID + "/modify/accept", nil, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodPost, "/recipes/" + f.recipeID + "/modify/reject", nil, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodPost, "/recipes/" + f.recipeID + "/modify/undo", nil, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodPost, "/recipes/" + f.recipeID + "/modify/redo", nil, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodGet, "/recipes/" + f.recipeID + "/versions", nil, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodGet, "/recipes/" + f.recipeID + "/versions/" + f.versionID, nil, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodGet, "/recipes/" + f.recipeID + "/diff?from=" + f.versionID, nil, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodPost, "/recipes/" + f.recipeID + "/versions/" + f.versionID + "/restore", nil, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodGet, "/recipes/" + f.recipeID + "/merge?theirs_version_id=" + f.versionID, nil, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodPost, "/recipes/" + f.recipeID + "/merge", models.MergeRecipeVersionsRequest{TheirsVersionID: f.versionID}, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodPut, "/recipes/" + f.recipeID, makeFakeRecipe("Stolen Soup", WithIngredients([]models.Ingredient{{Name: "Water", Quantity: 1, Unit: models.MeasurementUnitLiter}})), http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodPatch, "/recipes/" + f.recipeID, []models.PatchOperation{{Op: "remove", Path: "/steps/0"}}, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodPost, "/recipes/" + f.recipeID + "/favorite", nil, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodDelete, "/recipes/" + f.recipeID + "/favorite", nil, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodPut, "/recipes/" + f.recipeID + "/tags", models.SetRecipeTagsRequest{Tags: []string{"stolen"}}, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+		{http.MethodDelete, "/recipes/" + f.recipeID, nil, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+
+		{http.MethodGet, plan, nil, http.StatusNotFound, "MEAL_PLAN_NOT_FOUND"},
+		{http.MethodPut, plan, models.UpdateMealPlanRequest{Name: "Taken"}, http.StatusNotFound, "MEAL_PLAN_NOT_FOUND"},
+		{http.MethodGet, plan + "/shopping-list", nil, http.StatusNotFound, "MEAL_PLAN_NOT_FOUND"},
+		{http.MethodPost, plan + "/recipes", models.AddMealPlanRecipeRequest{RecipeID: f.recipeID}, http.StatusNotFound, "MEAL_PLAN_NOT_FOUND"},
+		{http.MethodPut, plan + "/recipes/" + f.entryID, move, http.StatusNotFound, "MEAL_PLAN_NOT_FOUND"},
+		{http.MethodDelete, plan + "/recipes/" + f.entryID, nil, http.StatusNotFound, "MEAL_PLAN_NOT_FOUND"},
+		{http.MethodDelete, plan, nil, http.StatusNotFound, "MEAL_PLAN_NOT_FOUND"},
+		// The intruder's own plan, but the owner's recipe
+		{http.MethodPost, "/plans/" + f.intruderPlan + "/recipes", models.AddMealPlanRecipeRequest{RecipeID: f.recipeID}, http.StatusNotFound, "RECIPE_NOT_FOUND"},
+
+		{http.MethodGet, collection, nil, http.StatusNotFound, "COLLECTION_NOT_FOUND"},
+		{http.MethodPut, collection, models.UpdateCollectionRequest{Name: "Taken"}, http.StatusNotFound, "COLLECTION_NOT_FOUND"},
+		{http.MethodPost, collection + "/recipes", models.AddCollectionRecipeRequest{RecipeID: f.recipeID}, http.StatusNotFound, "COLLECTION_NOT_FOUND"},
+		{http.MethodPut, collection + "/recipes/" + f.recipeID, models.MoveCollectionRecipeRequest{}, http.StatusNotFound, "COLLECTION_NOT_FOUND"},
+		{http.MethodDelete, collection + "/recipes/" + f.recipeID, nil, http.StatusNotFound, "COLLECTION_NOT_FOUND"},
+		{http.MethodDelete, collection, nil, http.StatusNotFound, "COLLECTION_NOT_FOUND"},
+	}
+}
+
+func TestOtherUsersResourcesAreNotFound(t *testing.T) {
+	f := newAuthorizationFixture(t)
+
+	for _, tc := range f.protectedRoutes() {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			status, data := f.do(t, f.intruderAuth, tc.method, tc.path, tc.body)
+			if status != tc.status {
+				t.Fatalf("expected %d, got %d: %s", tc.status, status, data)
+			}
+			var body struct {
+				Error models.APIError `json:"error"`
+			}
+			if err := json.Unmarshal(data, &body); err != nil || body.Error.Code != tc.code {
+				t.Errorf("expected %s, got %s", tc.code, data)
+			}
+		})
+	}
+
+	// Nothing the intruder tried should have changed the owner's data
+	var state models.ThreadState
+	f.mustDo(t, f.ownerAuth, http.MethodGet, "/thread/"+f.threadID, nil, &state)
+	if len(state.Suggestions) != 1 || state.Suggestions[0].Accepted {
+		t.Errorf("expected the owner's thread to be untouched, got %+v", state)
+	}
+	var recipe models.UserRecipe
+	f.mustDo(t, f.ownerAuth, http.MethodGet, "/recipes/"+f.recipeID, nil, &recipe)
+	if recipe.Title != "Stew" {
+		t.Errorf("expected the owner's recipe to be untouched, got %q", recipe.Title)
+	}
+	var plan models.MealPlan
+	f.mustDo(t, f.ownerAuth, http.MethodGet, "/plans/"+f.planID, nil, &plan)
+	if plan.Name != "Week" || len(plan.Recipes) != 1 || plan.Recipes[0].Day != 1 {
+		t.Errorf("expected the owner's plan to be untouched, got %+v", plan)
+	}
+	var collection models.Collection
+	f.mustDo(t, f.ownerAuth, http.MethodGet, "/collections/"+f.collectionID, nil, &collection)
+	if collection.Name != "Favorites" || len(collection.RecipeIDs) != 1 || recipe.IsFavorite || len(recipe.Tags) != 0 {
+		t.Errorf("expected the owner's collection and recipe labels to be untouched, got %+v and %+v", collection, recipe)
+	}
+}
+
+func TestListsOnlyIncludeOwnResources(t *testing.T) {
+	f := newAuthorizationFixture(t)
+
+	var recipes []models.UserRecipe
+	f.mustDo(t, f.intruderAuth, http.MethodGet, "/recipes", nil, &recipes)
+	if len(recipes) != 0 {
+		t.Errorf("expected no recipes, got %+v", recipes)
+	}
+	var threads []models.ThreadSummary
+	f.mustDo(t, f.intruderAuth, http.MethodGet, "/thread", nil, &threads)
+	if len(threads) != 0 {
+		t.Errorf("expected no threads, got %+v", threads)
+	}
+	f.mustDo(t, f.intruderAuth, http.MethodGet, "/thread?recipe_id="+f.recipeID, nil, &threads)
+	if len(threads) != 0 {
+		t.Errorf("expected no threads for another user's recipe, got %+v", threads)
+	}
+	var plans []models.MealPlan
+	f.mustDo(t, f.intruderAuth, http.MethodGet, "/plans", nil, &plans)
+	if len(plans) != 1 || plans[0].ID != f.intruderPlan {
+		t.Errorf("expected only the intruder's own plan, got %+v", plans)
+	}
+	var collections []models.Collection
+	f.mustDo(t, f.intruderAuth, http.MethodGet, "/collections", nil, &collections)
+	if len(collections) != 0 {
+		t.Errorf("expected no collections, got %+v", collections)
+	}
+	var tags []models.RecipeTag
+	f.mustDo(t, f.intruderAuth, http.MethodGet, "/recipes/tags", nil, &tags)
+	if len(tags) != 0 {
+		t.Errorf("expected no tags, got %+v", tags)
+	}
+	// Filtering by someone else's collection finds nothing rather than their recipes
+	f.mustDo(t, f.intruderAuth, http.MethodGet, "/recipes?collection="+f.collectionID, nil, &recipes)
+	if len(recipes) != 0 {
+		t.Errorf("expected no recipes from another user's collection, got %+v", recipes)
+	}
+}
+
+func TestProtectedRoutesRequireAuthentication(t *testing.T) {
+	f := newAuthorizationFixture(t)
+
+	routes := append(f.protectedRoutes(),
+		authorizationCase{method: http.MethodGet, path: "/profile"},
+		authorizationCase{method: http.MethodPut, path: "/profile", body: models.Profile{}},
+		authorizationCase{method: http.MethodGet, path: "/recipes"},
+		authorizationCase{method: http.MethodGet, path: "/thread"},
+		authorizationCase{method: http.MethodGet, path: "/plans"},
+		authorizationCase{method: http.MethodPost, path: "/plans", body: models.CreateMealPlanRequest{Name: "Week"}},
+		authorizationCase{method: http.MethodGet, path: "/recipes/tags"},
+		authorizationCase{method: http.MethodPost, path: "/recipes/import", body: models.ImportRecipeRequest{Text: "Soup"}},
+		authorizationCase{method: http.MethodGet, path: "/collections"},
+		authorizationCase{method: http.MethodPost, path: "/collections", body: models.CreateCollectionRequest{Name: "Mine"}},
+		authorizationCase{method: http.MethodGet, path: "/catalog"},
+		authorizationCase{method: http.MethodGet, path: "/catalog/" + f.recipeID},
+		authorizationCase{method: http.MethodPost, path: "/catalog/" + f.recipeID + "/fork"},
+		authorizationCase{method: http.MethodPost, path: "/thread/suggest", body: models.StartSuggestionThreadRequest{Prompt: "soup"}},
+		authorizationCase{method: http.MethodPost, path: "/thread/suggest/stream", body: models.StartSuggestionThreadRequest{Prompt: "soup"}},
+	)
+	for _, tc := range routes {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			status, data := f.do(t, "", tc.method, tc.path, tc.body)
+			if status != http.StatusUnauthorized {
+				t.Errorf("expected 401, got %d: %s", status, data)
+			}
+		})
+	}
+}
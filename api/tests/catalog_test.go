@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestCatalog(t *testing.T) {
+	ts, store := NewTestServer(t, &MLStub{})
+	defer ts.Close()
+	ctx := context.Background()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(method string, path string, body any, expected int, v any) {
+		t.Helper()
+		status, data := doRequest(t, method, ts.URL+path, auth, body)
+		if status != expected {
+			t.Fatalf("%s %s: expected %d, got %d: %s", method, path, expected, status, data)
+		}
+		if v != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Recipes saved in the same instant have no meaningful order, so titles
+	// are compared sorted
+	titles := func(recipes []models.GlobalRecipe) []string {
+		titles := []string{}
+		for _, recipe := range recipes {
+			titles = append(titles, recipe.Title)
+		}
+		slices.Sort(titles)
+		return titles
+	}
+
+	catalog := map[string]models.GlobalRecipe{}
+	for _, title := range []string{"Sourdough Bread", "Banana Bread", "Tomato Soup"} {
+		recipe := models.GlobalRecipe{
+			ID:         uuid.NewString(),
+			SourceType: models.RecipeSourceGenerated,
+			RecipeBody: makeFakeRecipe(title, WithIngredients([]models.Ingredient{{Name: "Flour", Quantity: 500, Unit: models.MeasurementUnitGram}})),
+		}
+		if err := store.SaveGlobalRecipe(ctx, recipe); err != nil {
+			t.Fatal(err)
+		}
+		catalog[title] = recipe
+	}
+
+	// Search
+	var recipes []models.GlobalRecipe
+	call(http.MethodGet, "/catalog", nil, http.StatusOK, &recipes)
+	if got := titles(recipes); !slices.Equal(got, []string{"Banana Bread", "Sourdough Bread", "Tomato Soup"}) {
+		t.Errorf("expected the whole catalog, got %v", got)
+	}
+	call(http.MethodGet, "/catalog?q=bread", nil, http.StatusOK, &recipes)
+	if got := titles(recipes); !slices.Equal(got, []string{"Banana Bread", "Sourdough Bread"}) {
+		t.Errorf("expected the breads, got %v", got)
+	}
+
+	call(http.MethodGet, "/catalog?limit=2", nil, http.StatusOK, &recipes)
+	if len(recipes) != 2 {
+		t.Errorf("expected a page of 2, got %d", len(recipes))
+	}
+	call(http.MethodGet, "/catalog?cursor=nope", nil, http.StatusBadRequest, nil)
+
+	// Get
+	soup := catalog["Tomato Soup"]
+	var recipe models.GlobalRecipe
+	call(http.MethodGet, "/catalog/"+soup.ID, nil, http.StatusOK, &recipe)
+	if recipe.Title != soup.Title || recipe.Revision != 1 {
+		t.Errorf("expected revision 1 of %s, got %+v", soup.Title, recipe)
+	}
+	call(http.MethodGet, "/catalog/"+uuid.NewString(), nil, http.StatusNotFound, nil)
+
+	// Fork
+	var fork models.UserRecipe
+	call(http.MethodPost, "/catalog/"+soup.ID+"/fork", nil, http.StatusCreated, &fork)
+	if fork.GlobalRecipeID == nil || *fork.GlobalRecipeID != soup.ID || fork.UpdateAvailable || fork.Title != soup.Title {
+		t.Fatalf("expected an up to date fork of %s, got %+v", soup.ID, fork)
+	}
+	var versions []models.RecipeVersion
+	call(http.MethodGet, "/recipes/"+fork.ID+"/versions", nil, http.StatusOK, &versions)
+	if len(versions) != 1 {
+		t.Errorf("expected the fork to start with one version, got %d", len(versions))
+	}
+
+	// Changing the catalog recipe doesn't change the fork, but flags it
+	soup.Servings = 8
+	if err := store.SaveGlobalRecipe(ctx, soup); err != nil {
+		t.Fatal(err)
+	}
+	var mine models.UserRecipe
+	call(http.MethodGet, "/recipes/"+fork.ID, nil, http.StatusOK, &mine)
+	if !mine.UpdateAvailable || mine.Servings == soup.Servings {
+		t.Errorf("expected the unchanged fork to have an update available, got %+v", mine)
+	}
+
+	var apiErr struct {
+		Error models.APIError `json:"error"`
+	}
+	call(http.MethodPost, "/catalog/"+uuid.NewString()+"/fork", nil, http.StatusNotFound, &apiErr)
+	if apiErr.Error.Code != "CATALOG_RECIPE_NOT_FOUND" {
+		t.Errorf("expected CATALOG_RECIPE_NOT_FOUND, got %+v", apiErr.Error)
+	}
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestFavoritesTagsAndCollections(t *testing.T) {
+	ts, store := NewTestServer(t, &MLStub{})
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(method string, path string, body any, expected int, v any) {
+		t.Helper()
+		status, data := doRequest(t, method, ts.URL+path, auth, body)
+		if status != expected {
+			t.Fatalf("%s %s: expected %d, got %d: %s", method, path, expected, status, data)
+		}
+		if v != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Recipes created in the same instant have no meaningful order, so
+	// titles are compared sorted
+	titles := func(path string) []string {
+		t.Helper()
+		var recipes []models.UserRecipe
+		call(http.MethodGet, path, nil, http.StatusOK, &recipes)
+		titles := []string{}
+		for _, recipe := range recipes {
+			titles = append(titles, recipe.Title)
+		}
+		slices.Sort(titles)
+		return titles
+	}
+
+	ids := map[string]string{}
+	for _, title := range []string{"Soup", "Stew", "Salad"} {
+		var recipe models.UserRecipe
+		body := makeFakeRecipe(title, WithIngredients([]models.Ingredient{{Name: "Water", Quantity: 1, Unit: models.MeasurementUnitLiter}}))
+		call(http.MethodPost, "/recipes", body, http.StatusCreated, &recipe)
+		if recipe.IsFavorite || recipe.Tags == nil || len(recipe.Tags) != 0 {
+			t.Fatalf("expected a new recipe to have no labels, got %+v", recipe)
+		}
+		ids[title] = recipe.ID
+	}
+
+	// Favorites
+	var recipe models.UserRecipe
+	call(http.MethodPost, "/recipes/"+ids["Soup"]+"/favorite", nil, http.StatusOK, &recipe)
+	if !recipe.IsFavorite {
+		t.Errorf("expected soup to be a favorite, got %+v", recipe)
+	}
+	call(http.MethodPost, "/recipes/"+ids["Stew"]+"/favorite", nil, http.StatusOK, &recipe)
+	call(http.MethodDelete, "/recipes/"+ids["Stew"]+"/favorite", nil, http.StatusOK, &recipe)
+	if recipe.IsFavorite {
+		t.Errorf("expected stew to no longer be a favorite, got %+v", recipe)
+	}
+	if got := titles("/recipes?favorites=true"); !slices.Equal(got, []string{"Soup"}) {
+		t.Errorf("expected only soup as a favorite, got %v", got)
+	}
+
+	// Tags
+	call(http.MethodPut, "/recipes/"+ids["Soup"]+"/tags", models.SetRecipeTagsRequest{Tags: []string{" Winter", "quick", "winter"}}, http.StatusOK, &recipe)
+	if !slices.Equal(recipe.Tags, []string{"quick", "winter"}) {
+		t.Errorf("expected normalized tags, got %v", recipe.Tags)
+	}
+	call(http.MethodPut, "/recipes/"+ids["Stew"]+"/tags", models.SetRecipeTagsRequest{Tags: []string{"winter"}}, http.StatusOK, &recipe)
+	var tags []models.RecipeTag
+	call(http.MethodGet, "/recipes/tags", nil, http.StatusOK, &tags)
+	if !slices.Equal(tags, []models.RecipeTag{{Tag: "quick", Count: 1}, {Tag: "winter", Count: 2}}) {
+		t.Errorf("expected tag counts, got %+v", tags)
+	}
+	if got := titles("/recipes?tag=Winter"); !slices.Equal(got, []string{"Soup", "Stew"}) {
+		t.Errorf("expected winter recipes, got %v", got)
+	}
+	if got := titles("/recipes?tag=winter,quick"); !slices.Equal(got, []string{"Soup"}) {
+		t.Errorf("expected recipes with both tags, got %v", got)
+	}
+	call(http.MethodPut, "/recipes/"+ids["Soup"]+"/tags", models.SetRecipeTagsRequest{Tags: []string{}}, http.StatusOK, &recipe)
+	if len(recipe.Tags) != 0 {
+		t.Errorf("expected tags to be cleared, got %v", recipe.Tags)
+	}
+	var apiErr struct {
+		Error models.APIError `json:"error"`
+	}
+	call(http.MethodPut, "/recipes/"+ids["Soup"]+"/tags", models.SetRecipeTagsRequest{Tags: []string{""}}, http.StatusBadRequest, &apiErr)
+	if apiErr.Error.Code != "INVALID_TAG" {
+		t.Errorf("expected INVALID_TAG, got %+v", apiErr.Error)
+	}
+
+	// Collections
+	var collection models.Collection
+	call(http.MethodPost, "/collections", models.CreateCollectionRequest{Name: " Dinners "}, http.StatusCreated, &collection)
+	if collection.Name != "Dinners" || collection.RecipeIDs == nil || len(collection.RecipeIDs) != 0 {
+		t.Fatalf("expected an empty collection, got %+v", collection)
+	}
+	path := "/collections/" + collection.ID
+	for _, title := range []string{"Soup", "Stew", "Salad", "Soup"} {
+		call(http.MethodPost, path+"/recipes", models.AddCollectionRecipeRequest{RecipeID: ids[title]}, http.StatusOK, &collection)
+	}
+	if !slices.Equal(collection.RecipeIDs, []string{ids["Soup"], ids["Stew"], ids["Salad"]}) {
+		t.Errorf("expected recipes in the order they were added, got %v", collection.RecipeIDs)
+	}
+	call(http.MethodPut, path+"/recipes/"+ids["Soup"], models.MoveCollectionRecipeRequest{Position: 10}, http.StatusOK, &collection)
+	call(http.MethodPut, path+"/recipes/"+ids["Salad"], models.MoveCollectionRecipeRequest{Position: 0}, http.StatusOK, &collection)
+	if !slices.Equal(collection.RecipeIDs, []string{ids["Salad"], ids["Stew"], ids["Soup"]}) {
+		t.Errorf("expected moved recipes, got %v", collection.RecipeIDs)
+	}
+	call(http.MethodDelete, path+"/recipes/"+ids["Stew"], nil, http.StatusOK, &collection)
+	call(http.MethodDelete, path+"/recipes/"+ids["Stew"], nil, http.StatusNotFound, nil)
+	call(http.MethodPost, path+"/recipes", models.AddCollectionRecipeRequest{RecipeID: "missing"}, http.StatusNotFound, nil)
+	call(http.MethodPut, path, models.UpdateCollectionRequest{Name: "Suppers"}, http.StatusOK, &collection)
+	if collection.Name != "Suppers" || !slices.Equal(collection.RecipeIDs, []string{ids["Salad"], ids["Soup"]}) {
+		t.Errorf("expected the renamed collection, got %+v", collection)
+	}
+	if got := titles("/recipes?collection=" + collection.ID); !slices.Equal(got, []string{"Salad", "Soup"}) {
+		t.Errorf("expected the collection's recipes, got %v", got)
+	}
+	if got := titles("/recipes?collection=" + collection.ID + "&favorites=true"); !slices.Equal(got, []string{"Soup"}) {
+		t.Errorf("expected the collection's favorites, got %v", got)
+	}
+
+	// Deleting a recipe takes it out of collections, deleting a collection
+	// keeps its recipes
+	call(http.MethodDelete, "/recipes/"+ids["Soup"], nil, http.StatusOK, nil)
+	call(http.MethodGet, path, nil, http.StatusOK, &collection)
+	if !slices.Equal(collection.RecipeIDs, []string{ids["Salad"]}) {
+		t.Errorf("expected the deleted recipe to leave the collection, got %v", collection.RecipeIDs)
+	}
+	call(http.MethodDelete, path, nil, http.StatusNoContent, nil)
+	call(http.MethodGet, path, nil, http.StatusNotFound, nil)
+	var collections []models.Collection
+	call(http.MethodGet, "/collections", nil, http.StatusOK, &collections)
+	if len(collections) != 0 {
+		t.Errorf("expected no collections, got %+v", collections)
+	}
+	if got := titles("/recipes"); !slices.Equal(got, []string{"Salad", "Stew"}) {
+		t.Errorf("expected the remaining recipes, got %v", got)
+	}
+}
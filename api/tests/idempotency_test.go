@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/clients"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func postWithIdempotencyKey(t *testing.T, url, auth, key string, body any) (*http.Response, []byte) {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", auth)
+	req.Header.Set("Idempotency-Key", key)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, data
+}
+
+func TestIdempotentRetriesReplayTheFirstResponse(t *testing.T) {
+	suggest := func(title string) models.SuggestChatResponse {
+		return models.SuggestChatResponse{Suggestions: []*models.Suggestion{
+			{Recipe: makeFakeRecipe(title), ResponseText: "Try " + title},
+		}}
+	}
+	ml := &MLStub{SuggestResponses: []models.SuggestChatResponse{suggest("Soup"), suggest("Stew"), suggest("Curry")}}
+	ts, store := NewTestServer(t, ml)
+	defer ts.Close()
+
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := ts.URL + "/thread/suggest"
+	request := models.StartSuggestionThreadRequest{Prompt: "something warm"}
+
+	resp, first := postWithIdempotencyKey(t, url, auth, "attempt-1", request)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, first)
+	}
+	resp, retry := postWithIdempotencyKey(t, url, auth, "attempt-1", request)
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(first, retry) {
+		t.Errorf("expected the retry to replay %s, got %d: %s", first, resp.StatusCode, retry)
+	}
+	if resp.Header.Get("Idempotent-Replayed") != "true" {
+		t.Error("expected the retry to be marked as replayed")
+	}
+	if ml.suggestCall != 1 {
+		t.Errorf("expected suggestions to be generated once, got %d", ml.suggestCall)
+	}
+
+	resp, data := postWithIdempotencyKey(t, url, auth, "attempt-1", models.StartSuggestionThreadRequest{Prompt: "something cold"})
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected reusing a key for another request to be 422, got %d: %s", resp.StatusCode, data)
+	}
+
+	resp, data = postWithIdempotencyKey(t, url, auth, "attempt-2", request)
+	var state models.ThreadState
+	if err := json.Unmarshal(data, &state); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected a new key to start a new thread, got %d: %s", resp.StatusCode, data)
+	}
+	if state.Suggestions[0].Suggestion.Title != "Stew" {
+		t.Errorf("expected fresh suggestions, got %s", state.Suggestions[0].Suggestion.Title)
+	}
+
+	// Keys belong to the user who sent them
+	other, err := createUser(store, "other@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherAuth, err := authHeader(other.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, data = postWithIdempotencyKey(t, url, otherAuth, "attempt-1", request)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Errorf("expected another user's key not to be replayed, got %d: %s", resp.StatusCode, data)
+	}
+}
+
+func TestIdempotentStreamFailuresAreNotReplayed(t *testing.T) {
+	suggest := func(title string) models.SuggestChatResponse {
+		return models.SuggestChatResponse{Suggestions: []*models.Suggestion{
+			{Recipe: makeFakeRecipe(title), ResponseText: "Try " + title},
+		}}
+	}
+	ml := &MLStub{
+		SuggestResponses: []models.SuggestChatResponse{suggest("Soup"), suggest("Stew")},
+		StreamErr:        &clients.MLError{Endpoint: clients.EndpointSuggestStream, Err: clients.ErrMLCallFailed},
+	}
+	ts, store := NewTestServer(t, ml)
+	defer ts.Close()
+
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := ts.URL + "/thread/suggest/stream"
+	request := models.StartSuggestionThreadRequest{Prompt: "something warm"}
+
+	// The stream fails after it has started, so its status is 200, but the
+	// key is released like it would be for a 502
+	resp, data := postWithIdempotencyKey(t, url, auth, "attempt-1", request)
+	if resp.StatusCode != http.StatusOK || !bytes.Contains(data, []byte("event: error")) {
+		t.Fatalf("expected the stream to fail with an error event, got %d: %s", resp.StatusCode, data)
+	}
+
+	ml.StreamErr = nil
+	resp, data = postWithIdempotencyKey(t, url, auth, "attempt-1", request)
+	if resp.Header.Get("Idempotent-Replayed") != "" || !bytes.Contains(data, []byte("event: done")) {
+		t.Fatalf("expected the retry to run again, got %d: %s", resp.StatusCode, data)
+	}
+	if ml.suggestCall != 2 {
+		t.Errorf("expected suggestions to be generated twice, got %d", ml.suggestCall)
+	}
+
+	// Successful streams are replayed
+	resp, retry := postWithIdempotencyKey(t, url, auth, "attempt-1", request)
+	if resp.Header.Get("Idempotent-Replayed") != "true" || !bytes.Equal(data, retry) {
+		t.Errorf("expected the successful stream to be replayed, got %d: %s", resp.StatusCode, retry)
+	}
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestImportRecipe(t *testing.T) {
+	ts, store := NewTestServer(t, &MLStub{})
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(method string, path string, body any, expected int, v any) {
+		t.Helper()
+		status, data := doRequest(t, method, ts.URL+path, auth, body)
+		if status != expected {
+			t.Fatalf("%s %s: expected %d, got %d: %s", method, path, expected, status, data)
+		}
+		if v != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	page, err := os.ReadFile("../internal/importer/testdata/jsonld.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var draft models.RecipeBody
+	call(http.MethodPost, "/recipes/import", models.ImportRecipeRequest{HTML: string(page)}, http.StatusOK, &draft)
+	if draft.Title != "Buttermilk Pancakes" || len(draft.Ingredients) != 5 || len(draft.Steps) != 3 {
+		t.Fatalf("expected the pancake recipe, got %+v", draft)
+	}
+	if flour := draft.Ingredients[0]; flour.Quantity != 1.5 || flour.Unit != models.MeasurementUnitCup {
+		t.Errorf("expected 1.5 cups of flour, got %+v", flour)
+	}
+
+	// Importing doesn't save anything until the draft is confirmed
+	var recipes []models.UserRecipe
+	call(http.MethodGet, "/recipes", nil, http.StatusOK, &recipes)
+	if len(recipes) != 0 {
+		t.Fatalf("expected no recipes before confirming, got %d", len(recipes))
+	}
+	var recipe models.UserRecipe
+	call(http.MethodPost, "/recipes", draft, http.StatusCreated, &recipe)
+	if recipe.Title != draft.Title || recipe.Servings != 4 {
+		t.Errorf("expected the confirmed draft, got %+v", recipe)
+	}
+
+	text := "Toast\nIngredients\n2 slices bread\n1 tbsp butter\nMethod\nToast the bread and butter it."
+	call(http.MethodPost, "/recipes/import", models.ImportRecipeRequest{Text: text}, http.StatusOK, &draft)
+	if draft.Title != "Toast" || len(draft.Ingredients) != 2 || len(draft.Steps) != 1 {
+		t.Errorf("expected the toast recipe, got %+v", draft)
+	}
+
+	var apiErr struct {
+		Error models.APIError `json:"error"`
+	}
+	call(http.MethodPost, "/recipes/import", models.ImportRecipeRequest{HTML: "<html><body><p>Nothing here</p></body></html>"}, http.StatusUnprocessableEntity, &apiErr)
+	if apiErr.Error.Code != "NO_RECIPE_FOUND" {
+		t.Errorf("expected NO_RECIPE_FOUND, got %+v", apiErr.Error)
+	}
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestManualRecipe(t *testing.T) {
+	ts, store := NewTestServer(t, &MLStub{})
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(method string, path string, body any, expected int, v any) {
+		t.Helper()
+		status, data := doRequest(t, method, ts.URL+path, auth, body)
+		if status != expected {
+			t.Fatalf("%s %s: expected %d, got %d: %s", method, path, expected, status, data)
+		}
+		if v != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	expectError := func(method string, path string, body any, status int, code string, field string) {
+		t.Helper()
+		var response struct {
+			Error models.APIError `json:"error"`
+		}
+		call(method, path, body, status, &response)
+		if response.Error.Code != code || response.Error.Field != field {
+			t.Errorf("%s %s: expected %s on %q, got %+v", method, path, code, field, response.Error)
+		}
+	}
+
+	body := makeFakeRecipe("Porridge", WithIngredients([]models.Ingredient{
+		{Name: "Oats", Quantity: 1, Unit: models.MeasurementUnitCup},
+		{Name: "Milk", Quantity: 2, Unit: models.MeasurementUnitCup},
+	}))
+	invalid := body
+	invalid.Servings = 0
+	expectError(http.MethodPost, "/recipes", invalid, http.StatusBadRequest, "VALIDATION_FAILED", "servings")
+
+	var recipe models.UserRecipe
+	call(http.MethodPost, "/recipes", body, http.StatusCreated, &recipe)
+	if recipe.Title != "Porridge" || recipe.ThreadID == "" {
+		t.Fatalf("expected the new recipe, got %+v", recipe)
+	}
+	path := "/recipes/" + recipe.ID
+
+	body.Title = "Creamy Porridge"
+	body.Ingredients[1].Quantity = 3
+	var updated models.UserRecipe
+	call(http.MethodPut, path, body, http.StatusOK, &updated)
+	if updated.Title != "Creamy Porridge" || updated.LatestVersionID == recipe.LatestVersionID {
+		t.Errorf("expected a new version with the edit, got %+v", updated)
+	}
+
+	patch := []models.PatchOperation{
+		{Op: "test", Path: "/ingredients/0/name", Value: json.RawMessage(`"Oats"`)},
+		{Op: "add", Path: "/ingredients/-", Value: json.RawMessage(`{"name":"Honey","quantity":1,"unit":"tbsp"}`)},
+		{Op: "add", Path: "/steps/-", Value: json.RawMessage(`"Drizzle with honey"`)},
+	}
+	var patched models.UserRecipe
+	call(http.MethodPatch, path, patch, http.StatusOK, &patched)
+	if len(patched.Ingredients) != 3 || patched.Ingredients[2].Name != "Honey" || len(patched.Steps) != 3 {
+		t.Errorf("expected honey to be added, got %+v", patched)
+	}
+
+	// Scaling is an edit like any other
+	var scaled models.UserRecipe
+	call(http.MethodPost, path+"/scale", models.ScaleRecipeRequest{Servings: 8}, http.StatusOK, &scaled)
+	if scaled.Servings != 8 || scaled.Ingredients[1].Quantity != 6 || scaled.LatestVersionID == patched.LatestVersionID {
+		t.Errorf("expected a new version for 8 servings, got %+v", scaled)
+	}
+	expectError(http.MethodPost, path+"/scale", models.ScaleRecipeRequest{Servings: 0}, http.StatusBadRequest, "INVALID_SERVINGS", "servings")
+
+	expectError(http.MethodPatch, path, []models.PatchOperation{{Op: "test", Path: "/title", Value: json.RawMessage(`"Porridge"`)}}, http.StatusConflict, "PATCH_TEST_FAILED", "")
+	expectError(http.MethodPatch, path, []models.PatchOperation{{Op: "remove", Path: "/steps/9"}}, http.StatusBadRequest, "INVALID_PATCH", "")
+	expectError(http.MethodPatch, path, []models.PatchOperation{{Op: "replace", Path: "/ingredients/0/unit", Value: json.RawMessage(`"handful"`)}}, http.StatusBadRequest, "VALIDATION_FAILED", "ingredients[0].unit")
+	expectError(http.MethodPut, "/recipes/missing", body, http.StatusNotFound, "RECIPE_NOT_FOUND", "")
+
+	var versions []models.RecipeVersionSummary
+	call(http.MethodGet, path+"/versions", nil, http.StatusOK, &versions)
+	if len(versions) != 4 || versions[3].ID != scaled.LatestVersionID {
+		t.Errorf("expected a version for each change, got %+v", versions)
+	}
+
+	var events []models.ThreadEvent
+	call(http.MethodGet, "/thread/"+recipe.ThreadID+"/events", nil, http.StatusOK, &events)
+	expected := []models.ThreadEventType{models.ThreadEventTypeRecipeCreated, models.ThreadEventTypeRecipeEdited, models.ThreadEventTypeRecipeEdited, models.ThreadEventTypeRecipeEdited}
+	if len(events) != len(expected) {
+		t.Fatalf("expected events %v, got %+v", expected, events)
+	}
+	for i, event := range events {
+		if event.Type != expected[i] {
+			t.Errorf("expected event %d to be %s, got %s", i, expected[i], event.Type)
+		}
+	}
+	var thread models.ThreadState
+	call(http.MethodGet, "/thread/"+recipe.ThreadID, nil, http.StatusOK, &thread)
+	if thread.CurrentRecipe == nil || len(thread.CurrentRecipe.Ingredients) != 3 || thread.CurrentRecipe.Servings != 8 {
+		t.Errorf("expected the thread to hold the scaled recipe, got %+v", thread.CurrentRecipe)
+	}
+
+	// Undo works on hand-edited recipes too
+	var undone models.UserRecipe
+	call(http.MethodPost, path+"/modify/undo", nil, http.StatusOK, &undone)
+	if undone.Servings != 4 || len(undone.Ingredients) != 3 {
+		t.Errorf("expected undo to go back to the patched version, got %+v", undone)
+	}
+	call(http.MethodPost, path+"/modify/undo", nil, http.StatusOK, &undone)
+	if undone.Title != "Creamy Porridge" || len(undone.Ingredients) != 2 {
+		t.Errorf("expected undo to go back to the PUT version, got %+v", undone)
+	}
+}
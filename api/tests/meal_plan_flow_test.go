@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestMealPlanFlow(t *testing.T) {
+	ts, store := NewTestServer(t, &MLStub{})
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(method string, path string, body any, expected int, v any) {
+		t.Helper()
+		status, data := doRequest(t, method, ts.URL+path, auth, body)
+		if status != expected {
+			t.Fatalf("%s %s: expected %d, got %d: %s", method, path, expected, status, data)
+		}
+		if v != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Unscheduled entries omit their day, so every response is decoded into
+	// a fresh plan rather than over the previous one
+	var plan models.MealPlan
+	updatePlan := func(method string, path string, body any) {
+		t.Helper()
+		plan = models.MealPlan{}
+		call(method, path, body, http.StatusOK, &plan)
+	}
+	// layout lists the titles of a plan's recipes by day, in order
+	layout := func(plan models.MealPlan) map[int][]string {
+		days := map[int][]string{}
+		for _, r := range plan.Recipes {
+			if r.Position != len(days[r.Day]) {
+				t.Fatalf("expected contiguous positions, got %q at %d on day %d", r.Title, r.Position, r.Day)
+			}
+			days[r.Day] = append(days[r.Day], r.Title)
+		}
+		return days
+	}
+	expectLayout := func(plan models.MealPlan, expected map[int][]string) {
+		t.Helper()
+		got := layout(plan)
+		if len(got) != len(expected) {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+		for day, titles := range expected {
+			if len(got[day]) != len(titles) {
+				t.Fatalf("expected %v, got %v", expected, got)
+			}
+			for i := range titles {
+				if got[day][i] != titles[i] {
+					t.Fatalf("expected %v, got %v", expected, got)
+				}
+			}
+		}
+	}
+
+	recipes := map[string]models.UserRecipe{}
+	for _, title := range []string{"Porridge", "Salad", "Soup", "Stew"} {
+		var recipe models.UserRecipe
+		call(http.MethodPost, "/recipes", makeFakeRecipe(title, WithIngredients([]models.Ingredient{{Name: "Water", Quantity: 1, Unit: models.MeasurementUnitLiter}})), http.StatusCreated, &recipe)
+		recipes[title] = recipe
+	}
+
+	call(http.MethodPost, "/plans", models.CreateMealPlanRequest{Name: "This week"}, http.StatusCreated, &plan)
+	if plan.Name != "This week" || len(plan.Recipes) != 0 {
+		t.Fatalf("expected an empty plan, got %+v", plan)
+	}
+	path := "/plans/" + plan.ID
+
+	// Recipes are appended to the end of their day
+	add := func(title string, day int) {
+		t.Helper()
+		updatePlan(http.MethodPost, path+"/recipes", models.AddMealPlanRecipeRequest{RecipeID: recipes[title].ID, Day: day})
+	}
+	add("Porridge", 1)
+	add("Soup", 1)
+	add("Stew", 1)
+	add("Salad", 2)
+	expectLayout(plan, map[int][]string{1: {"Porridge", "Soup", "Stew"}, 2: {"Salad"}})
+
+	entry := func(title string) string {
+		t.Helper()
+		for _, r := range plan.Recipes {
+			if r.Title == title {
+				return r.ID
+			}
+		}
+		t.Fatalf("%q isn't in the plan", title)
+		return ""
+	}
+
+	// Reorder within a day
+	updatePlan(http.MethodPut, path+"/recipes/"+entry("Stew"), models.MoveMealPlanRecipeRequest{Day: 1, Position: 0})
+	expectLayout(plan, map[int][]string{1: {"Stew", "Porridge", "Soup"}, 2: {"Salad"}})
+
+	// Move to another day, positions past the end append
+	updatePlan(http.MethodPut, path+"/recipes/"+entry("Porridge"), models.MoveMealPlanRecipeRequest{Day: 2, Position: 10})
+	expectLayout(plan, map[int][]string{1: {"Stew", "Soup"}, 2: {"Salad", "Porridge"}})
+
+	// Unschedule
+	updatePlan(http.MethodPut, path+"/recipes/"+entry("Soup"), models.MoveMealPlanRecipeRequest{Day: 0, Position: 0})
+	expectLayout(plan, map[int][]string{0: {"Soup"}, 1: {"Stew"}, 2: {"Salad", "Porridge"}})
+
+	// Removing closes the gap
+	updatePlan(http.MethodDelete, path+"/recipes/"+entry("Salad"), nil)
+	expectLayout(plan, map[int][]string{0: {"Soup"}, 1: {"Stew"}, 2: {"Porridge"}})
+
+	updatePlan(http.MethodGet, path, nil)
+	expectLayout(plan, map[int][]string{0: {"Soup"}, 1: {"Stew"}, 2: {"Porridge"}})
+
+	var apiErr struct {
+		Error models.APIError `json:"error"`
+	}
+	call(http.MethodDelete, path+"/recipes/missing", nil, http.StatusNotFound, &apiErr)
+	if apiErr.Error.Code != models.ApiErrMealPlanRecipeNotFound.Code {
+		t.Errorf("expected %s, got %+v", models.ApiErrMealPlanRecipeNotFound.Code, apiErr.Error)
+	}
+	call(http.MethodPut, path+"/recipes/"+entry("Soup"), models.MoveMealPlanRecipeRequest{Day: -1}, http.StatusBadRequest, &apiErr)
+	if apiErr.Error.Code != models.ApiErrInvalidMealPlanDay.Code {
+		t.Errorf("expected %s, got %+v", models.ApiErrInvalidMealPlanDay.Code, apiErr.Error)
+	}
+}
+
+func TestMealPlanPinsRecipeVersion(t *testing.T) {
+	ts, store := NewTestServer(t, &MLStub{})
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(method string, path string, body any, expected int, v any) {
+		t.Helper()
+		status, data := doRequest(t, method, ts.URL+path, auth, body)
+		if status != expected {
+			t.Fatalf("%s %s: expected %d, got %d: %s", method, path, expected, status, data)
+		}
+		if v != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	body := makeFakeRecipe("Porridge", WithServings(2), WithIngredients([]models.Ingredient{{Name: "Water", Quantity: 1, Unit: models.MeasurementUnitLiter}}))
+	var recipe models.UserRecipe
+	call(http.MethodPost, "/recipes", body, http.StatusCreated, &recipe)
+	var plan models.MealPlan
+	call(http.MethodPost, "/plans", models.CreateMealPlanRequest{Name: "This week"}, http.StatusCreated, &plan)
+	path := "/plans/" + plan.ID
+	call(http.MethodPost, path+"/recipes", models.AddMealPlanRecipeRequest{RecipeID: recipe.ID, Day: 1}, http.StatusOK, &plan)
+	if len(plan.Recipes) != 1 || plan.Recipes[0].RecipeVersionID != recipe.LatestVersionID {
+		t.Fatalf("expected the recipe pinned to %s, got %+v", recipe.LatestVersionID, plan.Recipes)
+	}
+
+	// Editing the recipe makes a new version and leaves the plan alone
+	body.Title = "Creamy Porridge"
+	body.Servings = 4
+	var updated models.UserRecipe
+	call(http.MethodPut, "/recipes/"+recipe.ID, body, http.StatusOK, &updated)
+	if updated.LatestVersionID == recipe.LatestVersionID {
+		t.Fatalf("expected a new version, got %+v", updated)
+	}
+
+	call(http.MethodGet, path, nil, http.StatusOK, &plan)
+	pinned := plan.Recipes[0]
+	if pinned.RecipeVersionID != recipe.LatestVersionID || pinned.Title != "Porridge" || pinned.Servings != 2 {
+		t.Errorf("expected the original version in the plan, got %+v", pinned)
+	}
+
+	// Adding the recipe again pins the new version alongside the old one
+	call(http.MethodPost, path+"/recipes", models.AddMealPlanRecipeRequest{RecipeID: recipe.ID, Day: 1}, http.StatusOK, &plan)
+	if len(plan.Recipes) != 2 {
+		t.Fatalf("expected two entries, got %+v", plan.Recipes)
+	}
+	if latest := plan.Recipes[1]; latest.RecipeVersionID != updated.LatestVersionID || latest.Title != "Creamy Porridge" || latest.Servings != 4 {
+		t.Errorf("expected the edited version, got %+v", latest)
+	}
+	if plan.Recipes[0].Title != "Porridge" {
+		t.Errorf("expected the original entry to keep its version, got %+v", plan.Recipes[0])
+	}
+}
@@ -2,7 +2,9 @@ package tests
 
 import (
 	"context"
+	"strings"
 
+	"github.com/ajohnston1219/eatme/api/internal/clients"
 	"github.com/ajohnston1219/eatme/api/internal/models"
 )
 
@@ -11,6 +13,12 @@ type MLStub struct {
 	suggestCall      int
 	ModifyResponses  []models.ModifyChatResponse
 	modifyCall       int
+	GeneralResponses []models.GeneralChatResponse
+	generalCall      int
+	// StreamErr makes streamed responses fail after their first token
+	StreamErr error
+	// BeforeToken is called before each streamed token is sent
+	BeforeToken func()
 }
 
 func (m *MLStub) SuggestChat(_ context.Context, _ *models.InternalSuggestChatRequest) (*models.SuggestChatResponse, error) {
@@ -26,5 +34,73 @@ func (m *MLStub) ModifyChat(_ context.Context, _ *models.InternalModifyChatReque
 }
 
 func (m *MLStub) GeneralChat(_ context.Context, _ *models.InternalGeneralChatRequest) (*models.GeneralChatResponse, error) {
-	return nil, nil
+	if m.generalCall >= len(m.GeneralResponses) {
+		return nil, nil
+	}
+	resp := m.GeneralResponses[m.generalCall]
+	m.generalCall++
+	return &resp, nil
+}
+
+// The streamed variants replay the canned responses, sending the response
+// text a word at a time, and fail partway through when StreamErr is set.
+
+func (m *MLStub) SuggestChatStream(ctx context.Context, req *models.InternalSuggestChatRequest, handlers clients.StreamHandlers) (*models.SuggestChatResponse, error) {
+	resp, err := m.SuggestChat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, suggestion := range resp.Suggestions {
+		if err := m.streamTokens(suggestion.ResponseText, handlers); err != nil {
+			return nil, err
+		}
+		if handlers.OnSuggestion != nil {
+			if err := handlers.OnSuggestion(suggestion); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return resp, nil
+}
+
+func (m *MLStub) ModifyChatStream(ctx context.Context, req *models.InternalModifyChatRequest, handlers clients.StreamHandlers) (*models.ModifyChatResponse, error) {
+	resp, err := m.ModifyChat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.streamTokens(resp.ResponseText, handlers); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (m *MLStub) GeneralChatStream(ctx context.Context, req *models.InternalGeneralChatRequest, handlers clients.StreamHandlers) (*models.GeneralChatResponse, error) {
+	resp, err := m.GeneralChat(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	if err := m.streamTokens(resp.ResponseText, handlers); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (m *MLStub) streamTokens(text string, handlers clients.StreamHandlers) error {
+	for _, word := range strings.SplitAfter(text, " ") {
+		if word == "" {
+			continue
+		}
+		if m.BeforeToken != nil {
+			m.BeforeToken()
+		}
+		if handlers.OnToken != nil {
+			if err := handlers.OnToken(word); err != nil {
+				return err
+			}
+		}
+		if m.StreamErr != nil {
+			return m.StreamErr
+		}
+	}
+	return nil
 }
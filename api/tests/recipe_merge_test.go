@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestMergeRecipeVersions(t *testing.T) {
+	ml := &MLStub{
+		SuggestResponses: []models.SuggestChatResponse{{Suggestions: []*models.Suggestion{
+			{Recipe: makeFakeRecipe("Soup"), ResponseText: "Try soup"},
+		}}},
+		ModifyResponses: []models.ModifyChatResponse{
+			{NewRecipe: makeFakeRecipe("Spicy Soup", WithServings(6)), ResponseText: "Added chili"},
+			{NewRecipe: makeFakeRecipe("Creamy Soup", WithSteps([]models.Step{"Step 1", "Step 2", "Stir in the cream"})), ResponseText: "Added cream"},
+		},
+	}
+	ts, store := NewTestServer(t, ml)
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(method string, path string, body any, expected int, v any) {
+		t.Helper()
+		status, data := doRequest(t, method, ts.URL+path, auth, body)
+		if status != expected {
+			t.Fatalf("%s %s: expected %d, got %d: %s", method, path, expected, status, data)
+		}
+		if v != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	var thread models.ThreadState
+	call(http.MethodPost, "/thread/suggest", models.StartSuggestionThreadRequest{Prompt: "soup"}, http.StatusOK, &thread)
+	var recipe models.UserRecipe
+	call(http.MethodPost, "/thread/"+thread.ID+"/accept/"+thread.Suggestions[0].ID, nil, http.StatusOK, &recipe)
+	path := "/recipes/" + recipe.ID
+
+	// Two modifications branching from the first version
+	call(http.MethodPost, path+"/modify/chat", models.ModifyRecipeViaChatRequest{Prompt: "spicier"}, http.StatusOK, nil)
+	call(http.MethodPost, path+"/modify/accept", nil, http.StatusNoContent, nil)
+	var spicy models.UserRecipe
+	call(http.MethodGet, path, nil, http.StatusOK, &spicy)
+	call(http.MethodPost, path+"/modify/undo", nil, http.StatusOK, nil)
+	call(http.MethodPost, path+"/modify/chat", models.ModifyRecipeViaChatRequest{Prompt: "creamier"}, http.StatusOK, nil)
+	call(http.MethodPost, path+"/modify/accept", nil, http.StatusNoContent, nil)
+	var creamy models.UserRecipe
+	call(http.MethodGet, path, nil, http.StatusOK, &creamy)
+
+	var merge models.RecipeMerge
+	call(http.MethodGet, path+"/merge?theirs_version_id="+spicy.LatestVersionID, nil, http.StatusOK, &merge)
+	if merge.BaseVersionID != recipe.LatestVersionID || merge.OursVersionID != creamy.LatestVersionID || merge.TheirsVersionID != spicy.LatestVersionID {
+		t.Errorf("expected the first version as the base of the latest and spicy versions, got %+v", merge)
+	}
+	if len(merge.Conflicts) != 1 || merge.Conflicts[0].ID != "field:title" {
+		t.Fatalf("expected only the title to conflict, got %+v", merge.Conflicts)
+	}
+	if merge.Recipe.Servings != 6 || len(merge.Recipe.Steps) != 3 {
+		t.Errorf("expected both sides' changes in the merge, got %+v", merge.Recipe)
+	}
+
+	expectError := func(body models.MergeRecipeVersionsRequest, status int, code string) models.APIError {
+		t.Helper()
+		var response struct {
+			Error models.APIError `json:"error"`
+		}
+		call(http.MethodPost, path+"/merge", body, status, &response)
+		if response.Error.Code != code {
+			t.Errorf("expected %s, got %+v", code, response.Error)
+		}
+		return response.Error
+	}
+	if conflict := expectError(models.MergeRecipeVersionsRequest{TheirsVersionID: spicy.LatestVersionID}, http.StatusConflict, "MERGE_CONFLICT"); conflict.Details != "field:title" {
+		t.Errorf("expected the unresolved conflict in the details, got %q", conflict.Details)
+	}
+	expectError(models.MergeRecipeVersionsRequest{}, http.StatusBadRequest, "MISSING_MERGE_THEIRS")
+	expectError(models.MergeRecipeVersionsRequest{TheirsVersionID: "missing"}, http.StatusNotFound, "RECIPE_VERSION_NOT_FOUND")
+	expectError(models.MergeRecipeVersionsRequest{
+		TheirsVersionID: spicy.LatestVersionID,
+		Resolutions:     []models.MergeResolution{{ConflictID: "field:servings", Side: models.MergeSideOurs}},
+	}, http.StatusBadRequest, "UNKNOWN_MERGE_CONFLICT")
+
+	var merged models.UserRecipe
+	call(http.MethodPost, path+"/merge", models.MergeRecipeVersionsRequest{
+		TheirsVersionID: spicy.LatestVersionID,
+		Resolutions:     []models.MergeResolution{{ConflictID: "field:title", Side: models.MergeSideTheirs}},
+	}, http.StatusOK, &merged)
+	if merged.Title != "Spicy Soup" || merged.Servings != 6 || len(merged.Steps) != 3 {
+		t.Errorf("expected the resolved merge, got %+v", merged)
+	}
+	var version models.RecipeVersion
+	call(http.MethodGet, path+"/versions/"+merged.LatestVersionID, nil, http.StatusOK, &version)
+	if version.ParentID == nil || *version.ParentID != creamy.LatestVersionID {
+		t.Errorf("expected the merge to be made from the latest version, got %+v", version)
+	}
+
+	// The thread follows the merged version
+	thread = models.ThreadState{}
+	call(http.MethodGet, "/thread/"+recipe.ThreadID, nil, http.StatusOK, &thread)
+	if thread.CurrentRecipe == nil || thread.CurrentRecipe.Title != "Spicy Soup" || thread.CurrentRecipe.Servings != 6 {
+		t.Errorf("expected the thread to hold the merged recipe, got %+v", thread.CurrentRecipe)
+	}
+	var events []models.ThreadEvent
+	call(http.MethodGet, "/thread/"+recipe.ThreadID+"/events", nil, http.StatusOK, &events)
+	if last := events[len(events)-1]; last.Type != models.ThreadEventTypeRecipeEdited {
+		t.Errorf("expected the merge to be recorded as an edit, got %s", last.Type)
+	}
+}
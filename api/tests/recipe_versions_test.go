@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestRecipeVersionHistory(t *testing.T) {
+	ml := &MLStub{
+		SuggestResponses: []models.SuggestChatResponse{{Suggestions: []*models.Suggestion{
+			{Recipe: makeFakeRecipe("Soup"), ResponseText: "Try soup"},
+		}}},
+		ModifyResponses: []models.ModifyChatResponse{
+			{NewRecipe: makeFakeRecipe("Spicy Soup"), ResponseText: "Added chili"},
+			{NewRecipe: makeFakeRecipe("Creamy Soup"), ResponseText: "Added cream"},
+		},
+	}
+	ts, store := NewTestServer(t, ml)
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(method string, path string, body any, expected int, v any) {
+		t.Helper()
+		status, data := doRequest(t, method, ts.URL+path, auth, body)
+		if status != expected {
+			t.Fatalf("%s %s: expected %d, got %d: %s", method, path, expected, status, data)
+		}
+		if v != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	var thread models.ThreadState
+	call(http.MethodPost, "/thread/suggest", models.StartSuggestionThreadRequest{Prompt: "soup"}, http.StatusOK, &thread)
+	var recipe models.UserRecipe
+	call(http.MethodPost, "/thread/"+thread.ID+"/accept/"+thread.Suggestions[0].ID, nil, http.StatusOK, &recipe)
+	path := "/recipes/" + recipe.ID
+
+	// Undoing the first modification and making another branches the tree
+	call(http.MethodPost, path+"/modify/chat", models.ModifyRecipeViaChatRequest{Prompt: "spicier"}, http.StatusOK, nil)
+	call(http.MethodPost, path+"/modify/accept", nil, http.StatusNoContent, nil)
+	call(http.MethodPost, path+"/modify/undo", nil, http.StatusOK, nil)
+	call(http.MethodPost, path+"/modify/chat", models.ModifyRecipeViaChatRequest{Prompt: "creamier"}, http.StatusOK, nil)
+	call(http.MethodPost, path+"/modify/accept", nil, http.StatusNoContent, nil)
+
+	var versions []models.RecipeVersionSummary
+	call(http.MethodGet, path+"/versions", nil, http.StatusOK, &versions)
+	if len(versions) != 3 {
+		t.Fatalf("expected 3 versions, got %+v", versions)
+	}
+	soup, spicy, creamy := versions[0], versions[1], versions[2]
+	if soup.Title != "Soup" || soup.ParentID != nil || soup.ID != recipe.LatestVersionID {
+		t.Errorf("expected the accepted suggestion first, got %+v", soup)
+	}
+	for _, child := range []models.RecipeVersionSummary{spicy, creamy} {
+		if child.ParentID == nil || *child.ParentID != soup.ID {
+			t.Errorf("expected %q to be made from the first version, got %+v", child.Title, child)
+		}
+	}
+	if spicy.Title != "Spicy Soup" || spicy.IsLatest || creamy.Title != "Creamy Soup" || !creamy.IsLatest {
+		t.Errorf("expected the branches oldest first with the latest marked, got %+v", versions[1:])
+	}
+
+	var version models.RecipeVersion
+	call(http.MethodGet, path+"/versions/"+spicy.ID, nil, http.StatusOK, &version)
+	if version.Title != "Spicy Soup" || version.UserRecipeID != recipe.ID {
+		t.Errorf("expected the spicy version, got %+v", version)
+	}
+
+	var diff models.RecipeDiff
+	call(http.MethodGet, path+"/diff?from="+spicy.ID, nil, http.StatusOK, &diff)
+	if diff.NewTitle == nil || *diff.NewTitle != "Creamy Soup" {
+		t.Errorf("expected the diff to the latest version to change the title, got %+v", diff)
+	}
+	diff = models.RecipeDiff{}
+	call(http.MethodGet, path+"/diff?from="+soup.ID+"&to="+spicy.ID, nil, http.StatusOK, &diff)
+	if diff.NewTitle == nil || *diff.NewTitle != "Spicy Soup" {
+		t.Errorf("expected the diff between versions to change the title, got %+v", diff)
+	}
+
+	expectError := func(method string, path string, status int, code string) {
+		t.Helper()
+		var body struct {
+			Error models.APIError `json:"error"`
+		}
+		call(method, path, nil, status, &body)
+		if body.Error.Code != code {
+			t.Errorf("%s %s: expected %s, got %s", method, path, code, body.Error.Code)
+		}
+	}
+	expectError(http.MethodGet, path+"/diff", http.StatusBadRequest, "MISSING_DIFF_FROM")
+	expectError(http.MethodGet, path+"/diff?from=missing", http.StatusNotFound, "RECIPE_VERSION_NOT_FOUND")
+	expectError(http.MethodGet, path+"/versions/missing", http.StatusNotFound, "RECIPE_VERSION_NOT_FOUND")
+	expectError(http.MethodPost, path+"/versions/missing/restore", http.StatusNotFound, "RECIPE_VERSION_NOT_FOUND")
+
+	// Restoring adds a new head rather than moving back to the old version
+	var restored models.UserRecipe
+	call(http.MethodPost, path+"/versions/"+spicy.ID+"/restore", nil, http.StatusOK, &restored)
+	if restored.Title != "Spicy Soup" || restored.LatestVersionID == spicy.ID {
+		t.Errorf("expected a new spicy version, got %+v", restored)
+	}
+	call(http.MethodGet, path+"/versions", nil, http.StatusOK, &versions)
+	if len(versions) != 4 {
+		t.Fatalf("expected 4 versions, got %+v", versions)
+	}
+	head := versions[3]
+	if head.ID != restored.LatestVersionID || !head.IsLatest || head.ParentID == nil || *head.ParentID != creamy.ID || head.Notes == nil {
+		t.Errorf("expected the restored version after the creamy one, got %+v", head)
+	}
+
+	// The thread follows the restored version, so undo goes back to the
+	// creamy one
+	thread = models.ThreadState{}
+	call(http.MethodGet, "/thread/"+recipe.ThreadID, nil, http.StatusOK, &thread)
+	if thread.CurrentRecipe == nil || thread.CurrentRecipe.Title != "Spicy Soup" {
+		t.Errorf("expected the thread to hold the restored recipe, got %+v", thread.CurrentRecipe)
+	}
+	var events []models.ThreadEvent
+	call(http.MethodGet, "/thread/"+recipe.ThreadID+"/events", nil, http.StatusOK, &events)
+	if last := events[len(events)-1]; last.Type != models.ThreadEventTypeRecipeEdited {
+		t.Errorf("expected the restore to be recorded as an edit, got %s", last.Type)
+	}
+	var undone models.UserRecipe
+	call(http.MethodPost, path+"/modify/undo", nil, http.StatusOK, &undone)
+	if undone.LatestVersionID != creamy.ID {
+		t.Errorf("expected undo to go back to the creamy version, got %+v", undone)
+	}
+}
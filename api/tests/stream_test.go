@@ -0,0 +1,258 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/clients"
+	"github.com/ajohnston1219/eatme/api/internal/db"
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+type sseEvent struct {
+	event string
+	data  string
+}
+
+// postStream posts to a streaming endpoint and reads the whole event stream,
+// failing on anything that isn't framed as "event: <name>\ndata: <json>\n\n".
+func postStream(t *testing.T, url, auth string, body any) (*http.Response, []sseEvent) {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", auth)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, data)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %q", ct)
+	}
+	if !strings.HasSuffix(string(data), "\n\n") {
+		t.Fatalf("expected the stream to end with a complete event, got %q", data)
+	}
+	var events []sseEvent
+	for _, frame := range strings.Split(strings.TrimSuffix(string(data), "\n\n"), "\n\n") {
+		lines := strings.Split(frame, "\n")
+		if len(lines) != 2 || !strings.HasPrefix(lines[0], "event: ") || !strings.HasPrefix(lines[1], "data: ") {
+			t.Fatalf("malformed event %q", frame)
+		}
+		event := sseEvent{event: strings.TrimPrefix(lines[0], "event: "), data: strings.TrimPrefix(lines[1], "data: ")}
+		if !json.Valid([]byte(event.data)) {
+			t.Fatalf("expected JSON data, got %q", event.data)
+		}
+		events = append(events, event)
+	}
+	return resp, events
+}
+
+func eventNames(events []sseEvent) string {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = e.event
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSuggestionStream(t *testing.T) {
+	suggestion := func(title string) *models.Suggestion {
+		return &models.Suggestion{
+			Recipe:       makeFakeRecipe(title, WithIngredients([]models.Ingredient{{Name: "Water", Quantity: 1, Unit: models.MeasurementUnitLiter}})),
+			ResponseText: "Try " + title,
+		}
+	}
+	ml := &MLStub{SuggestResponses: []models.SuggestChatResponse{
+		{Suggestions: []*models.Suggestion{suggestion("Soup"), suggestion("Stew")}},
+	}}
+	ts, store := NewTestServer(t, ml)
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is saved while the response is still streaming
+	tokens := 0
+	ml.BeforeToken = func() {
+		tokens++
+		threads, err := store.ListThreads(context.Background(), cook.ID, db.ThreadQuery{})
+		if err != nil {
+			t.Error(err)
+		}
+		if len(threads) != 0 {
+			t.Errorf("expected nothing to be saved before done, got %d threads", len(threads))
+		}
+	}
+	_, events := postStream(t, ts.URL+"/thread/suggest/stream", auth, models.StartSuggestionThreadRequest{Prompt: "something warm"})
+	if tokens != 4 {
+		t.Errorf("expected 4 tokens to be streamed, got %d", tokens)
+	}
+
+	expected := "token,token,suggestion,token,token,suggestion,done"
+	if names := eventNames(events); names != expected {
+		t.Fatalf("expected events %s, got %s", expected, names)
+	}
+	var text strings.Builder
+	var suggested []models.SuggestionGeneratedEvent
+	for _, e := range events[:len(events)-1] {
+		switch e.event {
+		case "token":
+			var token models.StreamToken
+			if err := json.Unmarshal([]byte(e.data), &token); err != nil {
+				t.Fatal(err)
+			}
+			text.WriteString(token.Text)
+		case "suggestion":
+			var s models.SuggestionGeneratedEvent
+			if err := json.Unmarshal([]byte(e.data), &s); err != nil {
+				t.Fatal(err)
+			}
+			suggested = append(suggested, s)
+		}
+	}
+	if text.String() != "Try SoupTry Stew" {
+		t.Errorf("expected the tokens to spell out the responses, got %q", text.String())
+	}
+	if len(suggested) != 2 || suggested[0].Recipe.Title != "Soup" || suggested[1].Recipe.Title != "Stew" {
+		t.Fatalf("expected Soup and Stew, got %+v", suggested)
+	}
+
+	// The done event carries the saved thread, with the suggestion IDs that
+	// were streamed
+	var done models.ThreadState
+	if err := json.Unmarshal([]byte(events[len(events)-1].data), &done); err != nil {
+		t.Fatal(err)
+	}
+	var saved models.ThreadState
+	status, data := doRequest(t, http.MethodGet, ts.URL+"/thread/"+done.ID, auth, nil)
+	if status != http.StatusOK {
+		t.Fatalf("expected the thread to be saved, got %d: %s", status, data)
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.OriginalPrompt != "something warm" || len(saved.Suggestions) != 2 {
+		t.Fatalf("expected the prompt and both suggestions, got %+v", saved)
+	}
+	for i, s := range saved.Suggestions {
+		if s.ID != suggested[i].SuggestionID || done.Suggestions[i].ID != s.ID {
+			t.Errorf("expected suggestion %d to keep its streamed ID %s, got %s", i, suggested[i].SuggestionID, s.ID)
+		}
+	}
+}
+
+func TestSuggestionStreamFailure(t *testing.T) {
+	ml := &MLStub{
+		SuggestResponses: []models.SuggestChatResponse{
+			{Suggestions: []*models.Suggestion{{Recipe: makeFakeRecipe("Soup"), ResponseText: "Try Soup"}}},
+		},
+		StreamErr: &clients.MLError{Endpoint: clients.EndpointSuggestStream, Err: clients.ErrMLCallFailed},
+	}
+	ts, store := NewTestServer(t, ml)
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The failure comes after the stream has started, so it is reported as
+	// an error event and nothing is saved
+	_, events := postStream(t, ts.URL+"/thread/suggest/stream", auth, models.StartSuggestionThreadRequest{Prompt: "something warm"})
+	if names := eventNames(events); names != "token,error" {
+		t.Fatalf("expected events token,error, got %s", names)
+	}
+	var apiErr models.APIError
+	if err := json.Unmarshal([]byte(events[1].data), &apiErr); err != nil {
+		t.Fatal(err)
+	}
+	if apiErr.Code != models.ApiErrChatFailed.Code {
+		t.Errorf("expected %s, got %+v", models.ApiErrChatFailed.Code, apiErr)
+	}
+	threads, err := store.ListThreads(context.Background(), cook.ID, db.ThreadQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(threads) != 0 {
+		t.Errorf("expected nothing to be saved, got %d threads", len(threads))
+	}
+}
+
+func TestModifyStreamFailure(t *testing.T) {
+	water := WithIngredients([]models.Ingredient{{Name: "Water", Quantity: 1, Unit: models.MeasurementUnitLiter}})
+	ml := &MLStub{
+		SuggestResponses: []models.SuggestChatResponse{
+			{Suggestions: []*models.Suggestion{{Recipe: makeFakeRecipe("Soup", water), ResponseText: "Try Soup"}}},
+		},
+		ModifyResponses: []models.ModifyChatResponse{
+			{ResponseText: "Made it spicy", NewRecipe: makeFakeRecipe("Spicy Soup", water)},
+		},
+	}
+	ts, store := NewTestServer(t, ml)
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(method string, path string, body any, expected int, v any) {
+		t.Helper()
+		status, data := doRequest(t, method, ts.URL+path, auth, body)
+		if status != expected {
+			t.Fatalf("%s %s: expected %d, got %d: %s", method, path, expected, status, data)
+		}
+		if v != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	var thread models.ThreadState
+	call(http.MethodPost, "/thread/suggest", models.StartSuggestionThreadRequest{Prompt: "soup"}, http.StatusOK, &thread)
+	var recipe models.UserRecipe
+	call(http.MethodPost, "/thread/"+thread.ID+"/accept/"+thread.Suggestions[0].ID, nil, http.StatusOK, &recipe)
+	call(http.MethodGet, "/thread/"+thread.ID, nil, http.StatusOK, &thread)
+
+	ml.StreamErr = &clients.MLError{Endpoint: clients.EndpointModifyStream, Err: clients.ErrMLCallFailed}
+	_, events := postStream(t, ts.URL+"/recipes/"+recipe.ID+"/modify/chat/stream", auth, models.ModifyRecipeViaChatRequest{Prompt: "make it spicy"})
+	if names := eventNames(events); names != "token,error" {
+		t.Fatalf("expected events token,error, got %s", names)
+	}
+
+	var after models.ThreadState
+	call(http.MethodGet, "/thread/"+thread.ID, nil, http.StatusOK, &after)
+	if after.Version != thread.Version || after.ModifiedRecipe != nil {
+		t.Errorf("expected the thread to be untouched at version %d, got version %d with %+v", thread.Version, after.Version, after.ModifiedRecipe)
+	}
+}
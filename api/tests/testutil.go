@@ -1,11 +1,16 @@
 package tests
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/ajohnston1219/eatme/api/internal/auth"
 	"github.com/ajohnston1219/eatme/api/internal/clients"
 	"github.com/ajohnston1219/eatme/api/internal/db"
 	"github.com/ajohnston1219/eatme/api/internal/models"
@@ -14,6 +19,8 @@ import (
 	"github.com/ajohnston1219/eatme/api/internal/user"
 )
 
+var testTokens = auth.NewTokenManager([]byte("test-secret"))
+
 func NewTestServer(t *testing.T, mlStub clients.MLClient) (*httptest.Server, *db.SQLiteStore) {
 	t.Helper()
 
@@ -22,12 +29,14 @@ func NewTestServer(t *testing.T, mlStub clients.MLClient) (*httptest.Server, *db
 	if err != nil {
 		t.Fatal(err)
 	}
+	// every connection to :memory: is its own database
+	sqlDB.SetMaxOpenConns(1)
 	store, err := db.NewSQLiteStoreWithDB(sqlDB)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	app := router.NewApp(store, mlStub)
+	app := router.NewApp(store, mlStub, testTokens)
 	r := router.NewRouter(app)
 
 	ts := httptest.NewServer(r)
@@ -82,7 +91,7 @@ func makeFakeRecipe(title string, opts ...FakeRecipeOpt) models.RecipeBody {
 }
 
 func createUser(store *db.SQLiteStore, email string) (*models.User, error) {
-	svc := user.NewUserService(store)
+	svc := user.NewUserService(store, testTokens)
 	user, err := svc.CreateUser(context.Background(), email, "password")
 	if err != nil {
 		return nil, err
@@ -90,8 +99,48 @@ func createUser(store *db.SQLiteStore, email string) (*models.User, error) {
 	return user, nil
 }
 
+func authHeader(userID string) (string, error) {
+	token, _, err := testTokens.IssueAccessToken(userID)
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + token, nil
+}
+
 func createRecipe(store *db.SQLiteStore, userID string, threadID string, recipeBody models.RecipeBody) (*models.UserRecipe, error) {
 	svc := recipe.NewRecipeService(store)
 	newRecipe, err := svc.NewRecipe(context.Background(), userID, threadID, recipeBody)
 	return newRecipe, err
 }
+
+// doRequest sends body as JSON, authenticated with auth unless it's empty,
+// and returns the response status and body.
+func doRequest(t *testing.T, method, url, auth string, body any) (int, []byte) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, data
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestThreadHistory(t *testing.T) {
+	f := newAuthorizationFixture(t)
+	thread := "/thread/" + f.threadID
+
+	var threads []models.ThreadSummary
+	f.mustDo(t, f.ownerAuth, http.MethodGet, "/thread?type=Suggestion&recipe_id="+f.recipeID, nil, &threads)
+	if len(threads) != 1 || threads[0].ID != f.threadID || threads[0].Version != 2 {
+		t.Errorf("expected the owner's thread, got %+v", threads)
+	}
+
+	var events []models.ThreadEvent
+	f.mustDo(t, f.ownerAuth, http.MethodGet, thread+"/events", nil, &events)
+	if len(events) != 2 || events[0].Type != models.ThreadEventTypePromptSet || events[1].Type != models.ThreadEventTypeSuggestionGenerated {
+		t.Fatalf("expected the thread's events in order, got %+v", events)
+	}
+
+	var state models.ThreadState
+	f.mustDo(t, f.ownerAuth, http.MethodGet, thread+"?at=1", nil, &state)
+	if state.Version != 1 || state.OriginalPrompt != "soup" || len(state.Suggestions) != 0 {
+		t.Errorf("expected the thread before its first suggestion, got %+v", state)
+	}
+	at := url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339))
+	f.mustDo(t, f.ownerAuth, http.MethodGet, thread+"?at="+at, nil, &state)
+	if state.Version != 2 || len(state.Suggestions) != 1 {
+		t.Errorf("expected the whole thread, got %+v", state)
+	}
+
+	invalid := []struct {
+		path string
+		code string
+	}{
+		{thread + "?at=3", "INVALID_THREAD_AT"},
+		{thread + "?at=-1", "INVALID_THREAD_AT"},
+		{thread + "?at=yesterday", "INVALID_THREAD_AT"},
+		{"/thread?type=Unknown", "INVALID_THREAD_TYPE"},
+		{"/thread?limit=0", "INVALID_LIMIT"},
+		{"/thread?cursor=nope", "INVALID_CURSOR"},
+	}
+	for _, tc := range invalid {
+		status, data := f.do(t, f.ownerAuth, http.MethodGet, tc.path, nil)
+		var body struct {
+			Error models.APIError `json:"error"`
+		}
+		if err := json.Unmarshal(data, &body); err != nil || status != http.StatusBadRequest || body.Error.Code != tc.code {
+			t.Errorf("%s: expected 400 %s, got %d: %s", tc.path, tc.code, status, data)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestThreadRecipesAreLocalized(t *testing.T) {
+	soup := makeFakeRecipe("Soup", WithIngredients([]models.Ingredient{
+		{Name: "Stock", Quantity: 1, Unit: models.MeasurementUnitLiter},
+		{Name: "Salt", Quantity: 1, Unit: models.MeasurementUnitTeaspoon},
+	}))
+	spicySoup := makeFakeRecipe("Spicy Soup", WithIngredients([]models.Ingredient{
+		{Name: "Stock", Quantity: 2, Unit: models.MeasurementUnitLiter},
+		{Name: "Salt", Quantity: 1, Unit: models.MeasurementUnitTeaspoon},
+	}))
+	ml := &MLStub{
+		SuggestResponses: []models.SuggestChatResponse{
+			{Suggestions: []*models.Suggestion{{Recipe: soup, ResponseText: "Try Soup"}}},
+			{Suggestions: []*models.Suggestion{{Recipe: soup, ResponseText: "Try Soup again"}}},
+		},
+		ModifyResponses: []models.ModifyChatResponse{
+			{ResponseText: "Made it spicy", NewRecipe: spicySoup},
+		},
+	}
+	ts, store := NewTestServer(t, ml)
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(method string, path string, body any, expected int, v any) {
+		t.Helper()
+		status, data := doRequest(t, method, ts.URL+path, auth, body)
+		if status != expected {
+			t.Fatalf("%s %s: expected %d, got %d: %s", method, path, expected, status, data)
+		}
+		if v != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	expectStock := func(recipe *models.RecipeBody, unit models.MeasurementUnit) {
+		t.Helper()
+		if recipe == nil {
+			t.Fatal("expected a recipe")
+		}
+		if stock := recipe.Ingredients[0]; stock.Unit != unit {
+			t.Errorf("expected the stock in %s, got %+v", unit, stock)
+		}
+	}
+
+	// Without a profile or unit_system, recipes are shown in imperial
+	var thread models.ThreadState
+	call(http.MethodPost, "/thread/suggest", models.StartSuggestionThreadRequest{Prompt: "soup"}, http.StatusOK, &thread)
+	expectStock(&thread.Suggestions[0].Suggestion, models.MeasurementUnitCup)
+	path := "/thread/" + thread.ID
+
+	var suggestions []models.RecipeSuggestion
+	call(http.MethodPost, path+"/suggest?unit_system=metric", models.GetNewSuggestionsRequest{}, http.StatusOK, &suggestions)
+	expectStock(&suggestions[0].Suggestion, models.MeasurementUnitLiter)
+
+	// The thread keeps the units the recipes were written in, so either
+	// system can be asked for
+	call(http.MethodGet, path+"?unit_system=metric", nil, http.StatusOK, &thread)
+	expectStock(&thread.Suggestions[0].Suggestion, models.MeasurementUnitLiter)
+	call(http.MethodGet, path+"?unit_system=imperial", nil, http.StatusOK, &thread)
+	expectStock(&thread.Suggestions[0].Suggestion, models.MeasurementUnitCup)
+
+	var recipe models.UserRecipe
+	call(http.MethodPost, path+"/accept/"+thread.Suggestions[0].ID, nil, http.StatusOK, &recipe)
+
+	var modified models.ModifyRecipeResponse
+	call(http.MethodPost, "/recipes/"+recipe.ID+"/modify/chat", models.ModifyRecipeViaChatRequest{Prompt: "make it spicy"}, http.StatusOK, &modified)
+	expectStock(&modified.CurrentRecipe, models.MeasurementUnitCup)
+	if changes := modified.Diff.ModifiedIngredients; len(changes) != 1 || changes[0].Unit != models.MeasurementUnitCup {
+		t.Errorf("expected the stock change in imperial, got %+v", changes)
+	}
+
+	thread = models.ThreadState{}
+	call(http.MethodGet, path+"?unit_system=metric", nil, http.StatusOK, &thread)
+	expectStock(thread.CurrentRecipe, models.MeasurementUnitLiter)
+	expectStock(thread.ModifiedRecipe, models.MeasurementUnitLiter)
+	if thread.ModifiedRecipe.Ingredients[0].Quantity != 2 {
+		t.Errorf("expected 2 liters of stock, got %+v", thread.ModifiedRecipe.Ingredients[0])
+	}
+
+	// An unknown system is rejected before the assistant is asked
+	var apiErr struct {
+		Error models.APIError `json:"error"`
+	}
+	call(http.MethodGet, path+"?unit_system=cubits", nil, http.StatusBadRequest, &apiErr)
+	if apiErr.Error.Code != models.ApiErrInvalidUnitSystem.Code {
+		t.Errorf("expected %s, got %+v", models.ApiErrInvalidUnitSystem.Code, apiErr.Error)
+	}
+	call(http.MethodPost, "/thread/suggest?unit_system=cubits", models.StartSuggestionThreadRequest{Prompt: "soup"}, http.StatusBadRequest, nil)
+	if ml.suggestCall != 2 {
+		t.Errorf("expected no suggestions to be generated, got %d calls", ml.suggestCall)
+	}
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestUndoRedoRecipeModifications(t *testing.T) {
+	ml := &MLStub{
+		SuggestResponses: []models.SuggestChatResponse{{Suggestions: []*models.Suggestion{
+			{Recipe: makeFakeRecipe("Soup"), ResponseText: "Try soup"},
+		}}},
+		ModifyResponses: []models.ModifyChatResponse{
+			{NewRecipe: makeFakeRecipe("Spicy Soup"), ResponseText: "Added chili"},
+			{NewRecipe: makeFakeRecipe("Creamy Soup"), ResponseText: "Added cream"},
+		},
+	}
+	ts, store := NewTestServer(t, ml)
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(path string, body any, expected int, v any) {
+		t.Helper()
+		status, data := doRequest(t, http.MethodPost, ts.URL+path, auth, body)
+		if status != expected {
+			t.Fatalf("POST %s: expected %d, got %d: %s", path, expected, status, data)
+		}
+		if v != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	var thread models.ThreadState
+	post("/thread/suggest", models.StartSuggestionThreadRequest{Prompt: "soup"}, http.StatusOK, &thread)
+	var recipe models.UserRecipe
+	post("/thread/"+thread.ID+"/accept/"+thread.Suggestions[0].ID, nil, http.StatusOK, &recipe)
+	modify := "/recipes/" + recipe.ID + "/modify/"
+	expectTitle := func(action string, expected string) {
+		t.Helper()
+		var moved models.UserRecipe
+		post(modify+action, nil, http.StatusOK, &moved)
+		if moved.Title != expected {
+			t.Errorf("%s: expected %q, got %q", action, expected, moved.Title)
+		}
+	}
+	expectConflict := func(action string, code string) {
+		t.Helper()
+		var body struct {
+			Error models.APIError `json:"error"`
+		}
+		post(modify+action, nil, http.StatusConflict, &body)
+		if body.Error.Code != code {
+			t.Errorf("%s: expected %s, got %s", action, code, body.Error.Code)
+		}
+	}
+
+	// The accepted suggestion is the first version
+	expectConflict("undo", "NOTHING_TO_UNDO")
+	expectConflict("redo", "NOTHING_TO_REDO")
+
+	post(modify+"chat", models.ModifyRecipeViaChatRequest{Prompt: "spicier"}, http.StatusOK, nil)
+	post(modify+"accept", nil, http.StatusNoContent, nil)
+	expectTitle("undo", "Soup")
+	expectTitle("redo", "Spicy Soup")
+	expectConflict("redo", "NOTHING_TO_REDO")
+	expectTitle("undo", "Soup")
+
+	// A new modification replaces what was undone
+	post(modify+"chat", models.ModifyRecipeViaChatRequest{Prompt: "creamier"}, http.StatusOK, nil)
+	post(modify+"accept", nil, http.StatusNoContent, nil)
+	expectConflict("redo", "NOTHING_TO_REDO")
+	expectTitle("undo", "Soup")
+	expectTitle("redo", "Creamy Soup")
+
+	status, data := doRequest(t, http.MethodGet, ts.URL+"/thread/"+thread.ID, auth, nil)
+	if err := json.Unmarshal(data, &thread); err != nil || status != http.StatusOK {
+		t.Fatalf("expected the thread, got %d: %s", status, data)
+	}
+	if thread.CurrentRecipe == nil || thread.CurrentRecipe.Title != "Creamy Soup" || len(thread.RedoVersionIDs) != 0 {
+		t.Errorf("expected the thread to follow the recipe, got %+v", thread)
+	}
+}
+
+func TestAcceptWithoutPendingModification(t *testing.T) {
+	ml := &MLStub{
+		SuggestResponses: []models.SuggestChatResponse{{Suggestions: []*models.Suggestion{
+			{Recipe: makeFakeRecipe("Soup"), ResponseText: "Try soup"},
+		}}},
+		ModifyResponses: []models.ModifyChatResponse{
+			{NewRecipe: makeFakeRecipe("Spicy Soup"), ResponseText: "Added chili"},
+			{NewRecipe: makeFakeRecipe("Creamy Soup"), ResponseText: "Added cream"},
+		},
+	}
+	ts, store := NewTestServer(t, ml)
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(path string, body any, expected int, v any) {
+		t.Helper()
+		status, data := doRequest(t, http.MethodPost, ts.URL+path, auth, body)
+		if status != expected {
+			t.Fatalf("POST %s: expected %d, got %d: %s", path, expected, status, data)
+		}
+		if v != nil {
+			if err := json.Unmarshal(data, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	var thread models.ThreadState
+	post("/thread/suggest", models.StartSuggestionThreadRequest{Prompt: "soup"}, http.StatusOK, &thread)
+	var recipe models.UserRecipe
+	post("/thread/"+thread.ID+"/accept/"+thread.Suggestions[0].ID, nil, http.StatusOK, &recipe)
+	modify := "/recipes/" + recipe.ID + "/modify/"
+	expectNothingToAccept := func() {
+		t.Helper()
+		var body struct {
+			Error models.APIError `json:"error"`
+		}
+		post(modify+"accept", nil, http.StatusConflict, &body)
+		if body.Error.Code != models.ApiErrNoPendingModification.Code {
+			t.Errorf("expected %s, got %+v", models.ApiErrNoPendingModification.Code, body.Error)
+		}
+	}
+
+	// Nothing has been modified yet
+	expectNothingToAccept()
+
+	// Accepting twice only accepts once
+	post(modify+"chat", models.ModifyRecipeViaChatRequest{Prompt: "spicier"}, http.StatusOK, nil)
+	post(modify+"accept", nil, http.StatusNoContent, nil)
+	expectNothingToAccept()
+
+	// Undoing drops the pending modification
+	post(modify+"chat", models.ModifyRecipeViaChatRequest{Prompt: "creamier"}, http.StatusOK, nil)
+	post(modify+"undo", nil, http.StatusOK, nil)
+	expectNothingToAccept()
+
+	var current models.UserRecipe
+	status, data := doRequest(t, http.MethodGet, ts.URL+"/recipes/"+recipe.ID, auth, nil)
+	if err := json.Unmarshal(data, &current); err != nil || status != http.StatusOK {
+		t.Fatalf("expected the recipe, got %d: %s", status, data)
+	}
+	if current.Title != "Soup" {
+		t.Errorf("expected the undone recipe to stay current, got %q", current.Title)
+	}
+}
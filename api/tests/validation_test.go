@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ajohnston1219/eatme/api/internal/models"
+)
+
+func TestRequestValidation(t *testing.T) {
+	ts, store := NewTestServer(t, &MLStub{})
+	defer ts.Close()
+	cook, err := createUser(store, "cook@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := authHeader(cook.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recipe := makeFakeRecipe("Soup", WithIngredients([]models.Ingredient{{Name: "Water", Quantity: -1, Unit: models.MeasurementUnitLiter}}))
+	testCases := []struct {
+		name  string
+		path  string
+		body  any
+		code  string
+		field string
+	}{
+		{"empty prompt", "/thread/suggest", models.StartSuggestionThreadRequest{Prompt: "  "}, "VALIDATION_FAILED", "prompt"},
+		{"negative quantity", "/recipes", recipe, "VALIDATION_FAILED", "ingredients[0].quantity"},
+		{"unnamed meal plan", "/plans", models.CreateMealPlanRequest{}, "INVALID_MEAL_PLAN_NAME", "name"},
+		{"unnamed collection", "/collections", models.CreateCollectionRequest{Name: " "}, "INVALID_COLLECTION_NAME", "name"},
+		{"empty import", "/recipes/import", models.ImportRecipeRequest{}, "INVALID_IMPORT", "html"},
+		{"missing refresh token", "/logout", models.LogoutRequest{}, "VALIDATION_FAILED", "refresh_token"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, data := doRequest(t, http.MethodPost, ts.URL+tc.path, auth, tc.body)
+			if status != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", status, data)
+			}
+			var body struct {
+				Error models.APIError `json:"error"`
+			}
+			if err := json.Unmarshal(data, &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error.Code != tc.code || body.Error.Field != tc.field {
+				t.Errorf("expected %s on %s, got %+v", tc.code, tc.field, body.Error)
+			}
+		})
+	}
+}
@@ -1,9 +1,38 @@
+import json
+from typing import AsyncIterator
 from fastapi import FastAPI
+from fastapi.responses import StreamingResponse
+from pydantic import BaseModel
 from models import RecipeSuggestion, SuggestChatRequest, SuggestChatResponse, ModifyChatRequest, ModifyChatResponse, GeneralChatRequest, GeneralChatResponse
-from engines import suggest, modify, answer, generate_image
+from engines import suggest, modify, answer, answer_stream, generate_image
 
 app = FastAPI()
 
+# ------------------------------------------------------------------ #
+# Server-Sent Events                                                 #
+#                                                                    #
+# Streaming endpoints emit `token` events ({"text": ...}) with       #
+# partial response text, `suggestion` events with each suggestion,   #
+# a `result` event with the final modify response and a closing      #
+# `done` event. Failures are reported as an `error` event, since the #
+# status code has already been sent.                                 #
+# ------------------------------------------------------------------ #
+def sse(event: str, data: BaseModel | dict) -> str:
+    payload = data.model_dump_json() if isinstance(data, BaseModel) else json.dumps(data)
+    return f"event: {event}\ndata: {payload}\n\n"
+
+def event_stream(events: AsyncIterator[str], name: str) -> StreamingResponse:
+    async def run():
+        try:
+            async for event in events:
+                yield event
+            yield sse("done", {})
+        except Exception as e:
+            print(f"Error in {name} stream:", e)
+            yield sse("error", {"error": str(e)})
+    return StreamingResponse(run(), media_type="text/event-stream",
+                             headers={"Cache-Control": "no-cache"})
+
 @app.post("/chat/suggest", response_model=SuggestChatResponse)
 async def chat(req: SuggestChatRequest):
     print("Incoming suggest request:", req)
@@ -48,4 +77,40 @@ async def chat(req: GeneralChatRequest):
         return GeneralChatResponse(response_text=response)
     except Exception as e:
         print("Error in general:", e)
-        raise e
\ No newline at end of file
+        raise e
+
+@app.post("/chat/suggest/stream")
+async def chat(req: SuggestChatRequest):
+    print("Incoming suggest stream request:", req)
+    async def events():
+        recipes = await suggest(req.profile, req.history, req.message)
+        text = "Here is an idea ↓"
+        # images are the slow part, so each suggestion is sent as soon as its image is ready
+        for recipe in recipes:
+            image_id = await generate_image(recipe)
+            recipe.image_url = f"http://localhost:8080/images/{image_id}.png"
+            yield sse("token", {"text": text})
+            yield sse("suggestion", RecipeSuggestion(recipe=recipe, response_text=text))
+    return event_stream(events(), "suggest")
+
+@app.post("/chat/modify/stream")
+async def chat(req: ModifyChatRequest):
+    print("Incoming modify stream request:", req)
+    async def events():
+        resp = await modify(req.recipe, req.profile, req.message)
+        yield sse("token", {"text": resp.response_text})
+        if resp.generate_new_image:
+            image_id = await generate_image(resp.new_recipe)
+            resp.new_recipe.image_url = f"http://localhost:8080/images/{image_id}.png"
+        else:
+            resp.new_recipe.image_url = req.recipe.image_url
+        yield sse("result", resp)
+    return event_stream(events(), "modify")
+
+@app.post("/chat/general/stream")
+async def chat(req: GeneralChatRequest):
+    print("Incoming general stream request:", req)
+    async def events():
+        async for text in answer_stream(req.recipe, req.profile, req.message):
+            yield sse("token", {"text": text})
+    return event_stream(events(), "general")
@@ -1,9 +1,10 @@
 from .recipe     import suggest, modify, generate_image
-from .qa         import answer
+from .qa         import answer, answer_stream
 
 __all__ = [
     "suggest",
     "modify",
     "answer",
+    "answer_stream",
     "generate_image"
 ]
@@ -5,9 +5,9 @@ API surface stays identical to the former OpenAI version.
 
 from __future__ import annotations
 import asyncio, json, logging, random, os
-from typing import Sequence, TypeVar, Type
+from typing import AsyncIterator, Sequence, TypeVar, Type
 from engines.schemas import schema_snippet
-from anthropic import Anthropic, \
+from anthropic import Anthropic, AsyncAnthropic, \
     RateLimitError, InternalServerError, APIStatusError
 from pydantic import BaseModel, ValidationError, TypeAdapter
 
@@ -16,6 +16,10 @@ client = Anthropic(
     # picks up ANTHROPIC_API_KEY from env by default
     api_key=os.getenv("ANTHROPIC_API_KEY"),
 )
+# streaming needs the async client so tokens can be forwarded as they arrive
+async_client = AsyncAnthropic(
+    api_key=os.getenv("ANTHROPIC_API_KEY"),
+)
 
 T = TypeVar("T", bound=BaseModel)
 
@@ -83,6 +87,28 @@ async def chat(
             logger.warning("Anthropic error (%s). Retrying in %.2fs…", err.__class__.__name__, sleep)
             await asyncio.sleep(sleep)
 
+async def chat_stream(
+    messages: Sequence[dict],
+    *,
+    model: str = MODEL,
+    max_tokens: int | None = None,
+    temperature: float = 0.0,
+) -> AsyncIterator[str]:
+    """
+    Like `chat`, but yields text deltas as the model produces them.
+    There are no retries: once tokens have been forwarded a retry would
+    repeat them, so errors are left to the caller.
+    """
+    prompt = _to_anthropic(messages)
+    async with async_client.messages.stream(
+        model=model,
+        max_tokens=max_tokens or 8192,
+        temperature=temperature,
+        messages=[{"role": "user", "content": prompt}],
+    ) as stream:
+        async for text in stream.text_stream:
+            yield text
+
 # ------------------------------------------------------------------ #
 # 3. JSON helper with Pydantic validation                            #
 # ------------------------------------------------------------------ #
@@ -137,4 +163,4 @@ async def as_json(
 # ------------------------------------------------------------------ #
 # 4. __all__ so other modules can `from engines.llm import …`        #
 # ------------------------------------------------------------------ #
-__all__ = ["chat", "as_json", "sys", "usr", "asst"]
+__all__ = ["chat", "chat_stream", "as_json", "sys", "usr", "asst"]
@@ -1,9 +1,15 @@
-from .llm import chat, sys, usr
+from typing import AsyncIterator
+from .llm import chat, chat_stream, sys, usr
 from models import Profile, Recipe
 
-async def answer(recipe: Recipe, profile: Profile, message: str) -> str:
-    messages = [
+def answer_messages(recipe: Recipe, profile: Profile, message: str) -> list[dict]:
+    return [
         sys("You are a helpful cooking assistant. Answer concisely but clearly."),
         usr(f"Preferences: {profile}\n\nRecipe: {recipe}\n\nUser request: \"{message}\"")
     ]
-    return await chat(messages)
+
+async def answer(recipe: Recipe, profile: Profile, message: str) -> str:
+    return await chat(answer_messages(recipe, profile, message))
+
+def answer_stream(recipe: Recipe, profile: Profile, message: str) -> AsyncIterator[str]:
+    return chat_stream(answer_messages(recipe, profile, message))
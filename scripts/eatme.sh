@@ -29,11 +29,14 @@ case "$1" in
       test)
         "$SCRIPT_DIR/api-test.sh"
         ;;
+      test-postgres)
+        "$SCRIPT_DIR/api-test-postgres.sh"
+        ;;
       docs)
         "$SCRIPT_DIR/api-docs.sh"
         ;;
       *)
-        echo "Usage: $0 api [test|docs]"
+        echo "Usage: $0 api [test|test-postgres|docs]"
         exit 1
         ;;
     esac
@@ -80,6 +83,7 @@ case "$1" in
     echo "    test              - Run tests"
     echo "  api <command>       - API development commands"
     echo "    test              - Run API tests"
+    echo "    test-postgres     - Run store tests against PostgreSQL"
     echo "    docs              - Generate API documentation"
     echo "  app <command>       - App development commands"
     echo "    start [host]      - Start app (host optional)"